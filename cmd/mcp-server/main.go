@@ -2,21 +2,43 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/johan-j/play-mcp/internal/plugins"
 	"github.com/johan-j/play-mcp/internal/plugins/financial"
 	"github.com/johan-j/play-mcp/internal/plugins/housing"
 	"github.com/johan-j/play-mcp/internal/server"
+	"github.com/johan-j/play-mcp/pkg/export"
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/records"
+	"github.com/johan-j/play-mcp/pkg/scheduler"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+	"github.com/johan-j/play-mcp/pkg/store"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
 	// Command line flags
 	var (
-		host  = flag.String("host", "localhost", "Server host")
-		port  = flag.Int("port", 8080, "Server port")
-		debug = flag.Bool("debug", false, "Enable debug logging")
+		host        = flag.String("host", "localhost", "Server host")
+		port        = flag.Int("port", 8080, "Server port")
+		debug       = flag.Bool("debug", false, "Enable debug logging")
+		companyData = flag.String("company-data", "", "Path to a company fundamentals dataset (JSON or CSV) to use instead of the bundled one")
+		fxRates     = flag.String("fx-rates", "", "Path to a file of FX rates (JSON or CSV) to use instead of mock rates")
+		fxAPI       = flag.String("fx-api", "", "Base URL of a Frankfurter-compatible FX rate API to use instead of mock rates")
+		listingSrc  = flag.String("listing-source", "homes.com", "Listing source used for property searches (homes.com, redfin.com, or public-records with -public-records)")
+		fetchConfig = flag.String("fetch-config", "", "Path to a JSON file of per-source fetch policies (rate limits, retries, robots.txt, HTTP cache)")
+		dbPath      = flag.String("db", "", "Path to a SQLite database for scraped properties; searches scrape live when empty")
+		refresh     = flag.Duration("refresh-after", 24*time.Hour, "How long stored search results and property details are served before scraping again")
+		propFormat  = flag.String("property-format", "legacy", "Default property JSON format: legacy, the original display strings, or typed for clients that read typed values")
+		ingestPath  = flag.String("ingest-config", "", "Path to a JSON file of neighborhoods to ingest on a schedule in the background")
+		geocodeData = flag.String("geocode-data", "", "Path to a JSON file of known address, ZIP code and city locations for the offline geocoder")
+		exportTTL   = flag.Duration("export-ttl", time.Hour, "How long exported files stay available for download at /exports/{id}")
+		publicURL   = flag.String("public-url", "", "Base URL clients reach this server at, used in export download links (default http://host:port)")
+		boundaries  = flag.String("boundaries", "", "Path to a JSON file listing GeoJSON or Shapefile neighborhood and school district boundaries to import")
+		publicRecs  = flag.String("public-records", "", "Path to a JSON file of county assessor and recorded sales files and their column mappings to import")
 	)
 	flag.Parse()
 
@@ -29,13 +51,117 @@ func main() {
 	// Create plugin registry
 	registry := plugins.NewRegistry()
 
+	// Exported results are kept for download links
+	exports := export.NewStore(*exportTTL)
+	if *publicURL == "" {
+		*publicURL = fmt.Sprintf("http://%s:%d", *host, *port)
+	}
+	exporter := export.NewExporter(exports, *publicURL)
+
 	// Register plugins
-	if err := registry.Register(financial.NewPlugin()); err != nil {
+	financialPlugin := financial.NewPlugin()
+	financialPlugin.SetExporter(exporter)
+	if *companyData != "" {
+		dataset, err := financial.LoadCompanyDataset(*companyData)
+		if err != nil {
+			logger.Fatalf("Failed to load company dataset: %v", err)
+		}
+		financialPlugin.SetCompanyDataset(dataset)
+		logger.Infof("Loaded %d companies from %s", len(dataset.Companies), *companyData)
+	}
+	if *fxRates != "" {
+		provider, err := financial.NewFileFXProvider(*fxRates)
+		if err != nil {
+			logger.Fatalf("Failed to load FX rates: %v", err)
+		}
+		financialPlugin.SetFXProvider(provider)
+		logger.Infof("Using FX rates from %s", *fxRates)
+	} else if *fxAPI != "" {
+		financialPlugin.SetFXProvider(financial.NewRESTFXProvider(*fxAPI))
+		logger.Infof("Using FX rates from %s", *fxAPI)
+	}
+	if err := registry.Register(financialPlugin); err != nil {
 		logger.Fatalf("Failed to register financial plugin: %v", err)
 	}
 	logger.Info("Registered financial plugin")
 
-	if err := registry.Register(housing.NewPlugin()); err != nil {
+	housingPlugin := housing.NewPlugin()
+	housingPlugin.SetExporter(exporter)
+	if *publicRecs != "" {
+		dataset, stats, err := records.LoadDataset(*publicRecs)
+		if err != nil {
+			logger.Fatalf("Failed to load public records: %v", err)
+		}
+		if err := housingPlugin.SetPublicRecords(dataset); err != nil {
+			logger.Fatalf("Failed to register public records: %v", err)
+		}
+		for _, s := range stats {
+			logger.Infof("Imported %d parcels and %d sales for %s (%d sales without a parcel, %d unreadable rows skipped)",
+				s.Parcels, s.Sales, s.County, s.UnmatchedSales, s.Skipped)
+		}
+	}
+	if err := housingPlugin.SetSearchSource(*listingSrc); err != nil {
+		logger.Fatalf("Invalid listing source: %v", err)
+	}
+	if err := housingPlugin.SetDefaultFormat(*propFormat); err != nil {
+		logger.Fatalf("Invalid property format: %v", err)
+	}
+	if *fetchConfig != "" {
+		policies, err := scraper.LoadFetchPolicies(*fetchConfig)
+		if err != nil {
+			logger.Fatalf("Failed to load fetch policies: %v", err)
+		}
+		if err := housingPlugin.SetFetchPolicies(policies); err != nil {
+			logger.Fatalf("Invalid fetch policies: %v", err)
+		}
+		logger.Infof("Using fetch policies from %s", *fetchConfig)
+	}
+	if *dbPath != "" {
+		propertyStore, err := store.Open(*dbPath)
+		if err != nil {
+			logger.Fatalf("Failed to open property store: %v", err)
+		}
+		defer propertyStore.Close()
+		housingPlugin.SetStore(propertyStore, *refresh)
+		logger.Infof("Storing scraped properties in %s (refreshed after %s)", *dbPath, *refresh)
+	} else {
+		housingPlugin.SetRefreshAfter(*refresh)
+	}
+	if *geocodeData != "" {
+		geocoder, err := geo.LoadLocalGeocoder(*geocodeData, scraper.PropertyKey)
+		if err != nil {
+			logger.Fatalf("Failed to load geocoder data: %v", err)
+		}
+		housingPlugin.SetGeocoder(geocoder)
+		logger.Infof("Geocoding properties with %s", *geocodeData)
+	}
+	if *boundaries != "" {
+		registry, err := geo.LoadBoundaryRegistry(*boundaries)
+		if err != nil {
+			logger.Fatalf("Failed to load boundaries: %v", err)
+		}
+		housingPlugin.SetBoundaries(registry)
+		logger.Infof("Loaded %d neighborhood and school district boundaries from %s", registry.Len(), *boundaries)
+	}
+	var jobs *scheduler.Scheduler
+	if *ingestPath != "" {
+		ingestConfig, err := housing.LoadIngestionConfig(*ingestPath)
+		if err != nil {
+			logger.Fatalf("Failed to load ingestion config: %v", err)
+		}
+		options, err := ingestConfig.SchedulerOptions()
+		if err != nil {
+			logger.Fatalf("Invalid ingestion config: %v", err)
+		}
+		jobs = scheduler.New(options)
+		if err := housingPlugin.AddIngestionJobs(jobs, ingestConfig); err != nil {
+			logger.Fatalf("Invalid ingestion config: %v", err)
+		}
+		jobs.Start()
+		defer jobs.Stop()
+		logger.Infof("Scheduled %d ingestion jobs from %s", len(ingestConfig.Neighborhoods), *ingestPath)
+	}
+	if err := registry.Register(housingPlugin); err != nil {
 		logger.Fatalf("Failed to register housing plugin: %v", err)
 	}
 	logger.Info("Registered housing plugin")
@@ -49,6 +175,10 @@ func main() {
 
 	// Create and start server
 	mcpServer := server.NewMCPServer(registry, logger)
+	mcpServer.SetExports(exports)
+	if jobs != nil {
+		mcpServer.SetScheduler(jobs)
+	}
 
 	config := server.Config{
 		Host: *host,
@@ -0,0 +1,415 @@
+package financial
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Strategy decides the desired position for each bar of a price series
+type Strategy interface {
+	Name() string
+	// Signals returns, for each bar, whether the strategy wants to be long
+	// after that bar's close. Signals must only look at bars[0..i].
+	Signals(bars []PriceBar) []bool
+}
+
+// BacktestConfig holds the execution assumptions for a backtest
+type BacktestConfig struct {
+	InitialCapital float64 `json:"initialCapital"`
+	Commission     float64 `json:"commission"`  // Flat commission per trade in dollars
+	SlippageBps    float64 `json:"slippageBps"` // Slippage applied to each fill in basis points
+}
+
+// EquityPoint represents portfolio value at the close of a bar
+type EquityPoint struct {
+	Date   string  `json:"date"`
+	Equity float64 `json:"equity"`
+}
+
+// Trade represents a completed round trip
+type Trade struct {
+	EntryDate  string  `json:"entryDate"`
+	EntryPrice float64 `json:"entryPrice"`
+	ExitDate   string  `json:"exitDate"`
+	ExitPrice  float64 `json:"exitPrice"`
+	Shares     int64   `json:"shares"`
+	PnL        float64 `json:"pnl"`
+	ReturnPct  float64 `json:"returnPct"`
+	ExitReason string  `json:"exitReason"`
+}
+
+// BacktestMetrics summarizes the performance of a backtest
+type BacktestMetrics struct {
+	TotalReturn float64 `json:"totalReturn"`
+	CAGR        float64 `json:"cagr"`
+	MaxDrawdown float64 `json:"maxDrawdown"`
+	Sharpe      float64 `json:"sharpe"`
+	WinRate     float64 `json:"winRate"`
+	TradeCount  int     `json:"tradeCount"`
+	FinalEquity float64 `json:"finalEquity"`
+}
+
+// BacktestResult represents the outcome of running a strategy over a price series
+type BacktestResult struct {
+	Symbol      string          `json:"symbol"`
+	Strategy    string          `json:"strategy"`
+	StartDate   string          `json:"startDate"`
+	EndDate     string          `json:"endDate"`
+	Config      BacktestConfig  `json:"config"`
+	Metrics     BacktestMetrics `json:"metrics"`
+	Benchmark   BacktestMetrics `json:"benchmark"`
+	Trades      []Trade         `json:"trades"`
+	EquityCurve []EquityPoint   `json:"equityCurve"`
+}
+
+// BuyAndHoldStrategy is long from the first bar to the last
+type BuyAndHoldStrategy struct{}
+
+// Name returns the strategy name
+func (s BuyAndHoldStrategy) Name() string {
+	return "buy_and_hold"
+}
+
+// Signals returns a long signal for every bar
+func (s BuyAndHoldStrategy) Signals(bars []PriceBar) []bool {
+	signals := make([]bool, len(bars))
+	for i := range signals {
+		signals[i] = true
+	}
+	return signals
+}
+
+// SMACrossoverStrategy is long while the fast moving average is above the slow one
+type SMACrossoverStrategy struct {
+	Fast int
+	Slow int
+}
+
+// Name returns the strategy name
+func (s SMACrossoverStrategy) Name() string {
+	return fmt.Sprintf("sma_crossover(%d/%d)", s.Fast, s.Slow)
+}
+
+// Signals returns a long signal whenever SMA(fast) > SMA(slow)
+func (s SMACrossoverStrategy) Signals(bars []PriceBar) []bool {
+	fast := simpleMovingAverage(bars, s.Fast)
+	slow := simpleMovingAverage(bars, s.Slow)
+
+	signals := make([]bool, len(bars))
+	for i := range bars {
+		if math.IsNaN(fast[i]) || math.IsNaN(slow[i]) {
+			continue
+		}
+		signals[i] = fast[i] > slow[i]
+	}
+	return signals
+}
+
+// RSIStrategy buys when RSI drops below Oversold and sells when it rises above Overbought
+type RSIStrategy struct {
+	Period     int
+	Oversold   float64
+	Overbought float64
+}
+
+// Name returns the strategy name
+func (s RSIStrategy) Name() string {
+	return fmt.Sprintf("rsi(%d, %g/%g)", s.Period, s.Oversold, s.Overbought)
+}
+
+// Signals returns a long signal from an oversold reading until an overbought one
+func (s RSIStrategy) Signals(bars []PriceBar) []bool {
+	rsi := relativeStrengthIndex(bars, s.Period)
+
+	signals := make([]bool, len(bars))
+	long := false
+	for i := range bars {
+		if !math.IsNaN(rsi[i]) {
+			if !long && rsi[i] < s.Oversold {
+				long = true
+			} else if long && rsi[i] > s.Overbought {
+				long = false
+			}
+		}
+		signals[i] = long
+	}
+	return signals
+}
+
+// parseStrategy builds a Strategy from a tool argument, which may be either a
+// strategy name or an object with a "type" field and strategy parameters
+func parseStrategy(arg interface{}) (Strategy, error) {
+	params := map[string]interface{}{}
+	var strategyType string
+
+	switch v := arg.(type) {
+	case nil:
+		strategyType = "buy_and_hold"
+	case string:
+		strategyType = v
+	case map[string]interface{}:
+		params = v
+		strategyType, _ = v["type"].(string)
+	default:
+		return nil, fmt.Errorf("strategy must be a string or an object")
+	}
+
+	intParam := func(name string, def int) int {
+		if val, ok := params[name].(float64); ok && val > 0 {
+			return int(val)
+		}
+		return def
+	}
+	floatParam := func(name string, def float64) float64 {
+		if val, ok := params[name].(float64); ok {
+			return val
+		}
+		return def
+	}
+
+	switch strings.ToLower(strategyType) {
+	case "buy_and_hold", "":
+		return BuyAndHoldStrategy{}, nil
+	case "sma_crossover":
+		s := SMACrossoverStrategy{Fast: intParam("fast", 20), Slow: intParam("slow", 50)}
+		if s.Fast >= s.Slow {
+			return nil, fmt.Errorf("sma_crossover requires fast (%d) < slow (%d)", s.Fast, s.Slow)
+		}
+		return s, nil
+	case "rsi":
+		s := RSIStrategy{
+			Period:     intParam("period", 14),
+			Oversold:   floatParam("oversold", 30),
+			Overbought: floatParam("overbought", 70),
+		}
+		if s.Oversold >= s.Overbought {
+			return nil, fmt.Errorf("rsi requires oversold (%g) < overbought (%g)", s.Oversold, s.Overbought)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy type: %s (supported: buy_and_hold, sma_crossover, rsi)", strategyType)
+	}
+}
+
+// runBacktest simulates a long-only, fully invested strategy over bars.
+// Signals generated on a bar's close are filled at the next bar's open, so the
+// strategy never trades on information it could not have had.
+func runBacktest(symbol string, bars []PriceBar, strategy Strategy, config BacktestConfig) BacktestResult {
+	result := BacktestResult{
+		Symbol:      symbol,
+		Strategy:    strategy.Name(),
+		Config:      config,
+		Trades:      []Trade{},
+		EquityCurve: []EquityPoint{},
+	}
+	if len(bars) == 0 {
+		return result
+	}
+	result.StartDate = bars[0].Date
+	result.EndDate = bars[len(bars)-1].Date
+
+	signals := strategy.Signals(bars)
+	slippage := config.SlippageBps / 10000
+
+	cash := config.InitialCapital
+	var shares int64
+	var open *Trade
+	var entryCost float64
+
+	exit := func(date string, price float64, reason string) {
+		fill := price * (1 - slippage)
+		proceeds := float64(shares)*fill - config.Commission
+		cash += proceeds
+		open.ExitDate = date
+		open.ExitPrice = roundTo(fill, 4)
+		open.PnL = roundTo(proceeds-entryCost, 2)
+		open.ReturnPct = roundTo((proceeds-entryCost)/entryCost*100, 4)
+		open.ExitReason = reason
+		result.Trades = append(result.Trades, *open)
+		open = nil
+		shares = 0
+	}
+
+	for i, bar := range bars {
+		// Execute the previous bar's signal at this bar's open
+		if i > 0 {
+			want := signals[i-1]
+			if want && shares == 0 {
+				fill := bar.Open * (1 + slippage)
+				qty := int64(math.Floor((cash - config.Commission) / fill))
+				if qty > 0 {
+					shares = qty
+					entryCost = float64(qty)*fill + config.Commission
+					cash -= entryCost
+					open = &Trade{EntryDate: bar.Date, EntryPrice: roundTo(fill, 4), Shares: qty}
+				}
+			} else if !want && shares > 0 {
+				exit(bar.Date, bar.Open, "signal")
+			}
+		}
+
+		result.EquityCurve = append(result.EquityCurve, EquityPoint{
+			Date:   bar.Date,
+			Equity: roundTo(cash+float64(shares)*bar.Close, 2),
+		})
+	}
+
+	// Liquidate any open position at the final close so every trade is realized
+	if shares > 0 {
+		last := bars[len(bars)-1]
+		exit(last.Date, last.Close, "end_of_test")
+		result.EquityCurve[len(result.EquityCurve)-1].Equity = roundTo(cash, 2)
+	}
+
+	result.Metrics = computeMetrics(result.EquityCurve, result.Trades, config.InitialCapital)
+	return result
+}
+
+// computeMetrics derives summary statistics from an equity curve and trade list
+func computeMetrics(curve []EquityPoint, trades []Trade, initial float64) BacktestMetrics {
+	metrics := BacktestMetrics{TradeCount: len(trades)}
+	if len(curve) == 0 || initial <= 0 {
+		return metrics
+	}
+
+	final := curve[len(curve)-1].Equity
+	metrics.FinalEquity = final
+	metrics.TotalReturn = roundTo((final/initial-1)*100, 4)
+
+	// CAGR uses calendar time between the first and last bar
+	first, err1 := time.Parse("2006-01-02", curve[0].Date)
+	last, err2 := time.Parse("2006-01-02", curve[len(curve)-1].Date)
+	if err1 == nil && err2 == nil && last.After(first) && final > 0 {
+		years := last.Sub(first).Hours() / 24 / 365.25
+		metrics.CAGR = roundTo((math.Pow(final/initial, 1/years)-1)*100, 4)
+	}
+
+	// Max drawdown as the largest peak-to-trough decline
+	peak := curve[0].Equity
+	maxDD := 0.0
+	for _, point := range curve {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - point.Equity) / peak; dd > maxDD {
+				maxDD = dd
+			}
+		}
+	}
+	metrics.MaxDrawdown = roundTo(maxDD*100, 4)
+
+	// Annualized Sharpe ratio of daily returns with a zero risk-free rate
+	if len(curve) > 2 {
+		returns := make([]float64, 0, len(curve)-1)
+		for i := 1; i < len(curve); i++ {
+			if curve[i-1].Equity > 0 {
+				returns = append(returns, curve[i].Equity/curve[i-1].Equity-1)
+			}
+		}
+		mean, std := meanStdDev(returns)
+		if std > 0 {
+			metrics.Sharpe = roundTo(mean/std*math.Sqrt(252), 4)
+		}
+	}
+
+	if len(trades) > 0 {
+		wins := 0
+		for _, trade := range trades {
+			if trade.PnL > 0 {
+				wins++
+			}
+		}
+		metrics.WinRate = roundTo(float64(wins)/float64(len(trades))*100, 4)
+	}
+
+	return metrics
+}
+
+// simpleMovingAverage returns the SMA for each bar, NaN until enough bars exist
+func simpleMovingAverage(bars []PriceBar, period int) []float64 {
+	out := make([]float64, len(bars))
+	sum := 0.0
+	for i, bar := range bars {
+		sum += bar.Close
+		if i >= period {
+			sum -= bars[i-period].Close
+		}
+		if i+1 < period {
+			out[i] = math.NaN()
+		} else {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// relativeStrengthIndex returns Wilder's RSI for each bar, NaN during warm-up
+func relativeStrengthIndex(bars []PriceBar, period int) []float64 {
+	out := make([]float64, len(bars))
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	if len(bars) <= period {
+		return out
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		change := bars[i].Close - bars[i-1].Close
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	out[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(bars); i++ {
+		change := bars[i].Close - bars[i-1].Close
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		out[i] = rsiValue(avgGain, avgLoss)
+	}
+	return out
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+	return mean, math.Sqrt(variance)
+}
+
+func roundTo(value float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(value*factor) / factor
+}
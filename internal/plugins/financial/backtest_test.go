@@ -0,0 +1,185 @@
+package financial
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+func fixtureBars(opens, closes []float64) []PriceBar {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]PriceBar, len(closes))
+	for i := range closes {
+		bars[i] = PriceBar{
+			Date:  start.AddDate(0, 0, i).Format("2006-01-02"),
+			Open:  opens[i],
+			High:  math.Max(opens[i], closes[i]),
+			Low:   math.Min(opens[i], closes[i]),
+			Close: closes[i],
+		}
+	}
+	return bars
+}
+
+func TestRunBacktestBuyAndHold(t *testing.T) {
+	bars := fixtureBars(
+		[]float64{100, 100, 110, 99},
+		[]float64{100, 110, 99, 120},
+	)
+
+	result := runBacktest("TEST", bars, BuyAndHoldStrategy{}, BacktestConfig{InitialCapital: 1000})
+
+	wantCurve := []float64{1000, 1100, 990, 1200}
+	for i, point := range result.EquityCurve {
+		if point.Equity != wantCurve[i] {
+			t.Errorf("equity[%d] = %v, want %v", i, point.Equity, wantCurve[i])
+		}
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("got %d trades, want 1", len(result.Trades))
+	}
+	trade := result.Trades[0]
+	if trade.EntryDate != "2024-01-02" || trade.Shares != 10 || trade.ExitReason != "end_of_test" {
+		t.Errorf("unexpected trade: %+v", trade)
+	}
+	if result.Metrics.TotalReturn != 20 {
+		t.Errorf("TotalReturn = %v, want 20", result.Metrics.TotalReturn)
+	}
+	if result.Metrics.MaxDrawdown != 10 {
+		t.Errorf("MaxDrawdown = %v, want 10", result.Metrics.MaxDrawdown)
+	}
+	if result.Metrics.WinRate != 100 {
+		t.Errorf("WinRate = %v, want 100", result.Metrics.WinRate)
+	}
+}
+
+func TestRunBacktestCommissionAndSlippage(t *testing.T) {
+	bars := fixtureBars(
+		[]float64{100, 100, 110, 99},
+		[]float64{100, 110, 99, 120},
+	)
+
+	config := BacktestConfig{InitialCapital: 1000, Commission: 5, SlippageBps: 100}
+	result := runBacktest("TEST", bars, BuyAndHoldStrategy{}, config)
+
+	// Entry fills at 101 (1% slippage), 9 shares after reserving the commission.
+	// Exit fills at 118.8, so proceeds are 9*118.8 - 5 = 1064.2 against a cost of 914.
+	trade := result.Trades[0]
+	if trade.Shares != 9 || trade.EntryPrice != 101 || trade.ExitPrice != 118.8 {
+		t.Errorf("unexpected fills: %+v", trade)
+	}
+	if trade.PnL != 150.2 {
+		t.Errorf("PnL = %v, want 150.2", trade.PnL)
+	}
+	if result.Metrics.FinalEquity != 1150.2 {
+		t.Errorf("FinalEquity = %v, want 1150.2", result.Metrics.FinalEquity)
+	}
+}
+
+func TestSMACrossoverSignals(t *testing.T) {
+	closes := []float64{10, 9, 8, 9, 10, 11, 10, 9}
+	bars := fixtureBars(closes, closes)
+
+	got := SMACrossoverStrategy{Fast: 1, Slow: 3}.Signals(bars)
+	want := []bool{false, false, false, true, true, true, false, false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("signals = %v, want %v", got, want)
+	}
+
+	result := runBacktest("TEST", bars, SMACrossoverStrategy{Fast: 1, Slow: 3}, BacktestConfig{InitialCapital: 1000})
+	if len(result.Trades) != 1 {
+		t.Fatalf("got %d trades, want 1", len(result.Trades))
+	}
+	if trade := result.Trades[0]; trade.EntryDate != "2024-01-05" || trade.ExitDate != "2024-01-08" {
+		t.Errorf("unexpected trade dates: %+v", trade)
+	}
+}
+
+func TestRSISignals(t *testing.T) {
+	closes := []float64{10, 9, 8, 7, 6, 7, 8, 9, 10, 11}
+	bars := fixtureBars(closes, closes)
+
+	got := RSIStrategy{Period: 3, Oversold: 30, Overbought: 70}.Signals(bars)
+	want := []bool{false, false, false, true, true, true, true, false, false, false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("signals = %v, want %v", got, want)
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	s, err := parseStrategy(map[string]interface{}{"type": "sma_crossover", "fast": float64(10), "slow": float64(30)})
+	if err != nil {
+		t.Fatalf("parseStrategy: %v", err)
+	}
+	if s.Name() != "sma_crossover(10/30)" {
+		t.Errorf("Name() = %s", s.Name())
+	}
+
+	if _, err := parseStrategy(map[string]interface{}{"type": "sma_crossover", "fast": float64(50), "slow": float64(20)}); err == nil {
+		t.Error("expected error for fast >= slow")
+	}
+	if _, err := parseStrategy("momentum"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+func TestMockPriceSeriesIsDeterministic(t *testing.T) {
+	p := NewPlugin()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	full := p.getMockPriceSeries("AAPL", start, start.AddDate(0, 6, 0))
+	tail := p.getMockPriceSeries("AAPL", start.AddDate(0, 3, 0), start.AddDate(0, 6, 0))
+
+	offset := len(full) - len(tail)
+	if !reflect.DeepEqual(full[offset:], tail) {
+		t.Error("overlapping ranges produced different bars")
+	}
+
+	a := runBacktest("AAPL", full, SMACrossoverStrategy{Fast: 20, Slow: 50}, BacktestConfig{InitialCapital: 10000})
+	b := runBacktest("AAPL", p.getMockPriceSeries("AAPL", start, start.AddDate(0, 6, 0)), SMACrossoverStrategy{Fast: 20, Slow: 50}, BacktestConfig{InitialCapital: 10000})
+	if !reflect.DeepEqual(a, b) {
+		t.Error("backtest results differ between identical runs")
+	}
+}
+
+func TestMockPriceSeriesSkipsClosedDays(t *testing.T) {
+	p := NewPlugin()
+	p.now = func() time.Time { return time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC) }
+
+	bars := p.getMockPriceSeries("AAPL", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC))
+
+	// July 4th and weekends are skipped; the series stops at the last completed session.
+	want := []string{"2024-07-01", "2024-07-02", "2024-07-03", "2024-07-05", "2024-07-08", "2024-07-09"}
+	got := make([]string, len(bars))
+	for i, bar := range bars {
+		got[i] = bar.Date
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dates = %v, want %v", got, want)
+	}
+}
+
+func TestBacktestDefaultsToPluginClock(t *testing.T) {
+	p := NewPlugin()
+	p.now = func() time.Time { return time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC) }
+
+	response, err := p.HandleToolCall(context.Background(), mcp.ToolCallRequest{Name: "backtest_strategy", Arguments: map[string]interface{}{
+		"symbol": "AAPL", "strategy": "buy_and_hold",
+	}})
+	if err != nil || response.IsError {
+		t.Fatalf("%v %+v", err, response)
+	}
+	var result BacktestResult
+	if err := json.Unmarshal([]byte(response.Content[1].Text), &result); err != nil {
+		t.Fatal(err)
+	}
+	// A year up to the last session the clock has completed
+	if result.StartDate != "2023-07-10" || result.EndDate != "2024-07-09" {
+		t.Errorf("backtest ran %s to %s", result.StartDate, result.EndDate)
+	}
+}
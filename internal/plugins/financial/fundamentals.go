@@ -0,0 +1,447 @@
+package financial
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/companies.json
+var bundledCompanies []byte
+
+// Company represents a listed company and its most recent annual filing
+type Company struct {
+	Symbol       string        `json:"symbol"`
+	Name         string        `json:"name"`
+	CIK          string        `json:"cik,omitempty"`
+	Exchange     string        `json:"exchange"`
+	Sector       string        `json:"sector"`
+	Industry     string        `json:"industry"`
+	Country      string        `json:"country,omitempty"`
+	Fundamentals *Fundamentals `json:"fundamentals,omitempty"`
+}
+
+// Fundamentals represents the financial statements from an annual filing
+type Fundamentals struct {
+	FiscalYear      int             `json:"fiscalYear"`
+	PeriodEnd       string          `json:"periodEnd"`
+	Currency        string          `json:"currency"`
+	IncomeStatement IncomeStatement `json:"incomeStatement"`
+	BalanceSheet    BalanceSheet    `json:"balanceSheet"`
+	CashFlow        CashFlow        `json:"cashFlow"`
+	EPS             EPS             `json:"eps"`
+}
+
+// IncomeStatement represents income statement line items
+type IncomeStatement struct {
+	Revenue         int64 `json:"revenue"`
+	CostOfRevenue   int64 `json:"costOfRevenue"`
+	GrossProfit     int64 `json:"grossProfit"`
+	OperatingIncome int64 `json:"operatingIncome"`
+	NetIncome       int64 `json:"netIncome"`
+	DilutedShares   int64 `json:"dilutedShares"`
+}
+
+// BalanceSheet represents balance sheet line items
+type BalanceSheet struct {
+	TotalAssets        int64 `json:"totalAssets"`
+	TotalLiabilities   int64 `json:"totalLiabilities"`
+	ShareholdersEquity int64 `json:"shareholdersEquity"`
+	CurrentAssets      int64 `json:"currentAssets"`
+	CurrentLiabilities int64 `json:"currentLiabilities"`
+	CashAndEquivalents int64 `json:"cashAndEquivalents"`
+	TotalDebt          int64 `json:"totalDebt"`
+}
+
+// CashFlow represents cash flow statement line items
+type CashFlow struct {
+	OperatingCashFlow   int64 `json:"operatingCashFlow"`
+	CapitalExpenditures int64 `json:"capitalExpenditures"`
+	FreeCashFlow        int64 `json:"freeCashFlow"`
+	DividendsPaid       int64 `json:"dividendsPaid"`
+}
+
+// EPS represents trailing and forward earnings per share
+type EPS struct {
+	Trailing float64 `json:"trailing"`
+	Forward  float64 `json:"forward"`
+}
+
+// KeyRatios represents valuation and profitability ratios derived from fundamentals
+type KeyRatios struct {
+	GrossMargin     float64 `json:"grossMargin"`
+	OperatingMargin float64 `json:"operatingMargin"`
+	NetMargin       float64 `json:"netMargin"`
+	ReturnOnEquity  float64 `json:"returnOnEquity"`
+	ReturnOnAssets  float64 `json:"returnOnAssets"`
+	DebtToEquity    float64 `json:"debtToEquity"`
+	CurrentRatio    float64 `json:"currentRatio"`
+	TrailingPE      float64 `json:"trailingPE"`
+	ForwardPE       float64 `json:"forwardPE"`
+	PriceToSales    float64 `json:"priceToSales"`
+	PriceToBook     float64 `json:"priceToBook"`
+	FreeCashFlowYld float64 `json:"freeCashFlowYield"`
+}
+
+// FundamentalsReport combines a company, its fundamentals and derived ratios
+type FundamentalsReport struct {
+	Symbol       string        `json:"symbol"`
+	CompanyName  string        `json:"companyName"`
+	Exchange     string        `json:"exchange"`
+	Sector       string        `json:"sector"`
+	Industry     string        `json:"industry"`
+	Price        float64       `json:"price"`
+	MarketCap    int64         `json:"marketCap"`
+	Fundamentals *Fundamentals `json:"fundamentals"`
+	Ratios       KeyRatios     `json:"ratios"`
+	Source       string        `json:"source"`
+}
+
+// CompanyMatch represents a company search result
+type CompanyMatch struct {
+	Symbol      string  `json:"symbol"`
+	CompanyName string  `json:"companyName"`
+	Exchange    string  `json:"exchange"`
+	Sector      string  `json:"sector"`
+	Industry    string  `json:"industry"`
+	Price       float64 `json:"price"`
+	Score       float64 `json:"score"`
+}
+
+// CompanySearchOptions narrows a company search
+type CompanySearchOptions struct {
+	Exchange string
+	Sector   string
+	Industry string
+	Limit    int
+}
+
+// CompanyDataset is an in-memory collection of companies keyed by symbol
+type CompanyDataset struct {
+	Source    string    `json:"source"`
+	Companies []Company `json:"companies"`
+	bySymbol  map[string]int
+}
+
+// LoadBundledCompanyDataset loads the company dataset compiled into the binary
+func LoadBundledCompanyDataset() (*CompanyDataset, error) {
+	return parseCompanyJSON(bytes.NewReader(bundledCompanies))
+}
+
+// LoadCompanyDataset imports a company dataset from a JSON or CSV file
+func LoadCompanyDataset(path string) (*CompanyDataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open company dataset: %v", err)
+	}
+	defer file.Close()
+
+	var dataset *CompanyDataset
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dataset, err = parseCompanyJSON(file)
+	case ".csv":
+		dataset, err = parseCompanyCSV(file)
+	default:
+		return nil, fmt.Errorf("unsupported company dataset format: %s (expected .json or .csv)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dataset.Source == "" {
+		dataset.Source = filepath.Base(path)
+	}
+	return dataset, nil
+}
+
+func parseCompanyJSON(r io.Reader) (*CompanyDataset, error) {
+	var dataset CompanyDataset
+	if err := json.NewDecoder(r).Decode(&dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse company dataset: %v", err)
+	}
+	dataset.index()
+	return &dataset, nil
+}
+
+// parseCompanyCSV reads one company per row. The symbol and name columns are
+// required; any statement columns that are present populate Fundamentals.
+func parseCompanyCSV(r io.Reader) (*CompanyDataset, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["symbol"]; !ok {
+		return nil, fmt.Errorf("CSV dataset is missing the symbol column")
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("CSV dataset is missing the name column")
+	}
+
+	dataset := &CompanyDataset{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %v", line, err)
+		}
+
+		str := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		num := func(name string) int64 {
+			v, _ := strconv.ParseFloat(str(name), 64)
+			return int64(v)
+		}
+		dec := func(name string) float64 {
+			v, _ := strconv.ParseFloat(str(name), 64)
+			return v
+		}
+
+		company := Company{
+			Symbol:   strings.ToUpper(str("symbol")),
+			Name:     str("name"),
+			CIK:      str("cik"),
+			Exchange: str("exchange"),
+			Sector:   str("sector"),
+			Industry: str("industry"),
+			Country:  str("country"),
+		}
+		if company.Symbol == "" {
+			continue
+		}
+
+		if str("revenue") != "" || str("total_assets") != "" {
+			currency := str("currency")
+			if currency == "" {
+				currency = "USD"
+			}
+			f := &Fundamentals{
+				FiscalYear: int(num("fiscal_year")),
+				PeriodEnd:  str("period_end"),
+				Currency:   currency,
+				IncomeStatement: IncomeStatement{
+					Revenue:         num("revenue"),
+					CostOfRevenue:   num("cost_of_revenue"),
+					GrossProfit:     num("gross_profit"),
+					OperatingIncome: num("operating_income"),
+					NetIncome:       num("net_income"),
+					DilutedShares:   num("diluted_shares"),
+				},
+				BalanceSheet: BalanceSheet{
+					TotalAssets:        num("total_assets"),
+					TotalLiabilities:   num("total_liabilities"),
+					ShareholdersEquity: num("shareholders_equity"),
+					CurrentAssets:      num("current_assets"),
+					CurrentLiabilities: num("current_liabilities"),
+					CashAndEquivalents: num("cash_and_equivalents"),
+					TotalDebt:          num("total_debt"),
+				},
+				CashFlow: CashFlow{
+					OperatingCashFlow:   num("operating_cash_flow"),
+					CapitalExpenditures: num("capital_expenditures"),
+					FreeCashFlow:        num("free_cash_flow"),
+					DividendsPaid:       num("dividends_paid"),
+				},
+				EPS: EPS{Trailing: dec("eps_trailing"), Forward: dec("eps_forward")},
+			}
+			if f.IncomeStatement.GrossProfit == 0 && f.IncomeStatement.CostOfRevenue != 0 {
+				f.IncomeStatement.GrossProfit = f.IncomeStatement.Revenue - f.IncomeStatement.CostOfRevenue
+			}
+			if f.BalanceSheet.ShareholdersEquity == 0 {
+				f.BalanceSheet.ShareholdersEquity = f.BalanceSheet.TotalAssets - f.BalanceSheet.TotalLiabilities
+			}
+			if f.CashFlow.FreeCashFlow == 0 {
+				f.CashFlow.FreeCashFlow = f.CashFlow.OperatingCashFlow + f.CashFlow.CapitalExpenditures
+			}
+			company.Fundamentals = f
+		}
+
+		dataset.Companies = append(dataset.Companies, company)
+	}
+
+	dataset.index()
+	return dataset, nil
+}
+
+func (d *CompanyDataset) index() {
+	d.bySymbol = make(map[string]int, len(d.Companies))
+	for i, company := range d.Companies {
+		d.bySymbol[strings.ToUpper(company.Symbol)] = i
+	}
+}
+
+// Get returns the company with the given symbol
+func (d *CompanyDataset) Get(symbol string) (*Company, bool) {
+	i, ok := d.bySymbol[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, false
+	}
+	return &d.Companies[i], true
+}
+
+// Search returns companies matching the query, best matches first. Symbols and
+// names are matched exactly, by prefix, by substring and by edit distance, so
+// small typos ("Microsft", "Nvida") still find the company.
+func (d *CompanyDataset) Search(query string, opts CompanySearchOptions) []Company {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	type scored struct {
+		company Company
+		score   float64
+	}
+	var results []scored
+
+	for _, company := range d.Companies {
+		if opts.Exchange != "" && !strings.EqualFold(company.Exchange, opts.Exchange) {
+			continue
+		}
+		if opts.Sector != "" && !strings.EqualFold(company.Sector, opts.Sector) {
+			continue
+		}
+		if opts.Industry != "" && !strings.Contains(strings.ToLower(company.Industry), strings.ToLower(opts.Industry)) {
+			continue
+		}
+
+		score := 1.0
+		if query != "" {
+			score = matchScore(query, company)
+		}
+		if score > 0 {
+			results = append(results, scored{company, score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].company.Symbol < results[j].company.Symbol
+	})
+
+	companies := make([]Company, 0, len(results))
+	for _, r := range results {
+		companies = append(companies, r.company)
+		if opts.Limit > 0 && len(companies) >= opts.Limit {
+			break
+		}
+	}
+	return companies
+}
+
+// matchScore rates how well a lowercase query matches a company, from 0 to 1
+func matchScore(query string, company Company) float64 {
+	symbol := strings.ToLower(company.Symbol)
+	name := strings.ToLower(company.Name)
+
+	switch {
+	case query == symbol:
+		return 1.0
+	case strings.HasPrefix(symbol, query):
+		return 0.9
+	case strings.HasPrefix(name, query):
+		return 0.85
+	case strings.Contains(name, query):
+		return 0.75
+	}
+
+	// Fuzzy match each query word against the words of the company name
+	best := 0.0
+	for _, q := range strings.Fields(query) {
+		for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+			return r == ' ' || r == ',' || r == '.' || r == '&' || r == '-'
+		}) {
+			if sim := similarity(q, word); sim > best {
+				best = sim
+			}
+		}
+	}
+	if best >= 0.75 {
+		return roundTo(0.7*best, 4)
+	}
+
+	if strings.Contains(strings.ToLower(company.Industry), query) || strings.Contains(strings.ToLower(company.Sector), query) {
+		return 0.5
+	}
+	return 0
+}
+
+// similarity returns 1 - normalized Levenshtein distance between two strings
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	longest := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// computeKeyRatios derives ratios from fundamentals and the current share price
+func computeKeyRatios(f *Fundamentals, price float64) KeyRatios {
+	var ratios KeyRatios
+	if f == nil {
+		return ratios
+	}
+
+	ratio := func(num, den float64) float64 {
+		if den == 0 {
+			return 0
+		}
+		return roundTo(num/den, 4)
+	}
+
+	is, bs, cf := f.IncomeStatement, f.BalanceSheet, f.CashFlow
+	marketCap := price * float64(is.DilutedShares)
+
+	ratios.GrossMargin = ratio(float64(is.GrossProfit), float64(is.Revenue))
+	ratios.OperatingMargin = ratio(float64(is.OperatingIncome), float64(is.Revenue))
+	ratios.NetMargin = ratio(float64(is.NetIncome), float64(is.Revenue))
+	ratios.ReturnOnEquity = ratio(float64(is.NetIncome), float64(bs.ShareholdersEquity))
+	ratios.ReturnOnAssets = ratio(float64(is.NetIncome), float64(bs.TotalAssets))
+	ratios.DebtToEquity = ratio(float64(bs.TotalDebt), float64(bs.ShareholdersEquity))
+	ratios.CurrentRatio = ratio(float64(bs.CurrentAssets), float64(bs.CurrentLiabilities))
+	ratios.TrailingPE = ratio(price, f.EPS.Trailing)
+	ratios.ForwardPE = ratio(price, f.EPS.Forward)
+	ratios.PriceToSales = ratio(marketCap, float64(is.Revenue))
+	ratios.PriceToBook = ratio(marketCap, float64(bs.ShareholdersEquity))
+	ratios.FreeCashFlowYld = ratio(float64(cf.FreeCashFlow), marketCap)
+
+	return ratios
+}
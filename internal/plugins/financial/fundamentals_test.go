@@ -0,0 +1,95 @@
+package financial
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+func TestBundledCompanyDatasetSearch(t *testing.T) {
+	dataset, err := LoadBundledCompanyDataset()
+	if err != nil {
+		t.Fatalf("LoadBundledCompanyDataset: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		opts  CompanySearchOptions
+		want  string
+	}{
+		{"exact symbol", "msft", CompanySearchOptions{}, "MSFT"},
+		{"name prefix", "Apple", CompanySearchOptions{}, "AAPL"},
+		{"typo", "Microsft", CompanySearchOptions{}, "MSFT"},
+		{"hyphenated name", "cola", CompanySearchOptions{}, "KO"},
+		{"typo in short name", "Tesle", CompanySearchOptions{}, "TSLA"},
+		{"industry", "semiconductors", CompanySearchOptions{}, "NVDA"},
+		{"exchange filter", "", CompanySearchOptions{Exchange: "NYSE", Sector: "Energy"}, "XOM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := dataset.Search(tt.query, tt.opts)
+			if len(results) == 0 {
+				t.Fatalf("no results for %q", tt.query)
+			}
+			if results[0].Symbol != tt.want {
+				t.Errorf("top result = %s, want %s", results[0].Symbol, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCompanyCSV(t *testing.T) {
+	input := `symbol,name,exchange,sector,industry,fiscal_year,revenue,cost_of_revenue,net_income,diluted_shares,total_assets,total_liabilities,eps_trailing,eps_forward
+acme,Acme Corp,NYSE,Industrials,Tools,2023,1000,600,100,50,2000,1500,2.0,2.5
+beta,Beta Inc,NASDAQ,Technology,Software,,,,,,,,,
+`
+	dataset, err := parseCompanyCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseCompanyCSV: %v", err)
+	}
+	if len(dataset.Companies) != 2 {
+		t.Fatalf("got %d companies, want 2", len(dataset.Companies))
+	}
+
+	acme, ok := dataset.Get("ACME")
+	if !ok || acme.Fundamentals == nil {
+		t.Fatal("expected ACME with fundamentals")
+	}
+	if acme.Fundamentals.IncomeStatement.GrossProfit != 400 {
+		t.Errorf("GrossProfit = %d, want 400", acme.Fundamentals.IncomeStatement.GrossProfit)
+	}
+	if acme.Fundamentals.BalanceSheet.ShareholdersEquity != 500 {
+		t.Errorf("ShareholdersEquity = %d, want 500", acme.Fundamentals.BalanceSheet.ShareholdersEquity)
+	}
+
+	ratios := computeKeyRatios(acme.Fundamentals, 40)
+	if ratios.NetMargin != 0.1 || ratios.TrailingPE != 20 || ratios.ForwardPE != 16 || ratios.PriceToBook != 4 {
+		t.Errorf("unexpected ratios: %+v", ratios)
+	}
+
+	if beta, _ := dataset.Get("BETA"); beta.Fundamentals != nil {
+		t.Error("expected BETA without fundamentals")
+	}
+}
+
+func TestSearchCompaniesExport(t *testing.T) {
+	p := NewPlugin()
+	response, err := p.HandleToolCall(context.Background(), mcp.ToolCallRequest{
+		Name: "search_companies",
+		Arguments: map[string]interface{}{
+			"query":  "microsoft",
+			"export": map[string]interface{}{"format": "csv", "columns": []interface{}{"symbol", "companyName"}},
+		},
+	})
+	if err != nil || response.IsError {
+		t.Fatalf("search_companies: %v %+v", err, response)
+	}
+	resource := response.Content[1].Resource
+	if resource == nil || resource.URI != "export://companies-microsoft.csv" || !strings.HasPrefix(resource.Text, "symbol,companyName\nMSFT,Microsoft") {
+		t.Errorf("export = %+v", resource)
+	}
+}
@@ -0,0 +1,366 @@
+package financial
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FXRate represents the price of one unit of From in units of To
+type FXRate struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Rate   float64 `json:"rate"`
+	Date   string  `json:"date"`
+	Source string  `json:"source"`
+}
+
+// FXProvider supplies foreign exchange rates. A zero date asks for the latest rate.
+type FXProvider interface {
+	Rate(ctx context.Context, from, to string, date time.Time) (FXRate, error)
+}
+
+// ConversionResult represents an amount converted between currencies
+type ConversionResult struct {
+	Amount    float64 `json:"amount"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Rate      float64 `json:"rate"`
+	Converted float64 `json:"converted"`
+	Date      string  `json:"date"`
+	Source    string  `json:"source"`
+}
+
+// symbolSuffixCurrencies maps exchange ticker suffixes to their trading currency
+var symbolSuffixCurrencies = map[string]string{
+	".T":  "JPY",
+	".DE": "EUR",
+	".PA": "EUR",
+	".AS": "EUR",
+	".L":  "GBP",
+	".TO": "CAD",
+	".CO": "DKK",
+	".HK": "HKD",
+	".SW": "CHF",
+	".AX": "AUD",
+}
+
+// symbolSuffixExchanges maps exchange ticker suffixes to calendar exchange codes
+var symbolSuffixExchanges = map[string]string{
+	".T":  "TSE",
+	".DE": "XETRA",
+	".L":  "LSE",
+	".TO": "TSX",
+}
+
+// currencyForSymbol returns the trading currency implied by a ticker suffix
+func currencyForSymbol(symbol string) string {
+	if i := strings.LastIndex(symbol, "."); i > 0 {
+		if currency, ok := symbolSuffixCurrencies[strings.ToUpper(symbol[i:])]; ok {
+			return currency
+		}
+	}
+	return "USD"
+}
+
+// normalizeCurrency validates and upper-cases an ISO 4217 currency code
+func normalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code %q (expected ISO 4217, e.g. USD, EUR, JPY)", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q (expected ISO 4217, e.g. USD, EUR, JPY)", code)
+		}
+	}
+	return code, nil
+}
+
+// convertAmount converts amount between currencies using the provider
+func convertAmount(ctx context.Context, provider FXProvider, amount float64, from, to string, date time.Time) (ConversionResult, error) {
+	rate, err := provider.Rate(ctx, from, to, date)
+	if err != nil {
+		return ConversionResult{}, err
+	}
+	return ConversionResult{
+		Amount:    amount,
+		From:      rate.From,
+		To:        rate.To,
+		Rate:      rate.Rate,
+		Converted: roundTo(amount*rate.Rate, 4),
+		Date:      rate.Date,
+		Source:    rate.Source,
+	}, nil
+}
+
+// MockFXProvider returns deterministic rates around fixed USD reference values
+type MockFXProvider struct{}
+
+// mockUSDRates holds units of each currency per US dollar
+var mockUSDRates = map[string]float64{
+	"USD": 1.0,
+	"EUR": 0.92,
+	"GBP": 0.79,
+	"JPY": 151.5,
+	"CAD": 1.36,
+	"AUD": 1.52,
+	"CHF": 0.90,
+	"CNY": 7.23,
+	"HKD": 7.82,
+	"DKK": 6.87,
+	"INR": 83.4,
+	"MXN": 16.9,
+}
+
+// Rate returns a mock rate that varies slightly, but deterministically, by date
+func (m MockFXProvider) Rate(ctx context.Context, from, to string, date time.Time) (FXRate, error) {
+	if date.IsZero() {
+		date = time.Now()
+	}
+	day := date.Format("2006-01-02")
+
+	perUSD := func(code string) (float64, error) {
+		base, ok := mockUSDRates[code]
+		if !ok {
+			return 0, fmt.Errorf("no mock rate for currency %s", code)
+		}
+		if code == "USD" {
+			return base, nil
+		}
+		h := fnv.New32a()
+		h.Write([]byte(code + day))
+		drift := (float64(h.Sum32()%1000)/1000.0 - 0.5) * 0.01 // +/- 0.5%
+		return base * (1 + drift), nil
+	}
+
+	fromRate, err := perUSD(from)
+	if err != nil {
+		return FXRate{}, err
+	}
+	toRate, err := perUSD(to)
+	if err != nil {
+		return FXRate{}, err
+	}
+
+	return FXRate{From: from, To: to, Rate: roundTo(toRate/fromRate, 6), Date: day, Source: "mock"}, nil
+}
+
+// FileFXProvider serves rates from a local file of dated USD-based snapshots.
+// JSON files hold [{"date": "2024-01-02", "base": "USD", "rates": {"EUR": 0.91}}];
+// CSV files hold date,base,quote,rate rows.
+type FileFXProvider struct {
+	path      string
+	snapshots []fxSnapshot // sorted by date
+}
+
+type fxSnapshot struct {
+	Date  string             `json:"date"`
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// NewFileFXProvider loads rates from a JSON or CSV file
+func NewFileFXProvider(path string) (*FileFXProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FX rates file: %v", err)
+	}
+	defer file.Close()
+
+	var snapshots []fxSnapshot
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(file).Decode(&snapshots); err != nil {
+			return nil, fmt.Errorf("failed to parse FX rates file: %v", err)
+		}
+	case ".csv":
+		snapshots, err = parseFXCSV(file)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported FX rates format: %s (expected .json or .csv)", path)
+	}
+
+	for i := range snapshots {
+		snapshots[i].Base = strings.ToUpper(snapshots[i].Base)
+		if snapshots[i].Base == "" {
+			snapshots[i].Base = "USD"
+		}
+		rates := make(map[string]float64, len(snapshots[i].Rates)+1)
+		for code, rate := range snapshots[i].Rates {
+			// Conversions divide by rates, so zero would give infinite amounts
+			if !(rate > 0) || math.IsInf(rate, 1) {
+				return nil, fmt.Errorf("FX rates file %s: %s rate on %s must be positive, got %v", path, strings.ToUpper(code), snapshots[i].Date, rate)
+			}
+			rates[strings.ToUpper(code)] = rate
+		}
+		rates[snapshots[i].Base] = 1
+		snapshots[i].Rates = rates
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Date < snapshots[j].Date })
+
+	return &FileFXProvider{path: path, snapshots: snapshots}, nil
+}
+
+func parseFXCSV(r io.Reader) ([]fxSnapshot, error) {
+	reader := csv.NewReader(r)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FX rates CSV: %v", err)
+	}
+
+	byKey := make(map[string]*fxSnapshot)
+	var order []string
+	for i, record := range records {
+		if len(record) < 4 {
+			return nil, fmt.Errorf("FX rates CSV line %d: expected date,base,quote,rate", i+1)
+		}
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("FX rates CSV line %d: invalid rate %q", i+1, record[3])
+		}
+
+		date, base := strings.TrimSpace(record[0]), strings.ToUpper(strings.TrimSpace(record[1]))
+		key := date + "|" + base
+		snapshot, ok := byKey[key]
+		if !ok {
+			snapshot = &fxSnapshot{Date: date, Base: base, Rates: map[string]float64{}}
+			byKey[key] = snapshot
+			order = append(order, key)
+		}
+		snapshot.Rates[strings.ToUpper(strings.TrimSpace(record[2]))] = rate
+	}
+
+	snapshots := make([]fxSnapshot, 0, len(order))
+	for _, key := range order {
+		snapshots = append(snapshots, *byKey[key])
+	}
+	return snapshots, nil
+}
+
+// Rate returns the rate from the latest snapshot on or before date
+func (f *FileFXProvider) Rate(ctx context.Context, from, to string, date time.Time) (FXRate, error) {
+	if len(f.snapshots) == 0 {
+		return FXRate{}, fmt.Errorf("FX rates file %s contains no rates", f.path)
+	}
+
+	snapshot := f.snapshots[len(f.snapshots)-1]
+	if !date.IsZero() {
+		day := date.Format("2006-01-02")
+		i := sort.Search(len(f.snapshots), func(i int) bool { return f.snapshots[i].Date > day })
+		if i == 0 {
+			return FXRate{}, fmt.Errorf("no FX rates on or before %s", day)
+		}
+		snapshot = f.snapshots[i-1]
+	}
+
+	fromRate, ok := snapshot.Rates[from]
+	if !ok {
+		return FXRate{}, fmt.Errorf("no %s rate for %s on %s", snapshot.Base, from, snapshot.Date)
+	}
+	toRate, ok := snapshot.Rates[to]
+	if !ok {
+		return FXRate{}, fmt.Errorf("no %s rate for %s on %s", snapshot.Base, to, snapshot.Date)
+	}
+
+	return FXRate{From: from, To: to, Rate: roundTo(toRate/fromRate, 6), Date: snapshot.Date, Source: filepath.Base(f.path)}, nil
+}
+
+// RESTFXProvider fetches rates from an HTTP API that answers
+// GET {baseURL}/{date|latest}?from=XXX&to=YYY with {"date": ..., "rates": {"YYY": rate}},
+// the shape used by Frankfurter-style services. Responses are cached per day.
+type RESTFXProvider struct {
+	baseURL string
+	client  *http.Client
+	mutex   sync.Mutex
+	cache   map[string]FXRate
+}
+
+// NewRESTFXProvider creates a provider for the API at baseURL
+func NewRESTFXProvider(baseURL string) *RESTFXProvider {
+	return &RESTFXProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   make(map[string]FXRate),
+	}
+}
+
+// Rate fetches the rate for the date, or the latest rate for a zero date
+func (r *RESTFXProvider) Rate(ctx context.Context, from, to string, date time.Time) (FXRate, error) {
+	if from == to {
+		day := date
+		if day.IsZero() {
+			day = time.Now()
+		}
+		return FXRate{From: from, To: to, Rate: 1, Date: day.Format("2006-01-02"), Source: r.baseURL}, nil
+	}
+
+	path := "latest"
+	if !date.IsZero() {
+		path = date.Format("2006-01-02")
+	}
+	key := path + "|" + from + "|" + to
+	if path == "latest" {
+		key += "|" + time.Now().Format("2006-01-02")
+	}
+
+	r.mutex.Lock()
+	cached, ok := r.cache[key]
+	r.mutex.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	query := url.Values{"from": {from}, "to": {to}}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s?%s", r.baseURL, path, query.Encode()), nil)
+	if err != nil {
+		return FXRate{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return FXRate{}, fmt.Errorf("FX rate request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return FXRate{}, fmt.Errorf("FX rate API returned HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Date  string             `json:"date"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return FXRate{}, fmt.Errorf("failed to parse FX rate response: %v", err)
+	}
+	rate, ok := body.Rates[to]
+	if !ok || rate <= 0 || math.IsNaN(rate) {
+		return FXRate{}, fmt.Errorf("FX rate API returned no %s rate for %s", to, from)
+	}
+
+	result := FXRate{From: from, To: to, Rate: rate, Date: body.Date, Source: r.baseURL}
+	r.mutex.Lock()
+	r.cache[key] = result
+	r.mutex.Unlock()
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package financial
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileFXProviderCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	rates := "date,base,quote,rate\n" +
+		"2024-01-02,USD,EUR,0.90\n" +
+		"2024-01-02,USD,JPY,140\n" +
+		"2024-01-05,USD,EUR,0.80\n" +
+		"2024-01-05,USD,JPY,150\n"
+	if err := os.WriteFile(path, []byte(rates), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewFileFXProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileFXProvider: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		from, to string
+		date     time.Time
+		want     float64
+		wantDate string
+	}{
+		{"USD", "EUR", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), 0.9, "2024-01-02"},
+		{"USD", "EUR", time.Time{}, 0.8, "2024-01-05"},
+		{"EUR", "JPY", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), 187.5, "2024-01-05"},
+		{"JPY", "USD", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), 0.007143, "2024-01-02"},
+	}
+	for _, tt := range tests {
+		rate, err := provider.Rate(ctx, tt.from, tt.to, tt.date)
+		if err != nil {
+			t.Errorf("Rate(%s, %s): %v", tt.from, tt.to, err)
+			continue
+		}
+		if rate.Rate != tt.want || rate.Date != tt.wantDate {
+			t.Errorf("Rate(%s, %s) = %v on %s, want %v on %s", tt.from, tt.to, rate.Rate, rate.Date, tt.want, tt.wantDate)
+		}
+	}
+
+	if _, err := provider.Rate(ctx, "USD", "EUR", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected error for a date before the first snapshot")
+	}
+}
+
+func TestFileFXProviderRejectsNonPositiveRates(t *testing.T) {
+	dir := t.TempDir()
+	for name, rates := range map[string]string{
+		"zero.csv":     "date,base,quote,rate\n2024-01-02,USD,EUR,0\n",
+		"negative.csv": "date,base,quote,rate\n2024-01-02,USD,JPY,-140\n",
+		"zero.json":    `[{"date": "2024-01-02", "base": "USD", "rates": {"EUR": 0.9, "GBP": 0}}]`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(rates), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewFileFXProvider(path); err == nil {
+			t.Errorf("%s: expected an error for a non-positive rate", name)
+		}
+	}
+}
+
+func TestRESTFXProvider(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/2024-03-01" || r.URL.Query().Get("from") != "USD" || r.URL.Query().Get("to") != "GBP" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"date":  "2024-03-01",
+			"rates": map[string]float64{"GBP": 0.79},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewRESTFXProvider(server.URL)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		rate, err := provider.Rate(context.Background(), "USD", "GBP", date)
+		if err != nil {
+			t.Fatalf("Rate: %v", err)
+		}
+		if rate.Rate != 0.79 {
+			t.Errorf("Rate = %v, want 0.79", rate.Rate)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1 (second should be cached)", requests)
+	}
+}
+
+func TestConvertStockData(t *testing.T) {
+	p := NewPlugin()
+	stock := p.getMockStockData("7203.T")
+	if stock.Currency != "JPY" {
+		t.Fatalf("Currency = %s, want JPY", stock.Currency)
+	}
+
+	if err := p.convertStockData(context.Background(), &stock, "usd"); err != nil {
+		t.Fatalf("convertStockData: %v", err)
+	}
+	if stock.Currency != "USD" || stock.OriginalCurrency != "JPY" {
+		t.Errorf("currencies = %s/%s, want USD/JPY", stock.Currency, stock.OriginalCurrency)
+	}
+	if stock.Price < 20 || stock.Price > 25 {
+		t.Errorf("converted price %v is outside the expected range", stock.Price)
+	}
+}
@@ -0,0 +1,269 @@
+package financial
+
+import (
+	"fmt"
+	"math"
+)
+
+// OptionType is either a call or a put
+type OptionType string
+
+const (
+	Call OptionType = "call"
+	Put  OptionType = "put"
+)
+
+// OptionParams describes a single option contract and its market inputs.
+// Rate, DividendYield and Volatility are annualized decimals; Expiry is in years.
+type OptionParams struct {
+	Type          OptionType `json:"type"`
+	Spot          float64    `json:"spot"`
+	Strike        float64    `json:"strike"`
+	Expiry        float64    `json:"expiryYears"`
+	Rate          float64    `json:"rate"`
+	DividendYield float64    `json:"dividendYield"`
+	Volatility    float64    `json:"volatility"`
+}
+
+// Greeks holds option sensitivities. Theta is per calendar day, vega and rho
+// are per one percentage point change in volatility and rate.
+type Greeks struct {
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Theta float64 `json:"theta"`
+	Vega  float64 `json:"vega"`
+	Rho   float64 `json:"rho"`
+}
+
+// OptionPricer prices an option and computes its Greeks
+type OptionPricer interface {
+	Name() string
+	Price(params OptionParams) (float64, Greeks, error)
+}
+
+func (o OptionParams) validate() error {
+	if o.Type != Call && o.Type != Put {
+		return fmt.Errorf("option type must be call or put, got %q", o.Type)
+	}
+	if o.Spot <= 0 || o.Strike <= 0 {
+		return fmt.Errorf("spot and strike must be positive")
+	}
+	if o.Expiry <= 0 {
+		return fmt.Errorf("time to expiry must be positive")
+	}
+	if o.Volatility <= 0 {
+		return fmt.Errorf("volatility must be positive")
+	}
+	return nil
+}
+
+// normCDF is the standard normal cumulative distribution function
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// normPDF is the standard normal probability density function
+func normPDF(x float64) float64 {
+	return math.Exp(-0.5*x*x) / math.Sqrt(2*math.Pi)
+}
+
+// BlackScholesPricer prices European options with the Black-Scholes-Merton formula
+type BlackScholesPricer struct{}
+
+// Name returns the model name
+func (BlackScholesPricer) Name() string { return "black_scholes" }
+
+// Price returns the closed-form price and analytic Greeks
+func (BlackScholesPricer) Price(o OptionParams) (float64, Greeks, error) {
+	if err := o.validate(); err != nil {
+		return 0, Greeks{}, err
+	}
+
+	sqrtT := math.Sqrt(o.Expiry)
+	d1 := (math.Log(o.Spot/o.Strike) + (o.Rate-o.DividendYield+0.5*o.Volatility*o.Volatility)*o.Expiry) / (o.Volatility * sqrtT)
+	d2 := d1 - o.Volatility*sqrtT
+	discQ := math.Exp(-o.DividendYield * o.Expiry)
+	discR := math.Exp(-o.Rate * o.Expiry)
+
+	greeks := Greeks{
+		Gamma: discQ * normPDF(d1) / (o.Spot * o.Volatility * sqrtT),
+		Vega:  o.Spot * discQ * normPDF(d1) * sqrtT / 100,
+	}
+	decay := -o.Spot * discQ * normPDF(d1) * o.Volatility / (2 * sqrtT)
+
+	var price float64
+	if o.Type == Call {
+		price = o.Spot*discQ*normCDF(d1) - o.Strike*discR*normCDF(d2)
+		greeks.Delta = discQ * normCDF(d1)
+		greeks.Theta = (decay - o.Rate*o.Strike*discR*normCDF(d2) + o.DividendYield*o.Spot*discQ*normCDF(d1)) / 365
+		greeks.Rho = o.Strike * o.Expiry * discR * normCDF(d2) / 100
+	} else {
+		price = o.Strike*discR*normCDF(-d2) - o.Spot*discQ*normCDF(-d1)
+		greeks.Delta = -discQ * normCDF(-d1)
+		greeks.Theta = (decay + o.Rate*o.Strike*discR*normCDF(-d2) - o.DividendYield*o.Spot*discQ*normCDF(-d1)) / 365
+		greeks.Rho = -o.Strike * o.Expiry * discR * normCDF(-d2) / 100
+	}
+
+	return price, greeks, nil
+}
+
+// maxBinomialSteps caps a binomial tree's steps; pricing takes time and
+// memory quadratic in them, several times over for greeks and implied volatility
+const maxBinomialSteps = 5000
+
+// BinomialPricer prices options on a Cox-Ross-Rubinstein tree, supporting early exercise
+type BinomialPricer struct {
+	Steps    int
+	American bool
+}
+
+// Name returns the model name
+func (b BinomialPricer) Name() string {
+	style := "european"
+	if b.American {
+		style = "american"
+	}
+	return fmt.Sprintf("binomial(%d, %s)", b.steps(), style)
+}
+
+func (b BinomialPricer) steps() int {
+	if b.Steps <= 0 {
+		return 200
+	}
+	return b.Steps
+}
+
+// Price returns the tree price. Delta, gamma and theta come from the tree
+// itself; vega and rho are central differences on repriced trees.
+func (b BinomialPricer) Price(o OptionParams) (float64, Greeks, error) {
+	if err := o.validate(); err != nil {
+		return 0, Greeks{}, err
+	}
+
+	price, delta, gamma, theta := b.tree(o)
+
+	bump := func(change func(*OptionParams, float64), h float64) float64 {
+		up, down := o, o
+		change(&up, h)
+		change(&down, -h)
+		upPrice, _, _, _ := b.tree(up)
+		downPrice, _, _, _ := b.tree(down)
+		return (upPrice - downPrice) / 2
+	}
+
+	greeks := Greeks{
+		Delta: delta,
+		Gamma: gamma,
+		Theta: theta / 365,
+		Vega:  bump(func(p *OptionParams, h float64) { p.Volatility += h }, 0.01),
+		Rho:   bump(func(p *OptionParams, h float64) { p.Rate += h }, 0.01),
+	}
+	return price, greeks, nil
+}
+
+// tree rolls the lattice back to the root, returning the price along with
+// delta and gamma from the first two steps and an annualized theta.
+func (b BinomialPricer) tree(o OptionParams) (price, delta, gamma, theta float64) {
+	n := b.steps()
+	dt := o.Expiry / float64(n)
+	u := math.Exp(o.Volatility * math.Sqrt(dt))
+	d := 1 / u
+	p := (math.Exp((o.Rate-o.DividendYield)*dt) - d) / (u - d)
+	disc := math.Exp(-o.Rate * dt)
+
+	payoff := func(spot float64) float64 {
+		if o.Type == Call {
+			return math.Max(spot-o.Strike, 0)
+		}
+		return math.Max(o.Strike-spot, 0)
+	}
+
+	values := make([]float64, n+1)
+	for i := 0; i <= n; i++ {
+		values[i] = payoff(o.Spot * math.Pow(u, float64(i)) * math.Pow(d, float64(n-i)))
+	}
+
+	var level1, level2 [3]float64
+	if n == 2 {
+		copy(level2[:], values)
+	}
+	for step := n - 1; step >= 0; step-- {
+		for i := 0; i <= step; i++ {
+			value := disc * (p*values[i+1] + (1-p)*values[i])
+			if b.American {
+				spot := o.Spot * math.Pow(u, float64(i)) * math.Pow(d, float64(step-i))
+				value = math.Max(value, payoff(spot))
+			}
+			values[i] = value
+		}
+		switch step {
+		case 2:
+			copy(level2[:], values[:3])
+		case 1:
+			copy(level1[:2], values[:2])
+		}
+	}
+	price = values[0]
+
+	if n >= 2 {
+		delta = (level1[1] - level1[0]) / (o.Spot*u - o.Spot*d)
+		upDelta := (level2[2] - level2[1]) / (o.Spot*u*u - o.Spot)
+		downDelta := (level2[1] - level2[0]) / (o.Spot - o.Spot*d*d)
+		gamma = (upDelta - downDelta) / (0.5 * (o.Spot*u*u - o.Spot*d*d))
+		theta = (level2[1] - price) / (2 * dt)
+	}
+	return price, delta, gamma, theta
+}
+
+// ImpliedVolatility solves for the volatility at which the pricer matches
+// marketPrice, using Newton steps safeguarded by bisection.
+func ImpliedVolatility(pricer OptionPricer, o OptionParams, marketPrice float64) (float64, error) {
+	if marketPrice <= 0 {
+		return 0, fmt.Errorf("market price must be positive")
+	}
+
+	priceAt := func(vol float64) (float64, float64, error) {
+		o.Volatility = vol
+		price, greeks, err := pricer.Price(o)
+		return price - marketPrice, greeks.Vega * 100, err
+	}
+
+	low, high := 1e-4, 5.0
+	lowDiff, _, err := priceAt(low)
+	if err != nil {
+		return 0, err
+	}
+	highDiff, _, err := priceAt(high)
+	if err != nil {
+		return 0, err
+	}
+	if lowDiff > 0 || highDiff < 0 {
+		return 0, fmt.Errorf("market price %.4f is outside the range the model can produce", marketPrice)
+	}
+
+	vol := 0.3
+	for i := 0; i < 100; i++ {
+		diff, vega, err := priceAt(vol)
+		if err != nil {
+			return 0, err
+		}
+		if math.Abs(diff) < 1e-8 {
+			return vol, nil
+		}
+		if diff > 0 {
+			high = vol
+		} else {
+			low = vol
+		}
+
+		next := vol - diff/vega
+		if vega <= 1e-10 || next <= low || next >= high {
+			next = (low + high) / 2
+		}
+		vol = next
+		if high-low < 1e-10 {
+			return vol, nil
+		}
+	}
+	return vol, nil
+}
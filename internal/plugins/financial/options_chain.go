@@ -0,0 +1,205 @@
+package financial
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/calendar"
+)
+
+// OptionContract represents a single listed option
+type OptionContract struct {
+	ContractSymbol    string     `json:"contractSymbol"`
+	Type              OptionType `json:"type"`
+	Strike            float64    `json:"strike"`
+	Bid               float64    `json:"bid"`
+	Ask               float64    `json:"ask"`
+	Last              float64    `json:"last"`
+	Volume            int64      `json:"volume"`
+	OpenInterest      int64      `json:"openInterest"`
+	ImpliedVolatility float64    `json:"impliedVolatility"`
+	Delta             float64    `json:"delta"`
+	InTheMoney        bool       `json:"inTheMoney"`
+}
+
+// OptionExpiration groups the calls and puts sharing an expiration date
+type OptionExpiration struct {
+	Date         string           `json:"date"`
+	DaysToExpiry int              `json:"daysToExpiry"`
+	Calls        []OptionContract `json:"calls"`
+	Puts         []OptionContract `json:"puts"`
+}
+
+// OptionsChain represents the listed options for an underlying
+type OptionsChain struct {
+	Symbol      string             `json:"symbol"`
+	Underlying  float64            `json:"underlyingPrice"`
+	Currency    string             `json:"currency"`
+	Expirations []OptionExpiration `json:"expirations"`
+	Source      string             `json:"source"`
+	Timestamp   string             `json:"timestamp"`
+}
+
+// OptionsSource supplies option chains for an underlying symbol
+type OptionsSource interface {
+	Chain(ctx context.Context, symbol string, asOf time.Time) (*OptionsChain, error)
+}
+
+// MockOptionsSource builds chains for monthly expirations by pricing each
+// strike with Black-Scholes on a deterministic volatility smile
+type MockOptionsSource struct {
+	quote       func(symbol string) StockData
+	calendarFor func(symbol string) *calendar.Calendar
+	Rate        float64
+	Expirations int
+	Strikes     int // strikes on each side of the money
+}
+
+// NewMockOptionsSource creates a mock source that takes spot prices from the plugin's quotes
+func NewMockOptionsSource(p *Plugin) *MockOptionsSource {
+	return &MockOptionsSource{
+		quote:       p.getMockStockData,
+		calendarFor: p.calendarForSymbol,
+		Rate:        0.045,
+		Expirations: 6,
+		Strikes:     8,
+	}
+}
+
+// Chain returns the mock chain as of the given time
+func (m *MockOptionsSource) Chain(ctx context.Context, symbol string, asOf time.Time) (*OptionsChain, error) {
+	quote := m.quote(symbol)
+	spot := quote.Price
+	if spot <= 0 {
+		return nil, fmt.Errorf("no price available for %s", symbol)
+	}
+	cal := m.calendarFor(symbol)
+	baseVol := mockBaseVolatility(symbol)
+	step := strikeIncrement(spot)
+	atm := math.Round(spot/step) * step
+
+	chain := &OptionsChain{
+		Symbol:     symbol,
+		Underlying: spot,
+		Currency:   quote.Currency,
+		Source:     "mock",
+		Timestamp:  quote.Timestamp,
+	}
+
+	for _, expiry := range monthlyExpirations(cal, asOf, m.Expirations) {
+		days := int(math.Ceil(expiry.Sub(asOf).Hours() / 24))
+		years := float64(days) / 365
+		expiration := OptionExpiration{Date: expiry.Format("2006-01-02"), DaysToExpiry: days}
+
+		for i := -m.Strikes; i <= m.Strikes; i++ {
+			strike := roundTo(atm+float64(i)*step, 2)
+			if strike <= 0 {
+				continue
+			}
+			// Equity-style skew: lower strikes trade at higher implied volatility,
+			// with the smile flattening for longer-dated expirations.
+			moneyness := math.Log(strike/spot) / math.Sqrt(years)
+			vol := baseVol * (1 - 0.08*moneyness + 0.04*moneyness*moneyness)
+			vol = math.Max(vol, 0.05)
+
+			for _, optionType := range []OptionType{Call, Put} {
+				params := OptionParams{Type: optionType, Spot: spot, Strike: strike, Expiry: years, Rate: m.Rate, Volatility: vol}
+				price, greeks, err := BlackScholesPricer{}.Price(params)
+				if err != nil {
+					return nil, err
+				}
+				contract := mockContract(symbol, expiry, params, price, greeks.Delta)
+				if optionType == Call {
+					expiration.Calls = append(expiration.Calls, contract)
+				} else {
+					expiration.Puts = append(expiration.Puts, contract)
+				}
+			}
+		}
+		chain.Expirations = append(chain.Expirations, expiration)
+	}
+
+	return chain, nil
+}
+
+// mockContract quotes a contract around its model price with deterministic size
+func mockContract(symbol string, expiry time.Time, o OptionParams, price, delta float64) OptionContract {
+	typeCode := "C"
+	inTheMoney := o.Spot > o.Strike
+	if o.Type == Put {
+		typeCode = "P"
+		inTheMoney = o.Spot < o.Strike
+	}
+	contractSymbol := fmt.Sprintf("%s%s%s%08d", symbol, expiry.Format("060102"), typeCode, int64(math.Round(o.Strike*1000)))
+
+	h := fnv.New32a()
+	h.Write([]byte(contractSymbol))
+	seed := h.Sum32()
+
+	spread := math.Max(0.01, price*0.04)
+	bid := math.Max(0, price-spread/2)
+	// Interest concentrates near the money and in nearer expirations
+	liquidity := math.Exp(-8*math.Abs(math.Log(o.Strike/o.Spot))) / (1 + o.Expiry)
+	openInterest := int64(float64(500+seed%4500) * liquidity)
+	volume := int64(float64(openInterest) * float64(seed%30) / 100)
+
+	return OptionContract{
+		ContractSymbol:    contractSymbol,
+		Type:              o.Type,
+		Strike:            o.Strike,
+		Bid:               roundTo(bid, 2),
+		Ask:               roundTo(bid+spread, 2),
+		Last:              roundTo(price, 2),
+		Volume:            volume,
+		OpenInterest:      openInterest,
+		ImpliedVolatility: roundTo(o.Volatility, 4),
+		Delta:             roundTo(delta, 4),
+		InTheMoney:        inTheMoney,
+	}
+}
+
+// mockBaseVolatility returns a stable at-the-money volatility between 18% and 42%
+func mockBaseVolatility(symbol string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(symbol))
+	return 0.18 + float64(h.Sum32()%240)/1000
+}
+
+// strikeIncrement returns a listed-strike spacing appropriate to the price level
+func strikeIncrement(spot float64) float64 {
+	switch {
+	case spot < 25:
+		return 1
+	case spot < 100:
+		return 2.5
+	case spot < 250:
+		return 5
+	case spot < 1000:
+		return 10
+	default:
+		return math.Pow(10, math.Floor(math.Log10(spot))-1) * 5
+	}
+}
+
+// monthlyExpirations returns the next n monthly expirations after t: the third
+// Friday of each month, moved to the prior trading day when that is a holiday.
+// Options expire at the close of that session.
+func monthlyExpirations(cal *calendar.Calendar, t time.Time, n int) []time.Time {
+	local := t.In(cal.Location)
+	var expirations []time.Time
+	for month := 0; len(expirations) < n && month < n+2; month++ {
+		first := time.Date(local.Year(), local.Month()+time.Month(month), 1, 0, 0, 0, 0, cal.Location)
+		day := first.AddDate(0, 0, (int(time.Friday)-int(first.Weekday())+7)%7+14)
+		for !cal.IsTradingDay(day) {
+			day = day.AddDate(0, 0, -1)
+		}
+		session := cal.SessionOn(day)
+		if session.Close.After(t) {
+			expirations = append(expirations, session.Close)
+		}
+	}
+	return expirations
+}
@@ -0,0 +1,159 @@
+package financial
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+func assertClose(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.6f, want %.6f (±%g)", name, got, want, tolerance)
+	}
+}
+
+// Reference values are from Hull, "Options, Futures, and Other Derivatives",
+// and Haug, "The Complete Guide to Option Pricing Formulas".
+func TestBlackScholesReferencePrices(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    OptionParams
+		want      float64
+		tolerance float64 // half a unit in the last published digit
+	}{
+		{"Hull call", OptionParams{Type: Call, Spot: 42, Strike: 40, Expiry: 0.5, Rate: 0.10, Volatility: 0.2}, 4.76, 0.005},
+		{"Hull put", OptionParams{Type: Put, Spot: 42, Strike: 40, Expiry: 0.5, Rate: 0.10, Volatility: 0.2}, 0.81, 0.005},
+		{"Haug put with dividend yield", OptionParams{Type: Put, Spot: 100, Strike: 95, Expiry: 0.5, Rate: 0.10, DividendYield: 0.05, Volatility: 0.2}, 2.4648, 0.00005},
+	}
+
+	for _, tt := range tests {
+		price, _, err := BlackScholesPricer{}.Price(tt.params)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		assertClose(t, tt.name, price, tt.want, tt.tolerance)
+	}
+}
+
+func TestBlackScholesReferenceGreeks(t *testing.T) {
+	// Hull's running example: 20-week call, S=49, K=50, r=5%, sigma=20%.
+	// Hull quotes theta per year and vega/rho per unit change.
+	params := OptionParams{Type: Call, Spot: 49, Strike: 50, Expiry: 20.0 / 52, Rate: 0.05, Volatility: 0.2}
+	price, greeks, err := BlackScholesPricer{}.Price(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertClose(t, "price", price, 2.40, 0.005)
+	assertClose(t, "delta", greeks.Delta, 0.522, 0.0005)
+	assertClose(t, "gamma", greeks.Gamma, 0.066, 0.0005)
+	assertClose(t, "theta", greeks.Theta*365, -4.31, 0.005)
+	assertClose(t, "vega", greeks.Vega*100, 12.1, 0.05)
+	assertClose(t, "rho", greeks.Rho*100, 8.91, 0.005)
+}
+
+func TestBinomialAmericanPut(t *testing.T) {
+	// Hull's five-step tree for an American put: S=K=50, r=10%, sigma=40%, five months.
+	params := OptionParams{Type: Put, Spot: 50, Strike: 50, Expiry: 5.0 / 12, Rate: 0.10, Volatility: 0.4}
+
+	price, _, err := BinomialPricer{Steps: 5, American: true}.Price(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "5-step price", price, 4.49, 0.005)
+
+	european, _, _ := BinomialPricer{Steps: 500}.Price(params)
+	american, greeks, _ := BinomialPricer{Steps: 500, American: true}.Price(params)
+	if american <= european {
+		t.Errorf("American put %.4f should be worth more than European %.4f", american, european)
+	}
+	if greeks.Delta >= 0 || greeks.Delta < -1 || greeks.Gamma <= 0 {
+		t.Errorf("unexpected put Greeks: %+v", greeks)
+	}
+}
+
+func TestBinomialConvergesToBlackScholes(t *testing.T) {
+	params := OptionParams{Type: Call, Spot: 49, Strike: 50, Expiry: 20.0 / 52, Rate: 0.05, Volatility: 0.2}
+
+	bsPrice, bsGreeks, _ := BlackScholesPricer{}.Price(params)
+	treePrice, treeGreeks, _ := BinomialPricer{Steps: 1000}.Price(params)
+
+	assertClose(t, "price", treePrice, bsPrice, 0.005)
+	assertClose(t, "delta", treeGreeks.Delta, bsGreeks.Delta, 0.005)
+	assertClose(t, "gamma", treeGreeks.Gamma, bsGreeks.Gamma, 0.005)
+	assertClose(t, "theta", treeGreeks.Theta, bsGreeks.Theta, 0.001)
+	assertClose(t, "vega", treeGreeks.Vega, bsGreeks.Vega, 0.005)
+	assertClose(t, "rho", treeGreeks.Rho, bsGreeks.Rho, 0.005)
+}
+
+func TestImpliedVolatility(t *testing.T) {
+	// Hull: a European call priced at 1.875 with S=21, K=20, r=10%, three months implies about 23.5%.
+	params := OptionParams{Type: Call, Spot: 21, Strike: 20, Expiry: 0.25, Rate: 0.10}
+	vol, err := ImpliedVolatility(BlackScholesPricer{}, params, 1.875)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "implied volatility", vol, 0.235, 0.001)
+
+	// Round trip through the American tree
+	params = OptionParams{Type: Put, Spot: 50, Strike: 55, Expiry: 0.75, Rate: 0.04, Volatility: 0.32}
+	pricer := BinomialPricer{Steps: 200, American: true}
+	price, _, _ := pricer.Price(params)
+	vol, err = ImpliedVolatility(pricer, params, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "round trip volatility", vol, 0.32, 1e-6)
+
+	if _, err := ImpliedVolatility(BlackScholesPricer{}, params, 0.5); err == nil {
+		t.Error("expected error for a price below intrinsic value")
+	}
+}
+
+func TestMockOptionsChainExpirations(t *testing.T) {
+	p := NewPlugin()
+	p.now = func() time.Time { return time.Date(2025, 3, 25, 15, 0, 0, 0, time.UTC) }
+
+	chain, err := p.options.Chain(context.Background(), "AAPL", p.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// April 18, 2025 is Good Friday, so April options expire on Thursday the 17th.
+	want := []string{"2025-04-17", "2025-05-16", "2025-06-20"}
+	if len(chain.Expirations) < len(want) {
+		t.Fatalf("got %d expirations", len(chain.Expirations))
+	}
+	for i, date := range want {
+		if chain.Expirations[i].Date != date {
+			t.Errorf("expiration[%d] = %s, want %s", i, chain.Expirations[i].Date, date)
+		}
+	}
+
+	for _, contract := range chain.Expirations[0].Calls {
+		if contract.Bid > contract.Ask || contract.ImpliedVolatility <= 0 {
+			t.Errorf("bad quote: %+v", contract)
+		}
+	}
+}
+
+func TestPriceOptionRejectsTooManySteps(t *testing.T) {
+	p := NewPlugin()
+	args := map[string]interface{}{
+		"option_type": "call", "spot": 100.0, "strike": 100.0, "days_to_expiry": 30.0, "volatility": 0.2,
+		"model": "binomial", "steps": 1e6,
+	}
+	response, err := p.HandleToolCall(context.Background(), mcp.ToolCallRequest{Name: "price_option", Arguments: args})
+	if err != nil || !response.IsError || !strings.Contains(response.Content[0].Text, "at most 5000") {
+		t.Fatalf("%v %+v", err, response)
+	}
+	args["steps"] = 500.0
+	if response, err := p.HandleToolCall(context.Background(), mcp.ToolCallRequest{Name: "price_option", Arguments: args}); err != nil || response.IsError {
+		t.Errorf("%v %+v", err, response)
+	}
+}
@@ -4,14 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/johan-j/play-mcp/pkg/calendar"
+	"github.com/johan-j/play-mcp/pkg/export"
 	"github.com/johan-j/play-mcp/pkg/mcp"
 )
 
 // Plugin implements the MCP plugin interface for financial data
-type Plugin struct{}
+type Plugin struct {
+	companies *CompanyDataset
+	fx        FXProvider
+	options   OptionsSource
+	exporter  *export.Exporter
+	now       func() time.Time
+}
 
 // StockData represents stock information
 type StockData struct {
@@ -23,15 +33,40 @@ type StockData struct {
 	Volume        int64   `json:"volume"`
 	MarketCap     int64   `json:"marketCap"`
 	PE            float64 `json:"pe"`
-	Timestamp     string  `json:"timestamp"`
+	Currency      string  `json:"currency"`
+	MarketState   string  `json:"marketState,omitempty"`
+	Timestamp     string  `json:"timestamp"` // Quote time, or the last close when the market is not in its regular session
+	// Set when prices were converted from the listing currency
+	OriginalCurrency string  `json:"originalCurrency,omitempty"`
+	FXRate           float64 `json:"fxRate,omitempty"`
+}
+
+// PriceBar represents a single day of OHLCV price data
+type PriceBar struct {
+	Date   string  `json:"date"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume int64   `json:"volume"`
+}
+
+// HistoricalData represents a price series for a symbol
+type HistoricalData struct {
+	Symbol           string     `json:"symbol"`
+	Period           string     `json:"period"`
+	Currency         string     `json:"currency"`
+	OriginalCurrency string     `json:"originalCurrency,omitempty"`
+	Data             []PriceBar `json:"data"`
 }
 
 // MarketSummary represents market summary data
 type MarketSummary struct {
-	Indices    []IndexData `json:"indices"`
-	TopGainers []StockData `json:"topGainers"`
-	TopLosers  []StockData `json:"topLosers"`
-	Timestamp  string      `json:"timestamp"`
+	Indices     []IndexData `json:"indices"`
+	TopGainers  []StockData `json:"topGainers"`
+	TopLosers   []StockData `json:"topLosers"`
+	MarketState string      `json:"marketState"`
+	Timestamp   string      `json:"timestamp"`
 }
 
 // IndexData represents stock index information
@@ -40,11 +75,40 @@ type IndexData struct {
 	Value         float64 `json:"value"`
 	Change        float64 `json:"change"`
 	ChangePercent float64 `json:"changePercent"`
+	Currency      string  `json:"currency"`
 }
 
-// NewPlugin creates a new financial plugin instance
+// NewPlugin creates a new financial plugin instance backed by the bundled company dataset
 func NewPlugin() *Plugin {
-	return &Plugin{}
+	companies, err := LoadBundledCompanyDataset()
+	if err != nil {
+		companies = &CompanyDataset{}
+		companies.index()
+	}
+	p := &Plugin{companies: companies, fx: MockFXProvider{}, now: time.Now}
+	p.options = NewMockOptionsSource(p)
+	return p
+}
+
+// SetOptionsSource replaces the source used for option chains
+func (p *Plugin) SetOptionsSource(source OptionsSource) {
+	p.options = source
+}
+
+// SetFXProvider replaces the provider used for currency conversion
+func (p *Plugin) SetFXProvider(provider FXProvider) {
+	p.fx = provider
+}
+
+// SetCompanyDataset replaces the company dataset used for search and fundamentals
+func (p *Plugin) SetCompanyDataset(dataset *CompanyDataset) {
+	p.companies = dataset
+}
+
+// SetExporter sets the exporter that serves download links for exported
+// results; without one, exports are only embedded in responses
+func (p *Plugin) SetExporter(exporter *export.Exporter) {
+	p.exporter = exporter
 }
 
 // Name returns the plugin name
@@ -68,7 +132,11 @@ func (p *Plugin) GetTools() []mcp.Tool {
 				Properties: map[string]interface{}{
 					"symbol": map[string]interface{}{
 						"type":        "string",
-						"description": "Stock symbol (e.g., AAPL, GOOGL, MSFT)",
+						"description": "Stock symbol (e.g., AAPL, GOOGL, MSFT, 7203.T, SAP.DE)",
+					},
+					"currency": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional ISO 4217 currency code to convert prices into (e.g., USD, EUR, JPY)",
 					},
 				},
 				Required: []string{"symbol"},
@@ -76,18 +144,50 @@ func (p *Plugin) GetTools() []mcp.Tool {
 		},
 		{
 			Name:        "search_companies",
-			Description: "Search for companies by name or partial name",
+			Description: "Search for companies by symbol, name or partial name (tolerates typos), optionally filtered by exchange, sector or industry",
 			InputSchema: mcp.ToolSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "Company name or partial name to search for",
+						"description": "Company symbol, name or partial name to search for",
+					},
+					"export": export.Schema(),
+					"exchange": map[string]interface{}{
+						"type":        "string",
+						"description": "Exchange to filter by (e.g., NASDAQ, NYSE)",
+					},
+					"sector": map[string]interface{}{
+						"type":        "string",
+						"description": "Sector to filter by (e.g., Technology, Healthcare)",
+					},
+					"industry": map[string]interface{}{
+						"type":        "string",
+						"description": "Industry to filter by (e.g., Semiconductors)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of results",
+						"default":     10,
 					},
 				},
 				Required: []string{"query"},
 			},
 		},
+		{
+			Name:        "get_fundamentals",
+			Description: "Get company fundamentals: income statement, balance sheet, cash flow, key ratios and trailing/forward EPS",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Stock symbol (e.g., AAPL, GOOGL, MSFT)",
+					},
+				},
+				Required: []string{"symbol"},
+			},
+		},
 		{
 			Name:        "get_market_summary",
 			Description: "Get current market summary including major indices and top movers",
@@ -102,6 +202,7 @@ func (p *Plugin) GetTools() []mcp.Tool {
 			InputSchema: mcp.ToolSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"export": export.Schema(),
 					"symbol": map[string]interface{}{
 						"type":        "string",
 						"description": "Stock symbol (e.g., AAPL, GOOGL, MSFT)",
@@ -111,10 +212,194 @@ func (p *Plugin) GetTools() []mcp.Tool {
 						"description": "Time period (1d, 5d, 1mo, 3mo, 6mo, 1y, 2y, 5y, 10y, ytd, max)",
 						"default":     "1mo",
 					},
+					"currency": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional ISO 4217 currency code to convert prices into, using each day's rate",
+					},
 				},
 				Required: []string{"symbol"},
 			},
 		},
+		{
+			Name:        "get_market_status",
+			Description: "Get whether an exchange is open (pre-market, regular, after-hours or closed), today's session hours, the last close, the next open and upcoming holidays",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"exchange": map[string]interface{}{
+						"type":        "string",
+						"description": "Exchange code (NYSE, NASDAQ, LSE, TSE, XETRA, TSX)",
+						"default":     "NYSE",
+					},
+					"at": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional RFC3339 timestamp to evaluate instead of now",
+					},
+				},
+			},
+		},
+		{
+			Name:        "convert_currency",
+			Description: "Convert an amount between currencies using current or historical FX rates",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"amount": map[string]interface{}{
+						"type":        "number",
+						"description": "Amount to convert",
+					},
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "ISO 4217 currency code to convert from (e.g., USD)",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "ISO 4217 currency code to convert to (e.g., EUR)",
+					},
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional date (YYYY-MM-DD) for a historical rate; defaults to the latest rate",
+					},
+				},
+				Required: []string{"amount", "from", "to"},
+			},
+		},
+		{
+			Name:        "backtest_strategy",
+			Description: "Backtest a simple trading strategy (buy-and-hold, SMA crossover, RSI thresholds) over historical data, returning the equity curve, trades, CAGR, max drawdown, Sharpe ratio and win rate",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Stock symbol (e.g., AAPL, GOOGL, MSFT)",
+					},
+					"start_date": map[string]interface{}{
+						"type":        "string",
+						"description": "Start date (YYYY-MM-DD), defaults to one year before end_date",
+					},
+					"end_date": map[string]interface{}{
+						"type":        "string",
+						"description": "End date (YYYY-MM-DD), defaults to today",
+					},
+					"strategy": map[string]interface{}{
+						"type":        "object",
+						"description": "Strategy definition, e.g. {\"type\": \"sma_crossover\", \"fast\": 20, \"slow\": 50}, {\"type\": \"rsi\", \"period\": 14, \"oversold\": 30, \"overbought\": 70} or {\"type\": \"buy_and_hold\"}",
+					},
+					"initial_capital": map[string]interface{}{
+						"type":        "number",
+						"description": "Starting cash in dollars",
+						"default":     10000,
+					},
+					"commission": map[string]interface{}{
+						"type":        "number",
+						"description": "Flat commission per trade in dollars",
+						"default":     0,
+					},
+					"slippage_bps": map[string]interface{}{
+						"type":        "number",
+						"description": "Slippage applied to each fill in basis points",
+						"default":     0,
+					},
+				},
+				Required: []string{"symbol", "strategy"},
+			},
+		},
+		{
+			Name:        "get_options_chain",
+			Description: "Get the options chain for a stock: expirations, strikes, bid/ask, volume, open interest, implied volatility and delta",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Underlying stock symbol (e.g., AAPL, MSFT)",
+					},
+					"expiration": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional expiration date (YYYY-MM-DD) to return only that expiration",
+					},
+					"option_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Return only calls or puts",
+						"enum":        []string{"call", "put"},
+					},
+				},
+				Required: []string{"symbol"},
+			},
+		},
+		{
+			Name:        "price_option",
+			Description: "Price a call or put with Black-Scholes or a binomial tree (European or American), returning Greeks, or solve for implied volatility from a market price",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"option_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Option type",
+						"enum":        []string{"call", "put"},
+					},
+					"strike": map[string]interface{}{
+						"type":        "number",
+						"description": "Strike price",
+					},
+					"spot": map[string]interface{}{
+						"type":        "number",
+						"description": "Underlying price; defaults to the current quote for symbol",
+					},
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Underlying symbol used to look up spot when spot is omitted",
+					},
+					"expiration": map[string]interface{}{
+						"type":        "string",
+						"description": "Expiration date (YYYY-MM-DD); alternatively pass days_to_expiry",
+					},
+					"days_to_expiry": map[string]interface{}{
+						"type":        "number",
+						"description": "Calendar days until expiration",
+					},
+					"volatility": map[string]interface{}{
+						"type":        "number",
+						"description": "Annualized volatility as a decimal (0.25 = 25%); required unless market_price is given",
+					},
+					"market_price": map[string]interface{}{
+						"type":        "number",
+						"description": "Observed option price; when given, implied volatility is solved for",
+					},
+					"rate": map[string]interface{}{
+						"type":        "number",
+						"description": "Annualized risk-free rate as a decimal",
+						"default":     0.045,
+					},
+					"dividend_yield": map[string]interface{}{
+						"type":        "number",
+						"description": "Annualized continuous dividend yield as a decimal",
+						"default":     0,
+					},
+					"model": map[string]interface{}{
+						"type":        "string",
+						"description": "Pricing model",
+						"enum":        []string{"black_scholes", "binomial"},
+						"default":     "black_scholes",
+					},
+					"style": map[string]interface{}{
+						"type":        "string",
+						"description": "Exercise style; american requires the binomial model",
+						"enum":        []string{"european", "american"},
+						"default":     "european",
+					},
+					"steps": map[string]interface{}{
+						"type":        "number",
+						"description": "Binomial tree steps",
+						"default":     200,
+						"minimum":     2,
+						"maximum":     maxBinomialSteps,
+					},
+				},
+				Required: []string{"option_type", "strike"},
+			},
+		},
 	}
 }
 
@@ -125,10 +410,22 @@ func (p *Plugin) HandleToolCall(ctx context.Context, request mcp.ToolCallRequest
 		return p.handleGetStockData(ctx, request.Arguments)
 	case "search_companies":
 		return p.handleSearchCompanies(ctx, request.Arguments)
+	case "get_fundamentals":
+		return p.handleGetFundamentals(ctx, request.Arguments)
 	case "get_market_summary":
 		return p.handleGetMarketSummary(ctx, request.Arguments)
 	case "get_historical_data":
 		return p.handleGetHistoricalData(ctx, request.Arguments)
+	case "get_market_status":
+		return p.handleGetMarketStatus(ctx, request.Arguments)
+	case "convert_currency":
+		return p.handleConvertCurrency(ctx, request.Arguments)
+	case "backtest_strategy":
+		return p.handleBacktestStrategy(ctx, request.Arguments)
+	case "get_options_chain":
+		return p.handleGetOptionsChain(ctx, request.Arguments)
+	case "price_option":
+		return p.handlePriceOption(ctx, request.Arguments)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", request.Name)
 	}
@@ -164,6 +461,15 @@ func (p *Plugin) handleGetStockData(ctx context.Context, args map[string]interfa
 	// Mock data - in real implementation, you would call a financial API
 	stockData := p.getMockStockData(strings.ToUpper(symbol))
 
+	if currency, ok := args["currency"].(string); ok && currency != "" {
+		if err := p.convertStockData(ctx, &stockData, currency); err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error converting currency: %v", err)}},
+			}, nil
+		}
+	}
+
 	data, err := json.MarshalIndent(stockData, "", "  ")
 	if err != nil {
 		return &mcp.ToolCallResponse{
@@ -189,8 +495,44 @@ func (p *Plugin) handleSearchCompanies(ctx context.Context, args map[string]inte
 		}, nil
 	}
 
-	// Mock search results
-	companies := p.searchMockCompanies(query)
+	opts := CompanySearchOptions{Limit: 10}
+	if v, ok := args["exchange"].(string); ok {
+		opts.Exchange = v
+	}
+	if v, ok := args["sector"].(string); ok {
+		opts.Sector = v
+	}
+	if v, ok := args["industry"].(string); ok {
+		opts.Industry = v
+	}
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		opts.Limit = int(v)
+	}
+	exportRequest, exporting, err := export.ParseRequest(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	companies := []CompanyMatch{}
+	for _, company := range p.companies.Search(query, opts) {
+		companies = append(companies, CompanyMatch{
+			Symbol:      company.Symbol,
+			CompanyName: company.Name,
+			Exchange:    company.Exchange,
+			Sector:      company.Sector,
+			Industry:    company.Industry,
+			Price:       p.getMockStockData(company.Symbol).Price,
+			Score:       roundTo(matchScore(strings.ToLower(strings.TrimSpace(query)), company), 4),
+		})
+	}
+
+	if exporting {
+		name := "companies-" + strings.Join(strings.Fields(strings.ToLower(query)), "-")
+		return p.exporter.Respond(companies, name, exportRequest), nil
+	}
 
 	data, err := json.MarshalIndent(companies, "", "  ")
 	if err != nil {
@@ -208,6 +550,54 @@ func (p *Plugin) handleSearchCompanies(ctx context.Context, args map[string]inte
 	}, nil
 }
 
+func (p *Plugin) handleGetFundamentals(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	symbol, ok := args["symbol"].(string)
+	if !ok || symbol == "" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "symbol parameter is required and must be a string"}},
+		}, nil
+	}
+	symbol = strings.ToUpper(symbol)
+
+	company, found := p.companies.Get(symbol)
+	if !found || company.Fundamentals == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("No fundamentals available for %s", symbol)}},
+		}, nil
+	}
+
+	quote := p.getMockStockData(symbol)
+	report := FundamentalsReport{
+		Symbol:       company.Symbol,
+		CompanyName:  company.Name,
+		Exchange:     company.Exchange,
+		Sector:       company.Sector,
+		Industry:     company.Industry,
+		Price:        quote.Price,
+		MarketCap:    int64(quote.Price * float64(company.Fundamentals.IncomeStatement.DilutedShares)),
+		Fundamentals: company.Fundamentals,
+		Ratios:       computeKeyRatios(company.Fundamentals, quote.Price),
+		Source:       p.companies.Source,
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling fundamentals: %v", err)}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Fundamentals for %s (FY%d):", symbol, company.Fundamentals.FiscalYear)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
 func (p *Plugin) handleGetMarketSummary(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
 	summary := p.getMockMarketSummary()
 
@@ -240,10 +630,32 @@ func (p *Plugin) handleGetHistoricalData(ctx context.Context, args map[string]in
 	if p, ok := args["period"].(string); ok {
 		period = p
 	}
+	exportRequest, exporting, err := export.ParseRequest(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
 
 	// Mock historical data
 	historicalData := p.getMockHistoricalData(strings.ToUpper(symbol), period)
 
+	if currency, ok := args["currency"].(string); ok && currency != "" {
+		if err := p.convertHistoricalData(ctx, &historicalData, currency); err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error converting currency: %v", err)}},
+			}, nil
+		}
+	}
+
+	// Exports hold one row per day
+	if exporting {
+		name := strings.ToLower(historicalData.Symbol + "-" + period)
+		return p.exporter.Respond(historicalData.Data, name, exportRequest), nil
+	}
+
 	data, err := json.MarshalIndent(historicalData, "", "  ")
 	if err != nil {
 		return &mcp.ToolCallResponse{
@@ -260,82 +672,364 @@ func (p *Plugin) handleGetHistoricalData(ctx context.Context, args map[string]in
 	}, nil
 }
 
+func (p *Plugin) handleGetMarketStatus(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	exchange := "NYSE"
+	if v, ok := args["exchange"].(string); ok && v != "" {
+		exchange = v
+	}
+	cal, err := calendar.ForExchange(exchange)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	at := p.now()
+	if v, ok := args["at"].(string); ok && v != "" {
+		if at, err = time.Parse(time.RFC3339, v); err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: "at must be an RFC3339 timestamp (e.g., 2024-07-03T14:00:00-04:00)"}},
+			}, nil
+		}
+	}
+
+	report := struct {
+		calendar.Status
+		UpcomingClosures []calendar.Closure `json:"upcomingClosures"`
+	}{
+		Status:           cal.Status(at),
+		UpcomingClosures: cal.UpcomingClosures(at, 5),
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling market status: %v", err)}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("%s is %s:", cal.Exchange, report.State)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+func (p *Plugin) handleConvertCurrency(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	amount, ok := args["amount"].(float64)
+	if !ok {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "amount parameter is required and must be a number"}},
+		}, nil
+	}
+
+	fromArg, _ := args["from"].(string)
+	from, err := normalizeCurrency(fromArg)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("from parameter: %v", err)}},
+		}, nil
+	}
+	toArg, _ := args["to"].(string)
+	to, err := normalizeCurrency(toArg)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("to parameter: %v", err)}},
+		}, nil
+	}
+
+	var date time.Time
+	if s, ok := args["date"].(string); ok && s != "" {
+		if date, err = time.Parse("2006-01-02", s); err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: "date must be in YYYY-MM-DD format"}},
+			}, nil
+		}
+	}
+
+	result, err := convertAmount(ctx, p.fx, amount, from, to, date)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error converting currency: %v", err)}},
+		}, nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling conversion: %v", err)}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("%g %s = %g %s:", amount, from, result.Converted, to)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+// convertStockData converts a quote's price fields into the target currency
+func (p *Plugin) convertStockData(ctx context.Context, stock *StockData, currency string) error {
+	target, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	if target == stock.Currency {
+		return nil
+	}
+
+	rate, err := p.fx.Rate(ctx, stock.Currency, target, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	stock.OriginalCurrency = stock.Currency
+	stock.Currency = target
+	stock.FXRate = rate.Rate
+	stock.Price = roundTo(stock.Price*rate.Rate, 4)
+	stock.Change = roundTo(stock.Change*rate.Rate, 4)
+	stock.MarketCap = int64(float64(stock.MarketCap) * rate.Rate)
+	return nil
+}
+
+// convertHistoricalData converts each bar using the FX rate for that bar's date
+func (p *Plugin) convertHistoricalData(ctx context.Context, history *HistoricalData, currency string) error {
+	target, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	if target == history.Currency {
+		return nil
+	}
+
+	for i, bar := range history.Data {
+		date, err := time.Parse("2006-01-02", bar.Date)
+		if err != nil {
+			return err
+		}
+		rate, err := p.fx.Rate(ctx, history.Currency, target, date)
+		if err != nil {
+			return err
+		}
+		history.Data[i].Open = roundTo(bar.Open*rate.Rate, 4)
+		history.Data[i].High = roundTo(bar.High*rate.Rate, 4)
+		history.Data[i].Low = roundTo(bar.Low*rate.Rate, 4)
+		history.Data[i].Close = roundTo(bar.Close*rate.Rate, 4)
+	}
+
+	history.OriginalCurrency = history.Currency
+	history.Currency = target
+	return nil
+}
+
+func (p *Plugin) handleBacktestStrategy(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	symbol, ok := args["symbol"].(string)
+	if !ok || symbol == "" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "symbol parameter is required and must be a string"}},
+		}, nil
+	}
+	symbol = strings.ToUpper(symbol)
+
+	strategy, err := parseStrategy(args["strategy"])
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Invalid strategy: %v", err)}},
+		}, nil
+	}
+
+	end := p.now()
+	if s, ok := args["end_date"].(string); ok && s != "" {
+		if end, err = time.Parse("2006-01-02", s); err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: "end_date must be in YYYY-MM-DD format"}},
+			}, nil
+		}
+	}
+	start := end.AddDate(-1, 0, 0)
+	if s, ok := args["start_date"].(string); ok && s != "" {
+		if start, err = time.Parse("2006-01-02", s); err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: "start_date must be in YYYY-MM-DD format"}},
+			}, nil
+		}
+	}
+	if !start.Before(end) {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "start_date must be before end_date"}},
+		}, nil
+	}
+
+	config := BacktestConfig{InitialCapital: 10000}
+	if v, ok := args["initial_capital"].(float64); ok && v > 0 {
+		config.InitialCapital = v
+	}
+	if v, ok := args["commission"].(float64); ok && v >= 0 {
+		config.Commission = v
+	}
+	if v, ok := args["slippage_bps"].(float64); ok && v >= 0 {
+		config.SlippageBps = v
+	}
+
+	bars := p.getMockPriceSeries(symbol, start, end)
+	result := runBacktest(symbol, bars, strategy, config)
+	result.Benchmark = runBacktest(symbol, bars, BuyAndHoldStrategy{}, config).Metrics
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling backtest result: %v", err)}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Backtest of %s on %s (%s to %s):", result.Strategy, symbol, result.StartDate, result.EndDate)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
 // Mock data functions (replace with real API calls in production)
 
 func (p *Plugin) getMockStockData(symbol string) StockData {
 	mockData := map[string]StockData{
 		"AAPL": {
 			Symbol: "AAPL", CompanyName: "Apple Inc.", Price: 193.50, Change: 2.30, ChangePercent: 1.20,
-			Volume: 45000000, MarketCap: 3000000000000, PE: 25.4, Timestamp: time.Now().Format(time.RFC3339),
+			Volume: 45000000, MarketCap: 3000000000000, PE: 25.4, Currency: "USD",
 		},
 		"GOOGL": {
 			Symbol: "GOOGL", CompanyName: "Alphabet Inc.", Price: 140.20, Change: -1.50, ChangePercent: -1.06,
-			Volume: 25000000, MarketCap: 1800000000000, PE: 22.1, Timestamp: time.Now().Format(time.RFC3339),
+			Volume: 25000000, MarketCap: 1800000000000, PE: 22.1, Currency: "USD",
 		},
 		"MSFT": {
 			Symbol: "MSFT", CompanyName: "Microsoft Corporation", Price: 410.80, Change: 5.20, ChangePercent: 1.28,
-			Volume: 35000000, MarketCap: 3100000000000, PE: 28.7, Timestamp: time.Now().Format(time.RFC3339),
+			Volume: 35000000, MarketCap: 3100000000000, PE: 28.7, Currency: "USD",
+		},
+		"7203.T": {
+			Symbol: "7203.T", CompanyName: "Toyota Motor Corporation", Price: 3450.00, Change: 42.00, ChangePercent: 1.23,
+			Volume: 28000000, MarketCap: 56000000000000, PE: 9.8, Currency: "JPY",
+		},
+		"SAP.DE": {
+			Symbol: "SAP.DE", CompanyName: "SAP SE", Price: 178.40, Change: -1.20, ChangePercent: -0.67,
+			Volume: 1500000, MarketCap: 208000000000, PE: 48.2, Currency: "EUR",
+		},
+		"SHOP.TO": {
+			Symbol: "SHOP.TO", CompanyName: "Shopify Inc.", Price: 104.20, Change: 2.10, ChangePercent: 2.06,
+			Volume: 2100000, MarketCap: 134000000000, PE: 72.5, Currency: "CAD",
+		},
+		"HSBA.L": {
+			Symbol: "HSBA.L", CompanyName: "HSBC Holdings plc", Price: 6.52, Change: 0.04, ChangePercent: 0.62,
+			Volume: 24000000, MarketCap: 121000000000, PE: 7.4, Currency: "GBP",
 		},
 	}
 
 	if data, exists := mockData[symbol]; exists {
+		p.stampQuote(&data)
 		return data
 	}
 
 	// Return generic data for unknown symbols
-	return StockData{
-		Symbol: symbol, CompanyName: symbol + " Corporation", Price: 100.00, Change: 0.50, ChangePercent: 0.50,
-		Volume: 1000000, MarketCap: 50000000000, PE: 20.0, Timestamp: time.Now().Format(time.RFC3339),
+	companyName := symbol + " Corporation"
+	if company, found := p.companies.Get(symbol); found {
+		companyName = company.Name
+	}
+	data := StockData{
+		Symbol: symbol, CompanyName: companyName, Price: 100.00, Change: 0.50, ChangePercent: 0.50,
+		Volume: 1000000, MarketCap: 50000000000, PE: 20.0, Currency: currencyForSymbol(symbol),
 	}
+	p.stampQuote(&data)
+	return data
 }
 
-func (p *Plugin) searchMockCompanies(query string) []StockData {
-	allCompanies := []StockData{
-		{Symbol: "AAPL", CompanyName: "Apple Inc.", Price: 193.50},
-		{Symbol: "GOOGL", CompanyName: "Alphabet Inc.", Price: 140.20},
-		{Symbol: "MSFT", CompanyName: "Microsoft Corporation", Price: 410.80},
-		{Symbol: "AMZN", CompanyName: "Amazon.com Inc.", Price: 145.30},
-		{Symbol: "TSLA", CompanyName: "Tesla Inc.", Price: 248.90},
-		{Symbol: "META", CompanyName: "Meta Platforms Inc.", Price: 485.20},
-		{Symbol: "NVDA", CompanyName: "NVIDIA Corporation", Price: 875.60},
+// stampQuote sets the market state and as-of time of a quote from its exchange calendar
+func (p *Plugin) stampQuote(data *StockData) {
+	status := p.calendarForSymbol(data.Symbol).Status(p.now())
+	data.MarketState = status.State
+	if status.State == calendar.StateRegular {
+		data.Timestamp = status.LocalTime.Format(time.RFC3339)
+	} else {
+		data.Timestamp = status.LastClose.Format(time.RFC3339)
 	}
+}
 
-	var results []StockData
-	queryLower := strings.ToLower(query)
-
-	for _, company := range allCompanies {
-		if strings.Contains(strings.ToLower(company.CompanyName), queryLower) ||
-			strings.Contains(strings.ToLower(company.Symbol), queryLower) {
-			results = append(results, company)
+// calendarForSymbol returns the trading calendar of the exchange listing a symbol
+func (p *Plugin) calendarForSymbol(symbol string) *calendar.Calendar {
+	if i := strings.LastIndex(symbol, "."); i > 0 {
+		if code, ok := symbolSuffixExchanges[strings.ToUpper(symbol[i:])]; ok {
+			if cal, err := calendar.ForExchange(code); err == nil {
+				return cal
+			}
 		}
 	}
-
-	return results
+	if company, found := p.companies.Get(symbol); found {
+		if cal, err := calendar.ForExchange(company.Exchange); err == nil {
+			return cal
+		}
+	}
+	return calendar.NYSE
 }
 
 func (p *Plugin) getMockMarketSummary() MarketSummary {
+	status := calendar.NYSE.Status(p.now())
+	timestamp := status.LastClose
+	if status.State == calendar.StateRegular {
+		timestamp = status.LocalTime
+	}
+
 	return MarketSummary{
 		Indices: []IndexData{
-			{Name: "S&P 500", Value: 5200.45, Change: 25.30, ChangePercent: 0.49},
-			{Name: "NASDAQ", Value: 16800.20, Change: -45.80, ChangePercent: -0.27},
-			{Name: "Dow Jones", Value: 38500.10, Change: 150.60, ChangePercent: 0.39},
+			{Name: "S&P 500", Value: 5200.45, Change: 25.30, ChangePercent: 0.49, Currency: "USD"},
+			{Name: "NASDAQ", Value: 16800.20, Change: -45.80, ChangePercent: -0.27, Currency: "USD"},
+			{Name: "Dow Jones", Value: 38500.10, Change: 150.60, ChangePercent: 0.39, Currency: "USD"},
 		},
 		TopGainers: []StockData{
-			{Symbol: "XYZ", CompanyName: "XYZ Corp", Price: 25.50, Change: 5.20, ChangePercent: 25.60},
-			{Symbol: "ABC", CompanyName: "ABC Inc", Price: 45.80, Change: 8.30, ChangePercent: 22.10},
+			{Symbol: "XYZ", CompanyName: "XYZ Corp", Price: 25.50, Change: 5.20, ChangePercent: 25.60, Currency: "USD"},
+			{Symbol: "ABC", CompanyName: "ABC Inc", Price: 45.80, Change: 8.30, ChangePercent: 22.10, Currency: "USD"},
 		},
 		TopLosers: []StockData{
-			{Symbol: "DEF", CompanyName: "DEF Ltd", Price: 15.20, Change: -3.80, ChangePercent: -20.00},
-			{Symbol: "GHI", CompanyName: "GHI Corp", Price: 32.10, Change: -6.20, ChangePercent: -16.20},
+			{Symbol: "DEF", CompanyName: "DEF Ltd", Price: 15.20, Change: -3.80, ChangePercent: -20.00, Currency: "USD"},
+			{Symbol: "GHI", CompanyName: "GHI Corp", Price: 32.10, Change: -6.20, ChangePercent: -16.20, Currency: "USD"},
 		},
-		Timestamp: time.Now().Format(time.RFC3339),
+		MarketState: status.State,
+		Timestamp:   timestamp.Format(time.RFC3339),
 	}
 }
 
-func (p *Plugin) getMockHistoricalData(symbol, period string) map[string]interface{} {
-	// Generate mock historical data points
-	dataPoints := make([]map[string]interface{}, 0)
+func (p *Plugin) getMockHistoricalData(symbol, period string) HistoricalData {
+	end := p.now()
+	start := end.AddDate(0, 0, -30)
+
+	return HistoricalData{
+		Symbol:   symbol,
+		Period:   period,
+		Currency: currencyForSymbol(symbol),
+		Data:     p.getMockPriceSeries(symbol, start, end),
+	}
+}
 
+// getMockPriceSeries generates a deterministic daily price series for a symbol.
+// Each bar depends only on the symbol and its date, so the same range always
+// yields the same data regardless of when or how it is requested. Bars exist
+// only for trading days of the symbol's exchange up to the last completed session.
+func (p *Plugin) getMockPriceSeries(symbol string, start, end time.Time) []PriceBar {
 	basePrice := 100.0
 	if symbol == "AAPL" {
 		basePrice = 190.0
@@ -343,27 +1037,230 @@ func (p *Plugin) getMockHistoricalData(symbol, period string) map[string]interfa
 		basePrice = 135.0
 	} else if symbol == "MSFT" {
 		basePrice = 400.0
+	} else if currencyForSymbol(symbol) != "USD" {
+		basePrice = p.getMockStockData(symbol).Price
 	}
 
-	// Generate 30 days of mock data
-	for i := 30; i >= 0; i-- {
-		date := time.Now().AddDate(0, 0, -i)
-		variation := (float64(i%10) - 5) * 2.0 // Simple variation
-		price := basePrice + variation
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	seed := h.Sum64()
 
-		dataPoints = append(dataPoints, map[string]interface{}{
-			"date":   date.Format("2006-01-02"),
-			"open":   price - 1.0,
-			"high":   price + 2.0,
-			"low":    price - 2.5,
-			"close":  price,
-			"volume": 1000000 + (i * 50000),
+	// Anchor the series to a fixed epoch so overlapping ranges agree
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cal := p.calendarForSymbol(symbol)
+	if lastClose := cal.LastClose(p.now()); end.After(lastClose) {
+		end = lastClose
+	}
+
+	bars := make([]PriceBar, 0)
+	for _, tradingDay := range cal.TradingDays(start, end) {
+		date := time.Date(tradingDay.Year(), tradingDay.Month(), tradingDay.Day(), 0, 0, 0, 0, time.UTC)
+		day := date.Sub(epoch).Hours() / 24
+		noise := float64((seed+uint64(int64(day))*2654435761)%1000)/1000.0 - 0.5
+
+		// Slow drift plus two cycles gives the series trends and reversals
+		price := basePrice * math.Exp(0.0002*day+0.08*math.Sin(2*math.Pi*day/90)+0.03*math.Sin(2*math.Pi*day/23)+0.01*noise)
+		price = math.Round(price*100) / 100
+
+		bars = append(bars, PriceBar{
+			Date:   date.Format("2006-01-02"),
+			Open:   math.Round((price-0.4*noise*basePrice/100)*100) / 100,
+			High:   math.Round(price*1.01*100) / 100,
+			Low:    math.Round(price*0.99*100) / 100,
+			Close:  price,
+			Volume: 1000000 + int64(seed%500000) + int64(math.Abs(noise)*500000),
 		})
 	}
 
-	return map[string]interface{}{
-		"symbol": symbol,
-		"period": period,
-		"data":   dataPoints,
+	return bars
+}
+
+func (p *Plugin) handleGetOptionsChain(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	symbol, ok := args["symbol"].(string)
+	if !ok || symbol == "" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "symbol parameter is required and must be a string"}},
+		}, nil
+	}
+	symbol = strings.ToUpper(symbol)
+
+	chain, err := p.options.Chain(ctx, symbol, p.now())
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error fetching options chain: %v", err)}},
+		}, nil
+	}
+
+	if expiration, ok := args["expiration"].(string); ok && expiration != "" {
+		var matched []OptionExpiration
+		available := make([]string, 0, len(chain.Expirations))
+		for _, e := range chain.Expirations {
+			available = append(available, e.Date)
+			if e.Date == expiration {
+				matched = append(matched, e)
+			}
+		}
+		if len(matched) == 0 {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("No %s options expire on %s. Available expirations: %s", symbol, expiration, strings.Join(available, ", "))}},
+			}, nil
+		}
+		chain.Expirations = matched
+	}
+
+	switch optionType, _ := args["option_type"].(string); strings.ToLower(optionType) {
+	case "call":
+		for i := range chain.Expirations {
+			chain.Expirations[i].Puts = nil
+		}
+	case "put":
+		for i := range chain.Expirations {
+			chain.Expirations[i].Calls = nil
+		}
+	}
+
+	data, err := json.MarshalIndent(chain, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling options chain: %v", err)}},
+		}, nil
 	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Options chain for %s (%d expirations):", symbol, len(chain.Expirations))},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+// OptionPriceResult represents the output of price_option
+type OptionPriceResult struct {
+	Model             string       `json:"model"`
+	Params            OptionParams `json:"params"`
+	Price             float64      `json:"price"`
+	Greeks            Greeks       `json:"greeks"`
+	ImpliedVolatility float64      `json:"impliedVolatility,omitempty"`
+	Intrinsic         float64      `json:"intrinsicValue"`
+	TimeValue         float64      `json:"timeValue"`
+}
+
+func (p *Plugin) handlePriceOption(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	errorResponse := func(text string) (*mcp.ToolCallResponse, error) {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: text}},
+		}, nil
+	}
+
+	optionType, _ := args["option_type"].(string)
+	params := OptionParams{Type: OptionType(strings.ToLower(optionType)), Rate: 0.045}
+	if params.Type != Call && params.Type != Put {
+		return errorResponse("option_type is required and must be call or put")
+	}
+	strike, ok := args["strike"].(float64)
+	if !ok {
+		return errorResponse("strike parameter is required and must be a number")
+	}
+	params.Strike = strike
+
+	if spot, ok := args["spot"].(float64); ok {
+		params.Spot = spot
+	} else if symbol, ok := args["symbol"].(string); ok && symbol != "" {
+		params.Spot = p.getMockStockData(strings.ToUpper(symbol)).Price
+	} else {
+		return errorResponse("either spot or symbol is required")
+	}
+
+	if days, ok := args["days_to_expiry"].(float64); ok {
+		params.Expiry = days / 365
+	} else if expiration, ok := args["expiration"].(string); ok && expiration != "" {
+		date, err := time.Parse("2006-01-02", expiration)
+		if err != nil {
+			return errorResponse("expiration must be a date in YYYY-MM-DD format")
+		}
+		now := p.now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		params.Expiry = date.Sub(today).Hours() / 24 / 365
+	} else {
+		return errorResponse("either expiration or days_to_expiry is required")
+	}
+
+	if v, ok := args["rate"].(float64); ok {
+		params.Rate = v
+	}
+	if v, ok := args["dividend_yield"].(float64); ok {
+		params.DividendYield = v
+	}
+
+	var pricer OptionPricer = BlackScholesPricer{}
+	style, _ := args["style"].(string)
+	american := strings.EqualFold(style, "american")
+	if model, _ := args["model"].(string); strings.EqualFold(model, "binomial") {
+		steps := 200
+		if v, ok := args["steps"].(float64); ok && v >= 2 {
+			steps = int(v)
+		}
+		if steps > maxBinomialSteps {
+			return errorResponse(fmt.Sprintf("steps must be at most %d", maxBinomialSteps))
+		}
+		pricer = BinomialPricer{Steps: steps, American: american}
+	} else if american {
+		return errorResponse("American exercise requires the binomial model")
+	}
+
+	result := OptionPriceResult{Model: pricer.Name()}
+	if marketPrice, ok := args["market_price"].(float64); ok {
+		params.Volatility = 0.3 // placeholder so the inputs validate before solving
+		if err := params.validate(); err != nil {
+			return errorResponse(err.Error())
+		}
+		vol, err := ImpliedVolatility(pricer, params, marketPrice)
+		if err != nil {
+			return errorResponse(fmt.Sprintf("Error solving implied volatility: %v", err))
+		}
+		params.Volatility = vol
+		result.ImpliedVolatility = roundTo(vol, 6)
+	} else if vol, ok := args["volatility"].(float64); ok {
+		params.Volatility = vol
+	} else {
+		return errorResponse("either volatility or market_price is required")
+	}
+
+	price, greeks, err := pricer.Price(params)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Error pricing option: %v", err))
+	}
+
+	intrinsic := math.Max(params.Spot-params.Strike, 0)
+	if params.Type == Put {
+		intrinsic = math.Max(params.Strike-params.Spot, 0)
+	}
+	result.Params = params
+	result.Price = roundTo(price, 4)
+	result.Greeks = Greeks{
+		Delta: roundTo(greeks.Delta, 4),
+		Gamma: roundTo(greeks.Gamma, 4),
+		Theta: roundTo(greeks.Theta, 4),
+		Vega:  roundTo(greeks.Vega, 4),
+		Rho:   roundTo(greeks.Rho, 4),
+	}
+	result.Intrinsic = roundTo(intrinsic, 4)
+	result.TimeValue = roundTo(price-intrinsic, 4)
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Error marshaling option price: %v", err))
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("%.2f strike %s priced with %s (theta per day, vega and rho per 1%%):", params.Strike, params.Type, result.Model)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
 }
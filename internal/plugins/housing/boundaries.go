@@ -0,0 +1,115 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// NeighborhoodInfo describes a registered neighborhood or school district
+type NeighborhoodInfo struct {
+	Name                string   `json:"name"`
+	Slug                string   `json:"slug"`
+	Kind                string   `json:"kind"`
+	City                string   `json:"city,omitempty"`
+	State               string   `json:"state,omitempty"`
+	Bounds              geo.BBox `json:"bounds"`
+	CollectedProperties int      `json:"collectedProperties"` // Collected properties with precise locations inside it
+}
+
+// SetBoundaries sets the registry of neighborhood and school district
+// boundaries that collected properties are assigned to and that search
+// URLs take their neighborhood slugs from
+func (p *Plugin) SetBoundaries(boundaries *geo.BoundaryRegistry) {
+	p.boundaries = boundaries
+}
+
+// searchQuery builds a listing search for an area, using the registered
+// neighborhood's slug so listing site URLs match the registry
+func (p *Plugin) searchQuery(city, state, neighborhood string) scraper.SearchQuery {
+	query := scraper.SearchQuery{City: city, State: state, Neighborhood: neighborhood, Status: "sold"}
+	if p.boundaries != nil && neighborhood != "" {
+		if b, ok := p.boundaries.Find(geo.KindNeighborhood, city, state, neighborhood); ok {
+			query.NeighborhoodSlug = b.Slug
+		}
+	}
+	return query
+}
+
+// assignBoundaries sets a property's neighborhood and school district to the
+// registered boundaries containing it. Only locations from the listing, its
+// parcel record or its exact address are used: ZIP code and city centroids say
+// nothing about which neighborhood a property is in.
+func (p *Plugin) assignBoundaries(property *PropertyData) {
+	if p.boundaries == nil || !preciseLocation(*property) {
+		return
+	}
+	location := geo.Point{Lat: property.Latitude, Lon: property.Longitude}
+	if b, ok := p.boundaries.Locate(geo.KindNeighborhood, location); ok {
+		property.Neighborhood = b.Name
+	}
+	if b, ok := p.boundaries.Locate(geo.KindSchoolDistrict, location); ok {
+		property.SchoolDistrict = b.Name
+	}
+}
+
+func (p *Plugin) handleListNeighborhoods(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	city, _ := args["city"].(string)
+	state, _ := args["state"].(string)
+	kind, _ := args["kind"].(string)
+	if kind == "" {
+		kind = geo.KindNeighborhood
+	}
+	if kind != geo.KindNeighborhood && kind != geo.KindSchoolDistrict && kind != "all" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("unknown kind %q (available: %s, %s, all)", kind, geo.KindNeighborhood, geo.KindSchoolDistrict)}},
+		}, nil
+	}
+	if p.boundaries == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "no neighborhood boundaries are loaded; start the server with -boundaries"}},
+		}, nil
+	}
+
+	if kind == "all" {
+		kind = ""
+	}
+	p.loadCollected(ctx)
+	list := []NeighborhoodInfo{}
+	for _, b := range p.boundaries.List(kind, city, state) {
+		list = append(list, NeighborhoodInfo{
+			Name:                b.Name,
+			Slug:                b.Slug,
+			Kind:                b.Kind,
+			City:                b.City,
+			State:               b.State,
+			Bounds:              b.Bounds(),
+			CollectedProperties: len(p.index.InArea(b.Area)),
+		})
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling neighborhoods: %v", err)}},
+		}, nil
+	}
+	area := "all areas"
+	if label := strings.Trim(areaName(city, state, ""), ", "); label != "" {
+		area = label
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Found %d registered boundaries in %s:", len(list), area)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
@@ -0,0 +1,81 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+func TestBoundaryAssignment(t *testing.T) {
+	p := NewPlugin()
+	listings := []scraper.Property{
+		// Homes.com calls this Manoa, but it is inside the Kaimuki boundary
+		{Address: "1 Waialae Ave", City: "Honolulu", State: "HI", Neighborhood: "Manoa", Status: "sold", Price: 1100000, Latitude: 21.2850, Longitude: -157.8000},
+		{Address: "2819 Poelua St", City: "Honolulu", State: "HI", Status: "sold", Price: 1500000, Latitude: 21.3124, Longitude: -157.8087},
+		// Placed only at its ZIP code's centroid, so not assigned
+		{Address: "3122 Kaloaluiki St", City: "Honolulu", State: "HI", ZipCode: "96822", Status: "sold", Price: 1400000},
+	}
+	for i := range listings {
+		listings[i].ID = scraper.PropertyID(listings[i].Address, listings[i].City, listings[i].State)
+	}
+	if err := p.RegisterSource(statsSource{listings: listings}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSearchSource("test"); err != nil {
+		t.Fatal(err)
+	}
+	geocoder := geo.NewLocalGeocoder(scraper.PropertyKey)
+	geocoder.AddZipCode("96822", geo.Point{Lat: 21.3133, Lon: -157.8116})
+	p.SetGeocoder(geocoder)
+
+	square := func(minLat, minLon, maxLat, maxLon float64) geo.MultiPolygon {
+		return geo.MultiPolygon{{Outer: []geo.Point{{Lat: minLat, Lon: minLon}, {Lat: minLat, Lon: maxLon}, {Lat: maxLat, Lon: maxLon}, {Lat: maxLat, Lon: minLon}}}}
+	}
+	registry := geo.NewBoundaryRegistry()
+	for _, b := range []geo.Boundary{
+		{Kind: geo.KindNeighborhood, Name: "Manoa", Slug: "manoa-valley", City: "Honolulu", State: "HI", Area: square(21.295, -157.83, 21.345, -157.795)},
+		{Kind: geo.KindNeighborhood, Name: "Kaimuki", City: "Honolulu", State: "HI", Area: square(21.27, -157.81, 21.295, -157.785)},
+		{Kind: geo.KindSchoolDistrict, Name: "Honolulu District", City: "Honolulu", State: "HI", Area: square(21.25, -157.88, 21.37, -157.71)},
+	} {
+		if err := registry.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.SetBoundaries(registry)
+
+	if query := p.searchQuery("Honolulu", "HI", "manoa"); query.NeighborhoodSlug != "manoa-valley" {
+		t.Errorf("query = %+v", query)
+	}
+
+	properties, err := p.searchRealProperties(context.Background(), SearchFilters{City: "Honolulu", State: "HI"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]string{
+		"1 Waialae Ave":      {"Kaimuki", "Honolulu District"},
+		"2819 Poelua St":     {"Manoa", "Honolulu District"},
+		"3122 Kaloaluiki St": {"", ""},
+	}
+	for _, property := range properties {
+		if got := [2]string{property.Neighborhood, property.SchoolDistrict}; got != want[property.Address] {
+			t.Errorf("%s assigned to %v, want %v", property.Address, got, want[property.Address])
+		}
+	}
+
+	response, err := p.HandleToolCall(context.Background(), mcp.ToolCallRequest{Name: "list_neighborhoods", Arguments: map[string]interface{}{"city": "Honolulu"}})
+	if err != nil || response.IsError {
+		t.Fatalf("list_neighborhoods: %v %+v", err, response)
+	}
+	var list []NeighborhoodInfo
+	if err := json.Unmarshal([]byte(response.Content[1].Text), &list); err != nil {
+		t.Fatal(err)
+	}
+	// The ZIP-geocoded property's centroid does not count toward Manoa's collected properties
+	if len(list) != 2 || list[0].Name != "Kaimuki" || list[0].CollectedProperties != 1 || list[1].Slug != "manoa-valley" || list[1].CollectedProperties != 1 {
+		t.Errorf("list = %+v", list)
+	}
+}
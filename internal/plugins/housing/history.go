@@ -0,0 +1,285 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/store"
+)
+
+// statusForSale is the listing status of homes on the market
+const statusForSale = "for_sale"
+
+// Listing change query limits
+const (
+	defaultChangesDays  = 30
+	defaultChangesLimit = 100
+	maxChangesLimit     = 500
+)
+
+// PropertyHistory is a property's recorded listing events and price history
+type PropertyHistory struct {
+	Property  PropertyRef          `json:"property"`
+	Status    string               `json:"status"`
+	FirstSeen time.Time            `json:"firstSeen"`
+	FetchedAt time.Time            `json:"fetchedAt"`
+	Events    []store.ListingEvent `json:"events"`
+	// PriceHistory merges the listing site's history with the recorded
+	// events, newest first
+	PriceHistory []PriceEvent `json:"priceHistory"`
+}
+
+// ListingChanges is what changed in an area's listings since a date
+type ListingChanges struct {
+	City         string               `json:"city"`
+	State        string               `json:"state"`
+	Neighborhood string               `json:"neighborhood,omitempty"`
+	Since        Date                 `json:"since"`
+	Counts       map[string]int       `json:"counts"` // Events of each kind, including any past the limit
+	Total        int                  `json:"total"`
+	Events       []store.ListingEvent `json:"events"` // Newest first
+}
+
+// mergePriceHistory adds recorded listing events to a listing site's price
+// history, skipping events the site already lists that day
+func mergePriceHistory(site []PriceEvent, events []store.ListingEvent) []PriceEvent {
+	key := func(e PriceEvent) string {
+		return e.Date.Format(dateLayout) + "|" + e.Event + "|" + fmt.Sprint(e.Price)
+	}
+	history := append([]PriceEvent{}, site...)
+	seen := make(map[string]bool)
+	for _, e := range site {
+		seen[key(e)] = true
+	}
+	for _, event := range events {
+		observed := event.ObservedAt.UTC()
+		e := PriceEvent{
+			Date:  Date{time.Date(observed.Year(), observed.Month(), observed.Day(), 0, 0, 0, 0, time.UTC)},
+			Event: strings.ReplaceAll(event.Event, "_", " "),
+			Price: int64(event.Price),
+		}
+		if !seen[key(e)] {
+			seen[key(e)] = true
+			history = append(history, e)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Date.After(history[j].Date.Time) })
+	return history
+}
+
+// historyProperty finds the stored property a get_property_history call
+// names by ID, URL or address, fetching a URL it has not seen
+func (p *Plugin) historyProperty(ctx context.Context, args map[string]interface{}) (*store.StoredProperty, error) {
+	if id, _ := args["property_id"].(string); id != "" {
+		return p.store.Property(ctx, id)
+	}
+	if url, _ := args["url"].(string); url != "" {
+		stored, err := p.store.PropertyByURL(ctx, url)
+		if err != nil || stored != nil {
+			return stored, err
+		}
+		source, err := p.sources.ForURL(url)
+		if err != nil {
+			return nil, err
+		}
+		property, err := p.propertyDetail(ctx, source, url)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching property details: %v", err)
+		}
+		return p.store.Property(ctx, property.ID)
+	}
+
+	address, _ := args["address"].(string)
+	city, _ := args["city"].(string)
+	state, _ := args["state"].(string)
+	if address == "" || city == "" || state == "" {
+		return nil, fmt.Errorf("property_id, url, or address, city and state are required")
+	}
+	property, ok := p.store.LookupProperty(ctx, address, city, state)
+	if !ok {
+		return nil, nil
+	}
+	return p.store.Property(ctx, property.ID)
+}
+
+func (p *Plugin) handleGetPropertyHistory(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	if p.store == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "property history needs the property store; start the server with -db"}},
+		}, nil
+	}
+	stored, err := p.historyProperty(ctx, args)
+	if err == nil && stored == nil {
+		err = fmt.Errorf("no stored property matches; search for it or fetch its details first")
+	}
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	events, err := p.store.PropertyListingEvents(ctx, stored.ID)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error reading listing events: %v", err)}},
+		}, nil
+	}
+
+	property := newPropertyData(stored.Property, stored.Source, stored.FetchedAt)
+	history := PropertyHistory{
+		Property:     PropertyRef{ID: property.ID, Address: property.Address, City: property.City, State: property.State, Price: property.Price, URL: stored.URL},
+		Status:       property.Status,
+		FirstSeen:    stored.FirstSeen,
+		FetchedAt:    stored.FetchedAt,
+		Events:       events,
+		PriceHistory: mergePriceHistory(property.PriceHistory, events),
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling history: %v", err)}},
+		}, nil
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("History of %s: %d listing events recorded since %s:", property.Address, len(events), stored.FirstSeen.Format(dateLayout))},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+// parseChangeQuery reads get_listing_changes arguments
+func parseChangeQuery(args map[string]interface{}, now time.Time) (store.ChangeQuery, int, error) {
+	q := store.ChangeQuery{Since: now.AddDate(0, 0, -defaultChangesDays)}
+	q.City, _ = args["city"].(string)
+	q.State, _ = args["state"].(string)
+	q.Neighborhood, _ = args["neighborhood"].(string)
+	if q.City == "" || q.State == "" {
+		return q, 0, fmt.Errorf("city and state parameters are required")
+	}
+	if since, _ := args["since"].(string); since != "" {
+		t, err := time.Parse(dateLayout, since)
+		if err != nil {
+			return q, 0, fmt.Errorf("since must be a date like 2025-03-01")
+		}
+		q.Since = t
+	}
+	if events, ok := args["events"].([]interface{}); ok {
+		for _, e := range events {
+			name, _ := e.(string)
+			if !containsString(store.ListingEvents, name) {
+				return q, 0, fmt.Errorf("unknown listing event %q (available: %s)", name, strings.Join(store.ListingEvents, ", "))
+			}
+			q.Events = append(q.Events, name)
+		}
+	}
+	limit := defaultChangesLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	if limit > maxChangesLimit {
+		return q, 0, fmt.Errorf("limit must be at most %d", maxChangesLimit)
+	}
+	return q, limit, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listingChanges returns an area's listing events. A registered neighborhood
+// also covers the properties its boundary holds, wherever they were scraped.
+func (p *Plugin) listingChanges(ctx context.Context, q store.ChangeQuery) ([]store.ListingEvent, error) {
+	events, err := p.store.ListingChanges(ctx, q)
+	if err != nil || q.Neighborhood == "" || p.boundaries == nil {
+		return events, err
+	}
+	if _, ok := p.boundaries.Find(geo.KindNeighborhood, q.City, q.State, q.Neighborhood); !ok {
+		return events, nil
+	}
+
+	p.loadCollected(ctx)
+	included := make(map[int64]bool, len(events))
+	for _, event := range events {
+		included[event.ID] = true
+	}
+	cityWide := q
+	cityWide.Neighborhood = ""
+	all, err := p.store.ListingChanges(ctx, cityWide)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range all {
+		if property, ok := p.collectedProperty(event.PropertyID); ok && !included[event.ID] && strings.EqualFold(property.Neighborhood, q.Neighborhood) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].ObservedAt.Equal(events[j].ObservedAt) {
+			return events[i].ObservedAt.After(events[j].ObservedAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+	return events, nil
+}
+
+func (p *Plugin) handleGetListingChanges(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	if p.store == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "listing changes need the property store; start the server with -db"}},
+		}, nil
+	}
+	q, limit, err := parseChangeQuery(args, p.now())
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	events, err := p.listingChanges(ctx, q)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error reading listing changes: %v", err)}},
+		}, nil
+	}
+
+	changes := ListingChanges{City: q.City, State: q.State, Neighborhood: q.Neighborhood, Since: Date{q.Since}, Counts: map[string]int{}, Total: len(events)}
+	for _, event := range events {
+		changes.Counts[event.Event]++
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	changes.Events = events
+
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling listing changes: %v", err)}},
+		}, nil
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("%d listing changes in %s since %s (%d price cuts, %d relisted, %d sold):", changes.Total,
+				areaName(q.City, q.State, q.Neighborhood), q.Since.Format(dateLayout), changes.Counts[store.EventPriceCut], changes.Counts[store.EventRelisted], changes.Counts[store.EventSold])},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
@@ -0,0 +1,224 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+	"github.com/johan-j/play-mcp/pkg/store"
+)
+
+func TestMergePriceHistory(t *testing.T) {
+	day := func(s string) Date {
+		d, _ := time.Parse(dateLayout, s)
+		return Date{d}
+	}
+	site := []PriceEvent{
+		{Date: day("2025-03-01"), Event: "listed", Price: 1650000},
+		{Date: day("2024-01-10"), Event: "sold", Price: 1200000},
+	}
+	events := []store.ListingEvent{
+		// The site already lists this one
+		{Event: store.EventListed, Price: 1650000, ObservedAt: time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)},
+		{Event: store.EventPriceCut, Price: 1599000, ObservedAt: time.Date(2025, 4, 2, 6, 0, 0, 0, time.UTC)},
+	}
+	history := mergePriceHistory(site, events)
+	if len(history) != 3 || history[0].Event != "price cut" || history[0].Price != 1599000 || history[0].Date != day("2025-04-02") || history[2].Event != "sold" {
+		t.Errorf("history = %+v", history)
+	}
+}
+
+func TestParseChangeQuery(t *testing.T) {
+	now := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	q, limit, err := parseChangeQuery(map[string]interface{}{"city": "Honolulu", "state": "HI"}, now)
+	if err != nil || limit != defaultChangesLimit || !q.Since.Equal(now.AddDate(0, 0, -30)) || q.Events != nil {
+		t.Errorf("defaults = %+v, %d, %v", q, limit, err)
+	}
+	q, limit, err = parseChangeQuery(map[string]interface{}{"city": "Honolulu", "state": "HI", "since": "2025-03-01",
+		"events": []interface{}{"price_cut", "relisted"}, "limit": 20.0}, now)
+	if err != nil || limit != 20 || q.Since.Format(dateLayout) != "2025-03-01" || len(q.Events) != 2 {
+		t.Errorf("query = %+v, %d, %v", q, limit, err)
+	}
+	for _, args := range []map[string]interface{}{
+		{"city": "Honolulu"},
+		{"city": "Honolulu", "state": "HI", "since": "March"},
+		{"city": "Honolulu", "state": "HI", "events": []interface{}{"foreclosed"}},
+		{"city": "Honolulu", "state": "HI", "limit": 1000.0},
+	} {
+		if _, _, err := parseChangeQuery(args, now); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestHistoryTools(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "housing.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	p := NewPlugin()
+	p.SetStore(s, time.Hour)
+	registry := geo.NewBoundaryRegistry()
+	kaimuki := geo.Boundary{Kind: geo.KindNeighborhood, Name: "Kaimuki", City: "Honolulu", State: "HI", Area: geo.MultiPolygon{{Outer: []geo.Point{
+		{Lat: 21.27, Lon: -157.82}, {Lat: 21.27, Lon: -157.79}, {Lat: 21.30, Lon: -157.79}, {Lat: 21.30, Lon: -157.82},
+	}}}}
+	if err := registry.Add(kaimuki); err != nil {
+		t.Fatal(err)
+	}
+	p.SetBoundaries(registry)
+	ctx := context.Background()
+
+	listing := func(address, neighborhood, status string, price int) scraper.Property {
+		property := scraper.Property{Address: address, City: "Honolulu", State: "HI", Neighborhood: neighborhood, Status: status, Price: price, DaysOnMarket: 2}
+		property.ID = scraper.PropertyID(address, property.City, property.State)
+		return property
+	}
+	// Listed without a neighborhood, inside the Kaimuki boundary
+	kapahulu := listing("5 Kapahulu Ave", "", statusForSale, 900000)
+	kapahulu.Latitude, kapahulu.Longitude = 21.28, -157.81
+	manoa := store.AreaKey("test", scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa", Status: statusForSale})
+	city := store.AreaKey("test", scraper.SearchQuery{City: "Honolulu", State: "HI", Status: statusForSale})
+	for _, scrape := range []struct {
+		area     string
+		listings []scraper.Property
+	}{
+		{manoa, []scraper.Property{listing("2714 Hipawai Pl", "", statusForSale, 1650000)}},
+		{manoa, []scraper.Property{listing("2714 Hipawai Pl", "", statusForSale, 1599000)}},
+		// Scraped city-wide, but listed in Kaimuki
+		{city, []scraper.Property{listing("1 Waialae Ave", "Kaimuki", statusForSale, 1100000), kapahulu}},
+		{city, []scraper.Property{listing("1 Waialae Ave", "Kaimuki", "pending", 1100000)}},
+	} {
+		if _, err := s.SaveArea(ctx, "test", scrape.area, scrape.listings); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "get_property_history", Arguments: map[string]interface{}{
+		"address": "2714 Hipawai Pl", "city": "Honolulu", "state": "HI",
+	}})
+	if err != nil || response.IsError {
+		t.Fatalf("%v %+v", err, response)
+	}
+	var history PropertyHistory
+	if err := json.Unmarshal([]byte(response.Content[1].Text), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Events) != 2 || !history.Events[0].Initial || history.Events[1].Event != store.EventPriceCut || history.Events[1].PreviousPrice != 1650000 || history.Property.Price != 1599000 {
+		t.Errorf("history = %+v", history)
+	}
+
+	changes := func(args map[string]interface{}) ListingChanges {
+		t.Helper()
+		response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "get_listing_changes", Arguments: args})
+		if err != nil || response.IsError {
+			t.Fatalf("%v %+v", err, response)
+		}
+		var changes ListingChanges
+		if err := json.Unmarshal([]byte(response.Content[1].Text), &changes); err != nil {
+			t.Fatal(err)
+		}
+		return changes
+	}
+	all := changes(map[string]interface{}{"city": "Honolulu", "state": "HI", "limit": 2.0})
+	if all.Total != 5 || len(all.Events) != 2 || all.Counts[store.EventListed] != 3 || all.Counts[store.EventPending] != 1 || all.Events[0].Event != store.EventPending {
+		t.Errorf("city changes = %+v", all)
+	}
+	cuts := changes(map[string]interface{}{"city": "Honolulu", "state": "HI", "neighborhood": "Manoa", "events": []interface{}{"price_cut"}})
+	if cuts.Total != 1 || cuts.Events[0].Address != "2714 Hipawai Pl" || cuts.Events[0].Price != 1599000 {
+		t.Errorf("Manoa price cuts = %+v", cuts)
+	}
+	// The boundary adds what was scraped city-wide inside it
+	inKaimuki := changes(map[string]interface{}{"city": "Honolulu", "state": "HI", "neighborhood": "Kaimuki"})
+	if inKaimuki.Total != 3 || inKaimuki.Events[0].Address != "1 Waialae Ave" || inKaimuki.Counts[store.EventListed] != 2 {
+		t.Errorf("Kaimuki changes = %+v", inKaimuki)
+	}
+
+	// Nothing changes after the scrapes
+	if later := changes(map[string]interface{}{"city": "Honolulu", "state": "HI", "since": time.Now().AddDate(0, 0, 2).Format(dateLayout)}); later.Total != 0 || len(later.Events) != 0 {
+		t.Errorf("later changes = %+v", later)
+	}
+
+	if response, _ := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "get_property_history", Arguments: map[string]interface{}{"property_id": "prop_unknown"}}); !response.IsError {
+		t.Error("expected an error for an unknown property")
+	}
+	if response, _ := NewPlugin().HandleToolCall(ctx, mcp.ToolCallRequest{Name: "get_listing_changes", Arguments: map[string]interface{}{"city": "Honolulu", "state": "HI"}}); !response.IsError {
+		t.Error("expected an error without a store")
+	}
+}
+
+// completeSource is a listing source that reports whether its search
+// reached the last page of results
+type completeSource struct {
+	statsSource
+	complete bool
+}
+
+func (s completeSource) SearchComplete(ctx context.Context, query scraper.SearchQuery) ([]scraper.Property, bool, error) {
+	return s.listings, s.complete, nil
+}
+
+func TestRefreshAreaRecordsListingEvents(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "housing.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	p := NewPlugin()
+	p.SetStore(s, time.Hour)
+	ctx := context.Background()
+
+	listing := func(address, status string, price int) scraper.Property {
+		property := scraper.Property{Address: address, City: "Honolulu", State: "HI", Status: status, Price: price, DaysOnMarket: 2}
+		property.ID = scraper.PropertyID(address, property.City, property.State)
+		return property
+	}
+	forSale := scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa", Status: statusForSale}
+	sold := scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa", Status: "sold"}
+	for _, scrape := range []struct {
+		query    scraper.SearchQuery
+		listings []scraper.Property
+		complete bool
+	}{
+		{forSale, []scraper.Property{listing("2714 Hipawai Pl", statusForSale, 1650000), listing("2750 Hipawai Pl", statusForSale, 1200000), listing("2760 Hipawai Pl", statusForSale, 990000)}, true},
+		// 2760 drops out of the listings for sale
+		{forSale, []scraper.Property{listing("2714 Hipawai Pl", statusForSale, 1599000), listing("2750 Hipawai Pl", statusForSale, 1250000)}, true},
+		// A site serving no results withdraws nothing
+		{forSale, nil, true},
+		{forSale, []scraper.Property{listing("2714 Hipawai Pl", "pending", 1599000), listing("2750 Hipawai Pl", statusForSale, 1250000), listing("2760 Hipawai Pl", statusForSale, 975000)}, true},
+		// Under contract listings drop out until they close
+		{forSale, []scraper.Property{listing("2750 Hipawai Pl", statusForSale, 1250000), listing("2760 Hipawai Pl", statusForSale, 975000)}, true},
+		// A scrape that stopped before the last page withdraws nothing
+		{forSale, []scraper.Property{listing("2760 Hipawai Pl", statusForSale, 975000)}, false},
+		{sold, []scraper.Property{listing("2714 Hipawai Pl", "sold", 1590000)}, true},
+	} {
+		source := completeSource{statsSource{listings: scrape.listings}, scrape.complete}
+		if _, _, err := p.refreshArea(ctx, source, scrape.query, store.AreaKey("test", scrape.query)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for address, want := range map[string][]string{
+		"2714 Hipawai Pl": {store.EventListed, store.EventPriceCut, store.EventPending, store.EventSold},
+		"2750 Hipawai Pl": {store.EventListed, store.EventPriceIncrease},
+		"2760 Hipawai Pl": {store.EventListed, store.EventWithdrawn, store.EventRelisted},
+	} {
+		events, err := s.PropertyListingEvents(ctx, scraper.PropertyID(address, "Honolulu", "HI"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, event := range events {
+			got = append(got, event.Event)
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s events = %v, want %v", address, got, want)
+		}
+	}
+}
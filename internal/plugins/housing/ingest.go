@@ -0,0 +1,193 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/scheduler"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+	"github.com/johan-j/play-mcp/pkg/store"
+)
+
+// IngestionConfig lists the neighborhoods kept fresh by background jobs
+type IngestionConfig struct {
+	Schedule      string            `json:"schedule"`      // Default cron schedule, e.g. "0 5 * * *"
+	Jitter        string            `json:"jitter"`        // Random delay before scheduled runs, e.g. "10m"
+	MaxConcurrent int               `json:"maxConcurrent"` // Jobs allowed to run at once
+	HistorySize   int               `json:"historySize"`   // Runs kept per job
+	Neighborhoods []IngestionTarget `json:"neighborhoods"`
+}
+
+// IngestionTarget is a neighborhood, or a whole city, to ingest on a schedule
+type IngestionTarget struct {
+	Name         string `json:"name,omitempty"` // Job name; defaults to e.g. "honolulu-hi/manoa"
+	City         string `json:"city"`
+	State        string `json:"state"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	Schedule     string `json:"schedule,omitempty"` // Overrides the config's default schedule
+	// ForSale also scrapes the homes for sale, so the store records their
+	// listing events: price changes, pendings, sales and relists
+	ForSale bool `json:"forSale,omitempty"`
+}
+
+// IngestionResult is what an ingestion job found on its latest successful run
+type IngestionResult struct {
+	Area       string               `json:"area"`
+	Source     string               `json:"source"`
+	Properties int                  `json:"properties"`
+	New        int                  `json:"new"`
+	ForSale    int                  `json:"forSale,omitempty"` // Homes for sale found, for targets that track them
+	Stats      *scraper.MarketStats `json:"stats,omitempty"`
+	FetchedAt  time.Time            `json:"fetchedAt"`
+
+	listings []scraper.Property // Served to searches when there is no store
+}
+
+// LoadIngestionConfig reads an ingestion config from a JSON file
+func LoadIngestionConfig(path string) (*IngestionConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ingestion config: %v", err)
+	}
+	var config IngestionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse ingestion config: %v", err)
+	}
+	for i, target := range config.Neighborhoods {
+		if target.City == "" || target.State == "" {
+			return nil, fmt.Errorf("neighborhood %d needs a city and state", i+1)
+		}
+		if target.Schedule == "" && config.Schedule == "" {
+			return nil, fmt.Errorf("%s has no schedule and there is no default schedule", target.JobName())
+		}
+	}
+	return &config, nil
+}
+
+// SchedulerOptions returns the scheduler options the config asks for
+func (c *IngestionConfig) SchedulerOptions() (scheduler.Options, error) {
+	options := scheduler.Options{MaxConcurrent: c.MaxConcurrent, HistorySize: c.HistorySize}
+	if c.Jitter != "" {
+		jitter, err := time.ParseDuration(c.Jitter)
+		if err != nil {
+			return options, fmt.Errorf("invalid jitter: %v", err)
+		}
+		options.Jitter = jitter
+	}
+	return options, nil
+}
+
+// JobName returns the target's job name
+func (t IngestionTarget) JobName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	name := geo.Slug(t.City) + "-" + geo.Slug(t.State)
+	if t.Neighborhood != "" {
+		name += "/" + geo.Slug(t.Neighborhood)
+	}
+	return name
+}
+
+// AddIngestionJobs schedules a job per configured neighborhood that scrapes
+// its sold listings and market statistics. Searches are then served from the
+// job's results while they are fresh.
+func (p *Plugin) AddIngestionJobs(s *scheduler.Scheduler, config *IngestionConfig) error {
+	for _, target := range config.Neighborhoods {
+		schedule := target.Schedule
+		if schedule == "" {
+			schedule = config.Schedule
+		}
+		target := target
+		if err := s.Add(target.JobName(), schedule, func(ctx context.Context) (interface{}, error) {
+			return p.ingest(ctx, target)
+		}); err != nil {
+			return err
+		}
+	}
+	p.scheduler = s
+	return nil
+}
+
+// ingest scrapes a target's sold listings, into the store when there is one,
+// and its market statistics. With a store, targets can track homes for sale
+// too.
+func (p *Plugin) ingest(ctx context.Context, target IngestionTarget) (*IngestionResult, error) {
+	source, ok := p.sources.Get(p.searchSource)
+	if !ok {
+		return nil, fmt.Errorf("listing source %s is not registered", p.searchSource)
+	}
+	query := p.searchQuery(target.City, target.State, target.Neighborhood)
+	area := store.AreaKey(source.Name(), query)
+	result := &IngestionResult{Area: area, Source: source.Name()}
+
+	if p.store != nil {
+		found, added, err := p.refreshArea(ctx, source, query, area)
+		if err != nil {
+			return nil, err
+		}
+		result.Properties, result.New = found, added
+		areas := []string{area}
+		if target.ForSale {
+			forSale := query
+			forSale.Status = statusForSale
+			forSaleArea := store.AreaKey(source.Name(), forSale)
+			if result.ForSale, _, err = p.refreshArea(ctx, source, forSale, forSaleArea); err != nil {
+				return nil, err
+			}
+			areas = append(areas, forSaleArea)
+		}
+		var properties []PropertyData
+		for _, area := range areas {
+			stored, err := p.store.AreaProperties(ctx, area)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range stored {
+				properties = append(properties, newPropertyData(s.Property, s.Source, s.FetchedAt))
+			}
+		}
+		p.collect(ctx, properties)
+	} else {
+		listings, err := source.Search(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape properties: %v", err)
+		}
+		result.Properties, result.listings = len(listings), listings
+		properties := make([]PropertyData, 0, len(listings))
+		for _, listing := range listings {
+			properties = append(properties, newPropertyData(listing, source.Name(), p.now()))
+		}
+		p.collect(ctx, properties)
+	}
+
+	stats, err := source.MarketStats(ctx, query)
+	if err != nil && !errors.Is(err, scraper.ErrNotSupported) {
+		return nil, fmt.Errorf("failed to scrape market stats: %v", err)
+	}
+	result.Stats = stats
+	result.FetchedAt = p.now()
+
+	p.mu.Lock()
+	p.ingested[area] = result
+	p.mu.Unlock()
+	return result, nil
+}
+
+// ingestedListings returns the listings an ingestion job found for an area,
+// if it ran within refreshAfter
+func (p *Plugin) ingestedListings(area string) (*IngestionResult, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result, ok := p.ingested[area]
+	if !ok || result.listings == nil || p.now().Sub(result.FetchedAt) >= p.refreshAfter {
+		return nil, false
+	}
+	return result, true
+}
@@ -0,0 +1,418 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+// Analysis modes of analyze_investment_property
+const (
+	ModeInvestment = "investment"
+	ModeRentVsBuy  = "rent_vs_buy"
+)
+
+// InvestmentAssumptions are the rent, expense and market assumptions of an
+// analysis. Rates are percents; growth and appreciation are per year.
+type InvestmentAssumptions struct {
+	MonthlyRent      float64 `json:"monthlyRent"`
+	RentGrowth       float64 `json:"rentGrowth"`
+	VacancyRate      float64 `json:"vacancyRate"`
+	ManagementFee    float64 `json:"managementFee"`   // Of collected rent
+	MaintenanceRate  float64 `json:"maintenanceRate"` // Of scheduled rent
+	CapExRate        float64 `json:"capExRate"`       // Of scheduled rent
+	OtherExpenses    float64 `json:"otherExpenses"`   // Monthly dollars
+	ExpenseGrowth    float64 `json:"expenseGrowth"`
+	Appreciation     float64 `json:"appreciation"`
+	ClosingCosts     float64 `json:"closingCosts"` // Of the price, paid when buying
+	SellingCosts     float64 `json:"sellingCosts"` // Of the sale price
+	HoldYears        int     `json:"holdYears"`
+	InvestmentReturn float64 `json:"investmentReturn,omitempty"` // What a renter's savings earn, for rent vs buy
+}
+
+// defaultInvestmentAssumptions are used for what a call leaves out
+var defaultInvestmentAssumptions = InvestmentAssumptions{
+	RentGrowth: 3, VacancyRate: 5, MaintenanceRate: 5, CapExRate: 5, ExpenseGrowth: 3,
+	Appreciation: 3, ClosingCosts: 3, SellingCosts: 6, HoldYears: 10, InvestmentReturn: 5,
+}
+
+// ProFormaYear is a year of owning a rental
+type ProFormaYear struct {
+	Year              int     `json:"year"`
+	GrossRent         float64 `json:"grossRent"`
+	VacancyLoss       float64 `json:"vacancyLoss"`
+	EffectiveIncome   float64 `json:"effectiveIncome"`
+	OperatingExpenses float64 `json:"operatingExpenses"`
+	NOI               float64 `json:"noi"`
+	DebtService       float64 `json:"debtService"` // Principal, interest and PMI
+	CashFlow          float64 `json:"cashFlow"`
+	PropertyValue     float64 `json:"propertyValue"`
+	LoanBalance       float64 `json:"loanBalance"`
+	Equity            float64 `json:"equity"`
+}
+
+// InvestmentAnalysis is the return on a rental property
+type InvestmentAnalysis struct {
+	Property       *PropertyRef          `json:"property,omitempty"`
+	Assumptions    InvestmentAssumptions `json:"assumptions"`
+	Financing      *MortgageSchedule     `json:"financing"`
+	CashInvested   float64               `json:"cashInvested"` // Down payment, points and closing costs
+	CapRate        float64               `json:"capRate"`      // First year NOI over the price, percent
+	CashOnCash     *float64              `json:"cashOnCash"`   // First year cash flow over cash invested, percent
+	DSCR           *float64              `json:"dscr"`         // First year NOI over debt service
+	IRR            *float64              `json:"irr"`          // Percent a year, selling at the end of the hold
+	SaleProceeds   float64               `json:"saleProceeds"` // After selling costs and the loan payoff
+	TotalCashFlow  float64               `json:"totalCashFlow"`
+	TotalProfit    float64               `json:"totalProfit"`
+	EquityMultiple float64               `json:"equityMultiple"`
+	ProForma       []ProFormaYear        `json:"proForma"`
+}
+
+// RentVsBuyYear compares buying with renting after a year. Whoever pays
+// less that year invests the difference.
+type RentVsBuyYear struct {
+	Year          int     `json:"year"`
+	OwnershipCost float64 `json:"ownershipCost"` // Debt service, tax, insurance, HOA and upkeep
+	Rent          float64 `json:"rent"`
+	HomeEquity    float64 `json:"homeEquity"` // What selling would net
+	BuyerWealth   float64 `json:"buyerWealth"`
+	RenterWealth  float64 `json:"renterWealth"`
+}
+
+// RentVsBuy compares buying a home with renting one like it and investing
+// the down payment and closing costs instead
+type RentVsBuy struct {
+	Property      *PropertyRef          `json:"property,omitempty"`
+	Assumptions   InvestmentAssumptions `json:"assumptions"`
+	Financing     *MortgageSchedule     `json:"financing"`
+	UpfrontCost   float64               `json:"upfrontCost"`
+	BuyerWealth   float64               `json:"buyerWealth"`
+	RenterWealth  float64               `json:"renterWealth"`
+	Advantage     float64               `json:"advantage"` // How much better off buying leaves you; negative when renting wins
+	Better        string                `json:"better"`    // buy or rent
+	BreakevenYear int                   `json:"breakevenYear,omitempty"`
+	Years         []RentVsBuyYear       `json:"years"`
+}
+
+// ownership is a purchase the analyses share: its financing and the costs
+// that grow with inflation
+type ownership struct {
+	terms     LoanTerms
+	financing *MortgageSchedule
+	years     []AmortizationPeriod // Financing by year
+	fixed     float64              // Monthly tax, insurance and HOA dues in the first year
+	upfront   float64
+}
+
+func newOwnership(terms LoanTerms, tax, hoa, insurance CostItem, a InvestmentAssumptions) (*ownership, error) {
+	if a.MonthlyRent <= 0 {
+		return nil, fmt.Errorf("monthly rent must be positive")
+	}
+	if a.HoldYears < 1 || a.HoldYears > 50 {
+		return nil, fmt.Errorf("hold period must be between 1 and 50 years")
+	}
+	if a.VacancyRate < 0 || a.VacancyRate > 100 || a.SellingCosts < 0 || a.SellingCosts > 100 {
+		return nil, fmt.Errorf("vacancy and selling costs must be between 0 and 100 percent")
+	}
+	financing, err := amortize(terms)
+	if err != nil {
+		return nil, err
+	}
+	o := &ownership{
+		terms:     financing.Terms,
+		financing: financing,
+		years:     annual(financing.Schedule),
+		fixed:     tax.Monthly + hoa.Monthly + insurance.Monthly,
+		upfront:   round(financing.CashToClose+terms.Price*a.ClosingCosts/100, 2),
+	}
+	financing.Schedule, financing.Granularity = nil, ""
+	return o, nil
+}
+
+// debtService returns a year's principal, interest and PMI, and the balance
+// left at its end
+func (o *ownership) debtService(year int) (float64, float64) {
+	if year > len(o.years) {
+		return 0, 0
+	}
+	y := o.years[year-1]
+	return y.Payment + y.PMI, y.Balance
+}
+
+// grown returns an amount after growing at a percent a year for years
+func grown(amount, percent float64, years int) float64 {
+	return amount * math.Pow(1+percent/100, float64(years))
+}
+
+// analyzeInvestment projects a rental's income, expenses and financing over
+// the hold period and sells it at the end
+func analyzeInvestment(terms LoanTerms, tax, hoa, insurance CostItem, a InvestmentAssumptions) (*InvestmentAnalysis, error) {
+	o, err := newOwnership(terms, tax, hoa, insurance, a)
+	if err != nil {
+		return nil, err
+	}
+	result := &InvestmentAnalysis{Assumptions: a, Financing: o.financing, CashInvested: o.upfront}
+	flows := []float64{-o.upfront}
+	for year := 1; year <= a.HoldYears; year++ {
+		gross := grown(a.MonthlyRent*12, a.RentGrowth, year-1)
+		row := ProFormaYear{Year: year, GrossRent: gross, VacancyLoss: gross * a.VacancyRate / 100}
+		row.EffectiveIncome = gross - row.VacancyLoss
+		row.OperatingExpenses = row.EffectiveIncome*a.ManagementFee/100 + gross*(a.MaintenanceRate+a.CapExRate)/100 +
+			grown((o.fixed+a.OtherExpenses)*12, a.ExpenseGrowth, year-1)
+		row.NOI = row.EffectiveIncome - row.OperatingExpenses
+		row.DebtService, row.LoanBalance = o.debtService(year)
+		row.CashFlow = row.NOI - row.DebtService
+		row.PropertyValue = grown(terms.Price, a.Appreciation, year)
+		row.Equity = row.PropertyValue - row.LoanBalance
+		result.TotalCashFlow += row.CashFlow
+		flows = append(flows, row.CashFlow)
+		result.ProForma = append(result.ProForma, row.rounded())
+	}
+
+	last := result.ProForma[len(result.ProForma)-1]
+	result.SaleProceeds = round(last.PropertyValue*(1-a.SellingCosts/100)-last.LoanBalance, 2)
+	flows[len(flows)-1] += result.SaleProceeds
+	result.TotalCashFlow = round(result.TotalCashFlow, 2)
+	result.TotalProfit = round(result.TotalCashFlow+result.SaleProceeds-o.upfront, 2)
+
+	first := result.ProForma[0]
+	result.CapRate = round(first.NOI/terms.Price*100, 2)
+	if o.upfront > 0 {
+		result.CashOnCash = percentOf(first.CashFlow, o.upfront)
+		result.EquityMultiple = round((result.TotalCashFlow+result.SaleProceeds)/o.upfront, 2)
+		if rate, ok := irr(flows); ok {
+			rate = round(rate*100, 2)
+			result.IRR = &rate
+		}
+	}
+	if first.DebtService > 0 {
+		dscr := round(first.NOI/first.DebtService, 2)
+		result.DSCR = &dscr
+	}
+	return result, nil
+}
+
+func (y ProFormaYear) rounded() ProFormaYear {
+	for _, v := range []*float64{&y.GrossRent, &y.VacancyLoss, &y.EffectiveIncome, &y.OperatingExpenses, &y.NOI,
+		&y.DebtService, &y.CashFlow, &y.PropertyValue, &y.LoanBalance, &y.Equity} {
+		*v = round(*v, 2)
+	}
+	return y
+}
+
+// percentOf returns part as a percent of whole
+func percentOf(part, whole float64) *float64 {
+	v := round(part/whole*100, 2)
+	return &v
+}
+
+// compareRentVsBuy follows a buyer and a renter of the same home over the
+// hold period. The renter invests what the buyer paid upfront; each year
+// whoever pays less invests the difference.
+func compareRentVsBuy(terms LoanTerms, tax, hoa, insurance CostItem, a InvestmentAssumptions) (*RentVsBuy, error) {
+	o, err := newOwnership(terms, tax, hoa, insurance, a)
+	if err != nil {
+		return nil, err
+	}
+	result := &RentVsBuy{Assumptions: a, Financing: o.financing, UpfrontCost: o.upfront}
+	growth := 1 + a.InvestmentReturn/100
+	var buyerSavings float64
+	renterSavings := o.upfront
+	for year := 1; year <= a.HoldYears; year++ {
+		rent := grown(a.MonthlyRent*12, a.RentGrowth, year-1)
+		debt, balance := o.debtService(year)
+		cost := debt + grown(o.fixed*12, a.ExpenseGrowth, year-1) + rent*(a.MaintenanceRate+a.CapExRate)/100
+
+		buyerSavings *= growth
+		renterSavings *= growth
+		if cost > rent {
+			renterSavings += cost - rent
+		} else {
+			buyerSavings += rent - cost
+		}
+		equity := grown(terms.Price, a.Appreciation, year)*(1-a.SellingCosts/100) - balance
+		row := RentVsBuyYear{
+			Year:          year,
+			OwnershipCost: round(cost, 2),
+			Rent:          round(rent, 2),
+			HomeEquity:    round(equity, 2),
+			BuyerWealth:   round(equity+buyerSavings, 2),
+			RenterWealth:  round(renterSavings, 2),
+		}
+		if result.BreakevenYear == 0 && row.BuyerWealth >= row.RenterWealth {
+			result.BreakevenYear = year
+		}
+		result.Years = append(result.Years, row)
+	}
+
+	last := result.Years[len(result.Years)-1]
+	result.BuyerWealth, result.RenterWealth = last.BuyerWealth, last.RenterWealth
+	result.Advantage = round(last.BuyerWealth-last.RenterWealth, 2)
+	result.Better = "buy"
+	if result.Advantage < 0 {
+		result.Better = "rent"
+	}
+	return result, nil
+}
+
+// irr returns the yearly rate at which cash flows, one a year starting now,
+// have a net present value of zero. It needs flows that change sign once,
+// such as an investment followed by returns.
+func irr(flows []float64) (float64, bool) {
+	npv := func(rate float64) float64 {
+		var v float64
+		for i, flow := range flows {
+			v += flow / math.Pow(1+rate, float64(i))
+		}
+		return v
+	}
+	low, high := -0.99, 10.0
+	if npv(low)*npv(high) > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200 && high-low > 1e-9; i++ {
+		mid := (low + high) / 2
+		if npv(low)*npv(mid) <= 0 {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// parseInvestmentAssumptions reads the assumption arguments of
+// analyze_investment_property
+func parseInvestmentAssumptions(args map[string]interface{}) (InvestmentAssumptions, error) {
+	a := defaultInvestmentAssumptions
+	a.MonthlyRent, _ = args["monthly_rent"].(float64)
+	floats := map[string]*float64{
+		"rent_growth": &a.RentGrowth, "vacancy_rate": &a.VacancyRate, "management_fee": &a.ManagementFee,
+		"maintenance_rate": &a.MaintenanceRate, "capex_rate": &a.CapExRate, "other_expenses": &a.OtherExpenses,
+		"expense_growth": &a.ExpenseGrowth, "appreciation": &a.Appreciation, "closing_costs": &a.ClosingCosts,
+		"selling_costs": &a.SellingCosts, "investment_return": &a.InvestmentReturn,
+	}
+	for name, dst := range floats {
+		if v, ok := args[name].(float64); ok {
+			*dst = v
+		}
+	}
+	if v, ok := args["hold_years"].(float64); ok {
+		a.HoldYears = int(v)
+	}
+	if a.MonthlyRent <= 0 {
+		return a, fmt.Errorf("monthly_rent is required: the rent the property would earn, or what renting a home like it costs")
+	}
+	return a, nil
+}
+
+func (p *Plugin) handleAnalyzeInvestmentProperty(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = ModeInvestment
+	}
+	if mode != ModeInvestment && mode != ModeRentVsBuy {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("unknown mode %q (available: %s, %s)", mode, ModeInvestment, ModeRentVsBuy)}},
+		}, nil
+	}
+	assumptions, err := parseInvestmentAssumptions(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	// Without an interest rate the property is bought with cash
+	loan := mortgageArgs{terms: LoanTerms{TermYears: defaultTermYears}, downPercent: 100}
+	property, err := p.mortgageProperty(ctx, args)
+	if _, financed := args["interest_rate"]; financed && err == nil {
+		loan, err = parseMortgageArgs(args)
+	}
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	price, err := mortgagePrice(property, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	tax, hoa, insurance := ownershipCosts(property, args, price)
+	name := usd(price) + " property"
+	if property != nil {
+		name = property.Address
+	}
+
+	var result interface{}
+	var summary string
+	if mode == ModeRentVsBuy {
+		comparison, err := compareRentVsBuy(loan.at(price), tax, hoa, insurance, assumptions)
+		if err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Cannot compare renting with buying %s: %v", name, err)}},
+			}, nil
+		}
+		comparison.Property = propertyRef(property)
+		result = comparison
+		summary = fmt.Sprintf("After %d years, buying %s leaves %s in wealth and renting at %s a month leaves %s, so %s is better by %s",
+			assumptions.HoldYears, name, usd(comparison.BuyerWealth), usd(assumptions.MonthlyRent), usd(comparison.RenterWealth),
+			map[string]string{"buy": "buying", "rent": "renting"}[comparison.Better], usd(math.Abs(comparison.Advantage)))
+		if comparison.BreakevenYear > 0 {
+			summary += fmt.Sprintf("; buying breaks even in year %d", comparison.BreakevenYear)
+		}
+	} else {
+		analysis, err := analyzeInvestment(loan.at(price), tax, hoa, insurance, assumptions)
+		if err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Cannot analyze %s: %v", name, err)}},
+			}, nil
+		}
+		analysis.Property = propertyRef(property)
+		result = analysis
+		summary = fmt.Sprintf("%s as a rental at %s a month: %v%% cap rate, %s cash-on-cash, %s DSCR and %s IRR over %d years",
+			name, usd(assumptions.MonthlyRent), analysis.CapRate, optionalPercent(analysis.CashOnCash),
+			optionalNumber(analysis.DSCR), optionalPercent(analysis.IRR), assumptions.HoldYears)
+	}
+	unknown := unknownCosts(MonthlyCost{PropertyTax: tax, HOA: hoa, Insurance: insurance})
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling analysis: %v", err)}},
+		}, nil
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: summary + unknown + ":"},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+// optionalPercent formats a percent that may not apply
+func optionalPercent(v *float64) string {
+	if v == nil {
+		return "no"
+	}
+	return fmt.Sprintf("%v%%", *v)
+}
+
+// optionalNumber formats a ratio that may not apply
+func optionalNumber(v *float64) string {
+	if v == nil {
+		return "no"
+	}
+	return fmt.Sprintf("%v", *v)
+}
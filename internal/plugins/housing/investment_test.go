@@ -0,0 +1,157 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+func TestIRR(t *testing.T) {
+	for _, test := range []struct {
+		flows []float64
+		want  float64
+	}{
+		{[]float64{-100, 110}, 0.10},
+		{[]float64{-1000, 0, 1210}, 0.10},
+		{[]float64{-1000, 500, 500}, 0},
+	} {
+		if got, ok := irr(test.flows); !ok || math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("irr(%v) = %v, %v", test.flows, got, ok)
+		}
+	}
+	if _, ok := irr([]float64{100, 100}); ok {
+		t.Error("found an IRR for flows that never change sign")
+	}
+}
+
+// flat assumes no growth, vacancy, upkeep or transaction costs
+var flat = InvestmentAssumptions{HoldYears: 5}
+
+func TestAnalyzeInvestmentCash(t *testing.T) {
+	// $2,000 a month against $300 of tax and insurance: $20,400 of NOI a
+	// year on $200,000, held five years and sold for what it cost
+	a := flat
+	a.MonthlyRent = 2000
+	result, err := analyzeInvestment(LoanTerms{Price: 200000, DownPayment: 200000, TermYears: 30},
+		CostItem{200, CostFromListing}, CostItem{0, CostUnknown}, CostItem{100, CostFromInput}, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CapRate != 10.2 || *result.CashOnCash != 10.2 || result.DSCR != nil || result.CashInvested != 200000 {
+		t.Errorf("cap rate %v, cash-on-cash %v, DSCR %v, invested %v", result.CapRate, result.CashOnCash, result.DSCR, result.CashInvested)
+	}
+	if result.IRR == nil || *result.IRR != 10.2 || result.EquityMultiple != 1.51 || result.SaleProceeds != 200000 || result.TotalProfit != 102000 {
+		t.Errorf("IRR %v, multiple %v, proceeds %v, profit %v", result.IRR, result.EquityMultiple, result.SaleProceeds, result.TotalProfit)
+	}
+	if len(result.ProForma) != 5 || result.ProForma[4] != (ProFormaYear{Year: 5, GrossRent: 24000, EffectiveIncome: 24000,
+		OperatingExpenses: 3600, NOI: 20400, CashFlow: 20400, PropertyValue: 200000, Equity: 200000}) {
+		t.Errorf("pro forma = %+v", result.ProForma)
+	}
+}
+
+func TestAnalyzeInvestmentFinanced(t *testing.T) {
+	a := defaultInvestmentAssumptions
+	a.MonthlyRent = 4000
+	terms := LoanTerms{Price: 500000, DownPayment: 100000, Rate: 6, TermYears: 30}
+	result, err := analyzeInvestment(terms, CostItem{250, CostFromListing}, CostItem{0, CostUnknown}, CostItem{100, CostFromInput}, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20% down plus 3% closing costs; a year of $2,398.20 payments
+	first := result.ProForma[0]
+	if result.CashInvested != 115000 || first.DebtService != round(2398.20*12, 2) {
+		t.Fatalf("invested %v, debt service %v", result.CashInvested, first.DebtService)
+	}
+	// $48,000 of rent less 5% vacancy, 10% upkeep and $4,200 of fixed costs
+	if first.EffectiveIncome != 45600 || first.OperatingExpenses != 9000 || first.NOI != 36600 {
+		t.Errorf("first year = %+v", first)
+	}
+	if *result.DSCR != round(36600/first.DebtService, 2) || *result.CashOnCash != round(first.CashFlow/115000*100, 2) || result.CapRate != 7.32 {
+		t.Errorf("DSCR %v, cash-on-cash %v, cap rate %v", *result.DSCR, *result.CashOnCash, result.CapRate)
+	}
+	// Rent grows 3% a year, and the loan balance follows the amortization
+	schedule, _ := amortize(terms)
+	last := result.ProForma[9]
+	if last.GrossRent != round(48000*math.Pow(1.03, 9), 2) || last.LoanBalance != schedule.Schedule[119].Balance {
+		t.Errorf("year 10 = %+v", last)
+	}
+	if result.IRR == nil || *result.IRR <= 0 {
+		t.Errorf("IRR = %v", result.IRR)
+	}
+
+	if _, err := analyzeInvestment(terms, CostItem{}, CostItem{}, CostItem{}, flat); err == nil {
+		t.Error("expected an error without rent")
+	}
+}
+
+func TestCompareRentVsBuy(t *testing.T) {
+	// A $300,000 home bought with cash that rents for $1,500 a month. At a 10%
+	// return, the renter's invested $300,000 outgrows the buyer's rent savings
+	// by $120,000 x (1.1^years - 1).
+	a := flat
+	a.MonthlyRent = 1500
+	a.InvestmentReturn = 10
+	none := CostItem{Source: CostUnknown}
+	result, err := compareRentVsBuy(LoanTerms{Price: 300000, DownPayment: 300000, TermYears: 30}, none, none, none, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := round(-120000*(math.Pow(1.1, 5)-1), 2); math.Abs(result.Advantage-want) > 0.02 || result.Better != "rent" || result.BreakevenYear != 0 {
+		t.Errorf("advantage %v (want %v), better %s, breakeven %d", result.Advantage, want, result.Better, result.BreakevenYear)
+	}
+
+	// Earning nothing, buying wins from the first year
+	a.InvestmentReturn = 0
+	result, _ = compareRentVsBuy(LoanTerms{Price: 300000, DownPayment: 300000, TermYears: 30}, none, none, none, a)
+	if result.Better != "buy" || result.BreakevenYear != 1 || result.Advantage != 90000 || result.Years[0].OwnershipCost != 0 {
+		t.Errorf("comparison = %+v", result)
+	}
+}
+
+func TestAnalyzeInvestmentTool(t *testing.T) {
+	p := NewPlugin()
+	p.collected["prop_poelua"] = PropertyData{
+		ID: "prop_poelua", Address: "2819 Poelua St", City: "Honolulu", State: "HI", Price: 1200000,
+		PropertyTax: &Money{Amount: 3600, Currency: "USD", Period: PeriodAnnual},
+	}
+	ctx := context.Background()
+
+	response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "analyze_investment_property", Arguments: map[string]interface{}{
+		"property_id": "prop_poelua", "monthly_rent": 5500.0, "interest_rate": 6.5, "down_payment_percent": 25.0,
+	}})
+	if err != nil || response.IsError {
+		t.Fatalf("%v %+v", err, response)
+	}
+	var analysis InvestmentAnalysis
+	if err := json.Unmarshal([]byte(response.Content[1].Text), &analysis); err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Financing.LoanAmount != 900000 || analysis.Property.ID != "prop_poelua" || len(analysis.ProForma) != 10 || analysis.ProForma[0].OperatingExpenses != round(3600+66000*0.1, 2) {
+		t.Errorf("analysis = %+v", analysis)
+	}
+	// $5,500 does not cover a $900,000 loan
+	if analysis.ProForma[0].CashFlow >= 0 || *analysis.DSCR >= 1 {
+		t.Errorf("cash flow %v, DSCR %v", analysis.ProForma[0].CashFlow, *analysis.DSCR)
+	}
+
+	response, _ = p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "analyze_investment_property", Arguments: map[string]interface{}{
+		"property_id": "prop_poelua", "monthly_rent": 4500.0, "interest_rate": 6.5, "mode": "rent_vs_buy", "hold_years": 7.0,
+	}})
+	var comparison RentVsBuy
+	if response.IsError || json.Unmarshal([]byte(response.Content[1].Text), &comparison) != nil || len(comparison.Years) != 7 || comparison.Better == "" {
+		t.Errorf("comparison = %+v", response)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"property_id": "prop_poelua"},
+		{"property_id": "prop_poelua", "monthly_rent": 5500.0, "mode": "flip"},
+		{"monthly_rent": 5500.0},
+	} {
+		if response, _ := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "analyze_investment_property", Arguments: args}); !response.IsError {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
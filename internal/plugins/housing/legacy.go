@@ -0,0 +1,115 @@
+package housing
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Property output formats
+const (
+	FormatTyped  = "typed"
+	FormatLegacy = "legacy"
+)
+
+// LegacyPropertyData is PropertyData in its original JSON shape, for clients
+// that expect money, distances, scores and dates as display strings and price
+// history and comparables as lists of strings
+type LegacyPropertyData struct {
+	PropertyData
+	ListedDate         string   `json:"listedDate"`
+	PropertyTax        string   `json:"propertyTax"`
+	HOAFees            string   `json:"hoaFees"`
+	PriceHistory       []string `json:"priceHistory"`
+	NearbyComparables  []string `json:"nearbyComparables"`
+	WalkScore          string   `json:"walkScore"`
+	TransitScore       string   `json:"transitScore"`
+	DistanceToBeach    string   `json:"distanceToBeach"`
+	DistanceToDowntown string   `json:"distanceToDowntown"`
+	HomeInsurance      string   `json:"homeInsurance"`
+	SoldDate           string   `json:"soldDate"`
+}
+
+// Legacy converts the property to its original JSON shape
+func (d PropertyData) Legacy() LegacyPropertyData {
+	legacy := LegacyPropertyData{
+		PropertyData:       d,
+		ListedDate:         d.ListedDate.String(),
+		PropertyTax:        moneyString(d.PropertyTax),
+		HOAFees:            moneyString(d.HOAFees),
+		WalkScore:          scoreString(d.WalkScore),
+		TransitScore:       scoreString(d.TransitScore),
+		DistanceToBeach:    distanceString(d.DistanceToBeach),
+		DistanceToDowntown: distanceString(d.DistanceToDowntown),
+		HomeInsurance:      moneyString(d.HomeInsurance),
+		SoldDate:           d.SoldDate.String(),
+	}
+	if d.PriceHistory != nil {
+		legacy.PriceHistory = []string{}
+		for _, event := range d.PriceHistory {
+			legacy.PriceHistory = append(legacy.PriceHistory, event.String())
+		}
+	}
+	if d.NearbyComparables != nil {
+		legacy.NearbyComparables = []string{}
+		for _, comparable := range d.NearbyComparables {
+			legacy.NearbyComparables = append(legacy.NearbyComparables, comparable.String())
+		}
+	}
+	return legacy
+}
+
+// encodeProperties returns properties ready to marshal in the requested format
+func encodeProperties(properties []PropertyData, format string) interface{} {
+	if format != FormatLegacy {
+		return properties
+	}
+	legacy := make([]LegacyPropertyData, len(properties))
+	for i, property := range properties {
+		legacy[i] = property.Legacy()
+	}
+	return legacy
+}
+
+// outputFormat returns the format requested by a tool call's "format"
+// argument, falling back to the plugin's default
+func (p *Plugin) outputFormat(args map[string]interface{}) (string, error) {
+	format, _ := args["format"].(string)
+	switch format {
+	case "":
+		return p.format, nil
+	case FormatTyped, FormatLegacy:
+		return format, nil
+	default:
+		return "", fmt.Errorf("format must be %s or %s", FormatTyped, FormatLegacy)
+	}
+}
+
+// SetDefaultFormat sets the property output format used when a tool call does not choose one
+func (p *Plugin) SetDefaultFormat(format string) error {
+	if format != FormatTyped && format != FormatLegacy {
+		return fmt.Errorf("unknown property format %q (available: %s, %s)", format, FormatTyped, FormatLegacy)
+	}
+	p.format = format
+	return nil
+}
+
+func moneyString(m *Money) string {
+	if m == nil {
+		return ""
+	}
+	return m.String()
+}
+
+func distanceString(d *Distance) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func scoreString(score int) string {
+	if score == 0 {
+		return ""
+	}
+	return strconv.Itoa(score)
+}
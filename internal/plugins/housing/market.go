@@ -0,0 +1,278 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+	"github.com/johan-j/play-mcp/pkg/store"
+)
+
+// Market statistics methods
+const (
+	StatsScraped  = "scraped"  // Read from the listing site's market statistics
+	StatsComputed = "computed" // Computed from the area's sold listings
+)
+
+// marketStatsURIPrefix starts market statistics resource URIs, which are
+// housing://market-stats/{state}/{city} or housing://market-stats/{state}/{city}/{neighborhood}
+const marketStatsURIPrefix = "housing://market-stats/"
+
+// MarketStatsReport is an area's market statistics and how they were produced
+type MarketStatsReport struct {
+	scraper.MarketStats
+	Method string `json:"method"` // scraped or computed
+	Source string `json:"source"`
+	// SampleSize is how many sold listings computed statistics are based on
+	SampleSize int `json:"sampleSize,omitempty"`
+	// FallbackReason says why scraped statistics were not used
+	FallbackReason string `json:"fallbackReason,omitempty"`
+}
+
+// marketStats returns the market statistics for an area, computing them from
+// its sold listings when the listing source cannot provide them
+func (p *Plugin) marketStats(ctx context.Context, city, state, neighborhood string) (*MarketStatsReport, error) {
+	source, ok := p.sources.Get(p.searchSource)
+	if !ok {
+		return nil, fmt.Errorf("listing source %s is not registered", p.searchSource)
+	}
+	query := p.searchQuery(city, state, neighborhood)
+
+	var err error
+	stats := p.ingestedStats(store.AreaKey(source.Name(), query))
+	if stats == nil {
+		stats, err = source.MarketStats(ctx, query)
+	}
+	if err == nil && (stats == nil || stats.MedianSalePrice == 0 && stats.AveragePricePerSqFt == 0) {
+		err = fmt.Errorf("no market statistics found")
+	}
+	if err == nil {
+		return &MarketStatsReport{MarketStats: *stats, Method: StatsScraped, Source: source.Name()}, nil
+	}
+
+	sold, searchErr := p.searchRealProperties(ctx, SearchFilters{City: city, State: state, Neighborhood: neighborhood})
+	if searchErr != nil {
+		return nil, fmt.Errorf("scraping market stats failed (%v) and so did finding sold listings: %v", err, searchErr)
+	}
+	computed, sample := computeMarketStats(sold, p.now())
+	if sample == 0 {
+		return nil, fmt.Errorf("scraping market stats failed (%v) and no priced sold listings were found", err)
+	}
+	computed.Area = areaName(city, state, neighborhood)
+	return &MarketStatsReport{
+		MarketStats:    *computed,
+		Method:         StatsComputed,
+		Source:         source.Name(),
+		SampleSize:     sample,
+		FallbackReason: err.Error(),
+	}, nil
+}
+
+// ingestedStats returns the market statistics an ingestion job scraped for
+// an area, if it ran within refreshAfter
+func (p *Plugin) ingestedStats(area string) *scraper.MarketStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result, ok := p.ingested[area]
+	if !ok || result.Stats == nil || p.now().Sub(result.FetchedAt) >= p.refreshAfter {
+		return nil
+	}
+	return result.Stats
+}
+
+// computeMarketStats derives market statistics from sold listings and
+// returns how many listings they are based on. Sales from the last 12
+// months are used when their dates are known, and the year-over-year change
+// compares them with the 12 months before. Homes for sale and months of
+// supply cannot be told from sold listings and are left zero.
+func computeMarketStats(sold []PropertyData, now time.Time) (*scraper.MarketStats, int) {
+	yearAgo, twoYearsAgo := now.AddDate(-1, 0, 0), now.AddDate(-2, 0, 0)
+	var recent, previous []PropertyData
+	for _, property := range sold {
+		switch {
+		case property.Price <= 0:
+		case property.SoldDate.IsZero() || !property.SoldDate.Before(yearAgo):
+			recent = append(recent, property)
+		case !property.SoldDate.Before(twoYearsAgo):
+			previous = append(previous, property)
+		}
+	}
+
+	stats := &scraper.MarketStats{Timestamp: now.Format(time.RFC3339)}
+	if len(recent) == 0 {
+		return stats, 0
+	}
+
+	var prices, houses, townhouses, perSqFt, days []float64
+	for _, property := range recent {
+		prices = append(prices, float64(property.Price))
+		switch property.PropertyType {
+		case "house":
+			houses = append(houses, float64(property.Price))
+		case "townhouse":
+			townhouses = append(townhouses, float64(property.Price))
+		}
+		if property.SquareFeet > 0 {
+			perSqFt = append(perSqFt, float64(property.Price)/float64(property.SquareFeet))
+		}
+		if property.DaysOnMarket > 0 {
+			days = append(days, float64(property.DaysOnMarket))
+		}
+		if !property.SoldDate.IsZero() {
+			stats.SalesLast12Months++
+		}
+	}
+	stats.MedianSalePrice = int(median(prices))
+	stats.MedianSingleFamilyPrice = int(median(houses))
+	stats.MedianTownhousePrice = int(median(townhouses))
+	stats.AveragePricePerSqFt = int(mean(perSqFt) + 0.5)
+	stats.AverageDaysOnMarket = int(mean(days) + 0.5)
+
+	// A year-over-year change from a handful of sales is noise
+	const minYearSales = 3
+	if stats.SalesLast12Months >= minYearSales && len(previous) >= minYearSales {
+		var dated, before []float64
+		for _, property := range recent {
+			if !property.SoldDate.IsZero() {
+				dated = append(dated, float64(property.Price))
+			}
+		}
+		for _, property := range previous {
+			before = append(before, float64(property.Price))
+		}
+		stats.YearOverYearChange = round((median(dated)/median(before)-1)*100, 1)
+	}
+	return stats, len(recent)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// areaName formats an area the way listing sites name it, e.g. "Manoa, Honolulu, HI"
+func areaName(city, state, neighborhood string) string {
+	area := fmt.Sprintf("%s, %s", city, state)
+	if neighborhood != "" {
+		area = neighborhood + ", " + area
+	}
+	return area
+}
+
+// parseAreaURI reads the area from a resource URI of the form
+// {prefix}{state}/{city} or {prefix}{state}/{city}/{neighborhood}, along
+// with any query parameters
+func parseAreaURI(uri, prefix string) (city, state, neighborhood string, params url.Values, ok bool) {
+	if !strings.HasPrefix(uri, prefix) {
+		return "", "", "", nil, false
+	}
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(uri, prefix), "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", "", "", nil, false
+	}
+	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", "", "", nil, false
+	}
+	for i, part := range parts {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil || strings.TrimSpace(unescaped) == "" {
+			return "", "", "", nil, false
+		}
+		parts[i] = unescaped
+	}
+	state, city = parts[0], parts[1]
+	if len(parts) == 3 {
+		neighborhood = parts[2]
+	}
+	return city, state, neighborhood, params, true
+}
+
+// ReadResource reads a market statistics or trends resource
+func (p *Plugin) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	var report interface{}
+	if city, state, neighborhood, _, ok := parseAreaURI(uri, marketStatsURIPrefix); ok {
+		stats, err := p.marketStats(ctx, city, state, neighborhood)
+		if err != nil {
+			return nil, err
+		}
+		report = stats
+	} else if city, state, neighborhood, params, ok := parseAreaURI(uri, trendsURIPrefix); ok {
+		options, err := parseTrendOptions(map[string]interface{}{
+			"interval":       params.Get("interval"),
+			"property_type":  params.Get("property_type"),
+			"periods":        params.Get("periods"),
+			"rolling_window": params.Get("rolling_window"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		trends, err := p.marketTrends(ctx, city, state, neighborhood, options)
+		if err != nil {
+			return nil, err
+		}
+		report = trends
+	} else {
+		return nil, fmt.Errorf("%w: %s", mcp.ErrResourceNotFound, uri)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContents{{URI: uri, MimeType: "application/json", Text: string(data)}},
+	}, nil
+}
+
+func (p *Plugin) handleGetMarketStats(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	city, _ := args["city"].(string)
+	state, _ := args["state"].(string)
+	if city == "" || state == "" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "city and state parameters are required and must be non-empty strings"}},
+		}, nil
+	}
+	neighborhood, _ := args["neighborhood"].(string)
+
+	report, err := p.marketStats(ctx, city, state, neighborhood)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error getting market stats: %v", err)}},
+		}, nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling market stats: %v", err)}},
+		}, nil
+	}
+
+	method := fmt.Sprintf("scraped from %s", report.Source)
+	if report.Method == StatsComputed {
+		method = fmt.Sprintf("computed from %d sold listings because %v", report.SampleSize, report.FallbackReason)
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Market statistics for %s (%s):", areaName(city, state, neighborhood), method)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
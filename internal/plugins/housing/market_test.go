@@ -0,0 +1,107 @@
+package housing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// statsSource is a listing source whose market statistics scrape fails
+type statsSource struct {
+	listings []scraper.Property
+}
+
+func (s statsSource) Name() string            { return "test" }
+func (s statsSource) Matches(url string) bool { return false }
+func (s statsSource) Search(ctx context.Context, query scraper.SearchQuery) ([]scraper.Property, error) {
+	return s.listings, nil
+}
+func (s statsSource) Detail(ctx context.Context, url string) (*scraper.Property, error) {
+	return nil, scraper.ErrNotSupported
+}
+func (s statsSource) MarketStats(ctx context.Context, query scraper.SearchQuery) (*scraper.MarketStats, error) {
+	return nil, errors.New("page blocked")
+}
+
+func TestComputeMarketStats(t *testing.T) {
+	now := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	sold := func(daysAgo int) Date { return Date{now.AddDate(0, 0, -daysAgo)} }
+	stats, sample := computeMarketStats([]PropertyData{
+		{Price: 1000000, SquareFeet: 1000, DaysOnMarket: 10, PropertyType: "house", SoldDate: sold(30)},
+		{Price: 1200000, SquareFeet: 1500, DaysOnMarket: 20, PropertyType: "house", SoldDate: sold(60)},
+		{Price: 600000, SquareFeet: 1000, DaysOnMarket: 30, PropertyType: "townhouse", SoldDate: sold(90)},
+		{Price: 800000, PropertyType: "condo"},
+		{Price: 900000, SoldDate: sold(400)},
+		{Price: 800000, SoldDate: sold(500)},
+		{Price: 700000, SoldDate: sold(600)},
+		{Price: 100000, SoldDate: sold(900)}, // Too old for either year
+		{SoldDate: sold(5)},                  // No price
+	}, now)
+
+	if sample != 4 {
+		t.Errorf("sample = %d, want 4", sample)
+	}
+	if stats.MedianSalePrice != 900000 || stats.MedianSingleFamilyPrice != 1100000 || stats.MedianTownhousePrice != 600000 {
+		t.Errorf("medians = %d, %d, %d", stats.MedianSalePrice, stats.MedianSingleFamilyPrice, stats.MedianTownhousePrice)
+	}
+	if stats.AveragePricePerSqFt != 800 || stats.AverageDaysOnMarket != 20 || stats.SalesLast12Months != 3 {
+		t.Errorf("$/sqft = %d, DOM = %d, sales = %d", stats.AveragePricePerSqFt, stats.AverageDaysOnMarket, stats.SalesLast12Months)
+	}
+	// Dated sales: median $1,000,000 against $800,000 the year before
+	if stats.YearOverYearChange != 25 {
+		t.Errorf("YoY change = %v, want 25", stats.YearOverYearChange)
+	}
+}
+
+func TestParseAreaURI(t *testing.T) {
+	tests := []struct {
+		uri                       string
+		city, state, neighborhood string
+		ok                        bool
+	}{
+		{"housing://market-stats/HI/Honolulu", "Honolulu", "HI", "", true},
+		{"housing://market-stats/CA/San%20Jose/Willow%20Glen", "San Jose", "CA", "Willow Glen", true},
+		{"housing://market-stats/HI", "", "", "", false},
+		{"housing://market-stats/HI/Honolulu/Manoa/extra", "", "", "", false},
+		{"housing://sold-properties", "", "", "", false},
+	}
+	for _, tt := range tests {
+		city, state, neighborhood, _, ok := parseAreaURI(tt.uri, marketStatsURIPrefix)
+		if city != tt.city || state != tt.state || neighborhood != tt.neighborhood || ok != tt.ok {
+			t.Errorf("parseAreaURI(%q) = %q, %q, %q, %v", tt.uri, city, state, neighborhood, ok)
+		}
+	}
+}
+
+func TestMarketStatsFallsBackToSoldListings(t *testing.T) {
+	p := NewPlugin()
+	if err := p.RegisterSource(statsSource{listings: []scraper.Property{
+		{Address: "1 Oahu Ave", City: "Honolulu", State: "HI", Price: 1000000, SquareFeet: 1000},
+		{Address: "2 Oahu Ave", City: "Honolulu", State: "HI", Price: 1400000, SquareFeet: 1400},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSearchSource("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := p.ReadResource(context.Background(), "housing://market-stats/HI/Honolulu/Manoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := result.Contents[0].Text
+	for _, want := range []string{`"method": "computed"`, `"sampleSize": 2`, `"medianSalePrice": 1200000`, `"area": "Manoa, Honolulu, HI"`, "page blocked"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("resource is missing %s:\n%s", want, text)
+		}
+	}
+
+	if _, err := p.ReadResource(context.Background(), "housing://elsewhere"); !errors.Is(err, mcp.ErrResourceNotFound) {
+		t.Errorf("unknown URI error = %v", err)
+	}
+}
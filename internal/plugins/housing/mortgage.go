@@ -0,0 +1,807 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+// Loan types
+const (
+	LoanFixed = "fixed"
+	LoanARM   = "arm"
+)
+
+// Where a monthly cost came from
+const (
+	CostFromListing  = "listing"
+	CostFromInput    = "input"
+	CostFromEstimate = "estimate"
+	CostUnknown      = "unknown"
+)
+
+// Mortgage defaults
+const (
+	defaultTermYears       = 30
+	defaultDownPercent     = 20.0
+	defaultPMIRate         = 0.5  // Annual percent of the loan
+	defaultFrontEndRatio   = 28.0 // Housing costs, percent of gross income
+	defaultBackEndRatio    = 36.0 // Housing costs and other debts
+	pmiRequiredLTV         = 0.80 // PMI is charged on loans above this share of the price
+	pmiTerminationLTV      = 0.78 // and stops once the balance is scheduled to reach this
+	maxAffordabilityPrice  = 1e9
+	affordabilityPriceStep = 1000.0
+)
+
+// LoanTerms describes a mortgage
+type LoanTerms struct {
+	Price       float64   `json:"price"`
+	DownPayment float64   `json:"downPayment"`
+	Rate        float64   `json:"rate"` // Annual percent; an ARM's initial rate
+	TermYears   int       `json:"termYears"`
+	Type        string    `json:"type"` // fixed or arm
+	ARM         *ARMTerms `json:"arm,omitempty"`
+	Points      float64   `json:"points,omitempty"`  // Discount points, each 1% of the loan paid upfront
+	PMIRate     float64   `json:"pmiRate,omitempty"` // Annual percent of the loan, while PMI applies
+}
+
+// ARMTerms describes how an adjustable rate changes after its fixed period.
+// At each adjustment the rate moves toward IndexRate, limited by the caps.
+type ARMTerms struct {
+	InitialYears     int     `json:"initialYears"`
+	AdjustmentMonths int     `json:"adjustmentMonths"`
+	IndexRate        float64 `json:"indexRate"` // Fully indexed rate assumed at every adjustment
+	InitialCap       float64 `json:"initialCap"`
+	PeriodicCap      float64 `json:"periodicCap"`
+	LifetimeCap      float64 `json:"lifetimeCap"`
+}
+
+// AmortizationPeriod is a month or year of a loan; amounts are totals over
+// the period and the balance is what remains at its end
+type AmortizationPeriod struct {
+	Period    int     `json:"period"`
+	Rate      float64 `json:"rate"`
+	Payment   float64 `json:"payment"` // Principal and interest
+	Principal float64 `json:"principal"`
+	Interest  float64 `json:"interest"`
+	PMI       float64 `json:"pmi"`
+	Balance   float64 `json:"balance"`
+}
+
+// MortgageSchedule is a loan's amortization and what it costs in total
+type MortgageSchedule struct {
+	Property          *PropertyRef         `json:"property,omitempty"`
+	Terms             LoanTerms            `json:"terms"`
+	LoanAmount        float64              `json:"loanAmount"`
+	LoanToValue       float64              `json:"loanToValue"` // Percent
+	PointsCost        float64              `json:"pointsCost"`
+	CashToClose       float64              `json:"cashToClose"`       // Down payment and points
+	MonthlyPayment    float64              `json:"monthlyPayment"`    // First principal and interest payment
+	MaxMonthlyPayment float64              `json:"maxMonthlyPayment"` // Highest, once an ARM adjusts
+	MonthlyPMI        float64              `json:"monthlyPMI"`
+	PMIMonths         int                  `json:"pmiMonths"`
+	TotalInterest     float64              `json:"totalInterest"`
+	TotalPMI          float64              `json:"totalPMI"`
+	TotalCost         float64              `json:"totalCost"` // Cash to close plus every payment
+	Granularity       string               `json:"granularity,omitempty"`
+	Schedule          []AmortizationPeriod `json:"schedule,omitempty"`
+}
+
+// CostItem is a monthly cost and where it came from
+type CostItem struct {
+	Monthly float64 `json:"monthly"`
+	Source  string  `json:"source"` // listing, input, estimate or unknown
+}
+
+// MonthlyCost is the monthly cost of owning a property with a mortgage
+type MonthlyCost struct {
+	Property             *PropertyRef `json:"property,omitempty"`
+	Price                float64      `json:"price"`
+	LoanAmount           float64      `json:"loanAmount"`
+	PrincipalAndInterest float64      `json:"principalAndInterest"`
+	PMI                  float64      `json:"pmi"`
+	PropertyTax          CostItem     `json:"propertyTax"`
+	HOA                  CostItem     `json:"hoa"`
+	Insurance            CostItem     `json:"insurance"`
+	Total                float64      `json:"total"`
+}
+
+// AffordabilityLimits are a borrower's income and the debt-to-income ratios
+// lenders hold them to, in percent of gross monthly income
+type AffordabilityLimits struct {
+	AnnualIncome  float64 `json:"annualIncome"`
+	MonthlyDebts  float64 `json:"monthlyDebts"`
+	FrontEndRatio float64 `json:"frontEndRatio"`
+	BackEndRatio  float64 `json:"backEndRatio"`
+}
+
+// Affordability is the most a borrower can spend on housing
+type Affordability struct {
+	Limits            AffordabilityLimits    `json:"limits"`
+	MaxHousingPayment float64                `json:"maxHousingPayment"`
+	LimitedBy         string                 `json:"limitedBy"` // front-end or back-end ratio
+	MaxPrice          float64                `json:"maxPrice"`
+	CostAtMaxPrice    MonthlyCost            `json:"costAtMaxPrice"`
+	Property          *PropertyAffordability `json:"property,omitempty"`
+}
+
+// PropertyAffordability checks one property against a borrower's limits
+type PropertyAffordability struct {
+	Cost          MonthlyCost `json:"cost"`
+	Affordable    bool        `json:"affordable"`
+	FrontEndRatio float64     `json:"frontEndRatio"`
+	BackEndRatio  float64     `json:"backEndRatio"`
+	IncomeNeeded  float64     `json:"incomeNeeded"` // Annual income the property needs at these ratios
+}
+
+// monthlyPayment returns the payment that repays principal over months at an
+// annual percent rate
+func monthlyPayment(principal, rate float64, months int) float64 {
+	if principal <= 0 || months <= 0 {
+		return 0
+	}
+	r := rate / 1200
+	if r == 0 {
+		return principal / float64(months)
+	}
+	return principal * r / (1 - math.Pow(1+r, -float64(months)))
+}
+
+// validate checks terms and fills in ARM defaults
+func (t *LoanTerms) validate() error {
+	switch {
+	case t.Price <= 0:
+		return fmt.Errorf("price must be positive")
+	case t.DownPayment < 0 || t.DownPayment > t.Price:
+		return fmt.Errorf("down payment must be between 0 and the price")
+	case t.Rate < 0 || t.Rate > 30:
+		return fmt.Errorf("interest rate must be between 0 and 30 percent")
+	case t.TermYears < 1 || t.TermYears > 50:
+		return fmt.Errorf("term must be between 1 and 50 years")
+	case t.Points < 0 || t.PMIRate < 0:
+		return fmt.Errorf("points and PMI rate must not be negative")
+	}
+	switch t.Type {
+	case "":
+		t.Type = LoanFixed
+	case LoanFixed:
+		t.ARM = nil
+	case LoanARM:
+		if t.ARM == nil {
+			t.ARM = &ARMTerms{}
+		}
+		arm := t.ARM
+		if arm.InitialYears == 0 {
+			arm.InitialYears = 5
+		}
+		if arm.AdjustmentMonths == 0 {
+			arm.AdjustmentMonths = 12
+		}
+		if arm.InitialCap == 0 && arm.PeriodicCap == 0 && arm.LifetimeCap == 0 {
+			arm.InitialCap, arm.PeriodicCap, arm.LifetimeCap = 2, 2, 5
+		}
+		// Without an index, assume the worst: the rate rises to its lifetime cap
+		if arm.IndexRate == 0 {
+			arm.IndexRate = t.Rate + arm.LifetimeCap
+		}
+		if arm.InitialYears >= t.TermYears {
+			return fmt.Errorf("the ARM's initial period must be shorter than its term")
+		}
+	default:
+		return fmt.Errorf("unknown loan type %q (available: %s, %s)", t.Type, LoanFixed, LoanARM)
+	}
+	return nil
+}
+
+// adjust returns an ARM's rate after an adjustment from rate, the first one
+// limited by the initial cap and later ones by the periodic cap
+func (a *ARMTerms) adjust(rate, startRate float64, first bool) float64 {
+	limit := a.PeriodicCap
+	if first {
+		limit = a.InitialCap
+	}
+	next := math.Max(rate-limit, math.Min(rate+limit, a.IndexRate))
+	next = math.Max(startRate-a.LifetimeCap, math.Min(startRate+a.LifetimeCap, next))
+	return math.Max(next, 0)
+}
+
+// amortize computes a loan's schedule month by month. Payments are rounded
+// to the cent, and the last one pays off whatever balance remains.
+func amortize(terms LoanTerms) (*MortgageSchedule, error) {
+	if err := terms.validate(); err != nil {
+		return nil, err
+	}
+	loan := terms.Price - terms.DownPayment
+	months := terms.TermYears * 12
+	s := &MortgageSchedule{
+		Terms:       terms,
+		LoanAmount:  round(loan, 2),
+		LoanToValue: round(loan/terms.Price*100, 2),
+		PointsCost:  round(loan*terms.Points/100, 2),
+	}
+	s.CashToClose = round(terms.DownPayment+s.PointsCost, 2)
+
+	// PMI is a flat monthly premium on the original loan
+	var pmi float64
+	if loan > pmiRequiredLTV*terms.Price {
+		pmi = round(loan*terms.PMIRate/1200, 2)
+	}
+	s.MonthlyPMI = pmi
+
+	rate := terms.Rate
+	balance := round(loan, 2)
+	payment := round(monthlyPayment(balance, rate, months), 2)
+	s.MonthlyPayment = payment
+	for month := 1; month <= months && balance > 0; month++ {
+		if arm := terms.ARM; arm != nil {
+			since := month - 1 - arm.InitialYears*12
+			if since >= 0 && since%arm.AdjustmentMonths == 0 {
+				if next := arm.adjust(rate, terms.Rate, since == 0); next != rate {
+					rate = next
+					payment = round(monthlyPayment(balance, rate, months-month+1), 2)
+				}
+			}
+		}
+		interest := round(balance*rate/1200, 2)
+		principal := payment - interest
+		if principal > balance || month == months {
+			principal = balance
+		}
+		row := AmortizationPeriod{Period: month, Rate: rate, Interest: interest, Principal: round(principal, 2)}
+		row.Payment = round(row.Principal+interest, 2)
+		if pmi > 0 && balance > pmiTerminationLTV*terms.Price {
+			row.PMI = pmi
+			s.PMIMonths++
+		}
+		balance = round(balance-principal, 2)
+		row.Balance = balance
+
+		s.MaxMonthlyPayment = math.Max(s.MaxMonthlyPayment, row.Payment)
+		s.TotalInterest += interest
+		s.TotalPMI += row.PMI
+		s.TotalCost += row.Payment + row.PMI
+		s.Schedule = append(s.Schedule, row)
+	}
+	s.TotalInterest = round(s.TotalInterest, 2)
+	s.TotalPMI = round(s.TotalPMI, 2)
+	s.TotalCost = round(s.TotalCost+s.CashToClose, 2)
+	s.Granularity = "monthly"
+	return s, nil
+}
+
+// annual sums a monthly schedule into years
+func annual(monthly []AmortizationPeriod) []AmortizationPeriod {
+	var years []AmortizationPeriod
+	for i, month := range monthly {
+		if i%12 == 0 {
+			years = append(years, AmortizationPeriod{Period: i/12 + 1})
+		}
+		year := &years[len(years)-1]
+		year.Rate = month.Rate
+		year.Payment = round(year.Payment+month.Payment, 2)
+		year.Principal = round(year.Principal+month.Principal, 2)
+		year.Interest = round(year.Interest+month.Interest, 2)
+		year.PMI = round(year.PMI+month.PMI, 2)
+		year.Balance = month.Balance
+	}
+	return years
+}
+
+// monthlyCost adds taxes, HOA dues and insurance to a loan's first payment
+func monthlyCost(terms LoanTerms, tax, hoa, insurance CostItem) (MonthlyCost, error) {
+	s, err := amortize(terms)
+	if err != nil {
+		return MonthlyCost{}, err
+	}
+	cost := MonthlyCost{
+		Price:                terms.Price,
+		LoanAmount:           s.LoanAmount,
+		PrincipalAndInterest: s.MonthlyPayment,
+		PMI:                  s.MonthlyPMI,
+		PropertyTax:          CostItem{Monthly: round(tax.Monthly, 2), Source: tax.Source},
+		HOA:                  CostItem{Monthly: round(hoa.Monthly, 2), Source: hoa.Source},
+		Insurance:            CostItem{Monthly: round(insurance.Monthly, 2), Source: insurance.Source},
+	}
+	cost.Total = round(cost.PrincipalAndInterest+cost.PMI+cost.PropertyTax.Monthly+cost.HOA.Monthly+cost.Insurance.Monthly, 2)
+	return cost, nil
+}
+
+// maxHousingPayment returns the most a borrower's ratios allow for housing
+// each month, and which ratio limits it
+func (l AffordabilityLimits) maxHousingPayment() (float64, string) {
+	income := l.AnnualIncome / 12
+	front := income * l.FrontEndRatio / 100
+	back := income*l.BackEndRatio/100 - l.MonthlyDebts
+	if back < front {
+		return math.Max(back, 0), "back-end"
+	}
+	return front, "front-end"
+}
+
+// affordability finds the highest price, in steps of $1,000, whose monthly
+// cost fits a borrower's limits. costAt returns the cost at a price; it must
+// grow with the price.
+func affordability(limits AffordabilityLimits, costAt func(price float64) (MonthlyCost, error)) (*Affordability, error) {
+	if limits.AnnualIncome <= 0 {
+		return nil, fmt.Errorf("annual income must be positive")
+	}
+	if limits.MonthlyDebts < 0 || limits.FrontEndRatio <= 0 || limits.BackEndRatio <= 0 {
+		return nil, fmt.Errorf("debts must not be negative and debt-to-income ratios must be positive")
+	}
+	a := &Affordability{Limits: limits}
+	a.MaxHousingPayment, a.LimitedBy = limits.maxHousingPayment()
+	a.MaxHousingPayment = round(a.MaxHousingPayment, 2)
+
+	fits := func(price float64) (bool, error) {
+		cost, err := costAt(price)
+		return err == nil && cost.Total <= a.MaxHousingPayment, err
+	}
+	// Find a price that does not fit, then bisect down to the step
+	low, high := 0.0, affordabilityPriceStep
+	for {
+		ok, err := fits(high)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if low = high; high >= maxAffordabilityPrice {
+			break
+		}
+		high *= 2
+	}
+	for high-low > affordabilityPriceStep {
+		mid := math.Floor((low+high)/2/affordabilityPriceStep) * affordabilityPriceStep
+		ok, err := fits(mid)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	a.MaxPrice = low
+	if low > 0 {
+		a.CostAtMaxPrice, _ = costAt(low)
+	}
+	return a, nil
+}
+
+// check measures a property's monthly cost against the limits
+func (l AffordabilityLimits) check(cost MonthlyCost) *PropertyAffordability {
+	income := l.AnnualIncome / 12
+	c := &PropertyAffordability{
+		Cost:          cost,
+		FrontEndRatio: round(cost.Total/income*100, 2),
+		BackEndRatio:  round((cost.Total+l.MonthlyDebts)/income*100, 2),
+	}
+	c.Affordable = c.FrontEndRatio <= l.FrontEndRatio && c.BackEndRatio <= l.BackEndRatio
+	needed := math.Max(cost.Total/(l.FrontEndRatio/100), (cost.Total+l.MonthlyDebts)/(l.BackEndRatio/100))
+	c.IncomeNeeded = math.Ceil(needed * 12)
+	return c
+}
+
+// monthlyAmount converts a recurring cost to a monthly amount; costs without
+// a period are taken to recur every defaultPeriod
+func monthlyAmount(m *Money, defaultPeriod string) float64 {
+	period := m.Period
+	if period == "" {
+		period = defaultPeriod
+	}
+	if period == PeriodAnnual {
+		return m.Amount / 12
+	}
+	return m.Amount
+}
+
+// mortgageArgs are the loan arguments the mortgage tools share; the price
+// and down payment depend on the property
+type mortgageArgs struct {
+	terms       LoanTerms
+	downPayment float64 // Dollars, when given instead of a percent
+	downPercent float64
+	granularity string
+}
+
+// at returns the loan terms for a price
+func (a mortgageArgs) at(price float64) LoanTerms {
+	t := a.terms
+	t.Price = price
+	t.DownPayment = round(price*a.downPercent/100, 2)
+	if a.downPayment > 0 {
+		t.DownPayment = math.Min(a.downPayment, price)
+	}
+	return t
+}
+
+// parseMortgageArgs reads the loan arguments of the mortgage tools
+func parseMortgageArgs(args map[string]interface{}) (mortgageArgs, error) {
+	a := mortgageArgs{downPercent: defaultDownPercent, granularity: "annual"}
+	rate, ok := args["interest_rate"].(float64)
+	if !ok {
+		return a, fmt.Errorf("interest_rate is required, as an annual percent (e.g., 6.5)")
+	}
+	a.terms = LoanTerms{Rate: rate, TermYears: defaultTermYears, PMIRate: defaultPMIRate}
+	if v, ok := args["term_years"].(float64); ok {
+		a.terms.TermYears = int(v)
+	}
+	if v, ok := args["down_payment"].(float64); ok {
+		a.downPayment = v
+	}
+	if v, ok := args["down_payment_percent"].(float64); ok {
+		if v < 0 || v > 100 {
+			return a, fmt.Errorf("down_payment_percent must be between 0 and 100")
+		}
+		a.downPercent = v
+	}
+	a.terms.Points, _ = args["points"].(float64)
+	if v, ok := args["pmi_rate"].(float64); ok {
+		a.terms.PMIRate = v
+	}
+
+	a.terms.Type, _ = args["loan_type"].(string)
+	if a.terms.Type == LoanARM {
+		arm := &ARMTerms{}
+		if v, ok := args["arm_initial_years"].(float64); ok {
+			arm.InitialYears = int(v)
+		}
+		if v, ok := args["arm_adjustment_months"].(float64); ok {
+			arm.AdjustmentMonths = int(v)
+		}
+		arm.IndexRate, _ = args["arm_index_rate"].(float64)
+		if caps, ok := args["arm_caps"].([]interface{}); ok {
+			if len(caps) != 3 {
+				return a, fmt.Errorf("arm_caps must be three numbers: initial, periodic and lifetime (e.g., [2, 2, 5])")
+			}
+			for i, dst := range []*float64{&arm.InitialCap, &arm.PeriodicCap, &arm.LifetimeCap} {
+				*dst, _ = caps[i].(float64)
+			}
+		}
+		a.terms.ARM = arm
+	}
+
+	if v, ok := args["schedule"].(string); ok && v != "" {
+		if v != "annual" && v != "monthly" && v != "none" {
+			return a, fmt.Errorf("unknown schedule %q (available: annual, monthly, none)", v)
+		}
+		a.granularity = v
+	}
+	return a, nil
+}
+
+// mortgageSchema returns the input schema properties of a mortgage tool: its
+// own, plus the property and loan arguments the mortgage tools share
+func mortgageSchema(own map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"property_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of a collected property (from a search) to take the price and costs from",
+		},
+		"url": map[string]interface{}{
+			"type":        "string",
+			"description": "Property page URL from homes.com or redfin.com to take the price and costs from",
+		},
+		"price":                numberSchema("Purchase price in dollars; overrides the property's price"),
+		"interest_rate":        numberSchema("Annual interest rate in percent (e.g., 6.5); an ARM's initial rate"),
+		"term_years":           integerSchema("Loan term in years (default 30)"),
+		"down_payment":         numberSchema("Down payment in dollars, instead of down_payment_percent"),
+		"down_payment_percent": numberSchema("Down payment in percent of the price (default 20)"),
+		"points":               numberSchema("Discount points paid upfront, each 1% of the loan"),
+		"pmi_rate":             numberSchema("Annual PMI in percent of the loan, charged while the loan is over 80% of the price and until it is scheduled to reach 78% (default 0.5)"),
+		"loan_type": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{LoanFixed, LoanARM},
+			"description": "Fixed rate (default) or adjustable rate",
+		},
+		"arm_initial_years":     integerSchema("Years an ARM's initial rate is fixed (default 5)"),
+		"arm_adjustment_months": integerSchema("Months between an ARM's adjustments after that (default 12)"),
+		"arm_index_rate":        numberSchema("Fully indexed rate (index plus margin) an ARM adjusts toward, in percent; the initial rate plus the lifetime cap when omitted"),
+		"arm_caps": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "number", "minimum": 0},
+			"description": "An ARM's initial, periodic and lifetime caps in percentage points (default [2, 2, 5])",
+		},
+	}
+	for name, schema := range own {
+		properties[name] = schema
+	}
+	return properties
+}
+
+// costArgsSchema is the input schema of the costs that override a property's
+var costArgsSchema = map[string]interface{}{
+	"property_tax":      numberSchema("Annual property tax in dollars; overrides the listing's"),
+	"property_tax_rate": numberSchema("Annual property tax in percent of the price, used when the tax is not known (e.g., 0.35)"),
+	"hoa_fees":          numberSchema("Monthly HOA dues in dollars; overrides the listing's"),
+	"insurance":         numberSchema("Annual homeowners insurance in dollars; overrides the listing's"),
+}
+
+// mortgageProperty returns the property named by property_id or url, or nil
+// when the call names none
+func (p *Plugin) mortgageProperty(ctx context.Context, args map[string]interface{}) (*PropertyData, error) {
+	if id, _ := args["property_id"].(string); id != "" {
+		property, ok := p.collectedProperty(id)
+		if !ok {
+			return nil, fmt.Errorf("property %s has not been collected; search for it first or pass its url", id)
+		}
+		return &property, nil
+	}
+	if url, _ := args["url"].(string); url != "" {
+		source, err := p.sources.ForURL(url)
+		if err != nil {
+			return nil, err
+		}
+		property, err := p.propertyDetail(ctx, source, url)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching property details: %v", err)
+		}
+		return &property, nil
+	}
+	return nil, nil
+}
+
+// mortgagePrice returns the price argument, or else the property's price
+func mortgagePrice(property *PropertyData, args map[string]interface{}) (float64, error) {
+	if v, ok := args["price"].(float64); ok && v > 0 {
+		return v, nil
+	}
+	if property != nil && property.Price > 0 {
+		return float64(property.Price), nil
+	}
+	if property != nil {
+		return 0, fmt.Errorf("%s has no price; pass price", property.Address)
+	}
+	return 0, fmt.Errorf("price is required unless property_id or url names a property")
+}
+
+// propertyRef identifies a property in calculator results
+func propertyRef(property *PropertyData) *PropertyRef {
+	if property == nil {
+		return nil
+	}
+	return &PropertyRef{ID: property.ID, Address: property.Address, City: property.City, State: property.State, Price: property.Price}
+}
+
+// ownershipCosts returns the monthly tax, HOA and insurance of a property at
+// a price: from the arguments, else the listing, else the tax rate argument.
+// Listed taxes scale with the price, so a price other than the listing's
+// pays the listing's effective rate.
+func ownershipCosts(property *PropertyData, args map[string]interface{}, price float64) (tax, hoa, insurance CostItem) {
+	tax, hoa, insurance = CostItem{Source: CostUnknown}, CostItem{Source: CostUnknown}, CostItem{Source: CostUnknown}
+	if property != nil {
+		if property.PropertyTax != nil && property.Price > 0 {
+			tax = CostItem{Monthly: monthlyAmount(property.PropertyTax, PeriodAnnual) * price / float64(property.Price), Source: CostFromListing}
+		}
+		if property.HOAFees != nil {
+			hoa = CostItem{Monthly: monthlyAmount(property.HOAFees, PeriodMonthly), Source: CostFromListing}
+		}
+		if property.HomeInsurance != nil {
+			insurance = CostItem{Monthly: monthlyAmount(property.HomeInsurance, PeriodAnnual), Source: CostFromListing}
+		}
+	}
+	if v, ok := args["property_tax"].(float64); ok {
+		tax = CostItem{Monthly: v / 12, Source: CostFromInput}
+	} else if v, ok := args["property_tax_rate"].(float64); ok && tax.Source == CostUnknown {
+		tax = CostItem{Monthly: price * v / 100 / 12, Source: CostFromEstimate}
+	}
+	if v, ok := args["hoa_fees"].(float64); ok {
+		hoa = CostItem{Monthly: v, Source: CostFromInput}
+	}
+	if v, ok := args["insurance"].(float64); ok {
+		insurance = CostItem{Monthly: v / 12, Source: CostFromInput}
+	}
+	return tax, hoa, insurance
+}
+
+// unknownCosts names the costs a monthly cost leaves out
+func unknownCosts(cost MonthlyCost) string {
+	var unknown []string
+	for _, item := range []struct {
+		name string
+		cost CostItem
+	}{{"property tax", cost.PropertyTax}, {"HOA dues", cost.HOA}, {"insurance", cost.Insurance}} {
+		if item.cost.Source == CostUnknown {
+			unknown = append(unknown, item.name)
+		}
+	}
+	if len(unknown) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (not including unknown %s)", strings.Join(unknown, " or "))
+}
+
+// mortgageInputs reads the loan arguments and the property a mortgage tool
+// call names, if any
+func (p *Plugin) mortgageInputs(ctx context.Context, args map[string]interface{}) (mortgageArgs, *PropertyData, error) {
+	loan, err := parseMortgageArgs(args)
+	if err != nil {
+		return loan, nil, err
+	}
+	property, err := p.mortgageProperty(ctx, args)
+	return loan, property, err
+}
+
+func (p *Plugin) handleCalculateMortgage(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	loan, property, err := p.mortgageInputs(ctx, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	price, err := mortgagePrice(property, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	schedule, err := amortize(loan.at(price))
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Invalid loan: %v", err)}},
+		}, nil
+	}
+	schedule.Property = propertyRef(property)
+	switch loan.granularity {
+	case "annual":
+		schedule.Schedule = annual(schedule.Schedule)
+		schedule.Granularity = "annual"
+	case "none":
+		schedule.Schedule, schedule.Granularity = nil, ""
+	}
+
+	data, err := json.MarshalIndent(schedule, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling schedule: %v", err)}},
+		}, nil
+	}
+	summary := fmt.Sprintf("%s loan at %v%% over %d years: %s a month in principal and interest, %s in interest in total",
+		usd(schedule.LoanAmount), schedule.Terms.Rate, schedule.Terms.TermYears, usd(schedule.MonthlyPayment), usd(schedule.TotalInterest))
+	if schedule.MaxMonthlyPayment > schedule.MonthlyPayment {
+		summary += fmt.Sprintf(", rising to %s a month once the rate adjusts", usd(schedule.MaxMonthlyPayment))
+	}
+	if property != nil {
+		summary = property.Address + ": " + summary
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: summary + ":"},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+func (p *Plugin) handleCalculateMonthlyCost(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	loan, property, err := p.mortgageInputs(ctx, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	price, err := mortgagePrice(property, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	tax, hoa, insurance := ownershipCosts(property, args, price)
+	cost, err := monthlyCost(loan.at(price), tax, hoa, insurance)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Invalid loan: %v", err)}},
+		}, nil
+	}
+	cost.Property = propertyRef(property)
+
+	data, err := json.MarshalIndent(cost, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling monthly cost: %v", err)}},
+		}, nil
+	}
+	name := usd(price)
+	if property != nil {
+		name = property.Address
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Monthly cost of %s: %s%s:", name, usd(cost.Total), unknownCosts(cost))},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+func (p *Plugin) handleCalculateAffordability(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	loan, property, err := p.mortgageInputs(ctx, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	limits := AffordabilityLimits{FrontEndRatio: defaultFrontEndRatio, BackEndRatio: defaultBackEndRatio}
+	limits.AnnualIncome, _ = args["annual_income"].(float64)
+	limits.MonthlyDebts, _ = args["monthly_debts"].(float64)
+	if v, ok := args["front_end_ratio"].(float64); ok {
+		limits.FrontEndRatio = v
+	}
+	if v, ok := args["back_end_ratio"].(float64); ok {
+		limits.BackEndRatio = v
+	}
+
+	costAt := func(price float64) (MonthlyCost, error) {
+		tax, hoa, insurance := ownershipCosts(property, args, price)
+		return monthlyCost(loan.at(price), tax, hoa, insurance)
+	}
+	result, err := affordability(limits, costAt)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Cannot calculate affordability: %v", err)}},
+		}, nil
+	}
+	summary := fmt.Sprintf("With %s a year in income, housing can cost up to %s a month (limited by the %s ratio), enough for a price of up to %s",
+		usd(limits.AnnualIncome), usd(result.MaxHousingPayment), result.LimitedBy, usd(result.MaxPrice))
+
+	// Check the named property or price against the limits
+	if _, ok := args["price"].(float64); ok || property != nil {
+		price, err := mortgagePrice(property, args)
+		if err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+			}, nil
+		}
+		cost, err := costAt(price)
+		if err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Invalid loan: %v", err)}},
+			}, nil
+		}
+		cost.Property = propertyRef(property)
+		result.Property = limits.check(cost)
+		name, verdict := usd(price), "is not affordable"
+		if property != nil {
+			name = property.Address
+		}
+		if result.Property.Affordable {
+			verdict = "is affordable"
+		}
+		summary += fmt.Sprintf("; %s %s at %s a month, which needs %s a year", name, verdict, usd(cost.Total), usd(result.Property.IncomeNeeded))
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling affordability: %v", err)}},
+		}, nil
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: summary + ":"},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+// usd formats a dollar amount, e.g. "$2,398.20"
+func usd(amount float64) string {
+	return Money{Amount: amount, Currency: "USD"}.String()
+}
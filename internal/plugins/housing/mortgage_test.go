@@ -0,0 +1,212 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+func TestAmortizeFixed(t *testing.T) {
+	// $400,000 at 6% over 30 years, with 20% down and 2 points
+	s, err := amortize(LoanTerms{Price: 500000, DownPayment: 100000, Rate: 6, TermYears: 30, Points: 2, PMIRate: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.MonthlyPayment != 2398.20 || s.LoanAmount != 400000 || s.PointsCost != 8000 || s.CashToClose != 108000 {
+		t.Errorf("payment %v, loan %v, points %v, cash %v", s.MonthlyPayment, s.LoanAmount, s.PointsCost, s.CashToClose)
+	}
+	if len(s.Schedule) != 360 || s.Schedule[0].Interest != 2000 || s.Schedule[0].Principal != 398.20 || s.Schedule[359].Balance != 0 {
+		t.Fatalf("schedule has %d months, first %+v", len(s.Schedule), s.Schedule[0])
+	}
+	// At 80% down no PMI is charged, and the principal adds up to the loan
+	var principal float64
+	for _, month := range s.Schedule {
+		principal += month.Principal
+	}
+	if math.Abs(principal-400000) > 0.01 || s.TotalPMI != 0 || s.MonthlyPMI != 0 {
+		t.Errorf("principal %v, PMI %v", principal, s.TotalPMI)
+	}
+	if math.Abs(s.TotalInterest-(2398.20*360-400000)) > 5 {
+		t.Errorf("total interest = %v", s.TotalInterest)
+	}
+	if s.TotalCost != round(108000+400000+s.TotalInterest, 2) {
+		t.Errorf("total cost = %v", s.TotalCost)
+	}
+
+	years := annual(s.Schedule)
+	if len(years) != 30 || years[0].Payment != round(2398.20*12, 2) || years[0].Balance != s.Schedule[11].Balance {
+		t.Errorf("first year = %+v", years[0])
+	}
+
+	// Without interest the loan is repaid evenly
+	s, _ = amortize(LoanTerms{Price: 120000, Rate: 0, TermYears: 10})
+	if s.MonthlyPayment != 1000 || s.TotalInterest != 0 || s.Schedule[119].Balance != 0 {
+		t.Errorf("zero rate: %v a month, %v interest", s.MonthlyPayment, s.TotalInterest)
+	}
+
+	for _, terms := range []LoanTerms{
+		{Price: 0, Rate: 6, TermYears: 30},
+		{Price: 100, DownPayment: 200, Rate: 6, TermYears: 30},
+		{Price: 100, Rate: 6, TermYears: 0},
+		{Price: 100, Rate: 6, TermYears: 30, Type: "balloon"},
+	} {
+		if _, err := amortize(terms); err == nil {
+			t.Errorf("expected an error for %+v", terms)
+		}
+	}
+}
+
+func TestAmortizePMI(t *testing.T) {
+	// 10% down: $187.50 a month until the balance is scheduled to reach 78%
+	// of the price, $390,000
+	s, err := amortize(LoanTerms{Price: 500000, DownPayment: 50000, Rate: 6, TermYears: 30, PMIRate: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.MonthlyPMI != 187.50 || s.PMIMonths == 0 || s.TotalPMI != round(187.50*float64(s.PMIMonths), 2) {
+		t.Fatalf("PMI %v for %d months, %v in total", s.MonthlyPMI, s.PMIMonths, s.TotalPMI)
+	}
+	last, next := s.Schedule[s.PMIMonths-1], s.Schedule[s.PMIMonths]
+	if last.PMI == 0 || next.PMI != 0 || last.Balance > 390000 || s.Schedule[s.PMIMonths-2].Balance <= 390000 {
+		t.Errorf("PMI ends after month %d: %+v then %+v", s.PMIMonths, last, next)
+	}
+}
+
+func TestAmortizeARM(t *testing.T) {
+	// A 5/1 ARM at 5% with 2/2/5 caps, indexed to 12%: fixed for five years,
+	// then up 2 points a year until the 10% lifetime cap
+	s, err := amortize(LoanTerms{Price: 400000, DownPayment: 80000, Rate: 5, TermYears: 30, Type: LoanARM,
+		ARM: &ARMTerms{InitialYears: 5, IndexRate: 12, InitialCap: 2, PeriodicCap: 2, LifetimeCap: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	years := annual(s.Schedule)
+	for i, want := range []float64{5, 5, 5, 5, 5, 7, 9, 10, 10} {
+		if years[i].Rate != want {
+			t.Errorf("year %d rate = %v, want %v", i+1, years[i].Rate, want)
+		}
+	}
+	// Each adjustment re-amortizes the balance over the remaining term
+	if want := round(monthlyPayment(s.Schedule[59].Balance, 7, 300), 2); s.Schedule[60].Payment != want {
+		t.Errorf("payment after the first adjustment = %v, want %v", s.Schedule[60].Payment, want)
+	}
+	if s.MonthlyPayment != round(monthlyPayment(320000, 5, 360), 2) || s.MaxMonthlyPayment <= s.MonthlyPayment || s.Schedule[359].Balance != 0 {
+		t.Errorf("payments %v to %v", s.MonthlyPayment, s.MaxMonthlyPayment)
+	}
+
+	// Without an index rate the ARM is assumed to reach its lifetime cap
+	terms := LoanTerms{Price: 400000, Rate: 5, TermYears: 30, Type: LoanARM}
+	if _, err := amortize(terms); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := amortize(terms); s.Terms.ARM.IndexRate != 10 || s.Schedule[359].Rate != 10 {
+		t.Errorf("default ARM = %+v", s.Terms.ARM)
+	}
+}
+
+func TestAffordability(t *testing.T) {
+	// $120,000 a year allows $2,800 a month at 28%, less than the $3,100
+	// 36% leaves after $500 of other debts
+	limits := AffordabilityLimits{AnnualIncome: 120000, MonthlyDebts: 500, FrontEndRatio: 28, BackEndRatio: 36}
+	costAt := func(price float64) (MonthlyCost, error) {
+		tax := CostItem{Monthly: price * 0.01 / 12, Source: CostFromEstimate}
+		return monthlyCost(LoanTerms{Price: price, DownPayment: price / 5, Rate: 6, TermYears: 30}, tax, CostItem{Source: CostUnknown}, CostItem{Monthly: 100, Source: CostFromInput})
+	}
+	a, err := affordability(limits, costAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.MaxHousingPayment != 2800 || a.LimitedBy != "front-end" {
+		t.Errorf("max payment %v limited by %s", a.MaxHousingPayment, a.LimitedBy)
+	}
+	above, _ := costAt(a.MaxPrice + 1000)
+	if a.MaxPrice != 479000 || a.CostAtMaxPrice.Total > 2800 || above.Total <= 2800 {
+		t.Errorf("max price %v costs %v", a.MaxPrice, a.CostAtMaxPrice.Total)
+	}
+
+	// The property costs more than the limits, and needs more income
+	cost, _ := costAt(600000)
+	check := limits.check(cost)
+	if check.Affordable || check.FrontEndRatio <= 28 || check.IncomeNeeded <= 120000 {
+		t.Errorf("check = %+v", check)
+	}
+	if needed := limits.check(cost).IncomeNeeded; needed != math.Ceil(cost.Total/0.28*12) {
+		t.Errorf("income needed = %v", needed)
+	}
+
+	// Heavy debts make the back-end ratio the limit
+	limits.MonthlyDebts = 2000
+	if a, _ := affordability(limits, costAt); a.LimitedBy != "back-end" || a.MaxHousingPayment != 1600 {
+		t.Errorf("max payment %v limited by %s", a.MaxHousingPayment, a.LimitedBy)
+	}
+	if _, err := affordability(AffordabilityLimits{FrontEndRatio: 28, BackEndRatio: 36}, costAt); err == nil {
+		t.Error("expected an error without income")
+	}
+}
+
+func TestMortgageTools(t *testing.T) {
+	p := NewPlugin()
+	p.collected["prop_poelua"] = PropertyData{
+		ID: "prop_poelua", Address: "2819 Poelua St", City: "Honolulu", State: "HI", Price: 1200000,
+		PropertyTax: &Money{Amount: 3600, Currency: "USD", Period: PeriodAnnual},
+		HOAFees:     &Money{Amount: 150, Currency: "USD", Period: PeriodMonthly},
+	}
+	ctx := context.Background()
+	call := func(tool string, args map[string]interface{}, result interface{}) string {
+		t.Helper()
+		response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: tool, Arguments: args})
+		if err != nil || response.IsError {
+			t.Fatalf("%s: %v %+v", tool, err, response)
+		}
+		if err := json.Unmarshal([]byte(response.Content[1].Text), result); err != nil {
+			t.Fatal(err)
+		}
+		return response.Content[0].Text
+	}
+
+	var cost MonthlyCost
+	summary := call("calculate_monthly_cost", map[string]interface{}{"property_id": "prop_poelua", "interest_rate": 6.0}, &cost)
+	if cost.LoanAmount != 960000 || cost.PropertyTax != (CostItem{300, CostFromListing}) || cost.HOA != (CostItem{150, CostFromListing}) || cost.Insurance.Source != CostUnknown {
+		t.Errorf("cost = %+v", cost)
+	}
+	if cost.Total != round(cost.PrincipalAndInterest+450, 2) || !strings.Contains(summary, "not including unknown insurance") {
+		t.Errorf("total %v: %s", cost.Total, summary)
+	}
+
+	// Arguments override the listing; a different price scales its taxes
+	call("calculate_monthly_cost", map[string]interface{}{"property_id": "prop_poelua", "price": 1000000.0, "interest_rate": 6.0, "insurance": 2400.0, "hoa_fees": 0.0}, &cost)
+	if cost.PropertyTax.Monthly != 250 || cost.HOA != (CostItem{0, CostFromInput}) || cost.Insurance != (CostItem{200, CostFromInput}) {
+		t.Errorf("overridden cost = %+v", cost)
+	}
+
+	var schedule MortgageSchedule
+	call("calculate_mortgage", map[string]interface{}{"price": 500000.0, "interest_rate": 6.0, "down_payment": 100000.0, "schedule": "none"}, &schedule)
+	if schedule.MonthlyPayment != 2398.20 || schedule.Schedule != nil {
+		t.Errorf("schedule = %+v", schedule)
+	}
+	call("calculate_mortgage", map[string]interface{}{"property_id": "prop_poelua", "interest_rate": 5.0, "loan_type": "arm", "arm_caps": []interface{}{1.0, 1.0, 3.0}}, &schedule)
+	if schedule.Property == nil || schedule.Property.ID != "prop_poelua" || len(schedule.Schedule) != 30 || schedule.Schedule[29].Rate != 8 {
+		t.Errorf("ARM schedule = %+v", schedule)
+	}
+
+	var affordable Affordability
+	call("calculate_affordability", map[string]interface{}{"property_id": "prop_poelua", "interest_rate": 6.0, "annual_income": 150000.0}, &affordable)
+	if affordable.Property == nil || affordable.Property.Affordable || affordable.MaxPrice >= 1200000 {
+		t.Errorf("affordability = %+v", affordable)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"price": 500000.0},
+		{"interest_rate": 6.0},
+		{"property_id": "prop_unknown", "interest_rate": 6.0},
+		{"price": 500000.0, "interest_rate": 6.0, "loan_type": "arm", "arm_caps": []interface{}{2.0}},
+	} {
+		if response, _ := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "calculate_mortgage", Arguments: args}); !response.IsError {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
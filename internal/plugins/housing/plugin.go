@@ -4,14 +4,40 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/johan-j/play-mcp/pkg/export"
+	"github.com/johan-j/play-mcp/pkg/geo"
 	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/records"
+	"github.com/johan-j/play-mcp/pkg/scheduler"
 	"github.com/johan-j/play-mcp/pkg/scraper"
+	"github.com/johan-j/play-mcp/pkg/store"
 )
 
 // Plugin implements the MCP plugin interface for housing data
-type Plugin struct{}
+type Plugin struct {
+	sources      *scraper.SourceRegistry
+	searchSource string
+	store        *store.Store
+	refreshAfter time.Duration
+	scheduler    *scheduler.Scheduler
+	format       string // Default property output format, typed or legacy
+	geocoder     geo.Geocoder
+	index        *geo.Index // Locations of collected properties by ID
+	boundaries   *geo.BoundaryRegistry
+	records      *records.Dataset
+	exporter     *export.Exporter
+	loadStored   sync.Once
+	now          func() time.Time
+
+	mu        sync.RWMutex
+	ingested  map[string]*IngestionResult // Latest ingestion job results by area
+	collected map[string]PropertyData     // Properties seen by searches, lookups and ingestion, by ID
+}
 
 // PropertyData represents comprehensive property information for pricing analysis
 type PropertyData struct {
@@ -20,6 +46,7 @@ type PropertyData struct {
 	City         string   `json:"city"`
 	State        string   `json:"state"`
 	ZipCode      string   `json:"zipCode"`
+	APN          string   `json:"apn,omitempty"` // Assessor's parcel number
 	Price        int64    `json:"price"`
 	Bedrooms     int      `json:"bedrooms"`
 	Bathrooms    float64  `json:"bathrooms"`
@@ -28,52 +55,135 @@ type PropertyData struct {
 	YearBuilt    int      `json:"yearBuilt"`
 	PropertyType string   `json:"propertyType"`
 	Status       string   `json:"status"`
-	ListedDate   string   `json:"listedDate"`
+	ListedDate   Date     `json:"listedDate"`
 	Description  string   `json:"description"`
 	Features     []string `json:"features"`
 	Images       []string `json:"images"`
 	// Enhanced fields for comprehensive pricing analysis
-	PropertyCondition  string   `json:"propertyCondition"`
-	SchoolDistrict     string   `json:"schoolDistrict"`
-	ElementarySchool   string   `json:"elementarySchool"`
-	MiddleSchool       string   `json:"middleSchool"`
-	HighSchool         string   `json:"highSchool"`
-	SchoolRatings      []string `json:"schoolRatings"`
-	Neighborhood       string   `json:"neighborhood"`
-	PropertyTax        string   `json:"propertyTax"`
-	HOAFees            string   `json:"hoaFees"`
-	ParkingSpaces      int      `json:"parkingSpaces"`
-	Garage             string   `json:"garage"`
-	Heating            string   `json:"heating"`
-	Cooling            string   `json:"cooling"`
-	Flooring           []string `json:"flooring"`
-	Appliances         []string `json:"appliances"`
-	LastRenovated      string   `json:"lastRenovated"`
-	PriceHistory       []string `json:"priceHistory"`
-	NearbyComparables  []string `json:"nearbyComparables"`
-	WalkScore          string   `json:"walkScore"`
-	TransitScore       string   `json:"transitScore"`
-	DistanceToBeach    string   `json:"distanceToBeach"`
-	DistanceToDowntown string   `json:"distanceToDowntown"`
-	FloodZone          string   `json:"floodZone"`
-	HomeInsurance      string   `json:"homeInsurance"`
-	SoldDate           string   `json:"soldDate"`
-	DaysOnMarket       int      `json:"daysOnMarket"`
-	PricePerSqFt       int      `json:"pricePerSqFt"`
-	Agent              string   `json:"agent"`
-	Brokerage          string   `json:"brokerage"`
+	PropertyCondition  string        `json:"propertyCondition"`
+	SchoolDistrict     string        `json:"schoolDistrict"`
+	ElementarySchool   string        `json:"elementarySchool"`
+	MiddleSchool       string        `json:"middleSchool"`
+	HighSchool         string        `json:"highSchool"`
+	SchoolRatings      []string      `json:"schoolRatings"`
+	Neighborhood       string        `json:"neighborhood"`
+	PropertyTax        *Money        `json:"propertyTax,omitempty"`
+	HOAFees            *Money        `json:"hoaFees,omitempty"`
+	ParkingSpaces      int           `json:"parkingSpaces"`
+	Garage             string        `json:"garage"`
+	Heating            string        `json:"heating"`
+	Cooling            string        `json:"cooling"`
+	Flooring           []string      `json:"flooring"`
+	Appliances         []string      `json:"appliances"`
+	LastRenovated      string        `json:"lastRenovated"`
+	PriceHistory       []PriceEvent  `json:"priceHistory"`
+	NearbyComparables  []PropertyRef `json:"nearbyComparables"`
+	WalkScore          int           `json:"walkScore,omitempty"`    // 0 to 100
+	TransitScore       int           `json:"transitScore,omitempty"` // 0 to 100
+	DistanceToBeach    *Distance     `json:"distanceToBeach,omitempty"`
+	DistanceToDowntown *Distance     `json:"distanceToDowntown,omitempty"`
+	FloodZone          string        `json:"floodZone"`
+	HomeInsurance      *Money        `json:"homeInsurance,omitempty"`
+	SoldDate           Date          `json:"soldDate"`
+	DaysOnMarket       int           `json:"daysOnMarket"`
+	PricePerSqFt       int           `json:"pricePerSqFt"`
+	Agent              string        `json:"agent"`
+	Brokerage          string        `json:"brokerage"`
+	Latitude           float64       `json:"latitude,omitempty"`
+	Longitude          float64       `json:"longitude,omitempty"`
+	// LocationPrecision says where the coordinates came from: the listing, its
+	// public record, or the geocoder's address, zip or city match
+	LocationPrecision string `json:"locationPrecision,omitempty"`
+	// DistanceMiles is the distance from a radius search's center
+	DistanceMiles float64 `json:"distanceMiles,omitempty"`
+	// Confidence maps field names to how reliably they were extracted, from 0 to 1
+	Confidence map[string]float64 `json:"confidence,omitempty"`
+	// FieldSources maps field names to the listing site each merged value came from
+	FieldSources map[string]string `json:"fieldSources,omitempty"`
+	// PublicRecord is the property's county parcel record, with -public-records
+	PublicRecord *records.Parcel `json:"publicRecord,omitempty"`
+	// Provenance: the listing site the data came from and when it was scraped
+	Source    string `json:"source,omitempty"`
+	FetchedAt string `json:"fetchedAt,omitempty"`
 }
 
-// SearchFilters represents search criteria for properties
+// SearchFilters represents search criteria for properties. Zero values leave
+// a criterion unset.
 type SearchFilters struct {
-	City         string `json:"city,omitempty"`
-	State        string `json:"state,omitempty"`
-	Neighborhood string `json:"neighborhood,omitempty"`
+	City           string   `json:"city,omitempty"`
+	State          string   `json:"state,omitempty"`
+	Neighborhood   string   `json:"neighborhood,omitempty"`
+	ZipCode        string   `json:"zipCode,omitempty"`
+	MinPrice       int64    `json:"minPrice,omitempty"`
+	MaxPrice       int64    `json:"maxPrice,omitempty"`
+	MinBeds        int      `json:"minBeds,omitempty"`
+	MaxBeds        int      `json:"maxBeds,omitempty"`
+	MinBaths       float64  `json:"minBaths,omitempty"`
+	MaxBaths       float64  `json:"maxBaths,omitempty"`
+	MinSqFt        int      `json:"minSqFt,omitempty"`
+	MaxSqFt        int      `json:"maxSqFt,omitempty"`
+	MinLotSize     float64  `json:"minLotSize,omitempty"`
+	MaxLotSize     float64  `json:"maxLotSize,omitempty"`
+	MinYearBuilt   int      `json:"minYearBuilt,omitempty"`
+	MaxYearBuilt   int      `json:"maxYearBuilt,omitempty"`
+	PropertyTypes  []string `json:"propertyTypes,omitempty"`
+	SoldWithinDays int      `json:"soldWithinDays,omitempty"`
+	// Sort is a sort key such as price, prefixed with - for descending
+	Sort   string `json:"sort,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
 }
 
 // NewPlugin creates a new housing plugin instance
 func NewPlugin() *Plugin {
-	return &Plugin{}
+	return &Plugin{
+		sources:      scraper.NewDefaultSourceRegistry(),
+		searchSource: "homes.com",
+		refreshAfter: 24 * time.Hour,
+		format:       FormatLegacy,
+		now:          time.Now,
+		index:        geo.NewIndex(),
+		ingested:     make(map[string]*IngestionResult),
+		collected:    make(map[string]PropertyData),
+	}
+}
+
+// SetExporter sets the exporter that serves download links for exported
+// results; without one, exports are only embedded in responses
+func (p *Plugin) SetExporter(exporter *export.Exporter) {
+	p.exporter = exporter
+}
+
+// SetStore makes searches and detail lookups read from s first, scraping
+// again only when an area or page was last fetched more than refreshAfter ago
+func (p *Plugin) SetStore(s *store.Store, refreshAfter time.Duration) {
+	p.store = s
+	p.refreshAfter = refreshAfter
+	p.sources.SetPropertyLookup(s)
+}
+
+// SetRefreshAfter sets how long stored and ingested results are served before scraping again
+func (p *Plugin) SetRefreshAfter(refreshAfter time.Duration) {
+	p.refreshAfter = refreshAfter
+}
+
+// SetSearchSource selects the listing source used for location searches
+func (p *Plugin) SetSearchSource(name string) error {
+	if _, ok := p.sources.Get(name); !ok {
+		return fmt.Errorf("unknown listing source %q (available: %s)", name, strings.Join(p.sources.Names(), ", "))
+	}
+	p.searchSource = strings.ToLower(name)
+	return nil
+}
+
+// RegisterSource adds a listing source, making its site available to property lookups
+func (p *Plugin) RegisterSource(source scraper.ListingSource) error {
+	return p.sources.Register(source)
+}
+
+// SetFetchPolicies configures how each listing source rate limits, retries and caches requests
+func (p *Plugin) SetFetchPolicies(policies scraper.FetchPolicies) error {
+	return p.sources.SetFetchPolicies(policies)
 }
 
 // Name returns the plugin name
@@ -88,13 +198,14 @@ func (p *Plugin) Description() string {
 
 // GetTools returns the available tools for this plugin
 func (p *Plugin) GetTools() []mcp.Tool {
-	return []mcp.Tool{
+	tools := []mcp.Tool{
 		{
 			Name:        "search_sold_properties",
-			Description: "Search for sold properties based on location",
+			Description: "Search for sold properties by location, filtered by price, beds, baths, size, year built, type and sale date, with sorting and paging. Filters on a value exclude properties where it is unknown.",
 			InputSchema: mcp.ToolSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"export": export.Schema(),
 					"city": map[string]interface{}{
 						"type":        "string",
 						"description": "City name",
@@ -105,36 +216,460 @@ func (p *Plugin) GetTools() []mcp.Tool {
 					},
 					"neighborhood": map[string]interface{}{
 						"type":        "string",
-						"description": "Neighborhood name (e.g., Manoa, Waikiki)",
+						"description": "Optional neighborhood name (e.g., Manoa, Mission District); searches the whole city when omitted",
+					},
+					"zip_code": map[string]interface{}{
+						"type":        "string",
+						"description": "Only properties in this ZIP code (a prefix such as 968 also works)",
+					},
+					"min_price":      numberSchema("Minimum sale price in dollars"),
+					"max_price":      numberSchema("Maximum sale price in dollars"),
+					"min_beds":       integerSchema("Minimum bedrooms"),
+					"max_beds":       integerSchema("Maximum bedrooms"),
+					"min_baths":      numberSchema("Minimum bathrooms (e.g., 1.5)"),
+					"max_baths":      numberSchema("Maximum bathrooms"),
+					"min_sqft":       integerSchema("Minimum living area in square feet"),
+					"max_sqft":       integerSchema("Maximum living area in square feet"),
+					"min_lot_size":   numberSchema("Minimum lot size"),
+					"max_lot_size":   numberSchema("Maximum lot size"),
+					"min_year_built": integerSchema("Earliest year built"),
+					"max_year_built": integerSchema("Latest year built"),
+					"property_type": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Property types to include (e.g., house, condo, townhouse)",
+					},
+					"sold_within_days": integerSchema("Only properties sold within this many days"),
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"description": "Sort key, prefixed with - for descending: price, soldDate, bedrooms, bathrooms, squareFeet, lotSize, yearBuilt, pricePerSqFt or daysOnMarket (default -soldDate); properties missing the value sort last",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Results per page (default 50, at most 200)",
+						"minimum":     1,
+						"maximum":     maxSearchLimit,
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "Cursor from a previous call's results to get the next page; use it with the same filters and sort",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{FormatTyped, FormatLegacy},
+						"description": "Output format: legacy (the original display strings, the default) or typed (money, distances, scores and dates as structured values)",
+					},
+				},
+				Required: []string{"city", "state"},
+			},
+		},
+		{
+			Name:        "estimate_property_value",
+			Description: "Estimate a property's value from comparable sales in its area: comps are chosen by proximity, recency, type and size, adjusted for square footage, bedrooms, bathrooms, lot size, age and condition, and combined into an estimate with an 80% range. Returns the comps and every adjustment.",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"address": map[string]interface{}{
+						"type":        "string",
+						"description": "Street address (e.g., 2819 Poelua St)",
+					},
+					"city": map[string]interface{}{
+						"type":        "string",
+						"description": "City name",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "State abbreviation (e.g., HI)",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Property page URL from homes.com or redfin.com, instead of address, city and state",
+					},
+					"neighborhood": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional neighborhood to draw comparables from; the whole city when omitted",
+					},
+					"zip_code":         map[string]interface{}{"type": "string", "description": "Subject's ZIP code, when not known from listings"},
+					"square_feet":      integerSchema("Subject's living area, when not known from listings"),
+					"bedrooms":         integerSchema("Subject's bedrooms, when not known from listings"),
+					"bathrooms":        numberSchema("Subject's bathrooms, when not known from listings"),
+					"lot_size":         numberSchema("Subject's lot size, in the same unit as the listings"),
+					"year_built":       integerSchema("Subject's year built, when not known from listings"),
+					"property_type":    map[string]interface{}{"type": "string", "description": "Subject's type (e.g., house, condo, townhouse)"},
+					"condition":        map[string]interface{}{"type": "string", "description": "Subject's condition: poor, fair, average, good, excellent, renovated or new"},
+					"max_comps":        integerSchema("Most comparables to use (default 6)"),
+					"sold_within_days": integerSchema("Only use sales within this many days (default 365)"),
+				},
+			},
+		},
+		{
+			Name:        "get_market_stats",
+			Description: "Get market statistics for a city or neighborhood: median sale price (overall, single family and townhouse), average price per square foot, days on market, sales in the last 12 months, homes for sale, months of supply and year-over-year change. When the listing site's statistics are unavailable they are computed from the area's sold listings; the method field says which.",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"city": map[string]interface{}{
+						"type":        "string",
+						"description": "City name",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "State abbreviation (e.g., CA, NY, TX)",
+					},
+					"neighborhood": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional neighborhood name (e.g., Manoa); statistics for the whole city when omitted",
+					},
+				},
+				Required: []string{"city", "state"},
+			},
+		},
+		{
+			Name:        "get_market_trends",
+			Description: "Get monthly or quarterly market trend series for a neighborhood or city, computed from collected sold listings: median price, median $/sq ft, sales count, days on market and list-to-sale ratio per period, with rolling windows, period-over-period and year-over-year changes, and a heating/cooling summary",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"city": map[string]interface{}{
+						"type":        "string",
+						"description": "City name",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "State abbreviation (e.g., HI)",
+					},
+					"neighborhood": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional neighborhood name (e.g., Manoa); the whole city when omitted",
+					},
+					"property_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Only sales of this type (e.g., house, condo, townhouse)",
+					},
+					"interval": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{IntervalMonthly, IntervalQuarterly},
+						"description": "Period length (default monthly)",
 					},
+					"periods":        integerSchema("Periods to return, ending with the current one (default 24 months or 8 quarters)"),
+					"rolling_window": integerSchema("Periods in each rolling window (default 3 months or 1 quarter)"),
 				},
-				Required: []string{"city", "state", "neighborhood"},
+				Required: []string{"city", "state"},
+			},
+		},
+		{
+			Name:        "search_properties_nearby",
+			Description: "Find collected properties within a radius of an address or coordinates, nearest first, e.g. sold within 0.5 mi of an address. Searches every property earlier searches, lookups and ingestion jobs have collected that has a location.",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: spatialSchema(map[string]interface{}{
+					"address":   map[string]interface{}{"type": "string", "description": "Street address at the center (e.g., 2819 Poelua St)"},
+					"city":      map[string]interface{}{"type": "string", "description": "City of the center address"},
+					"state":     map[string]interface{}{"type": "string", "description": "State abbreviation of the center address"},
+					"zip_code":  map[string]interface{}{"type": "string", "description": "ZIP code of the center address, to help geocoding"},
+					"latitude":  map[string]interface{}{"type": "number", "description": "Latitude of the center, instead of an address"},
+					"longitude": map[string]interface{}{"type": "number", "description": "Longitude of the center, instead of an address"},
+					"radius":    numberSchema("Search radius (default 0.5)"),
+					"unit": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"mi", "km", "m", "ft"},
+						"description": "Unit of the radius (default mi)",
+					},
+				}),
+			},
+		},
+		{
+			Name:        "search_properties_in_area",
+			Description: "Find collected properties inside a GeoJSON Polygon, MultiPolygon, Feature or FeatureCollection, newest sales first",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: spatialSchema(map[string]interface{}{
+					"geojson": map[string]interface{}{
+						"type":        "object",
+						"description": "GeoJSON area with [longitude, latitude] positions; holes are excluded",
+					},
+				}),
+				Required: []string{"geojson"},
+			},
+		},
+		{
+			Name:        "list_neighborhoods",
+			Description: "List the registered neighborhood and school district boundaries that collected properties are assigned to, with their URL slugs, bounding boxes and how many collected properties each holds",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"city": map[string]interface{}{
+						"type":        "string",
+						"description": "Only boundaries in this city (e.g., Honolulu)",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "Only boundaries in this state (e.g., HI)",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{geo.KindNeighborhood, geo.KindSchoolDistrict, "all"},
+						"description": "Kind of boundary to list (default neighborhood)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "calculate_mortgage",
+			Description: "Calculate a mortgage's amortization schedule: fixed or adjustable rate (with initial, periodic and lifetime caps), discount points and PMI. Takes the price from a collected property or property page when property_id or url is given.",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: mortgageSchema(map[string]interface{}{
+					"schedule": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"annual", "monthly", "none"},
+						"description": "Schedule rows to return: one per year (default), one per month, or only the totals",
+					},
+				}),
+				Required: []string{"interest_rate"},
+			},
+		},
+		{
+			Name:        "calculate_monthly_cost",
+			Description: "Calculate the total monthly cost of owning a property: principal and interest, PMI, property tax, HOA dues and insurance. Taxes, dues and insurance come from the listing of the property named by property_id or url unless given, and each says where it came from.",
+			InputSchema: mcp.ToolSchema{
+				Type:       "object",
+				Properties: mortgageSchema(costArgsSchema),
+				Required:   []string{"interest_rate"},
+			},
+		},
+		{
+			Name:        "calculate_affordability",
+			Description: "Calculate the most a borrower can pay for a home given their income, monthly debts and front-end and back-end debt-to-income limits, and whether the property named by property_id, url or price is affordable and what income it needs",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: mortgageSchema(map[string]interface{}{
+					"annual_income":     numberSchema("Gross annual income in dollars"),
+					"monthly_debts":     numberSchema("Monthly payments on other debts (car, student loans, cards) in dollars"),
+					"front_end_ratio":   numberSchema("Most of gross monthly income housing may cost, in percent (default 28)"),
+					"back_end_ratio":    numberSchema("Most of gross monthly income housing and other debts may cost, in percent (default 36)"),
+					"property_tax":      costArgsSchema["property_tax"],
+					"property_tax_rate": costArgsSchema["property_tax_rate"],
+					"hoa_fees":          costArgsSchema["hoa_fees"],
+					"insurance":         costArgsSchema["insurance"],
+				}),
+				Required: []string{"annual_income", "interest_rate"},
+			},
+		},
+		{
+			Name:        "analyze_investment_property",
+			Description: "Analyze a property as a rental: cap rate, cash-on-cash return, DSCR, IRR over a hold period and a year-by-year pro forma, from rent, vacancy, expense, appreciation and financing assumptions and the listing's tax, HOA dues and insurance. The rent_vs_buy mode instead compares buying the property with renting a home like it.",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: mortgageSchema(map[string]interface{}{
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{ModeInvestment, ModeRentVsBuy},
+						"description": "investment (default) analyzes a rental; rent_vs_buy compares buying with renting at monthly_rent",
+					},
+					"interest_rate":     numberSchema("Annual interest rate in percent (e.g., 6.5); omit to buy with cash"),
+					"monthly_rent":      numberSchema("Monthly rent the property earns, or what renting a home like it costs in rent_vs_buy mode"),
+					"rent_growth":       numberSchema("Yearly rent growth in percent (default 3)"),
+					"vacancy_rate":      numberSchema("Share of rent lost to vacancy and collections, in percent (default 5)"),
+					"management_fee":    numberSchema("Property management fee in percent of collected rent (default 0)"),
+					"maintenance_rate":  numberSchema("Maintenance in percent of rent (default 5)"),
+					"capex_rate":        numberSchema("Capital expenditure reserve in percent of rent (default 5)"),
+					"other_expenses":    numberSchema("Other monthly expenses in dollars, such as utilities the owner pays"),
+					"expense_growth":    numberSchema("Yearly growth of taxes, insurance, HOA dues and other expenses in percent (default 3)"),
+					"appreciation":      numberSchema("Yearly appreciation of the property in percent (default 3)"),
+					"closing_costs":     numberSchema("Closing costs when buying, in percent of the price (default 3)"),
+					"selling_costs":     numberSchema("Selling costs at the end of the hold, in percent of the sale price (default 6)"),
+					"hold_years":        integerSchema("Years the property is held before selling (default 10)"),
+					"investment_return": numberSchema("Yearly return on money not spent on the home, in rent_vs_buy mode, in percent (default 5)"),
+					"property_tax":      costArgsSchema["property_tax"],
+					"property_tax_rate": costArgsSchema["property_tax_rate"],
+					"hoa_fees":          costArgsSchema["hoa_fees"],
+					"insurance":         costArgsSchema["insurance"],
+				}),
+				Required: []string{"monthly_rent"},
 			},
 		},
 		{
 			Name:        "fetch_property_detail",
-			Description: "Fetch detailed information about a specific property from homes.com or redfin.com",
+			Description: "Fetch detailed information about a specific property page; the listing source is chosen by the URL's site (homes.com, redfin.com)",
 			InputSchema: mcp.ToolSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"export": export.Schema(),
 					"url": map[string]interface{}{
 						"type":        "string",
 						"description": "Full property URL from homes.com or redfin.com (e.g., https://www.homes.com/property/2819-poelua-st-honolulu-hi/n207sqkl8vl1p/ or https://www.redfin.com/HI/Honolulu/2819-Poelua-St-96822/home/88513618)",
 					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{FormatTyped, FormatLegacy},
+						"description": "Output format: legacy (the original display strings, the default) or typed (money, distances, scores and dates as structured values)",
+					},
 				},
 				Required: []string{"url"},
 			},
 		},
 	}
+
+	if p.store != nil {
+		tools = append(tools,
+			mcp.Tool{
+				Name:        "get_property_history",
+				Description: "Get a stored property's listing events (listed, price cut or increase, pending, sold, relisted, withdrawn), recorded by comparing successive scrapes, merged with the listing site's price history",
+				InputSchema: mcp.ToolSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"property_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Property ID from a search",
+						},
+						"url": map[string]interface{}{
+							"type":        "string",
+							"description": "Property page URL from homes.com or redfin.com; fetched if it has not been",
+						},
+						"address": map[string]interface{}{
+							"type":        "string",
+							"description": "Street address, with city and state, instead of an ID or URL",
+						},
+						"city":  map[string]interface{}{"type": "string", "description": "City name"},
+						"state": map[string]interface{}{"type": "string", "description": "State abbreviation (e.g., HI)"},
+					},
+				},
+			},
+			mcp.Tool{
+				Name:        "get_listing_changes",
+				Description: "Get what changed in a city's or neighborhood's listings since a date: new listings, price cuts and increases, pendings, sales, relists and withdrawals, newest first with counts of each. Withdrawals are listings missing from a scrape that reached the last page of the area's listings for sale",
+				InputSchema: mcp.ToolSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"city":  map[string]interface{}{"type": "string", "description": "City name"},
+						"state": map[string]interface{}{"type": "string", "description": "State abbreviation (e.g., HI)"},
+						"neighborhood": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional neighborhood (e.g., Manoa)",
+						},
+						"since": map[string]interface{}{
+							"type":        "string",
+							"description": "Changes on or after this date, YYYY-MM-DD (default 30 days ago)",
+						},
+						"events": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string", "enum": store.ListingEvents},
+							"description": "Only these kinds of events (default all)",
+						},
+						"limit": integerSchema(fmt.Sprintf("Most events to return (default %d, at most %d)", defaultChangesLimit, maxChangesLimit)),
+					},
+					Required: []string{"city", "state"},
+				},
+			},
+		)
+	}
+	if p.records != nil {
+		tools = append(tools, mcp.Tool{
+			Name:        "get_public_record",
+			Description: "Get a property's county assessor record: parcel number, land use, size, assessed land and improvement values, annual tax and recorded sales",
+			InputSchema: mcp.ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"apn": map[string]interface{}{
+						"type":        "string",
+						"description": "Assessor's parcel number, with or without punctuation",
+					},
+					"property_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Property ID from a search",
+					},
+					"address": map[string]interface{}{
+						"type":        "string",
+						"description": "Street address, with city and state, instead of an APN or ID",
+					},
+					"city":  map[string]interface{}{"type": "string", "description": "City name"},
+					"state": map[string]interface{}{"type": "string", "description": "State abbreviation (e.g., HI); required with an apn or address"},
+				},
+			},
+		})
+	}
+	if p.scheduler != nil {
+		tools = append(tools,
+			mcp.Tool{
+				Name:        "get_ingestion_jobs",
+				Description: "Get the status of the background ingestion jobs that keep configured neighborhoods fresh: schedule, next run, run history and latest results",
+				InputSchema: mcp.ToolSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional job name (e.g., honolulu-hi/manoa); returns every job when omitted",
+						},
+					},
+				},
+			},
+			mcp.Tool{
+				Name:        "run_ingestion_job",
+				Description: "Start an ingestion job now instead of waiting for its schedule",
+				InputSchema: mcp.ToolSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Job name (e.g., honolulu-hi/manoa)",
+						},
+					},
+					Required: []string{"name"},
+				},
+			},
+		)
+	}
+	return tools
+}
+
+// numberSchema returns the JSON schema of a non-negative number argument
+func numberSchema(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "number", "minimum": 0, "description": description}
+}
+
+// integerSchema returns the JSON schema of a non-negative integer argument
+func integerSchema(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": 0, "description": description}
 }
 
 // HandleToolCall handles tool calls for this plugin
-func (p *Plugin) HandleToolCall(_ context.Context, request mcp.ToolCallRequest) (*mcp.ToolCallResponse, error) {
+func (p *Plugin) HandleToolCall(ctx context.Context, request mcp.ToolCallRequest) (*mcp.ToolCallResponse, error) {
 	switch request.Name {
 	case "search_sold_properties":
-		return p.handleSearchSoldProperties(request.Arguments)
+		return p.handleSearchSoldProperties(ctx, request.Arguments)
 	case "fetch_property_detail":
-		return p.handleFetchPropertyDetail(request.Arguments)
+		return p.handleFetchPropertyDetail(ctx, request.Arguments)
+	case "estimate_property_value":
+		return p.handleEstimatePropertyValue(ctx, request.Arguments)
+	case "get_market_stats":
+		return p.handleGetMarketStats(ctx, request.Arguments)
+	case "get_market_trends":
+		return p.handleGetMarketTrends(ctx, request.Arguments)
+	case "search_properties_nearby":
+		return p.handleSearchPropertiesNearby(ctx, request.Arguments)
+	case "search_properties_in_area":
+		return p.handleSearchPropertiesInArea(ctx, request.Arguments)
+	case "list_neighborhoods":
+		return p.handleListNeighborhoods(ctx, request.Arguments)
+	case "calculate_mortgage":
+		return p.handleCalculateMortgage(ctx, request.Arguments)
+	case "calculate_monthly_cost":
+		return p.handleCalculateMonthlyCost(ctx, request.Arguments)
+	case "calculate_affordability":
+		return p.handleCalculateAffordability(ctx, request.Arguments)
+	case "analyze_investment_property":
+		return p.handleAnalyzeInvestmentProperty(ctx, request.Arguments)
+	case "get_property_history":
+		return p.handleGetPropertyHistory(ctx, request.Arguments)
+	case "get_listing_changes":
+		return p.handleGetListingChanges(ctx, request.Arguments)
+	case "get_public_record":
+		return p.handleGetPublicRecord(ctx, request.Arguments)
+	case "get_ingestion_jobs":
+		return p.handleGetIngestionJobs(ctx, request.Arguments)
+	case "run_ingestion_job":
+		return p.handleRunIngestionJob(ctx, request.Arguments)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", request.Name)
 	}
@@ -149,10 +684,22 @@ func (p *Plugin) GetResources() []mcp.Resource {
 			Description: "Recently sold real estate properties by location",
 			MimeType:    "application/json",
 		},
+		{
+			URI:         marketStatsURIPrefix + "{state}/{city}/{neighborhood}",
+			Name:        "Market Statistics",
+			Description: "Market statistics for a city, e.g. housing://market-stats/HI/Honolulu, or a neighborhood, e.g. housing://market-stats/HI/Honolulu/Manoa; path segments are URL-escaped",
+			MimeType:    "application/json",
+		},
+		{
+			URI:         trendsURIPrefix + "{state}/{city}/{neighborhood}",
+			Name:        "Market Trends",
+			Description: "Monthly market trend series for a neighborhood, e.g. housing://trends/HI/Honolulu/Manoa; add ?interval=quarterly, property_type, periods or rolling_window to change the series",
+			MimeType:    "application/json",
+		},
 	}
 }
 
-func (p *Plugin) handleSearchSoldProperties(args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+func (p *Plugin) handleSearchSoldProperties(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
 	// Validate required parameters
 	city, ok := args["city"].(string)
 	if !ok || city == "" {
@@ -170,47 +717,52 @@ func (p *Plugin) handleSearchSoldProperties(args map[string]interface{}) (*mcp.T
 		}, nil
 	}
 
-	neighborhood, ok := args["neighborhood"].(string)
-	if !ok || neighborhood == "" {
+	format, err := p.outputFormat(args)
+	if err != nil {
 		return &mcp.ToolCallResponse{
 			IsError: true,
-			Content: []mcp.Content{{Type: "text", Text: "neighborhood parameter is required and must be a non-empty string"}},
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
 		}, nil
 	}
 
-	filters := SearchFilters{
-		City:         city,
-		State:        state,
-		Neighborhood: neighborhood,
-	}
-
-	// Check if this is a Manoa/Honolulu search and use scraper
-	if (strings.ToLower(filters.City) == "honolulu" && strings.ToLower(filters.State) == "hi") ||
-		strings.Contains(strings.ToLower(filters.City), "manoa") ||
-		strings.ToLower(filters.Neighborhood) == "manoa" {
-		properties, err := p.searchRealProperties(filters)
-		if err == nil && len(properties) > 0 {
-			data, err := json.MarshalIndent(properties, "", "  ")
-			if err != nil {
-				return &mcp.ToolCallResponse{
-					IsError: true,
-					Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling property data: %v", err)}},
-				}, nil
-			}
+	filters, err := parseSearchFilters(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
 
-			return &mcp.ToolCallResponse{
-				Content: []mcp.Content{
-					{Type: "text", Text: fmt.Sprintf("Found %d sold properties matching your criteria:", len(properties))},
-					{Type: "text", Text: string(data)},
-				},
-			}, nil
-		}
+	exportRequest, exporting, err := export.ParseRequest(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
 	}
 
-	// Fall back to mock properties for other locations
-	properties := p.searchMockProperties(filters)
+	properties, err := p.searchRealProperties(ctx, filters)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error searching properties: %v", err)}},
+		}, nil
+	}
 
-	data, err := json.MarshalIndent(properties, "", "  ")
+	page, err := paginate(properties, filters)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	// Exports hold every match in sort order; limit and cursor only page JSON
+	if exporting {
+		return p.exporter.Respond(encodeProperties(properties, format), "sold-"+geo.Slug(city+" "+state), exportRequest), nil
+	}
+
+	data, err := json.MarshalIndent(encodeProperties(page.Properties, format), "", "  ")
 	if err != nil {
 		return &mcp.ToolCallResponse{
 			IsError: true,
@@ -218,15 +770,21 @@ func (p *Plugin) handleSearchSoldProperties(args map[string]interface{}) (*mcp.T
 		}, nil
 	}
 
-	return &mcp.ToolCallResponse{
-		Content: []mcp.Content{
-			{Type: "text", Text: fmt.Sprintf("Found %d sold properties matching your criteria:", len(properties))},
-			{Type: "text", Text: string(data)},
-		},
-	}, nil
+	header := fmt.Sprintf("Found %d sold properties matching your criteria:", page.Total)
+	if len(page.Properties) < page.Total {
+		header = fmt.Sprintf("Found %d sold properties matching your criteria, showing %d-%d:", page.Total, page.Offset+1, page.Offset+len(page.Properties))
+	}
+	content := []mcp.Content{
+		{Type: "text", Text: header},
+		{Type: "text", Text: string(data)},
+	}
+	if page.NextCursor != "" {
+		content = append(content, mcp.Content{Type: "text", Text: fmt.Sprintf("More results available; pass cursor %q for the next page", page.NextCursor)})
+	}
+	return &mcp.ToolCallResponse{Content: content}, nil
 }
 
-func (p *Plugin) handleFetchPropertyDetail(args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+func (p *Plugin) handleFetchPropertyDetail(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
 	// Validate required parameters
 	url, ok := args["url"].(string)
 	if !ok || url == "" {
@@ -236,16 +794,32 @@ func (p *Plugin) handleFetchPropertyDetail(args map[string]interface{}) (*mcp.To
 		}, nil
 	}
 
-	// Validate URL format
-	if !strings.Contains(url, "homes.com/property/") && !strings.Contains(url, "redfin.com/") {
+	format, err := p.outputFormat(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	exportRequest, exporting, err := export.ParseRequest(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	// Pick the listing source by the URL's host
+	source, err := p.sources.ForURL(url)
+	if err != nil {
 		return &mcp.ToolCallResponse{
 			IsError: true,
-			Content: []mcp.Content{{Type: "text", Text: "URL must be a valid homes.com or redfin.com property URL"}},
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
 		}, nil
 	}
 
-	homesScraper := scraper.NewHomesScraper()
-	propertyDetail, err := homesScraper.ScrapePropertyDetail(url)
+	property, err := p.propertyDetail(ctx, source, url)
 	if err != nil {
 		return &mcp.ToolCallResponse{
 			IsError: true,
@@ -253,57 +827,14 @@ func (p *Plugin) handleFetchPropertyDetail(args map[string]interface{}) (*mcp.To
 		}, nil
 	}
 
-	// Convert scraper property to our comprehensive PropertyData format
-	property := PropertyData{
-		ID:           propertyDetail.ID,
-		Address:      propertyDetail.Address,
-		City:         propertyDetail.City,
-		State:        propertyDetail.State,
-		ZipCode:      propertyDetail.ZipCode,
-		Price:        int64(propertyDetail.Price),
-		Bedrooms:     propertyDetail.Bedrooms,
-		Bathrooms:    propertyDetail.Bathrooms,
-		SquareFeet:   propertyDetail.SquareFeet,
-		LotSize:      propertyDetail.LotSize,
-		PropertyType: propertyDetail.PropertyType,
-		Status:       propertyDetail.Status,
-		YearBuilt:    propertyDetail.YearBuilt,
-		Description:  propertyDetail.Description,
-		Features:     propertyDetail.Features,
-		ListedDate:   propertyDetail.SoldDate,
-		SoldDate:     propertyDetail.SoldDate,
-		DaysOnMarket: propertyDetail.DaysOnMarket,
-		PricePerSqFt: propertyDetail.PricePerSqFt,
-		Agent:        propertyDetail.Agent,
-		Brokerage:    propertyDetail.Brokerage,
-		// Enhanced fields for pricing analysis
-		PropertyCondition:  propertyDetail.PropertyCondition,
-		SchoolDistrict:     propertyDetail.SchoolDistrict,
-		ElementarySchool:   propertyDetail.ElementarySchool,
-		MiddleSchool:       propertyDetail.MiddleSchool,
-		HighSchool:         propertyDetail.HighSchool,
-		SchoolRatings:      propertyDetail.SchoolRatings,
-		Neighborhood:       propertyDetail.Neighborhood,
-		PropertyTax:        propertyDetail.PropertyTax,
-		HOAFees:            propertyDetail.HOAFees,
-		ParkingSpaces:      propertyDetail.ParkingSpaces,
-		Garage:             propertyDetail.Garage,
-		Heating:            propertyDetail.Heating,
-		Cooling:            propertyDetail.Cooling,
-		Flooring:           propertyDetail.Flooring,
-		Appliances:         propertyDetail.Appliances,
-		LastRenovated:      propertyDetail.LastRenovated,
-		PriceHistory:       propertyDetail.PriceHistory,
-		NearbyComparables:  propertyDetail.NearbyComparables,
-		WalkScore:          propertyDetail.WalkScore,
-		TransitScore:       propertyDetail.TransitScore,
-		DistanceToBeach:    propertyDetail.DistanceToBeach,
-		DistanceToDowntown: propertyDetail.DistanceToDowntown,
-		FloodZone:          propertyDetail.FloodZone,
-		HomeInsurance:      propertyDetail.HomeInsurance,
-	}
-
-	data, err := json.MarshalIndent(property, "", "  ")
+	var output interface{} = property
+	if format == FormatLegacy {
+		output = property.Legacy()
+	}
+	if exporting {
+		return p.exporter.Respond(output, "property-"+geo.Slug(property.Address), exportRequest), nil
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return &mcp.ToolCallResponse{
 			IsError: true,
@@ -319,116 +850,284 @@ func (p *Plugin) handleFetchPropertyDetail(args map[string]interface{}) (*mcp.To
 	}, nil
 }
 
-// Mock data functions (replace with real API calls in production)
-
-func (p *Plugin) searchMockProperties(filters SearchFilters) []PropertyData {
-	mockProperties := []PropertyData{
-		{
-			ID: "prop_001", Address: "123 Main St", City: "San Francisco", State: "CA", ZipCode: "94102",
-			Price: 1250000, Bedrooms: 3, Bathrooms: 2.5, SquareFeet: 1800, LotSize: 0.12,
-			YearBuilt: 1925, PropertyType: "house", Status: "sold", ListedDate: "2025-01-15",
-			Description: "Beautiful Victorian home in prime location",
-			Features:    []string{"fireplace", "hardwood floors", "updated kitchen"},
-		},
-		{
-			ID: "prop_002", Address: "456 Oak Ave", City: "Los Angeles", State: "CA", ZipCode: "90210",
-			Price: 850000, Bedrooms: 2, Bathrooms: 2.0, SquareFeet: 1200, LotSize: 0.08,
-			YearBuilt: 1985, PropertyType: "condo", Status: "sold", ListedDate: "2025-01-20",
-			Description: "Modern condo with city views",
-			Features:    []string{"balcony", "parking", "pool", "gym"},
-		},
-		{
-			ID: "prop_003", Address: "789 Pine St", City: "Seattle", State: "WA", ZipCode: "98101",
-			Price: 675000, Bedrooms: 4, Bathrooms: 3.0, SquareFeet: 2200, LotSize: 0.15,
-			YearBuilt: 2010, PropertyType: "house", Status: "sold", ListedDate: "2025-01-10",
-			Description: "Contemporary home with mountain views",
-			Features:    []string{"deck", "garage", "open floor plan"},
-		},
+// propertyDetail returns a property page's details, from the store when it was fetched recently
+func (p *Plugin) propertyDetail(ctx context.Context, source scraper.ListingSource, url string) (PropertyData, error) {
+	if p.store != nil {
+		stored, err := p.store.PropertyByURL(ctx, url)
+		if err != nil {
+			log.Printf("Failed to read stored property for %s: %v", url, err)
+		} else if stored != nil && p.now().Sub(stored.FetchedAt) < p.refreshAfter {
+			return newPropertyData(stored.Property, stored.Source, stored.FetchedAt), nil
+		}
 	}
 
-	// Simple filtering logic
-	var results []PropertyData
-	for _, prop := range mockProperties {
-		matches := true
-
-		if filters.City != "" && !strings.EqualFold(prop.City, filters.City) {
-			matches = false
+	detail, err := source.Detail(ctx, url)
+	if err != nil {
+		return PropertyData{}, err
+	}
+	fetchedAt := p.now()
+	if p.store != nil && detail.ID != "" {
+		if err := p.store.SaveProperty(ctx, source.Name(), url, *detail); err != nil {
+			log.Printf("Failed to store property %s: %v", detail.ID, err)
 		}
-		if filters.State != "" && !strings.EqualFold(prop.State, filters.State) {
-			matches = false
+	}
+	property := []PropertyData{newPropertyData(*detail, source.Name(), fetchedAt)}
+	p.collect(ctx, property)
+	return property[0], nil
+}
+
+// newPropertyData converts a scraped property to PropertyData
+func newPropertyData(scraped scraper.Property, source string, fetchedAt time.Time) PropertyData {
+	soldDate := parseDate(scraped.SoldDate)
+	var priceHistory []PriceEvent
+	for _, entry := range scraped.PriceHistory {
+		if event, ok := parsePriceEvent(entry); ok {
+			priceHistory = append(priceHistory, event)
 		}
-		if filters.Neighborhood != "" && !strings.EqualFold(prop.City, filters.Neighborhood) {
-			matches = false
+	}
+	var comparables []PropertyRef
+	for _, comparable := range scraped.NearbyComparables {
+		comparables = append(comparables, newPropertyRef(comparable, scraped.City, scraped.State))
+	}
+
+	return PropertyData{
+		ID:           scraped.ID,
+		Address:      scraped.Address,
+		City:         scraped.City,
+		State:        scraped.State,
+		ZipCode:      scraped.ZipCode,
+		APN:          scraped.APN,
+		Price:        int64(scraped.Price),
+		Bedrooms:     scraped.Bedrooms,
+		Bathrooms:    scraped.Bathrooms,
+		SquareFeet:   scraped.SquareFeet,
+		LotSize:      scraped.LotSize,
+		PropertyType: scraped.PropertyType,
+		Status:       scraped.Status,
+		YearBuilt:    scraped.YearBuilt,
+		Description:  scraped.Description,
+		Features:     scraped.Features,
+		ListedDate:   soldDate,
+		SoldDate:     soldDate,
+		DaysOnMarket: scraped.DaysOnMarket,
+		PricePerSqFt: scraped.PricePerSqFt,
+		Agent:        scraped.Agent,
+		Brokerage:    scraped.Brokerage,
+		Latitude:     scraped.Latitude,
+		Longitude:    scraped.Longitude,
+		// Enhanced fields for pricing analysis
+		PropertyCondition:  scraped.PropertyCondition,
+		SchoolDistrict:     scraped.SchoolDistrict,
+		ElementarySchool:   scraped.ElementarySchool,
+		MiddleSchool:       scraped.MiddleSchool,
+		HighSchool:         scraped.HighSchool,
+		SchoolRatings:      scraped.SchoolRatings,
+		Neighborhood:       scraped.Neighborhood,
+		PropertyTax:        parseMoney(scraped.PropertyTax, PeriodAnnual),
+		HOAFees:            parseMoney(scraped.HOAFees, PeriodMonthly),
+		ParkingSpaces:      scraped.ParkingSpaces,
+		Garage:             scraped.Garage,
+		Heating:            scraped.Heating,
+		Cooling:            scraped.Cooling,
+		Flooring:           scraped.Flooring,
+		Appliances:         scraped.Appliances,
+		LastRenovated:      scraped.LastRenovated,
+		PriceHistory:       priceHistory,
+		NearbyComparables:  comparables,
+		WalkScore:          parseScore(scraped.WalkScore),
+		TransitScore:       parseScore(scraped.TransitScore),
+		DistanceToBeach:    parseDistance(scraped.DistanceToBeach),
+		DistanceToDowntown: parseDistance(scraped.DistanceToDowntown),
+		FloodZone:          scraped.FloodZone,
+		HomeInsurance:      parseMoney(scraped.HomeInsurance, PeriodAnnual),
+		Confidence:         scraped.Confidence,
+		FieldSources:       scraped.Provenance,
+		Source:             source,
+		FetchedAt:          fetchedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// searchRealProperties uses the scraper to get real property data, going
+// through the store when one is configured
+func (p *Plugin) searchRealProperties(ctx context.Context, filters SearchFilters) ([]PropertyData, error) {
+	source, ok := p.sources.Get(p.searchSource)
+	if !ok {
+		return nil, fmt.Errorf("listing source %s is not registered", p.searchSource)
+	}
+
+	query := p.searchQuery(filters.City, filters.State, filters.Neighborhood)
+	if p.store != nil {
+		properties, err := p.searchStoredProperties(ctx, source, query, filters)
+		p.collect(ctx, properties)
+		return properties, err
+	}
+
+	// Use a fresh ingestion job result for the area when there is one
+	var scrapedProperties []scraper.Property
+	fetchedAt := p.now()
+	if ingested, ok := p.ingestedListings(store.AreaKey(source.Name(), query)); ok {
+		scrapedProperties, fetchedAt = ingested.listings, ingested.FetchedAt
+	} else {
+		var err error
+		scrapedProperties, err = source.Search(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape properties: %v", err)
 		}
+	}
 
-		if matches {
-			results = append(results, prop)
+	properties := []PropertyData{}
+	for _, scraped := range scrapedProperties {
+		property := newPropertyData(scraped, source.Name(), fetchedAt)
+		if p.matchesFilters(property, filters) {
+			properties = append(properties, property)
 		}
 	}
+	p.collect(ctx, properties)
 
-	return results
+	return properties, nil
 }
 
-// searchRealProperties uses the scraper to get real property data
-func (p *Plugin) searchRealProperties(filters SearchFilters) ([]PropertyData, error) {
-	homesScraper := scraper.NewHomesScraper()
+// searchStoredProperties answers a search from the store, first refreshing
+// the area if it was last scraped more than refreshAfter ago. A refresh
+// only pages until it reaches listings the store already has.
+func (p *Plugin) searchStoredProperties(ctx context.Context, source scraper.ListingSource, query scraper.SearchQuery, filters SearchFilters) ([]PropertyData, error) {
+	area := store.AreaKey(source.Name(), query)
 
-	var scrapedProperties []scraper.Property
-	var err error
+	lastRun, err := p.store.LastRun(ctx, area)
+	if err != nil {
+		return nil, err
+	}
+	if lastRun == nil || p.now().Sub(lastRun.FinishedAt) >= p.refreshAfter {
+		if _, _, err := p.refreshArea(ctx, source, query, area); err != nil {
+			if lastRun == nil {
+				return nil, err
+			}
+			// Stale results beat none; they carry their fetchedAt
+			log.Printf("Refreshing %s failed, serving stored results: %v", area, err)
+		}
+	}
 
-	// Use neighborhood-specific scraping if neighborhood is specified
-	if filters.Neighborhood != "" {
-		city := filters.City
-		if city == "" {
-			city = "Honolulu" // Default to Honolulu for Hawaii neighborhoods
+	stored, err := p.store.AreaProperties(ctx, area)
+	if err != nil {
+		return nil, err
+	}
+	properties := []PropertyData{}
+	for _, s := range stored {
+		property := newPropertyData(s.Property, s.Source, s.FetchedAt)
+		if p.matchesFilters(property, filters) {
+			properties = append(properties, property)
 		}
-		state := filters.State
-		if state == "" {
-			state = "HI" // Default to Hawaii
+	}
+	return properties, nil
+}
+
+// refreshArea scrapes an area's new listings into the store, recording the
+// run, and returns how many listings were found and how many were new
+func (p *Plugin) refreshArea(ctx context.Context, source scraper.ListingSource, query scraper.SearchQuery, area string) (int, int, error) {
+	// Listings for sale change while they are listed, so those areas are
+	// scraped in full to catch price changes
+	if query.Status != statusForSale {
+		known, err := p.store.KnownIDs(ctx, area)
+		if err != nil {
+			return 0, 0, err
 		}
-		scrapedProperties, err = homesScraper.ScrapeNeighborhood(city, state, filters.Neighborhood, "sold")
-	} else {
-		// Fall back to Manoa-specific scraping
-		scrapedProperties, err = homesScraper.ScrapeManoa("sold")
+		query.KnownIDs = known
 	}
 
+	runID, err := p.store.StartRun(ctx, source.Name(), area)
+	if err != nil {
+		return 0, 0, err
+	}
+	var scraped []scraper.Property
+	complete := false
+	if searcher, ok := source.(scraper.CompleteSearcher); ok {
+		scraped, complete, err = searcher.SearchComplete(ctx, query)
+	} else {
+		scraped, err = source.Search(ctx, query)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to scrape properties: %v", err)
+		err = fmt.Errorf("failed to scrape properties: %v", err)
+		p.store.FinishRun(ctx, runID, 0, 0, err)
+		return 0, 0, err
+	}
+	added, err := p.store.SaveArea(ctx, source.Name(), area, scraped)
+	// Only a scrape that reached the last page of listings for sale shows
+	// which have come off the market; an empty one more likely means the
+	// site served no results
+	if err == nil && query.Status == statusForSale && complete && len(scraped) > 0 {
+		_, err = p.store.WithdrawMissing(ctx, source.Name(), area, scraped)
 	}
+	if finishErr := p.store.FinishRun(ctx, runID, len(scraped), added, err); err == nil {
+		err = finishErr
+	}
+	log.Printf("Refreshed %s: %d listings, %d new", area, len(scraped), added)
+	return len(scraped), added, err
+}
 
-	var properties []PropertyData
+func (p *Plugin) handleGetIngestionJobs(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	if p.scheduler == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "no ingestion jobs are configured"}},
+		}, nil
+	}
 
-	// Convert scraper properties to our PropertyData format
-	for _, scraped := range scrapedProperties {
-		property := PropertyData{
-			ID:           scraped.ID,
-			Address:      scraped.Address,
-			City:         scraped.City,
-			State:        scraped.State,
-			ZipCode:      scraped.ZipCode,
-			Price:        int64(scraped.Price),
-			Bedrooms:     scraped.Bedrooms,
-			Bathrooms:    scraped.Bathrooms,
-			SquareFeet:   scraped.SquareFeet,
-			PropertyType: scraped.PropertyType,
-			Status:       scraped.Status,
-			YearBuilt:    scraped.YearBuilt,
-			Description:  scraped.Description,
-			Features:     scraped.Features,
+	var result interface{}
+	header := "Ingestion jobs:"
+	if name, _ := args["name"].(string); name != "" {
+		status, err := p.scheduler.Status(name)
+		if err != nil {
+			return &mcp.ToolCallResponse{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+			}, nil
 		}
+		result, header = status, fmt.Sprintf("Ingestion job %s:", name)
+	} else {
+		result = p.scheduler.Statuses()
+	}
 
-		// Apply filters
-		if p.matchesFilters(property, filters) {
-			properties = append(properties, property)
-		}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling job status: %v", err)}},
+		}, nil
 	}
 
-	return properties, nil
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: header},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
 }
 
-// matchesFilters checks if a property matches the search filters
-func (p *Plugin) matchesFilters(property PropertyData, filters SearchFilters) bool {
-	// For now, since we removed the filtering fields, we'll just return true
-	// In a real implementation, you might want to check city, state, or neighborhood
-	return true
+func (p *Plugin) handleRunIngestionJob(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "name parameter is required and must be a non-empty string"}},
+		}, nil
+	}
+	if p.scheduler == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "no ingestion jobs are configured"}},
+		}, nil
+	}
+
+	if err := p.scheduler.Trigger(name); err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Started ingestion job %s; check get_ingestion_jobs for its result", name)},
+		},
+	}, nil
 }
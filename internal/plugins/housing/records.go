@@ -0,0 +1,131 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/records"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// LocationFromRecords is the location precision of coordinates from a
+// county parcel record
+const LocationFromRecords = "public_record"
+
+// SetPublicRecords registers a county records dataset as the listing source
+// records.SourceName, and fills in collected properties' missing facts and
+// tax bills from their parcels
+func (p *Plugin) SetPublicRecords(dataset *records.Dataset) error {
+	if err := p.sources.Register(records.NewSource(dataset)); err != nil {
+		return err
+	}
+	p.records = dataset
+	return nil
+}
+
+// addPublicRecord attaches a property's parcel record, filling in the facts
+// its listing lacks
+func (p *Plugin) addPublicRecord(property *PropertyData) {
+	if p.records == nil {
+		return
+	}
+	listing := scraper.Property{
+		APN: property.APN, Address: property.Address, City: property.City, State: property.State, ZipCode: property.ZipCode,
+		Price: int(property.Price), Bedrooms: property.Bedrooms, Bathrooms: property.Bathrooms, SquareFeet: property.SquareFeet,
+		LotSize: property.LotSize, YearBuilt: property.YearBuilt, PropertyType: property.PropertyType,
+		Latitude: property.Latitude, Longitude: property.Longitude, Provenance: map[string]string{},
+	}
+	parcel, ok := p.records.Enrich(&listing)
+	if !ok {
+		return
+	}
+	property.APN, property.ZipCode, property.PropertyType = listing.APN, listing.ZipCode, listing.PropertyType
+	property.Bedrooms, property.Bathrooms, property.SquareFeet = listing.Bedrooms, listing.Bathrooms, listing.SquareFeet
+	property.LotSize, property.YearBuilt = listing.LotSize, listing.YearBuilt
+	property.Latitude, property.Longitude = listing.Latitude, listing.Longitude
+	if listing.PricePerSqFt > 0 {
+		property.PricePerSqFt = listing.PricePerSqFt
+	}
+	if tax := parseMoney(listing.PropertyTax, PeriodAnnual); tax != nil {
+		property.PropertyTax = tax
+	}
+	if property.FieldSources == nil {
+		property.FieldSources = make(map[string]string)
+	}
+	for field, source := range listing.Provenance {
+		property.FieldSources[field] = source
+	}
+	property.PublicRecord = &parcel
+}
+
+// publicRecord finds the parcel a get_public_record call names by APN,
+// collected property or address
+func (p *Plugin) publicRecord(args map[string]interface{}) (records.Parcel, error) {
+	state, _ := args["state"].(string)
+	if apn, _ := args["apn"].(string); apn != "" {
+		if state == "" {
+			return records.Parcel{}, fmt.Errorf("state is required with an apn")
+		}
+		parcel, ok := p.records.Parcel(state, apn)
+		if !ok {
+			return parcel, fmt.Errorf("no parcel %s in %s", apn, state)
+		}
+		return parcel, nil
+	}
+
+	listing := scraper.Property{State: state}
+	if id, _ := args["property_id"].(string); id != "" {
+		property, ok := p.collectedProperty(id)
+		if !ok {
+			return records.Parcel{}, fmt.Errorf("property %s has not been collected; search for it first", id)
+		}
+		listing = scraper.Property{APN: property.APN, Address: property.Address, City: property.City, State: property.State}
+	} else {
+		listing.Address, _ = args["address"].(string)
+		listing.City, _ = args["city"].(string)
+		if listing.Address == "" || listing.City == "" || state == "" {
+			return records.Parcel{}, fmt.Errorf("apn and state, property_id, or address, city and state are required")
+		}
+	}
+	parcel, ok := p.records.Match(listing)
+	if !ok {
+		return parcel, fmt.Errorf("no parcel record for %s, %s, %s", listing.Address, listing.City, listing.State)
+	}
+	return parcel, nil
+}
+
+func (p *Plugin) handleGetPublicRecord(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	if p.records == nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "public records need a county dataset; start the server with -public-records"}},
+		}, nil
+	}
+	parcel, err := p.publicRecord(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	data, err := json.MarshalIndent(parcel, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling public record: %v", err)}},
+		}, nil
+	}
+	summary := fmt.Sprintf("Public record for %s (APN %s, %s): assessed at %s", parcel.Address, parcel.APN, parcel.County, usd(float64(parcel.AssessedValue)))
+	if sale, ok := parcel.LastSale(); ok {
+		summary += fmt.Sprintf(", last sold for %s on %s", usd(float64(sale.Price)), sale.Date)
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: summary + ":"},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
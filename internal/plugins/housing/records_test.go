@@ -0,0 +1,116 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/records"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+func TestPublicRecords(t *testing.T) {
+	dataset := records.NewDataset()
+	for _, parcel := range []records.Parcel{
+		{APN: "1-2-9-036-012-0000", County: "sample-county", Address: "2819 POELUA STREET", City: "Honolulu", State: "HI", PropertyType: "house",
+			YearBuilt: 1958, SquareFeet: 1850, Bedrooms: 3, Bathrooms: 2, LandValue: 1100000, ImprovementValue: 350000, TaxAmount: 4350,
+			Latitude: 21.3124, Longitude: -157.8087, Sales: []records.Sale{{Date: "2021-03-10", Price: 1250000}}},
+		{APN: "1-2-9-041-005-0000", County: "sample-county", Address: "2714 HIPAWAI PL", City: "Honolulu", State: "HI",
+			SquareFeet: 1420, Sales: []records.Sale{{Date: "2016-11-02", Price: 925000}}},
+	} {
+		if err := dataset.Add(parcel); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := NewPlugin()
+	if err := p.SetPublicRecords(dataset); err != nil {
+		t.Fatal(err)
+	}
+	listings := []scraper.Property{
+		{Address: "2819 Poelua St", City: "Honolulu", State: "HI", Status: "sold", Price: 1500000, Bedrooms: 4, SoldDate: "2025-02-01", PropertyTax: "$3,900/yr"},
+		{Address: "5 Kapahulu Ave", City: "Honolulu", State: "HI", Status: "sold", Price: 900000, SoldDate: "2025-01-15"},
+	}
+	for i := range listings {
+		listings[i].ID = scraper.PropertyID(listings[i].Address, listings[i].City, listings[i].State)
+	}
+	if err := p.RegisterSource(statsSource{listings: listings}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSearchSource("test"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	search := func() []PropertyData {
+		t.Helper()
+		response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "search_sold_properties", Arguments: map[string]interface{}{"city": "Honolulu", "state": "HI", "format": FormatTyped}})
+		if err != nil || response.IsError {
+			t.Fatalf("%v %+v", err, response)
+		}
+		var properties []PropertyData
+		if err := json.Unmarshal([]byte(response.Content[1].Text), &properties); err != nil {
+			t.Fatal(err)
+		}
+		return properties
+	}
+
+	// The listing keeps its price and beds and gains the parcel's facts and tax bill
+	properties := search()
+	poelua := properties[0]
+	if poelua.Price != 1500000 || poelua.Bedrooms != 4 || poelua.SquareFeet != 1850 || poelua.YearBuilt != 1958 || poelua.APN != "1-2-9-036-012-0000" {
+		t.Errorf("enriched listing = %+v", poelua)
+	}
+	if poelua.PropertyTax.Amount != 4350 || poelua.FieldSources["squareFeet"] != records.SourceName || poelua.LocationPrecision != LocationFromRecords {
+		t.Errorf("tax %+v, sources %v, location %s", poelua.PropertyTax, poelua.FieldSources, poelua.LocationPrecision)
+	}
+	if poelua.PublicRecord == nil || poelua.PublicRecord.AssessedValue != 1450000 || len(poelua.PublicRecord.Sales) != 1 {
+		t.Errorf("public record = %+v", poelua.PublicRecord)
+	}
+	if kapahulu := properties[1]; kapahulu.PublicRecord != nil || kapahulu.APN != "" {
+		t.Errorf("unrecorded listing = %+v", kapahulu)
+	}
+
+	record := func(args map[string]interface{}) (records.Parcel, string) {
+		t.Helper()
+		response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "get_public_record", Arguments: args})
+		if err != nil || response.IsError {
+			t.Fatalf("%v %+v", err, response)
+		}
+		var parcel records.Parcel
+		if err := json.Unmarshal([]byte(response.Content[1].Text), &parcel); err != nil {
+			t.Fatal(err)
+		}
+		return parcel, response.Content[0].Text
+	}
+	parcel, summary := record(map[string]interface{}{"apn": "129036012-0000", "state": "HI"})
+	if parcel.Address != "2819 POELUA STREET" || !strings.Contains(summary, "assessed at $1,450,000, last sold for $1,250,000 on 2021-03-10") {
+		t.Errorf("by APN: %s", summary)
+	}
+	if parcel, _ := record(map[string]interface{}{"property_id": poelua.ID}); parcel.APN != poelua.APN {
+		t.Errorf("by property ID = %+v", parcel)
+	}
+	if parcel, _ := record(map[string]interface{}{"address": "2714 Hipawai Place", "city": "Honolulu", "state": "HI"}); parcel.SquareFeet != 1420 {
+		t.Errorf("by address = %+v", parcel)
+	}
+	for _, args := range []map[string]interface{}{
+		{"apn": "1-2-9-036-012-0000"},
+		{"address": "5 Kapahulu Ave", "city": "Honolulu", "state": "HI"},
+		{"property_id": "prop_unknown"},
+		{},
+	} {
+		if response, _ := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "get_public_record", Arguments: args}); !response.IsError {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+
+	// Searches can come from the records instead of a listing site
+	if err := p.SetSearchSource(records.SourceName); err != nil {
+		t.Fatal(err)
+	}
+	properties = search()
+	if len(properties) != 2 || properties[0].Price != 1250000 || properties[0].Source != records.SourceName || properties[1].SoldDate.Format(dateLayout) != "2016-11-02" {
+		t.Errorf("recorded sales = %+v", properties)
+	}
+}
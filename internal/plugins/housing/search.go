@@ -0,0 +1,280 @@
+package housing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Search result paging limits
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// sortKeys maps the sort keys accepted by search_sold_properties to the
+// property value they order by; zero means the value is unknown
+var sortKeys = map[string]func(p PropertyData) float64{
+	"price":        func(p PropertyData) float64 { return float64(p.Price) },
+	"soldDate":     soldDateValue,
+	"bedrooms":     func(p PropertyData) float64 { return float64(p.Bedrooms) },
+	"bathrooms":    func(p PropertyData) float64 { return p.Bathrooms },
+	"squareFeet":   func(p PropertyData) float64 { return float64(p.SquareFeet) },
+	"lotSize":      func(p PropertyData) float64 { return p.LotSize },
+	"yearBuilt":    func(p PropertyData) float64 { return float64(p.YearBuilt) },
+	"pricePerSqFt": func(p PropertyData) float64 { return float64(p.PricePerSqFt) },
+	"daysOnMarket": func(p PropertyData) float64 { return float64(p.DaysOnMarket) },
+}
+
+// defaultSort lists the most recent sales first
+const defaultSort = "-soldDate"
+
+func soldDateValue(p PropertyData) float64 {
+	if p.SoldDate.IsZero() {
+		return 0
+	}
+	return float64(p.SoldDate.Unix())
+}
+
+// SearchPage is one page of search results
+type SearchPage struct {
+	Properties []PropertyData
+	Total      int    // Matches across every page
+	Offset     int    // Matches before this page
+	NextCursor string // Empty on the last page
+}
+
+// searchCursor marks where a page ended: the sort value and ID of its last
+// property, and a digest of the filters it was made for
+type searchCursor struct {
+	Sort    string  `json:"s"`
+	Value   float64 `json:"v"`
+	ID      string  `json:"id"`
+	Filters string  `json:"f"`
+}
+
+// parseSearchFilters reads search_sold_properties arguments into filters
+func parseSearchFilters(args map[string]interface{}) (SearchFilters, error) {
+	filters := SearchFilters{}
+	filters.City, _ = args["city"].(string)
+	filters.State, _ = args["state"].(string)
+	filters.Neighborhood, _ = args["neighborhood"].(string)
+	filters.ZipCode, _ = args["zip_code"].(string)
+	filters.Cursor, _ = args["cursor"].(string)
+
+	ints := map[string]*int{
+		"min_beds": &filters.MinBeds, "max_beds": &filters.MaxBeds,
+		"min_sqft": &filters.MinSqFt, "max_sqft": &filters.MaxSqFt,
+		"min_year_built": &filters.MinYearBuilt, "max_year_built": &filters.MaxYearBuilt,
+		"sold_within_days": &filters.SoldWithinDays, "limit": &filters.Limit,
+	}
+	for name, dst := range ints {
+		if v, ok := args[name].(float64); ok {
+			if v < 0 {
+				return filters, fmt.Errorf("%s must not be negative", name)
+			}
+			*dst = int(v)
+		}
+	}
+	floats := map[string]*float64{
+		"min_baths": &filters.MinBaths, "max_baths": &filters.MaxBaths,
+		"min_lot_size": &filters.MinLotSize, "max_lot_size": &filters.MaxLotSize,
+	}
+	for name, dst := range floats {
+		if v, ok := args[name].(float64); ok {
+			if v < 0 {
+				return filters, fmt.Errorf("%s must not be negative", name)
+			}
+			*dst = v
+		}
+	}
+	prices := map[string]*int64{"min_price": &filters.MinPrice, "max_price": &filters.MaxPrice}
+	for name, dst := range prices {
+		if v, ok := args[name].(float64); ok {
+			if v < 0 {
+				return filters, fmt.Errorf("%s must not be negative", name)
+			}
+			*dst = int64(v)
+		}
+	}
+	// A zero maximum is unset
+	for _, r := range []struct {
+		name     string
+		min, max float64
+	}{
+		{"price", float64(filters.MinPrice), float64(filters.MaxPrice)},
+		{"beds", float64(filters.MinBeds), float64(filters.MaxBeds)},
+		{"baths", filters.MinBaths, filters.MaxBaths},
+		{"sqft", float64(filters.MinSqFt), float64(filters.MaxSqFt)},
+		{"lot_size", filters.MinLotSize, filters.MaxLotSize},
+		{"year_built", float64(filters.MinYearBuilt), float64(filters.MaxYearBuilt)},
+	} {
+		if r.max > 0 && r.min > r.max {
+			return filters, fmt.Errorf("min_%s must not exceed max_%s", r.name, r.name)
+		}
+	}
+	if filters.Limit > maxSearchLimit {
+		return filters, fmt.Errorf("limit must be at most %d", maxSearchLimit)
+	}
+
+	switch types := args["property_type"].(type) {
+	case string:
+		filters.PropertyTypes = []string{types}
+	case []interface{}:
+		for _, t := range types {
+			if s, ok := t.(string); ok {
+				filters.PropertyTypes = append(filters.PropertyTypes, s)
+			}
+		}
+	}
+
+	filters.Sort, _ = args["sort"].(string)
+	if filters.Sort != "" {
+		if _, ok := sortKeys[strings.TrimPrefix(filters.Sort, "-")]; !ok {
+			return filters, fmt.Errorf("unknown sort key %q (available: %s, prefixed with - for descending)", filters.Sort, strings.Join(sortKeyNames(), ", "))
+		}
+	}
+	return filters, nil
+}
+
+// sortKeyNames returns the accepted sort keys in alphabetical order
+func sortKeyNames() []string {
+	names := make([]string, 0, len(sortKeys))
+	for name := range sortKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchesFilters checks if a property matches the search filters. A property
+// missing a filtered value, such as an unknown bedroom count, does not match.
+func (p *Plugin) matchesFilters(property PropertyData, filters SearchFilters) bool {
+	// Result pages can include nearby listings from other cities
+	if filters.City != "" && property.City != "" && !strings.EqualFold(property.City, filters.City) {
+		return false
+	}
+	if filters.State != "" && property.State != "" && !strings.EqualFold(property.State, filters.State) {
+		return false
+	}
+	if filters.ZipCode != "" && !strings.HasPrefix(property.ZipCode, filters.ZipCode) {
+		return false
+	}
+	if !inRange(float64(property.Price), float64(filters.MinPrice), float64(filters.MaxPrice)) ||
+		!inRange(float64(property.Bedrooms), float64(filters.MinBeds), float64(filters.MaxBeds)) ||
+		!inRange(property.Bathrooms, filters.MinBaths, filters.MaxBaths) ||
+		!inRange(float64(property.SquareFeet), float64(filters.MinSqFt), float64(filters.MaxSqFt)) ||
+		!inRange(property.LotSize, filters.MinLotSize, filters.MaxLotSize) ||
+		!inRange(float64(property.YearBuilt), float64(filters.MinYearBuilt), float64(filters.MaxYearBuilt)) {
+		return false
+	}
+	if len(filters.PropertyTypes) > 0 {
+		matched := false
+		for _, t := range filters.PropertyTypes {
+			if strings.EqualFold(t, property.PropertyType) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if filters.SoldWithinDays > 0 {
+		cutoff := p.now().AddDate(0, 0, -filters.SoldWithinDays).Truncate(24 * time.Hour)
+		if property.SoldDate.IsZero() || property.SoldDate.Before(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRange reports whether value is within [min, max], where a zero bound is
+// unset; with either bound set, an unknown (zero) value is out of range
+func inRange(value, min, max float64) bool {
+	if min == 0 && max == 0 {
+		return true
+	}
+	return value > 0 && value >= min && (max == 0 || value <= max)
+}
+
+// paginate sorts matching properties in place and returns the page after the
+// filters' cursor
+func paginate(properties []PropertyData, filters SearchFilters) (SearchPage, error) {
+	sortBy := filters.Sort
+	if sortBy == "" {
+		sortBy = defaultSort
+	}
+	value := sortKeys[strings.TrimPrefix(sortBy, "-")]
+	descending := strings.HasPrefix(sortBy, "-")
+
+	// Unknown values sort last in either direction, then ties go by ID
+	before := func(va float64, ida string, vb float64, idb string) bool {
+		switch {
+		case va == 0 && vb != 0:
+			return false
+		case vb == 0 && va != 0:
+			return true
+		case va != vb:
+			return va < vb != descending
+		}
+		return ida < idb
+	}
+	sort.SliceStable(properties, func(i, j int) bool {
+		return before(value(properties[i]), properties[i].ID, value(properties[j]), properties[j].ID)
+	})
+
+	page := SearchPage{Total: len(properties)}
+	digest := filters.digest()
+	if filters.Cursor != "" {
+		cursor, err := decodeCursor(filters.Cursor)
+		if err != nil || cursor.Sort != sortBy || cursor.Filters != digest {
+			return page, fmt.Errorf("invalid cursor for this search; start again without a cursor")
+		}
+		page.Offset = sort.Search(len(properties), func(i int) bool {
+			return before(cursor.Value, cursor.ID, value(properties[i]), properties[i].ID)
+		})
+	}
+
+	limit := filters.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	end := page.Offset + limit
+	if end > len(properties) {
+		end = len(properties)
+	}
+	page.Properties = properties[page.Offset:end]
+	if end < len(properties) {
+		last := properties[end-1]
+		page.NextCursor = encodeCursor(searchCursor{Sort: sortBy, Value: value(last), ID: last.ID, Filters: digest})
+	}
+	return page, nil
+}
+
+// digest identifies the filters a cursor belongs to, leaving out paging
+func (f SearchFilters) digest() string {
+	f.Cursor, f.Limit, f.Sort = "", 0, ""
+	data, _ := json.Marshal(f)
+	h := fnv.New32a()
+	h.Write(data)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func encodeCursor(cursor searchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (searchCursor, error) {
+	var cursor searchCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
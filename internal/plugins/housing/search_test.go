@@ -0,0 +1,95 @@
+package housing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testSearchProperties() []PropertyData {
+	sold := func(day int) Date { return Date{time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC)} }
+	return []PropertyData{
+		{ID: "prop_a", City: "Honolulu", State: "HI", ZipCode: "96822", Price: 1600000, Bedrooms: 3, Bathrooms: 2, SquareFeet: 1500, YearBuilt: 1955, PropertyType: "house", SoldDate: sold(14)},
+		{ID: "prop_b", City: "Honolulu", State: "HI", ZipCode: "96822", Price: 850000, Bedrooms: 2, Bathrooms: 2, SquareFeet: 980, YearBuilt: 1979, PropertyType: "condo", SoldDate: sold(28)},
+		{ID: "prop_c", City: "Honolulu", State: "HI", ZipCode: "96816", Price: 1942000, Bedrooms: 4, Bathrooms: 3, SquareFeet: 2100, YearBuilt: 2004, PropertyType: "house", SoldDate: sold(3)},
+		{ID: "prop_d", City: "Honolulu", State: "HI", ZipCode: "96822", Price: 1200000, PropertyType: "townhouse"},
+		{ID: "prop_e", City: "Kailua", State: "HI", ZipCode: "96734", Price: 1400000, Bedrooms: 3, SoldDate: sold(20)},
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	p := NewPlugin()
+	p.now = func() time.Time { return time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		args map[string]interface{}
+		want []string
+	}{
+		{map[string]interface{}{"city": "Honolulu"}, []string{"prop_a", "prop_b", "prop_c", "prop_d"}},
+		{map[string]interface{}{"city": "Honolulu", "min_price": 1000000.0, "max_price": 1700000.0}, []string{"prop_a", "prop_d"}},
+		{map[string]interface{}{"city": "Honolulu", "min_beds": 3.0}, []string{"prop_a", "prop_c"}}, // prop_d's bedrooms are unknown
+		{map[string]interface{}{"city": "Honolulu", "property_type": []interface{}{"condo", "Townhouse"}}, []string{"prop_b", "prop_d"}},
+		{map[string]interface{}{"zip_code": "96822", "max_year_built": 1970.0}, []string{"prop_a"}},
+		{map[string]interface{}{"state": "HI", "sold_within_days": 14.0}, []string{"prop_b", "prop_e"}},
+		{map[string]interface{}{"state": "HI", "min_sqft": 1000.0, "min_baths": 2.5}, []string{"prop_c"}},
+	}
+	for _, tt := range tests {
+		filters, err := parseSearchFilters(tt.args)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		var got []string
+		for _, property := range testSearchProperties() {
+			if p.matchesFilters(property, filters) {
+				got = append(got, property.ID)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%v matched %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"sort": "bogus"}, {"min_beds": -1.0}, {"limit": 500.0}, {"min_price": -1.0}, {"max_price": -5.0},
+		{"min_price": 900000.0, "max_price": 800000.0}, {"min_sqft": 2000.0, "max_sqft": 1500.0},
+	} {
+		if _, err := parseSearchFilters(args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	filters := SearchFilters{State: "HI", Sort: "-price", Limit: 2}
+	var pages [][]string
+	for {
+		page, err := paginate(testSearchProperties(), filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, property := range page.Properties {
+			ids = append(ids, property.ID)
+		}
+		pages = append(pages, ids)
+		if page.NextCursor == "" {
+			break
+		}
+		filters.Cursor = page.NextCursor
+	}
+	if want := "[[prop_c prop_a] [prop_e prop_d] [prop_b]]"; fmt.Sprint(pages) != want {
+		t.Errorf("pages = %v, want %s", pages, want)
+	}
+
+	// Unknown sale dates sort last even when the newest come first
+	page, _ := paginate(testSearchProperties(), SearchFilters{})
+	if page.Properties[0].ID != "prop_b" || page.Properties[4].ID != "prop_d" {
+		t.Errorf("default order starts %s and ends %s", page.Properties[0].ID, page.Properties[4].ID)
+	}
+
+	// A cursor only continues the search it came from
+	first, _ := paginate(testSearchProperties(), SearchFilters{State: "HI", Limit: 2})
+	if _, err := paginate(testSearchProperties(), SearchFilters{State: "HI", MinBeds: 3, Limit: 2, Cursor: first.NextCursor}); err == nil {
+		t.Error("expected an error for a cursor from other filters")
+	}
+}
@@ -0,0 +1,343 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/records"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// LocationFromListing is the location precision of coordinates the listing itself gave
+const LocationFromListing = "listing"
+
+// defaultRadiusMiles is the radius search_properties_nearby uses when none is given
+const defaultRadiusMiles = 0.5
+
+// SetGeocoder sets the geocoder that places collected properties whose
+// listings have no coordinates, and the addresses radius searches start from
+func (p *Plugin) SetGeocoder(geocoder geo.Geocoder) {
+	p.geocoder = geocoder
+}
+
+// collect adds properties to the spatial index, attaching their public
+// records, filling in coordinates from the parcel or geocoder for listings
+// without them and assigning registered neighborhoods and school districts.
+// Properties already collected keep their geocoded location rather than
+// being geocoded again.
+func (p *Plugin) collect(ctx context.Context, properties []PropertyData) {
+	for i := range properties {
+		property := &properties[i]
+		if property.ID == "" || property.ID == "prop_unknown" {
+			continue
+		}
+		listed := (property.Latitude != 0 || property.Longitude != 0) && property.Source != records.SourceName
+		p.addPublicRecord(property)
+		if listed {
+			property.LocationPrecision = LocationFromListing
+		} else if property.Latitude != 0 || property.Longitude != 0 {
+			property.LocationPrecision = LocationFromRecords
+		} else if known, ok := p.collectedProperty(property.ID); ok && known.LocationPrecision != "" {
+			property.Latitude, property.Longitude, property.LocationPrecision = known.Latitude, known.Longitude, known.LocationPrecision
+		} else if p.geocoder != nil {
+			result, err := p.geocoder.Geocode(ctx, geo.Query{Address: property.Address, City: property.City, State: property.State, ZipCode: property.ZipCode})
+			if err == nil {
+				property.Latitude, property.Longitude, property.LocationPrecision = result.Point.Lat, result.Point.Lon, result.Precision
+			} else if !errors.Is(err, geo.ErrNotFound) {
+				log.Printf("Geocoding %s failed: %v", property.Address, err)
+			}
+		}
+		p.assignBoundaries(property)
+
+		p.mu.Lock()
+		p.collected[property.ID] = *property
+		p.mu.Unlock()
+		// A ZIP code or city centroid would pass for a measured location
+		if preciseLocation(*property) {
+			p.index.Put(property.ID, geo.Point{Lat: property.Latitude, Lon: property.Longitude})
+		} else {
+			p.index.Remove(property.ID)
+		}
+	}
+}
+
+// preciseLocation reports whether a property's coordinates place the property
+// itself, from its listing, parcel record or address, rather than its ZIP
+// code's or city's centroid
+func preciseLocation(property PropertyData) bool {
+	switch property.LocationPrecision {
+	case geo.PrecisionZip, geo.PrecisionCity:
+		return false
+	}
+	return property.Latitude != 0 || property.Longitude != 0
+}
+
+// collectedProperty returns a collected property by ID
+func (p *Plugin) collectedProperty(id string) (PropertyData, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	property, ok := p.collected[id]
+	return property, ok
+}
+
+// loadCollected indexes every stored property the first time a spatial
+// search runs, so searches cover what earlier runs collected
+func (p *Plugin) loadCollected(ctx context.Context) {
+	if p.store == nil {
+		return
+	}
+	p.loadStored.Do(func() {
+		stored, err := p.store.Properties(ctx)
+		if err != nil {
+			log.Printf("Failed to load stored properties into the spatial index: %v", err)
+			return
+		}
+		properties := make([]PropertyData, 0, len(stored))
+		for _, s := range stored {
+			properties = append(properties, newPropertyData(s.Property, s.Source, s.FetchedAt))
+		}
+		p.collect(ctx, properties)
+		log.Printf("Indexed %d stored properties, %d with precise locations", len(properties), p.index.Len())
+	})
+}
+
+// spatialSchema returns the input schema properties of a spatial search:
+// its own, plus the property filters it shares with search_sold_properties
+func spatialSchema(own map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"status": map[string]interface{}{
+			"type":        "string",
+			"description": "Only properties with this listing status (e.g., sold, for_sale)",
+		},
+		"min_price":        numberSchema("Minimum price in dollars"),
+		"max_price":        numberSchema("Maximum price in dollars"),
+		"min_beds":         integerSchema("Minimum bedrooms"),
+		"max_beds":         integerSchema("Maximum bedrooms"),
+		"min_baths":        numberSchema("Minimum bathrooms"),
+		"min_sqft":         integerSchema("Minimum living area in square feet"),
+		"max_sqft":         integerSchema("Maximum living area in square feet"),
+		"sold_within_days": integerSchema("Only properties sold within this many days"),
+		"property_type": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Property types to include (e.g., house, condo, townhouse)",
+		},
+		"limit": map[string]interface{}{
+			"type":        "integer",
+			"description": "Most results to return (default 50, at most 200)",
+			"minimum":     1,
+			"maximum":     maxSearchLimit,
+		},
+		"format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{FormatTyped, FormatLegacy},
+			"description": "Output format: legacy (the default) or typed",
+		},
+	}
+	for name, schema := range own {
+		properties[name] = schema
+	}
+	return properties
+}
+
+// spatialFilters reads the filters of a spatial search. Location filters are
+// dropped: the city, state and ZIP code only say where the center address is.
+func spatialFilters(args map[string]interface{}) (SearchFilters, string, error) {
+	filters, err := parseSearchFilters(args)
+	if err != nil {
+		return filters, "", err
+	}
+	filters.City, filters.State, filters.Neighborhood, filters.ZipCode = "", "", "", ""
+	filters.Sort, filters.Cursor = "", ""
+	if filters.Limit == 0 {
+		filters.Limit = defaultSearchLimit
+	}
+	status, _ := args["status"].(string)
+	return filters, status, nil
+}
+
+// spatialMatches returns the collected properties for index hits that pass
+// the filters, with their distances, in hit order or by less when it is given,
+// up to the filters' limit, and how many matched in all
+func (p *Plugin) spatialMatches(hits []geo.Hit, filters SearchFilters, status string, withDistance bool, less func(a, b PropertyData) bool) ([]PropertyData, int) {
+	var matches []PropertyData
+	for _, hit := range hits {
+		property, ok := p.collectedProperty(hit.ID)
+		if !ok || !p.matchesFilters(property, filters) || status != "" && !strings.EqualFold(property.Status, status) {
+			continue
+		}
+		if withDistance {
+			property.DistanceMiles = round(hit.Miles, 3)
+		}
+		matches = append(matches, property)
+	}
+	if less != nil {
+		sort.SliceStable(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+	}
+	total := len(matches)
+	if total > filters.Limit {
+		matches = matches[:filters.Limit]
+	}
+	return matches, total
+}
+
+// radiusCenter finds where a radius search starts: the given coordinates, a
+// collected property at the address, or the geocoded address
+func (p *Plugin) radiusCenter(ctx context.Context, args map[string]interface{}) (geo.Point, string, error) {
+	lat, hasLat := args["latitude"].(float64)
+	lon, hasLon := args["longitude"].(float64)
+	if hasLat || hasLon {
+		center := geo.Point{Lat: lat, Lon: lon}
+		if !hasLat || !hasLon || !center.Valid() {
+			return center, "", fmt.Errorf("latitude and longitude must both be given and be valid coordinates")
+		}
+		return center, fmt.Sprintf("%.5f, %.5f", lat, lon), nil
+	}
+
+	address, _ := args["address"].(string)
+	city, _ := args["city"].(string)
+	state, _ := args["state"].(string)
+	zip, _ := args["zip_code"].(string)
+	if address == "" || city == "" || state == "" {
+		return geo.Point{}, "", fmt.Errorf("address, city and state parameters are required unless latitude and longitude are given")
+	}
+	if property, ok := p.collectedProperty(scraper.PropertyID(address, city, state)); ok && property.LocationPrecision != "" {
+		return geo.Point{Lat: property.Latitude, Lon: property.Longitude}, fmt.Sprintf("%s (located by %s)", address, property.LocationPrecision), nil
+	}
+	if p.geocoder == nil {
+		return geo.Point{}, "", fmt.Errorf("%s is not a collected property with a location and no geocoder is configured", address)
+	}
+	result, err := p.geocoder.Geocode(ctx, geo.Query{Address: address, City: city, State: state, ZipCode: zip})
+	if err != nil {
+		return geo.Point{}, "", fmt.Errorf("could not locate %s: %v", address, err)
+	}
+	return result.Point, fmt.Sprintf("%s (located by %s)", address, result.Precision), nil
+}
+
+func (p *Plugin) handleSearchPropertiesNearby(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	format, err := p.outputFormat(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	filters, status, err := spatialFilters(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	radius, ok := args["radius"].(float64)
+	if !ok {
+		radius = defaultRadiusMiles
+	}
+	unit, _ := args["unit"].(string)
+	miles, err := geo.ToMiles(radius, unit)
+	if err == nil && (miles <= 0 || miles > 50) {
+		err = fmt.Errorf("radius must be more than 0 and at most 50 miles")
+	}
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	p.loadCollected(ctx)
+	center, label, err := p.radiusCenter(ctx, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	matches, total := p.spatialMatches(p.index.Within(center, miles), filters, status, true, nil)
+	if unit == "" {
+		unit = "mi"
+	}
+	header := fmt.Sprintf("Found %d collected properties within %g %s of %s, nearest first", total, radius, unit, label)
+	return p.spatialResponse(header, matches, total, format)
+}
+
+func (p *Plugin) handleSearchPropertiesInArea(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	format, err := p.outputFormat(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+	filters, status, err := spatialFilters(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	// GeoJSON may come as an object or as a string holding one
+	var data []byte
+	switch v := args["geojson"].(type) {
+	case string:
+		data = []byte(v)
+	case map[string]interface{}:
+		data, _ = json.Marshal(v)
+	default:
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "geojson parameter is required: a GeoJSON Polygon, MultiPolygon, Feature or FeatureCollection"}},
+		}, nil
+	}
+	area, err := geo.ParseArea(data)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	p.loadCollected(ctx)
+	hits := p.index.InArea(area)
+	// Newest sales first, as in search_sold_properties
+	matches, total := p.spatialMatches(hits, filters, status, false, func(a, b PropertyData) bool { return soldDateValue(a) > soldDateValue(b) })
+	header := fmt.Sprintf("Found %d collected properties inside the area", total)
+	return p.spatialResponse(header, matches, total, format)
+}
+
+// spatialResponse formats the results of a spatial search
+func (p *Plugin) spatialResponse(header string, matches []PropertyData, total int, format string) (*mcp.ToolCallResponse, error) {
+	if matches == nil {
+		matches = []PropertyData{}
+	}
+	data, err := json.MarshalIndent(encodeProperties(matches, format), "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling property data: %v", err)}},
+		}, nil
+	}
+	if len(matches) < total {
+		header += fmt.Sprintf(", showing %d", len(matches))
+	}
+	p.mu.RLock()
+	collected := len(p.collected)
+	p.mu.RUnlock()
+	header += fmt.Sprintf(" (%d of %d collected properties have precise locations):", p.index.Len(), collected)
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: header},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
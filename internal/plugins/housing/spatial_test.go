@@ -0,0 +1,135 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+func TestSpatialSearch(t *testing.T) {
+	p := NewPlugin()
+	listings := []scraper.Property{
+		{Address: "2819 Poelua St", City: "Honolulu", State: "HI", Status: "sold", Price: 1500000, Latitude: 21.3124, Longitude: -157.8087},
+		{Address: "2750 Hipawai Pl", City: "Honolulu", State: "HI", Status: "sold", Price: 1650000, Latitude: 21.3150, Longitude: -157.8100},
+		{Address: "1 Kahala Ave", City: "Honolulu", State: "HI", Status: "sold", Price: 900000, Latitude: 21.2713, Longitude: -157.7738},
+		// No coordinates: placed at its ZIP code's centroid by the geocoder
+		{Address: "3122 Kaloaluiki St", City: "Honolulu", State: "HI", ZipCode: "96822", Status: "sold", Price: 1400000},
+	}
+	for i := range listings {
+		listings[i].ID = scraper.PropertyID(listings[i].Address, listings[i].City, listings[i].State)
+	}
+	if err := p.RegisterSource(statsSource{listings: listings}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSearchSource("test"); err != nil {
+		t.Fatal(err)
+	}
+	geocoder := geo.NewLocalGeocoder(scraper.PropertyKey)
+	geocoder.AddZipCode("96822", geo.Point{Lat: 21.3133, Lon: -157.8116})
+	p.SetGeocoder(geocoder)
+
+	ctx := context.Background()
+	if _, err := p.searchRealProperties(ctx, SearchFilters{City: "Honolulu", State: "HI"}); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(tool string, args map[string]interface{}) []PropertyData {
+		t.Helper()
+		args["format"] = FormatTyped
+		response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: tool, Arguments: args})
+		if err != nil || response.IsError {
+			t.Fatalf("%s: %v %+v", tool, err, response)
+		}
+		var properties []PropertyData
+		if err := json.Unmarshal([]byte(response.Content[1].Text), &properties); err != nil {
+			t.Fatal(err)
+		}
+		return properties
+	}
+
+	// The center is a collected property, so its own location is used
+	nearby := call("search_properties_nearby", map[string]interface{}{"address": "2819 Poelua Street", "city": "Honolulu", "state": "HI", "radius": 0.5})
+	if len(nearby) != 2 || nearby[0].Address != "2819 Poelua St" || nearby[0].DistanceMiles != 0 {
+		t.Fatalf("nearby = %+v", nearby)
+	}
+	// Placed at its ZIP code's centroid, which is no measure of how near it is
+	if centroid, ok := p.collectedProperty(listings[3].ID); !ok || centroid.LocationPrecision != geo.PrecisionZip {
+		t.Errorf("geocoded property = %+v", centroid)
+	}
+	if _, ok := p.index.Get(listings[3].ID); ok {
+		t.Error("ZIP code centroid indexed as a location")
+	}
+	if filtered := call("search_properties_nearby", map[string]interface{}{"latitude": 21.3124, "longitude": -157.8087, "radius": 800.0, "unit": "m", "min_price": 1600000.0}); len(filtered) != 1 {
+		t.Errorf("filtered = %+v", filtered)
+	}
+
+	area := map[string]interface{}{
+		"type":        "Polygon",
+		"coordinates": []interface{}{[]interface{}{[]interface{}{-157.80, 21.26}, []interface{}{-157.76, 21.26}, []interface{}{-157.76, 21.29}, []interface{}{-157.80, 21.29}}},
+	}
+	inside := call("search_properties_in_area", map[string]interface{}{"geojson": area})
+	if len(inside) != 1 || !strings.HasPrefix(inside[0].Address, "1 Kahala") {
+		t.Errorf("inside = %+v", inside)
+	}
+
+	// Sold searches export every listing with coordinates as GeoJSON points,
+	// not just the page a limit would return
+	response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "search_sold_properties", Arguments: map[string]interface{}{
+		"city": "Honolulu", "state": "HI", "limit": 1.0,
+		"export": map[string]interface{}{"format": "geojson", "columns": []interface{}{"address", "price"}},
+	}})
+	if err != nil || response.IsError || response.Content[1].Resource == nil {
+		t.Fatalf("export: %v %+v", err, response)
+	}
+	if resource := response.Content[1].Resource; resource.URI != "export://sold-honolulu-hi.geojson" || strings.Count(resource.Text, `"Point"`) != 4 {
+		t.Errorf("export = %+v", resource)
+	}
+}
+
+func TestSearchPropertiesInAreaLimitsNewestSales(t *testing.T) {
+	p := NewPlugin()
+	// Index hits come in ID order, oldest sale first
+	var listings []scraper.Property
+	for i, soldDate := range []string{"2024-01-10", "2024-03-10", "2024-05-10", "2024-07-10", "2024-09-10"} {
+		address := fmt.Sprintf("%d Kahala Ave", i*10+1)
+		listings = append(listings, scraper.Property{
+			ID: scraper.PropertyID(address, "Honolulu", "HI"), Address: address, City: "Honolulu", State: "HI", Status: "sold",
+			Price: 900000, SoldDate: soldDate, Latitude: 21.2713, Longitude: -157.7738 + float64(i)*0.001,
+		})
+	}
+	if err := p.RegisterSource(statsSource{listings: listings}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSearchSource("test"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := p.searchRealProperties(ctx, SearchFilters{City: "Honolulu", State: "HI"}); err != nil {
+		t.Fatal(err)
+	}
+
+	area := map[string]interface{}{
+		"type":        "Polygon",
+		"coordinates": []interface{}{[]interface{}{[]interface{}{-157.80, 21.26}, []interface{}{-157.76, 21.26}, []interface{}{-157.76, 21.29}, []interface{}{-157.80, 21.29}}},
+	}
+	response, err := p.HandleToolCall(ctx, mcp.ToolCallRequest{Name: "search_properties_in_area", Arguments: map[string]interface{}{"geojson": area, "limit": 2.0, "format": FormatTyped}})
+	if err != nil || response.IsError {
+		t.Fatalf("%v %+v", err, response)
+	}
+	var inside []PropertyData
+	if err := json.Unmarshal([]byte(response.Content[1].Text), &inside); err != nil {
+		t.Fatal(err)
+	}
+	if len(inside) != 2 || inside[0].Address != "41 Kahala Ave" || inside[1].Address != "31 Kahala Ave" {
+		t.Errorf("inside = %+v", inside)
+	}
+	if !strings.HasPrefix(response.Content[0].Text, "Found 5 collected properties inside the area, showing 2") {
+		t.Errorf("header = %s", response.Content[0].Text)
+	}
+}
@@ -0,0 +1,417 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+// Trend series intervals
+const (
+	IntervalMonthly   = "monthly"
+	IntervalQuarterly = "quarterly"
+)
+
+// trendsURIPrefix starts market trends resource URIs, which are
+// housing://trends/{state}/{city}/{neighborhood} or housing://trends/{state}/{city}
+const trendsURIPrefix = "housing://trends/"
+
+// Market trend directions
+const (
+	TrendHeating      = "heating"
+	TrendCooling      = "cooling"
+	TrendStable       = "stable"
+	TrendInsufficient = "insufficient data"
+)
+
+// TrendOptions selects the series get_market_trends computes
+type TrendOptions struct {
+	Interval      string `json:"interval"`               // monthly or quarterly
+	PropertyType  string `json:"propertyType,omitempty"` // Only sales of this type when set
+	Periods       int    `json:"periods"`                // Periods to return, ending with the current one
+	RollingWindow int    `json:"rollingWindow"`          // Periods in each rolling window
+}
+
+// MarketTrends is a neighborhood's market series computed from sold listings
+type MarketTrends struct {
+	Area    string       `json:"area"`
+	Options TrendOptions `json:"options"`
+	// DataSource says which listings the series are computed from
+	DataSource string `json:"dataSource"`
+	// Listings is how many dated, priced sales fall in the series
+	Listings int `json:"listings"`
+	// Undated is how many sold listings were left out for lacking a sale date
+	Undated int           `json:"undated,omitempty"`
+	Periods []TrendPeriod `json:"periods"`
+	Summary TrendSummary  `json:"summary"`
+}
+
+// TrendStats are market measures over a set of sales. Medians are zero when
+// no sale had the value.
+type TrendStats struct {
+	Sales              int     `json:"sales"`
+	MedianPrice        int64   `json:"medianPrice,omitempty"`
+	MedianPricePerSqFt int     `json:"medianPricePerSqFt,omitempty"`
+	MedianDaysOnMarket int     `json:"medianDaysOnMarket,omitempty"`
+	ListToSaleRatio    float64 `json:"listToSaleRatio,omitempty"` // Median sale price over last list price
+}
+
+// TrendPeriod is one month or quarter of a trend series
+type TrendPeriod struct {
+	Period  string `json:"period"` // e.g. 2025-03 or 2025-Q1
+	Start   Date   `json:"start"`
+	End     Date   `json:"end"`               // Exclusive
+	Partial bool   `json:"partial,omitempty"` // The period has not ended yet
+	TrendStats
+	// Rolling covers this period and the ones before it, per the rolling window
+	Rolling TrendStats `json:"rolling"`
+	// Percentage changes in the rolling median price and $/sq ft against the
+	// previous period (month over month or quarter over quarter) and the
+	// same period a year earlier; null when either side has no sales
+	PriceChange        *float64 `json:"priceChange"`
+	PriceYoY           *float64 `json:"priceYoY"`
+	PricePerSqFtChange *float64 `json:"pricePerSqFtChange"`
+	PricePerSqFtYoY    *float64 `json:"pricePerSqFtYoY"`
+	SalesYoY           *float64 `json:"salesYoY"`
+}
+
+// TrendSummary compares the rolling window of the latest complete period with
+// the same window a year earlier
+type TrendSummary struct {
+	Period    string   `json:"period"`    // The latest complete period
+	Direction string   `json:"direction"` // heating, cooling, stable or insufficient data
+	Signals   []string `json:"signals"`
+}
+
+// Trend direction thresholds: a signal needs at least this much change a year on
+const (
+	trendPriceThreshold = 3.0  // Percent change in median price or $/sq ft
+	trendSalesThreshold = 15.0 // Percent change in sales
+	trendDOMThreshold   = 7    // Days change in median days on market
+	trendRatioThreshold = 0.01 // Change in list-to-sale ratio
+)
+
+// listEvents are the price history events that set a list price
+var listEvents = map[string]bool{"listed": true, "list": true, "relisted": true, "price change": true, "price changed": true, "price cut": true}
+
+// parseTrendOptions reads get_market_trends arguments, which may be numbers
+// or, from a resource URI's query, strings
+func parseTrendOptions(args map[string]interface{}) (TrendOptions, error) {
+	options := TrendOptions{Interval: IntervalMonthly}
+	if interval, _ := args["interval"].(string); interval != "" {
+		options.Interval = interval
+	}
+	if options.Interval != IntervalMonthly && options.Interval != IntervalQuarterly {
+		return options, fmt.Errorf("unknown interval %q (available: %s, %s)", options.Interval, IntervalMonthly, IntervalQuarterly)
+	}
+	options.PropertyType, _ = args["property_type"].(string)
+
+	ints := map[string]*int{"periods": &options.Periods, "rolling_window": &options.RollingWindow}
+	for name, dst := range ints {
+		switch v := args[name].(type) {
+		case float64:
+			*dst = int(v)
+		case string:
+			if v == "" {
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return options, fmt.Errorf("%s must be a whole number", name)
+			}
+			*dst = n
+		}
+		if *dst < 0 {
+			return options, fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	// Defaults: two years of months smoothed over a quarter, or two years of quarters
+	if options.Periods == 0 {
+		options.Periods = 24
+		if options.Interval == IntervalQuarterly {
+			options.Periods = 8
+		}
+	}
+	if options.RollingWindow == 0 {
+		options.RollingWindow = 3
+		if options.Interval == IntervalQuarterly {
+			options.RollingWindow = 1
+		}
+	}
+	if options.Periods > 120 || options.RollingWindow > 12 {
+		return options, fmt.Errorf("periods must be at most 120 and rolling_window at most 12")
+	}
+	return options, nil
+}
+
+// periodsPerYear returns how many of the interval's periods make a year
+func (o TrendOptions) periodsPerYear() int {
+	if o.Interval == IntervalQuarterly {
+		return 4
+	}
+	return 12
+}
+
+// periodStart returns the start of the period containing t
+func (o TrendOptions) periodStart(t time.Time) time.Time {
+	month := t.Month()
+	if o.Interval == IntervalQuarterly {
+		month = (month-1)/3*3 + 1
+	}
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// periodLabel names the period starting at start, e.g. 2025-03 or 2025-Q1
+func (o TrendOptions) periodLabel(start time.Time) string {
+	if o.Interval == IntervalQuarterly {
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())+2)/3)
+	}
+	return start.Format("2006-01")
+}
+
+// monthsPerPeriod returns the length of one of the interval's periods
+func (o TrendOptions) monthsPerPeriod() int {
+	return 12 / o.periodsPerYear()
+}
+
+// computeTrends buckets dated sold listings into periods ending with the one
+// containing now, and computes each period's measures, its rolling window and
+// the changes against the previous period and the year before
+func computeTrends(sold []PropertyData, options TrendOptions, now time.Time) MarketTrends {
+	trends := MarketTrends{Options: options, Periods: []TrendPeriod{}}
+
+	// Buckets reach back far enough for the first period's rolling window a
+	// year earlier, and for the summary's when only the current period is shown
+	lead := options.periodsPerYear() + options.RollingWindow
+	total := lead + options.Periods
+	current := options.periodStart(now)
+	first := current.AddDate(0, -(total-1)*options.monthsPerPeriod(), 0)
+	buckets := make([][]PropertyData, total)
+	for _, property := range sold {
+		if property.Price <= 0 || options.PropertyType != "" && !strings.EqualFold(property.PropertyType, options.PropertyType) {
+			continue
+		}
+		if property.SoldDate.IsZero() {
+			trends.Undated++
+			continue
+		}
+		start := options.periodStart(property.SoldDate.Time)
+		if start.Before(first) || start.After(current) {
+			continue
+		}
+		months := (start.Year()-first.Year())*12 + int(start.Month()-first.Month())
+		buckets[months/options.monthsPerPeriod()] = append(buckets[months/options.monthsPerPeriod()], property)
+		if months/options.monthsPerPeriod() >= lead {
+			trends.Listings++
+		}
+	}
+
+	rolling := make([]TrendStats, total)
+	for i := range buckets {
+		var window []PropertyData
+		for j := i - options.RollingWindow + 1; j <= i; j++ {
+			if j >= 0 {
+				window = append(window, buckets[j]...)
+			}
+		}
+		rolling[i] = trendStats(window)
+	}
+
+	yearBack := options.periodsPerYear()
+	for i := lead; i < total; i++ {
+		start := first.AddDate(0, i*options.monthsPerPeriod(), 0)
+		end := start.AddDate(0, options.monthsPerPeriod(), 0)
+		period := TrendPeriod{
+			Period:     options.periodLabel(start),
+			Start:      Date{start},
+			End:        Date{end},
+			Partial:    now.Before(end),
+			TrendStats: trendStats(buckets[i]),
+			Rolling:    rolling[i],
+		}
+		previous, yearAgo := rolling[i-1], rolling[i-yearBack]
+		period.PriceChange = percentChange(float64(previous.MedianPrice), float64(rolling[i].MedianPrice))
+		period.PriceYoY = percentChange(float64(yearAgo.MedianPrice), float64(rolling[i].MedianPrice))
+		period.PricePerSqFtChange = percentChange(float64(previous.MedianPricePerSqFt), float64(rolling[i].MedianPricePerSqFt))
+		period.PricePerSqFtYoY = percentChange(float64(yearAgo.MedianPricePerSqFt), float64(rolling[i].MedianPricePerSqFt))
+		period.SalesYoY = percentChange(float64(yearAgo.Sales), float64(rolling[i].Sales))
+		trends.Periods = append(trends.Periods, period)
+	}
+
+	// The current period is still partial: early in it, its few sales would
+	// read as a slowdown against a whole period a year before
+	latest := total - 2
+	trends.Summary = summarizeTrend(rolling[latest-yearBack], rolling[latest])
+	trends.Summary.Period = options.periodLabel(first.AddDate(0, latest*options.monthsPerPeriod(), 0))
+	return trends
+}
+
+// trendStats computes the market measures of a set of sales
+func trendStats(sales []PropertyData) TrendStats {
+	stats := TrendStats{Sales: len(sales)}
+	var prices, perSqFt, days, ratios []float64
+	for _, property := range sales {
+		prices = append(prices, float64(property.Price))
+		if property.SquareFeet > 0 {
+			perSqFt = append(perSqFt, float64(property.Price)/float64(property.SquareFeet))
+		}
+		if property.DaysOnMarket > 0 {
+			days = append(days, float64(property.DaysOnMarket))
+		}
+		if list := listPrice(property); list > 0 {
+			ratios = append(ratios, float64(property.Price)/float64(list))
+		}
+	}
+	stats.MedianPrice = int64(median(prices))
+	stats.MedianPricePerSqFt = int(math.Round(median(perSqFt)))
+	stats.MedianDaysOnMarket = int(math.Round(median(days)))
+	stats.ListToSaleRatio = round(median(ratios), 3)
+	return stats
+}
+
+// listPrice returns the last list price in a sold property's price history
+// before its sale, or zero when the history has none
+func listPrice(property PropertyData) int64 {
+	var price int64
+	var listed time.Time
+	for _, event := range property.PriceHistory {
+		if !listEvents[event.Event] || event.Price <= 0 {
+			continue
+		}
+		if !property.SoldDate.IsZero() && event.Date.After(property.SoldDate.Time) {
+			continue
+		}
+		// Undated events count in history order
+		if event.Date.IsZero() || !event.Date.Before(listed) {
+			price, listed = event.Price, event.Date.Time
+		}
+	}
+	return price
+}
+
+// percentChange returns the percentage change from before to after, or nil
+// when either is unknown
+func percentChange(before, after float64) *float64 {
+	if before == 0 || after == 0 {
+		return nil
+	}
+	change := round((after/before-1)*100, 1)
+	return &change
+}
+
+// summarizeTrend calls the market heating or cooling by how many measures
+// moved past their thresholds in each direction over the year
+func summarizeTrend(yearAgo, latest TrendStats) TrendSummary {
+	summary := TrendSummary{Direction: TrendInsufficient, Signals: []string{}}
+	const minSales = 3
+	if yearAgo.Sales < minSales || latest.Sales < minSales {
+		summary.Signals = append(summary.Signals, fmt.Sprintf("need at least %d sales in the latest window and a year before it; found %d and %d", minSales, latest.Sales, yearAgo.Sales))
+		return summary
+	}
+
+	heating, cooling := 0, 0
+	signal := func(up bool, text string) {
+		if up {
+			heating++
+		} else {
+			cooling++
+		}
+		summary.Signals = append(summary.Signals, text)
+	}
+	if change := percentChange(float64(yearAgo.MedianPrice), float64(latest.MedianPrice)); change != nil && math.Abs(*change) >= trendPriceThreshold {
+		signal(*change > 0, fmt.Sprintf("median price %+.1f%% year over year", *change))
+	}
+	if change := percentChange(float64(yearAgo.MedianPricePerSqFt), float64(latest.MedianPricePerSqFt)); change != nil && math.Abs(*change) >= trendPriceThreshold {
+		signal(*change > 0, fmt.Sprintf("median $/sq ft %+.1f%% year over year", *change))
+	}
+	if change := percentChange(float64(yearAgo.Sales), float64(latest.Sales)); change != nil && math.Abs(*change) >= trendSalesThreshold {
+		signal(*change > 0, fmt.Sprintf("sales %+.1f%% year over year", *change))
+	}
+	if yearAgo.MedianDaysOnMarket > 0 && latest.MedianDaysOnMarket > 0 {
+		if change := latest.MedianDaysOnMarket - yearAgo.MedianDaysOnMarket; absInt(change) >= trendDOMThreshold {
+			signal(change < 0, fmt.Sprintf("median days on market %+d year over year", change))
+		}
+	}
+	if yearAgo.ListToSaleRatio > 0 && latest.ListToSaleRatio > 0 {
+		if change := latest.ListToSaleRatio - yearAgo.ListToSaleRatio; math.Abs(change) >= trendRatioThreshold {
+			signal(change > 0, fmt.Sprintf("list-to-sale ratio %.3f, from %.3f a year before", latest.ListToSaleRatio, yearAgo.ListToSaleRatio))
+		}
+	}
+
+	switch {
+	case heating > cooling:
+		summary.Direction = TrendHeating
+	case cooling > heating:
+		summary.Direction = TrendCooling
+	default:
+		summary.Direction = TrendStable
+	}
+	return summary
+}
+
+// marketTrends computes an area's trend series from the sold listings the
+// plugin has collected: everything stored for the area when there is a
+// store, otherwise the listings of the current scrape
+func (p *Plugin) marketTrends(ctx context.Context, city, state, neighborhood string, options TrendOptions) (*MarketTrends, error) {
+	sold, err := p.searchRealProperties(ctx, SearchFilters{City: city, State: state, Neighborhood: neighborhood})
+	if err != nil {
+		return nil, err
+	}
+	trends := computeTrends(sold, options, p.now())
+	trends.Area = areaName(city, state, neighborhood)
+	trends.DataSource = "sold listings retained in the property store"
+	if p.store == nil {
+		trends.DataSource = "sold listings from the latest scrape only; run with a property store to retain listings over time"
+	}
+	return &trends, nil
+}
+
+func (p *Plugin) handleGetMarketTrends(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	city, _ := args["city"].(string)
+	state, _ := args["state"].(string)
+	if city == "" || state == "" {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "city and state parameters are required and must be non-empty strings"}},
+		}, nil
+	}
+	neighborhood, _ := args["neighborhood"].(string)
+
+	options, err := parseTrendOptions(args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	trends, err := p.marketTrends(ctx, city, state, neighborhood, options)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error computing market trends: %v", err)}},
+		}, nil
+	}
+
+	data, err := json.MarshalIndent(trends, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling market trends: %v", err)}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Market trends for %s (%s, %d sales): %s", trends.Area, options.Interval, trends.Listings, trends.Summary.Direction)},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
@@ -0,0 +1,83 @@
+package housing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeTrends(t *testing.T) {
+	now := time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)
+	sale := func(year int, month time.Month, price int64, days int) PropertyData {
+		return PropertyData{Price: price, SquareFeet: 1000, DaysOnMarket: days, PropertyType: "house",
+			SoldDate: Date{time.Date(year, month, 10, 0, 0, 0, 0, time.UTC)}}
+	}
+	sold := []PropertyData{
+		// A year before: quick sales over asking
+		sale(2024, 1, 1000000, 10), sale(2024, 2, 1000000, 10), sale(2024, 3, 1100000, 12),
+		// Now: lower prices, longer on the market and under asking
+		sale(2025, 1, 900000, 30), sale(2025, 2, 950000, 40), sale(2025, 3, 900000, 35),
+		{Price: 800000, PropertyType: "house"},                                        // Undated
+		{Price: 500000, PropertyType: "condo", SoldDate: Date{now.AddDate(0, -1, 0)}}, // Other type
+	}
+	for i := range sold[:3] {
+		sold[i].PriceHistory = []PriceEvent{{Event: "listed", Price: sold[i].Price * 95 / 100}}
+	}
+	for i := 3; i < 6; i++ {
+		sold[i].PriceHistory = []PriceEvent{
+			{Event: "listed", Price: sold[i].Price * 110 / 100},
+			{Event: "price cut", Price: sold[i].Price * 105 / 100},
+			{Event: "sold", Price: sold[i].Price},
+		}
+	}
+
+	options, err := parseTrendOptions(map[string]interface{}{"property_type": "house", "periods": float64(15)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trends := computeTrends(sold, options, now)
+
+	if len(trends.Periods) != 15 || trends.Periods[0].Period != "2024-02" || trends.Periods[14].Period != "2025-04" || !trends.Periods[14].Partial {
+		t.Fatalf("periods = %d, %s to %s", len(trends.Periods), trends.Periods[0].Period, trends.Periods[len(trends.Periods)-1].Period)
+	}
+	if trends.Listings != 5 || trends.Undated != 1 {
+		t.Errorf("listings = %d, undated = %d", trends.Listings, trends.Undated)
+	}
+
+	march := trends.Periods[13]
+	if march.Sales != 1 || march.MedianPrice != 900000 || march.Rolling.Sales != 3 || march.Rolling.MedianPrice != 900000 {
+		t.Errorf("2025-03 = %+v", march)
+	}
+	if march.Rolling.ListToSaleRatio != 0.952 {
+		t.Errorf("list-to-sale ratio = %v, want 0.952 from the price cut", march.Rolling.ListToSaleRatio)
+	}
+	// Rolling medians: $900,000 against $1,000,000 a year before
+	if march.PriceYoY == nil || *march.PriceYoY != -10 {
+		t.Errorf("price YoY = %v", march.PriceYoY)
+	}
+	if trends.Periods[5].PriceChange != nil {
+		t.Errorf("months without sales should have no change, got %v", *trends.Periods[5].PriceChange)
+	}
+
+	// April is partial, so the summary is of March's rolling window (Jan-Mar 2025)
+	if trends.Summary.Period != "2025-03" || trends.Summary.Direction != TrendCooling || len(trends.Summary.Signals) != 4 {
+		t.Errorf("summary = %+v", trends.Summary)
+	}
+	// On March 31 it is of February's, which has two sales, too few for a summary
+	trends = computeTrends(sold, options, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	if trends.Summary.Period != "2025-02" || trends.Summary.Direction != TrendInsufficient {
+		t.Errorf("summary = %+v", trends.Summary)
+	}
+}
+
+func TestParseTrendOptions(t *testing.T) {
+	options, err := parseTrendOptions(map[string]interface{}{"interval": "quarterly", "periods": "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.Periods != 4 || options.RollingWindow != 1 {
+		t.Errorf("options = %+v", options)
+	}
+	if _, err := parseTrendOptions(map[string]interface{}{"interval": "weekly"}); err == nil {
+		t.Error("expected an error for an unknown interval")
+	}
+}
@@ -0,0 +1,485 @@
+package housing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// Valuation model parameters
+const (
+	defaultMaxComps      = 6
+	minComps             = 3
+	defaultCompsLookback = 365 // Days of sales considered
+	// tierMiles is the distance that counts as one proximity tier
+	tierMiles = 1.0
+	// Distances assumed for comparables judged by address, in miles, so they
+	// rank against measured ones: a shared ZIP code is about as near as its
+	// typical radius
+	sameStreetMiles = 0.25
+	sameZipMiles    = 1.5
+	sameAreaMiles   = 3.0
+	// intervalZ gives an 80% interval for normally distributed adjusted prices
+	intervalZ          = 1.2816
+	intervalConfidence = 0.8
+	// minIntervalWidth is the narrowest half-width of the interval, as a share of the estimate
+	minIntervalWidth = 0.05
+	// sqftRateShare is the share of the comps' median price per square foot
+	// paid for each square foot of difference; extra space is worth less than average
+	sqftRateShare  = 0.5
+	bedroomAdjust  = 0.02  // Of the comp's price per bedroom of difference
+	bathroomAdjust = 0.015 // Of the comp's price per bathroom of difference
+	// lotElasticity is the price change for a 100% larger lot
+	lotElasticity = 0.1
+	ageAdjust     = 0.003 // Of the comp's price per year of difference in age
+	maxAgeAdjust  = 0.15
+	conditionStep = 0.05 // Of the comp's price per condition grade
+)
+
+// conditionGrades ranks property conditions for the condition adjustment
+var conditionGrades = map[string]int{
+	"poor": -2, "fair": -1, "average": 0, "good": 0, "very good": 1, "excellent": 1, "new": 2, "renovated": 1, "updated": 1,
+}
+
+// Valuation is a comparable-sales estimate of a property's value
+type Valuation struct {
+	Subject      ValuationSubject `json:"subject"`
+	Estimate     int64            `json:"estimate"`
+	Low          int64            `json:"low"`
+	High         int64            `json:"high"`
+	Confidence   float64          `json:"confidence"` // Probability the value lies between Low and High
+	PricePerSqFt int              `json:"pricePerSqFt,omitempty"`
+	Comparables  []Comparable     `json:"comparables"`
+	Method       string           `json:"method"`
+	Notes        []string         `json:"notes,omitempty"`
+}
+
+// ValuationSubject is the property being valued
+type ValuationSubject struct {
+	ID           string  `json:"id"`
+	Address      string  `json:"address"`
+	City         string  `json:"city"`
+	State        string  `json:"state"`
+	ZipCode      string  `json:"zipCode,omitempty"`
+	Neighborhood string  `json:"neighborhood,omitempty"`
+	PropertyType string  `json:"propertyType,omitempty"`
+	Bedrooms     int     `json:"bedrooms,omitempty"`
+	Bathrooms    float64 `json:"bathrooms,omitempty"`
+	SquareFeet   int     `json:"squareFeet"`
+	LotSize      float64 `json:"lotSize,omitempty"`
+	YearBuilt    int     `json:"yearBuilt,omitempty"`
+	Condition    string  `json:"condition,omitempty"`
+	Latitude     float64 `json:"latitude,omitempty"`
+	Longitude    float64 `json:"longitude,omitempty"`
+}
+
+// Comparable is a sold property used in a valuation, with the adjustments
+// that bring its sale price in line with the subject
+type Comparable struct {
+	Property      PropertyRef  `json:"property"`
+	SoldDate      Date         `json:"soldDate"`
+	SalePrice     int64        `json:"salePrice"`
+	SquareFeet    int          `json:"squareFeet"`
+	Bedrooms      int          `json:"bedrooms,omitempty"`
+	Bathrooms     float64      `json:"bathrooms,omitempty"`
+	PropertyType  string       `json:"propertyType,omitempty"`
+	Proximity     string       `json:"proximity"` // A distance such as "0.40 mi away", or same street, same ZIP code or same area
+	Similarity    float64      `json:"similarity"`
+	Weight        float64      `json:"weight"`
+	Adjustments   []Adjustment `json:"adjustments"`
+	AdjustedPrice int64        `json:"adjustedPrice"`
+}
+
+// Adjustment is one correction to a comparable's sale price
+type Adjustment struct {
+	Factor string `json:"factor"` // squareFeet, bedrooms, bathrooms, lotSize, age or condition
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+// candidateComp is a sold property being considered as a comparable
+type candidateComp struct {
+	property  PropertyData
+	proximity string
+	distance  float64 // Lower is more similar
+}
+
+// estimateValue values subject from the sold properties in its area
+func estimateValue(subject ValuationSubject, sold []PropertyData, now time.Time, maxComps, lookbackDays int) (*Valuation, error) {
+	if subject.SquareFeet <= 0 {
+		return nil, fmt.Errorf("the subject's square footage is unknown; pass square_feet")
+	}
+	if maxComps <= 0 {
+		maxComps = defaultMaxComps
+	}
+	if lookbackDays <= 0 {
+		lookbackDays = defaultCompsLookback
+	}
+
+	cutoff := now.AddDate(0, 0, -lookbackDays)
+	var candidates []candidateComp
+	for _, property := range sold {
+		if property.ID == subject.ID || property.Price <= 0 || property.SquareFeet <= 0 {
+			continue
+		}
+		if !property.SoldDate.IsZero() && property.SoldDate.Before(cutoff) {
+			continue
+		}
+		candidates = append(candidates, scoreComp(subject, property, now))
+	}
+
+	// Prefer the subject's own property type while there are enough of them
+	if subject.PropertyType != "" {
+		var sameType []candidateComp
+		for _, c := range candidates {
+			if strings.EqualFold(c.property.PropertyType, subject.PropertyType) {
+				sameType = append(sameType, c)
+			}
+		}
+		if len(sameType) >= minComps {
+			candidates = sameType
+		}
+	}
+	if len(candidates) < minComps {
+		return nil, fmt.Errorf("found %d usable sales in the last %d days; at least %d are needed", len(candidates), lookbackDays, minComps)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	if len(candidates) > maxComps {
+		candidates = candidates[:maxComps]
+	}
+
+	// Extra square feet are priced at a share of the comps' median rate
+	var rates []float64
+	for _, c := range candidates {
+		rates = append(rates, float64(c.property.Price)/float64(c.property.SquareFeet))
+	}
+	sqftRate := median(rates) * sqftRateShare
+
+	valuation := &Valuation{
+		Subject:    subject,
+		Confidence: intervalConfidence,
+		Method:     "Sales comparison: sold comparables chosen by proximity, recency, type and size, adjusted for square footage, bedrooms, bathrooms, lot size, age and condition, then weighted by similarity",
+	}
+	var sumWeights, sumPrices float64
+	for _, c := range candidates {
+		comp := adjustComp(subject, c, sqftRate)
+		valuation.Comparables = append(valuation.Comparables, comp)
+		sumWeights += comp.Weight
+		sumPrices += comp.Weight * float64(comp.AdjustedPrice)
+	}
+	estimate := sumPrices / sumWeights
+
+	// The spread of the adjusted prices sets the interval
+	var variance float64
+	for i := range valuation.Comparables {
+		comp := &valuation.Comparables[i]
+		weight := comp.Weight / sumWeights
+		d := float64(comp.AdjustedPrice) - estimate
+		variance += weight * d * d
+		comp.Weight = round(weight, 3)
+	}
+	halfWidth := math.Max(intervalZ*math.Sqrt(variance), minIntervalWidth*estimate)
+
+	valuation.Estimate = roundPrice(estimate)
+	valuation.Low = roundPrice(estimate - halfWidth)
+	valuation.High = roundPrice(estimate + halfWidth)
+	valuation.PricePerSqFt = int(estimate / float64(subject.SquareFeet))
+	if subject.Bedrooms == 0 || subject.Bathrooms == 0 || subject.YearBuilt == 0 {
+		valuation.Notes = append(valuation.Notes, "Some of the subject's facts are unknown, so comparables were not adjusted for them")
+	}
+	if halfWidth > 0.15*estimate {
+		valuation.Notes = append(valuation.Notes, "The comparables disagree widely after adjustment; treat the estimate with caution")
+	}
+	return valuation, nil
+}
+
+// scoreComp rates how similar a sold property is to the subject
+func scoreComp(subject ValuationSubject, property PropertyData, now time.Time) candidateComp {
+	c := candidateComp{property: property, proximity: "same area"}
+	// Nearness is measured when both are located, and otherwise judged from the address
+	miles := sameAreaMiles
+	here, there := geo.Point{Lat: subject.Latitude, Lon: subject.Longitude}, geo.Point{Lat: property.Latitude, Lon: property.Longitude}
+	if here.Valid() && there.Valid() && preciseLocation(property) {
+		miles = geo.DistanceMiles(here, there)
+		c.proximity = fmt.Sprintf("%.2f mi away", miles)
+	} else if sameStreet(subject.Address, property.Address) && strings.EqualFold(subject.City, property.City) {
+		c.proximity, miles = "same street", sameStreetMiles
+	} else if subject.ZipCode != "" && subject.ZipCode == property.ZipCode {
+		c.proximity, miles = "same ZIP code", sameZipMiles
+	}
+	c.distance = miles / tierMiles
+
+	// A year-old sale counts as much as moving to the next proximity tier
+	if !property.SoldDate.IsZero() {
+		c.distance += now.Sub(property.SoldDate.Time).Hours() / 24 / 365
+	} else {
+		c.distance += 1
+	}
+	if subject.PropertyType != "" && !strings.EqualFold(subject.PropertyType, property.PropertyType) {
+		c.distance += 1
+	}
+	// Doubling or halving the size costs two tiers
+	c.distance += 2 * math.Abs(math.Log2(float64(property.SquareFeet)/float64(subject.SquareFeet)))
+	return c
+}
+
+// adjustComp adjusts a comparable's sale price toward the subject
+func adjustComp(subject ValuationSubject, c candidateComp, sqftRate float64) Comparable {
+	p := c.property
+	price := float64(p.Price)
+	comp := Comparable{
+		Property:     PropertyRef{ID: p.ID, Address: p.Address, City: p.City, State: p.State, Price: p.Price},
+		SoldDate:     p.SoldDate,
+		SalePrice:    p.Price,
+		SquareFeet:   p.SquareFeet,
+		Bedrooms:     p.Bedrooms,
+		Bathrooms:    p.Bathrooms,
+		PropertyType: p.PropertyType,
+		Proximity:    c.proximity,
+		Similarity:   round(1/(1+c.distance), 3),
+		Weight:       1 / (1 + c.distance),
+		Adjustments:  []Adjustment{},
+	}
+	add := func(factor string, amount float64, reason string) {
+		if amount := int64(math.Round(amount)); amount != 0 {
+			comp.Adjustments = append(comp.Adjustments, Adjustment{Factor: factor, Amount: amount, Reason: reason})
+		}
+	}
+
+	if diff := subject.SquareFeet - p.SquareFeet; diff != 0 {
+		add("squareFeet", float64(diff)*sqftRate, fmt.Sprintf("%+d sq ft at $%.0f/sq ft", diff, sqftRate))
+	}
+	if subject.Bedrooms > 0 && p.Bedrooms > 0 && subject.Bedrooms != p.Bedrooms {
+		diff := subject.Bedrooms - p.Bedrooms
+		add("bedrooms", float64(diff)*bedroomAdjust*price, fmt.Sprintf("%+d bedrooms at %.1f%% each", diff, bedroomAdjust*100))
+	}
+	if subject.Bathrooms > 0 && p.Bathrooms > 0 && subject.Bathrooms != p.Bathrooms {
+		diff := subject.Bathrooms - p.Bathrooms
+		add("bathrooms", diff*bathroomAdjust*price, fmt.Sprintf("%+g bathrooms at %.1f%% each", diff, bathroomAdjust*100))
+	}
+	if subject.LotSize > 0 && p.LotSize > 0 && subject.LotSize != p.LotSize {
+		change := lotElasticity * math.Log2(subject.LotSize/p.LotSize)
+		add("lotSize", change*price, fmt.Sprintf("lot %.0f%% the size at %.0f%% per doubling", 100*subject.LotSize/p.LotSize, lotElasticity*100))
+	}
+	if subject.YearBuilt > 0 && p.YearBuilt > 0 && subject.YearBuilt != p.YearBuilt {
+		diff := subject.YearBuilt - p.YearBuilt
+		change := math.Max(-maxAgeAdjust, math.Min(maxAgeAdjust, float64(diff)*ageAdjust))
+		when := "later"
+		if diff < 0 {
+			when = "earlier"
+		}
+		add("age", change*price, fmt.Sprintf("built %d years %s at %.1f%% per year", absInt(diff), when, ageAdjust*100))
+	}
+	subjectGrade, subjectKnown := conditionGrades[strings.ToLower(subject.Condition)]
+	compGrade, compKnown := conditionGrades[strings.ToLower(p.PropertyCondition)]
+	if subjectKnown && compKnown && subjectGrade != compGrade {
+		diff := subjectGrade - compGrade
+		add("condition", float64(diff)*conditionStep*price, fmt.Sprintf("condition %s vs %s", subject.Condition, p.PropertyCondition))
+	}
+
+	adjusted := price
+	for _, a := range comp.Adjustments {
+		adjusted += float64(a.Amount)
+	}
+	comp.AdjustedPrice = int64(math.Round(adjusted))
+	return comp
+}
+
+// sameStreet reports whether two addresses are on the same street
+func sameStreet(a, b string) bool {
+	na, nb := scraper.NormalizeAddress(a), scraper.NormalizeAddress(b)
+	na.Number, na.UnitType, na.Unit = "", "", ""
+	nb.Number, nb.UnitType, nb.Unit = "", "", ""
+	return na.Street != "" && na == nb
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// roundPrice rounds an estimate to the nearest $1,000
+func roundPrice(v float64) int64 {
+	return int64(math.Round(v/1000) * 1000)
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (p *Plugin) handleEstimatePropertyValue(ctx context.Context, args map[string]interface{}) (*mcp.ToolCallResponse, error) {
+	subject, err := p.valuationSubject(ctx, args)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}, nil
+	}
+
+	sold, err := p.searchRealProperties(ctx, SearchFilters{City: subject.City, State: subject.State, Neighborhood: subject.Neighborhood})
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error finding comparable sales: %v", err)}},
+		}, nil
+	}
+	// Fill in what the listings know about the subject
+	for _, property := range sold {
+		if property.ID == subject.ID {
+			subject = subject.withFacts(property)
+		}
+	}
+	applySubjectArgs(&subject, args)
+
+	maxComps, _ := args["max_comps"].(float64)
+	lookback, _ := args["sold_within_days"].(float64)
+	valuation, err := estimateValue(subject, sold, p.now(), int(maxComps), int(lookback))
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Cannot estimate %s: %v", subject.Address, err)}},
+		}, nil
+	}
+
+	data, err := json.MarshalIndent(valuation, "", "  ")
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: fmt.Sprintf("Error marshaling valuation: %v", err)}},
+		}, nil
+	}
+
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: fmt.Sprintf("Estimated value of %s: %s (80%% range %s to %s) from %d comparable sales:",
+				subject.Address, Money{Amount: float64(valuation.Estimate), Currency: "USD"}, Money{Amount: float64(valuation.Low), Currency: "USD"},
+				Money{Amount: float64(valuation.High), Currency: "USD"}, len(valuation.Comparables))},
+			{Type: "text", Text: string(data)},
+		},
+	}, nil
+}
+
+// valuationSubject identifies the property to value from a property page URL
+// or an address, using stored details when there are any
+func (p *Plugin) valuationSubject(ctx context.Context, args map[string]interface{}) (ValuationSubject, error) {
+	neighborhood, _ := args["neighborhood"].(string)
+	if url, _ := args["url"].(string); url != "" {
+		source, err := p.sources.ForURL(url)
+		if err != nil {
+			return ValuationSubject{}, err
+		}
+		property, err := p.propertyDetail(ctx, source, url)
+		if err != nil {
+			return ValuationSubject{}, fmt.Errorf("error fetching property details: %v", err)
+		}
+		subject := ValuationSubject{Neighborhood: neighborhood}.withFacts(property)
+		if subject.City == "" || subject.State == "" {
+			return subject, fmt.Errorf("could not tell the property's city and state from %s", url)
+		}
+		return subject, nil
+	}
+
+	address, _ := args["address"].(string)
+	city, _ := args["city"].(string)
+	state, _ := args["state"].(string)
+	if address == "" || city == "" || state == "" {
+		return ValuationSubject{}, fmt.Errorf("address, city and state parameters are required unless url is given")
+	}
+	subject := ValuationSubject{ID: scraper.PropertyID(address, city, state), Address: address, City: city, State: state, Neighborhood: neighborhood}
+	if p.store != nil {
+		if stored, ok := p.store.LookupProperty(ctx, address, city, state); ok {
+			subject = subject.withFacts(newPropertyData(*stored, "", time.Time{}))
+		}
+	}
+	return subject, nil
+}
+
+// withFacts fills the subject's unknown facts from a listing of it
+func (s ValuationSubject) withFacts(property PropertyData) ValuationSubject {
+	fill := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	fill(&s.ID, property.ID)
+	fill(&s.Address, property.Address)
+	fill(&s.City, property.City)
+	fill(&s.State, property.State)
+	fill(&s.ZipCode, property.ZipCode)
+	fill(&s.Neighborhood, property.Neighborhood)
+	fill(&s.Condition, property.PropertyCondition)
+	if s.PropertyType == "" && property.PropertyType != "unknown" {
+		s.PropertyType = property.PropertyType
+	}
+	if s.Bedrooms == 0 {
+		s.Bedrooms = property.Bedrooms
+	}
+	if s.Bathrooms == 0 {
+		s.Bathrooms = property.Bathrooms
+	}
+	if s.SquareFeet == 0 {
+		s.SquareFeet = property.SquareFeet
+	}
+	if s.LotSize == 0 {
+		s.LotSize = property.LotSize
+	}
+	if s.YearBuilt == 0 {
+		s.YearBuilt = property.YearBuilt
+	}
+	if s.Latitude == 0 && s.Longitude == 0 && preciseLocation(property) {
+		s.Latitude, s.Longitude = property.Latitude, property.Longitude
+	}
+	return s
+}
+
+// applySubjectArgs overrides the subject's facts with those passed to the tool
+func applySubjectArgs(s *ValuationSubject, args map[string]interface{}) {
+	if v, ok := args["zip_code"].(string); ok && v != "" {
+		s.ZipCode = v
+	}
+	if v, ok := args["property_type"].(string); ok && v != "" {
+		s.PropertyType = v
+	}
+	if v, ok := args["condition"].(string); ok && v != "" {
+		s.Condition = v
+	}
+	if v, ok := args["square_feet"].(float64); ok && v > 0 {
+		s.SquareFeet = int(v)
+	}
+	if v, ok := args["bedrooms"].(float64); ok && v > 0 {
+		s.Bedrooms = int(v)
+	}
+	if v, ok := args["bathrooms"].(float64); ok && v > 0 {
+		s.Bathrooms = v
+	}
+	if v, ok := args["lot_size"].(float64); ok && v > 0 {
+		s.LotSize = v
+	}
+	if v, ok := args["year_built"].(float64); ok && v > 0 {
+		s.YearBuilt = int(v)
+	}
+}
@@ -0,0 +1,116 @@
+package housing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/geo"
+)
+
+func TestEstimateValue(t *testing.T) {
+	now := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	sold := func(daysAgo int) Date { return Date{now.AddDate(0, 0, -daysAgo)} }
+	comps := []PropertyData{
+		{ID: "prop_a", Address: "2714 Hipawai Pl", City: "Honolulu", State: "HI", ZipCode: "96822", Price: 1500000, SquareFeet: 1500, Bedrooms: 3, Bathrooms: 2, YearBuilt: 1960, PropertyType: "house", SoldDate: sold(30)},
+		{ID: "prop_b", Address: "2750 Hipawai Pl", City: "Honolulu", State: "HI", ZipCode: "96822", Price: 1650000, SquareFeet: 1700, Bedrooms: 4, Bathrooms: 2, YearBuilt: 1960, PropertyType: "house", SoldDate: sold(60)},
+		{ID: "prop_c", Address: "3122 Kaloaluiki St", City: "Honolulu", State: "HI", ZipCode: "96822", Price: 1400000, SquareFeet: 1400, Bedrooms: 3, Bathrooms: 2, YearBuilt: 1950, PropertyType: "house", SoldDate: sold(90)},
+		{ID: "prop_d", Address: "1 Kahala Ave", City: "Honolulu", State: "HI", ZipCode: "96816", Price: 1550000, SquareFeet: 1500, Bedrooms: 3, Bathrooms: 2.5, YearBuilt: 1970, PropertyType: "house", SoldDate: sold(120)},
+		// Too old, a different type, or missing a size: never used
+		{ID: "prop_e", Address: "9 Oahu Ave", City: "Honolulu", State: "HI", Price: 900000, SquareFeet: 1500, PropertyType: "house", SoldDate: sold(500)},
+		{ID: "prop_f", Address: "2721 Hipawai Pl", City: "Honolulu", State: "HI", Price: 700000, SquareFeet: 900, PropertyType: "condo", SoldDate: sold(10)},
+		{ID: "prop_g", Address: "2730 Hipawai Pl", City: "Honolulu", State: "HI", Price: 1500000, PropertyType: "house", SoldDate: sold(10)},
+		// The subject's own earlier sale
+		{ID: "prop_subject", Address: "2740 Hipawai Pl", City: "Honolulu", State: "HI", Price: 1000000, SquareFeet: 1600, PropertyType: "house", SoldDate: sold(20)},
+	}
+	subject := ValuationSubject{ID: "prop_subject", Address: "2740 Hipawai Pl", City: "Honolulu", State: "HI", ZipCode: "96822",
+		PropertyType: "house", SquareFeet: 1600, Bedrooms: 3, Bathrooms: 2, YearBuilt: 1960}
+
+	valuation, err := estimateValue(subject, comps, now, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(valuation.Comparables) != 4 {
+		t.Fatalf("used %d comparables, want 4", len(valuation.Comparables))
+	}
+	// All four sold for about $1,000/sq ft, so 1,600 sq ft is worth roughly $1.5M-$1.65M
+	if valuation.Estimate < 1500000 || valuation.Estimate > 1650000 {
+		t.Errorf("estimate = %d", valuation.Estimate)
+	}
+	if !(valuation.Low < valuation.Estimate && valuation.Estimate < valuation.High) || valuation.Confidence != 0.8 {
+		t.Errorf("interval = %d-%d at %v", valuation.Low, valuation.High, valuation.Confidence)
+	}
+
+	first := valuation.Comparables[0]
+	if first.Property.ID != "prop_a" || first.Proximity != "same street" {
+		t.Errorf("closest comparable = %s (%s)", first.Property.ID, first.Proximity)
+	}
+	// prop_a is 100 sq ft smaller, so it is adjusted up by half the median rate
+	if len(first.Adjustments) != 1 || first.Adjustments[0].Factor != "squareFeet" || first.Adjustments[0].Amount <= 0 {
+		t.Errorf("prop_a adjustments = %+v", first.Adjustments)
+	}
+	for _, comp := range valuation.Comparables {
+		if comp.Property.ID == "prop_b" {
+			factors := map[string]int64{}
+			for _, a := range comp.Adjustments {
+				factors[a.Factor] = a.Amount
+			}
+			if factors["bedrooms"] != -33000 || factors["squareFeet"] >= 0 {
+				t.Errorf("prop_b adjustments = %+v", comp.Adjustments)
+			}
+		}
+	}
+
+	if _, err := estimateValue(ValuationSubject{ID: "x"}, comps, now, 0, 0); err == nil {
+		t.Error("expected an error without the subject's square footage")
+	}
+	if _, err := estimateValue(subject, comps[:2], now, 0, 0); err == nil {
+		t.Error("expected an error with too few comparables")
+	}
+}
+
+func TestEstimateValueByDistance(t *testing.T) {
+	now := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	sold := Date{now.AddDate(0, 0, -30)}
+	comp := func(id, address string, lat, lon float64) PropertyData {
+		return PropertyData{ID: id, Address: address, City: "Honolulu", State: "HI", ZipCode: "96822", Price: 1500000, SquareFeet: 1500,
+			PropertyType: "house", SoldDate: sold, Latitude: lat, Longitude: lon}
+	}
+	comps := []PropertyData{
+		// On the subject's street, but a mile and a half up the valley
+		comp("prop_far", "2900 Hipawai Pl", 21.3330, -157.8050),
+		comp("prop_near", "3122 Kaloaluiki St", 21.3130, -157.8110),
+		comp("prop_zip", "2500 Oahu Ave", 21.3050, -157.8150),
+		// Without a location it is judged by its address
+		comp("prop_unlocated", "2800 Manoa Rd", 0, 0),
+	}
+	// A ZIP code centroid is no location either
+	centroid := comp("prop_centroid", "2850 Manoa Rd", 21.3133, -157.8116)
+	centroid.LocationPrecision = geo.PrecisionZip
+	comps = append(comps, centroid)
+	subject := ValuationSubject{ID: "prop_subject", Address: "2740 Hipawai Pl", City: "Honolulu", State: "HI", ZipCode: "96822",
+		PropertyType: "house", SquareFeet: 1500, Latitude: 21.3124, Longitude: -157.8100}
+
+	valuation, err := estimateValue(subject, comps, now, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proximity := map[string]string{}
+	for _, c := range valuation.Comparables {
+		proximity[c.Property.ID] = c.Proximity
+	}
+	if first := valuation.Comparables[0]; first.Property.ID != "prop_near" || first.Proximity != "0.08 mi away" {
+		t.Errorf("closest comparable = %s (%s)", first.Property.ID, first.Proximity)
+	}
+	if proximity["prop_unlocated"] != "same ZIP code" || proximity["prop_centroid"] != "same ZIP code" || !strings.HasSuffix(proximity["prop_far"], "mi away") {
+		t.Errorf("proximity = %v", proximity)
+	}
+	// A shared ZIP code is assumed as far as its typical radius, so measured comps nearer than that rank ahead
+	var order []string
+	for _, c := range valuation.Comparables {
+		order = append(order, c.Property.ID)
+	}
+	if strings.Join(order, " ") != "prop_near prop_zip prop_far prop_unlocated prop_centroid" {
+		t.Errorf("comparables ranked %v", order)
+	}
+}
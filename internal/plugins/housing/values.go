@@ -0,0 +1,264 @@
+package housing
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// Money is an amount of money, optionally recurring
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`         // ISO 4217 code, e.g. USD
+	Period   string  `json:"period,omitempty"` // monthly or annual for recurring costs
+}
+
+// Money periods
+const (
+	PeriodMonthly = "monthly"
+	PeriodAnnual  = "annual"
+)
+
+// Distance is a length with its unit
+type Distance struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"` // mi, km, ft or m
+}
+
+// Date is a calendar date, encoded as YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+// dateLayout is how Date is encoded
+const dateLayout = "2006-01-02"
+
+// PriceEvent is an entry in a property's price history
+type PriceEvent struct {
+	Date  Date   `json:"date"`
+	Event string `json:"event"` // e.g. listed, sold, price change
+	Price int64  `json:"price"`
+}
+
+// PropertyRef identifies another property, such as a comparable sale
+type PropertyRef struct {
+	ID      string `json:"id,omitempty"`
+	Address string `json:"address,omitempty"`
+	City    string `json:"city,omitempty"`
+	State   string `json:"state,omitempty"`
+	Price   int64  `json:"price,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// MarshalJSON encodes the date as YYYY-MM-DD, or null when it is unknown
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format(dateLayout))
+}
+
+// UnmarshalJSON decodes a YYYY-MM-DD date or null
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil || *s == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+	t, err := time.Parse(dateLayout, *s)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %v", *s, err)
+	}
+	d.Time = t
+	return nil
+}
+
+// String formats the date the way listing sites show it, e.g. "JAN 5, 2025"
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return strings.ToUpper(d.Format("Jan 2, 2006"))
+}
+
+// currencySymbols maps currency symbols to ISO 4217 codes
+var currencySymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY"}
+
+// String formats the amount with its currency symbol, e.g. "$1,234"
+func (m Money) String() string {
+	symbol := m.Currency + " "
+	for s, code := range currencySymbols {
+		if code == m.Currency {
+			symbol = s
+		}
+	}
+	whole := strconv.FormatInt(int64(m.Amount), 10)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if cents := int64(m.Amount*100+0.5) % 100; cents != 0 {
+		whole += fmt.Sprintf(".%02d", cents)
+	}
+	return symbol + whole
+}
+
+// String formats the distance, e.g. "0.5 mi"
+func (d Distance) String() string {
+	return strconv.FormatFloat(d.Value, 'f', -1, 64) + " " + d.Unit
+}
+
+var (
+	moneyRegex    = regexp.MustCompile(`([$€£¥])?\s*([0-9][0-9,]*(?:\.[0-9]+)?)\s*([kKmM])?\b`)
+	monthlyRegex  = regexp.MustCompile(`(?i)/\s*mo|month|monthly`)
+	annualRegex   = regexp.MustCompile(`(?i)/\s*yr|/\s*year|annual|yearly|per year`)
+	distanceRegex = regexp.MustCompile(`(?i)([0-9]*\.?[0-9]+)\s*(miles?|mi|kilometers?|km|feet|foot|ft|meters?|m)\b`)
+	scoreRegex    = regexp.MustCompile(`\b([0-9]{1,3})\b`)
+	eventRegex    = regexp.MustCompile(`(?i)\b(sold|listed|list|pending|contingent|price changed?|price cut|delisted|relisted|off market|expired)\b`)
+)
+
+// distanceUnits maps distance unit spellings to their abbreviations
+var distanceUnits = map[string]string{
+	"mile": "mi", "miles": "mi", "mi": "mi",
+	"kilometer": "km", "kilometers": "km", "km": "km",
+	"feet": "ft", "foot": "ft", "ft": "ft",
+	"meter": "m", "meters": "m", "m": "m",
+}
+
+// dateLayouts are the date formats listing sites use
+var dateLayouts = []string{"2006-01-02", "Jan 2, 2006", "January 2, 2006", "Jan 2 2006", "1/2/2006", "01/02/2006", "2 Jan 2006"}
+
+// dateRegex finds a date in text such as "SOLD JAN 5, 2025" or "Sold on 3/14/2025"
+var dateRegex = regexp.MustCompile(`(?i)\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4}|[a-z]{3,9}\.? \d{1,2},? \d{4}|\d{1,2} [a-z]{3,9} \d{4}`)
+
+// parseMoney reads an amount such as "$1,234", "$450/mo" or "$4,120 annually".
+// The period defaults to defaultPeriod when the text does not name one.
+func parseMoney(s, defaultPeriod string) *Money {
+	match := moneyRegex.FindStringSubmatch(s)
+	if match == nil {
+		return nil
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(match[2], ",", ""), 64)
+	if err != nil || amount == 0 {
+		return nil
+	}
+	switch strings.ToLower(match[3]) {
+	case "k":
+		amount *= 1e3
+	case "m":
+		amount *= 1e6
+	}
+	money := &Money{Amount: amount, Currency: "USD", Period: defaultPeriod}
+	if code, ok := currencySymbols[match[1]]; ok {
+		money.Currency = code
+	}
+	if monthlyRegex.MatchString(s) {
+		money.Period = PeriodMonthly
+	} else if annualRegex.MatchString(s) {
+		money.Period = PeriodAnnual
+	}
+	return money
+}
+
+// parseDistance reads a distance such as "0.5 miles" or "800 ft"
+func parseDistance(s string) *Distance {
+	match := distanceRegex.FindStringSubmatch(s)
+	if match == nil {
+		return nil
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil
+	}
+	return &Distance{Value: value, Unit: distanceUnits[strings.ToLower(match[2])]}
+}
+
+// parseScore reads a 0-100 score such as "72" or "Walk Score 72/100"
+func parseScore(s string) int {
+	match := scoreRegex.FindStringSubmatch(s)
+	if match == nil {
+		return 0
+	}
+	score, _ := strconv.Atoi(match[1])
+	if score > 100 {
+		return 0
+	}
+	return score
+}
+
+// parseDate finds a date in text such as "SOLD JAN 5, 2025" or "2025-01-05"
+func parseDate(s string) Date {
+	text := dateRegex.FindString(s)
+	if text == "" {
+		return Date{}
+	}
+	// time.Parse matches month names in any case
+	text = strings.Replace(text, ".", "", 1)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return Date{t}
+		}
+	}
+	return Date{}
+}
+
+// parsePriceEvent reads a price history entry such as "Sold $1,200,000 on Jan 5, 2025"
+func parsePriceEvent(s string) (PriceEvent, bool) {
+	event := PriceEvent{Date: parseDate(s)}
+	if match := eventRegex.FindString(s); match != "" {
+		event.Event = strings.ToLower(match)
+	}
+	// Skip the date's digits when looking for the price
+	if price := parseMoney(dateRegex.ReplaceAllString(s, ""), ""); price != nil {
+		event.Price = int64(price.Amount)
+	}
+	return event, event.Price > 0 || !event.Date.IsZero()
+}
+
+// newPropertyRef reads a comparable given as a property URL or a street
+// address, taking the city and state from the subject property when absent
+func newPropertyRef(s, city, state string) PropertyRef {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+		return PropertyRef{URL: s}
+	}
+	ref := PropertyRef{Address: s, City: city, State: state}
+	if price := strings.Index(s, "$"); price > 0 {
+		ref.Address = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s[:price]), "-–,:"))
+		if money := parseMoney(s[price:], ""); money != nil {
+			ref.Price = int64(money.Amount)
+		}
+	}
+	ref.ID = scraper.PropertyID(ref.Address, ref.City, ref.State)
+	return ref
+}
+
+// String formats the event the way price histories list it, e.g. "JAN 5, 2025: sold $1,200,000"
+func (e PriceEvent) String() string {
+	parts := []string{}
+	if !e.Date.IsZero() {
+		parts = append(parts, e.Date.String()+":")
+	}
+	if e.Event != "" {
+		parts = append(parts, e.Event)
+	}
+	if e.Price > 0 {
+		parts = append(parts, Money{Amount: float64(e.Price), Currency: "USD"}.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// String formats the reference as its address, or its URL when the address is unknown
+func (r PropertyRef) String() string {
+	if r.Address == "" {
+		return r.URL
+	}
+	return r.Address
+}
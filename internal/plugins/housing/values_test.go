@@ -0,0 +1,118 @@
+package housing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+func TestParseValues(t *testing.T) {
+	moneyTests := []struct {
+		in, period string
+		want       *Money
+	}{
+		{"$4,120", PeriodAnnual, &Money{Amount: 4120, Currency: "USD", Period: PeriodAnnual}},
+		{"$450/mo", PeriodAnnual, &Money{Amount: 450, Currency: "USD", Period: PeriodMonthly}},
+		{"€1,234.50 per year", PeriodMonthly, &Money{Amount: 1234.5, Currency: "EUR", Period: PeriodAnnual}},
+		{"$1.2M", "", &Money{Amount: 1.2e6, Currency: "USD"}},
+		{"N/A", PeriodAnnual, nil},
+	}
+	for _, tt := range moneyTests {
+		got := parseMoney(tt.in, tt.period)
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("parseMoney(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	if d := parseDistance("0.5 miles to the beach"); d == nil || *d != (Distance{Value: 0.5, Unit: "mi"}) {
+		t.Errorf("parseDistance = %+v", d)
+	}
+	if d := parseDistance("800 ft"); d == nil || *d != (Distance{Value: 800, Unit: "ft"}) {
+		t.Errorf("parseDistance = %+v", d)
+	}
+	if score := parseScore("Walk Score 72/100"); score != 72 {
+		t.Errorf("parseScore = %d", score)
+	}
+
+	want := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	for _, in := range []string{"SOLD JAN 5, 2025", "2025-01-05", "Sold on 1/5/2025", "January 5, 2025"} {
+		if got := parseDate(in); !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %v", in, got)
+		}
+	}
+
+	event, ok := parsePriceEvent("Sold $1,200,000 on Jan 5, 2025")
+	if !ok || event.Event != "sold" || event.Price != 1200000 || !event.Date.Equal(want) {
+		t.Errorf("parsePriceEvent = %+v, %v", event, ok)
+	}
+}
+
+func TestLegacyPropertyData(t *testing.T) {
+	scraped := scraper.Property{
+		ID: "prop_2819-poelua-st-honolulu-hi", Address: "2819 Poelua St", City: "Honolulu", State: "HI",
+		Price: 1325000, SoldDate: "JAN 17, 2025", PropertyTax: "$4,120", WalkScore: "41",
+		DistanceToBeach: "2.5 mi", PriceHistory: []string{"Listed $1,350,000 on Nov 2, 2024"},
+		NearbyComparables: []string{"2714 Hipawai Pl - $1,600,000"},
+	}
+	property := newPropertyData(scraped, "redfin.com", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	if property.PropertyTax == nil || property.PropertyTax.Period != PeriodAnnual || property.WalkScore != 41 {
+		t.Errorf("typed property = %+v", property)
+	}
+	if len(property.NearbyComparables) != 1 || property.NearbyComparables[0].ID != "prop_2714-hipawai-pl-honolulu-hi" || property.NearbyComparables[0].Price != 1600000 {
+		t.Errorf("comparables = %+v", property.NearbyComparables)
+	}
+
+	typed, _ := json.Marshal(property)
+	var typedFields map[string]interface{}
+	json.Unmarshal(typed, &typedFields)
+	if typedFields["soldDate"] != "2025-01-17" {
+		t.Errorf("typed soldDate = %v", typedFields["soldDate"])
+	}
+
+	legacy, err := json.Marshal(property.Legacy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(legacy, &fields)
+	wantStrings := map[string]string{
+		"soldDate": "JAN 17, 2025", "propertyTax": "$4,120", "hoaFees": "", "walkScore": "41",
+		"distanceToBeach": "2.5 mi", "address": "2819 Poelua St",
+	}
+	for field, want := range wantStrings {
+		if fields[field] != want {
+			t.Errorf("legacy %s = %#v, want %q", field, fields[field], want)
+		}
+	}
+	history, _ := fields["priceHistory"].([]interface{})
+	if len(history) != 1 || history[0] != "NOV 2, 2024: listed $1,350,000" {
+		t.Errorf("legacy priceHistory = %#v", fields["priceHistory"])
+	}
+	comparables, _ := fields["nearbyComparables"].([]interface{})
+	if len(comparables) != 1 || comparables[0] != "2714 Hipawai Pl" {
+		t.Errorf("legacy nearbyComparables = %#v", fields["nearbyComparables"])
+	}
+}
+
+func TestOutputFormatDefaultsToLegacy(t *testing.T) {
+	p := NewPlugin()
+	// Older clients send no format and keep the original shape
+	if format, err := p.outputFormat(map[string]interface{}{}); err != nil || format != FormatLegacy {
+		t.Errorf("default format = %q, %v", format, err)
+	}
+	if format, _ := p.outputFormat(map[string]interface{}{"format": FormatTyped}); format != FormatTyped {
+		t.Errorf("requested format = %q", format)
+	}
+	if err := p.SetDefaultFormat(FormatTyped); err != nil {
+		t.Fatal(err)
+	}
+	if format, _ := p.outputFormat(map[string]interface{}{}); format != FormatTyped {
+		t.Errorf("configured format = %q", format)
+	}
+	if _, err := p.outputFormat(map[string]interface{}{"format": "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/johan-j/play-mcp/pkg/mcp"
@@ -70,3 +71,19 @@ func (r *Registry) HandleToolCall(ctx context.Context, request mcp.ToolCallReque
 	}
 	return nil, fmt.Errorf("tool %s not found", request.Name)
 }
+
+// ReadResource reads a resource from the first plugin that serves its URI
+func (r *Registry) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	for _, plugin := range r.plugins {
+		reader, ok := plugin.(mcp.ResourceReader)
+		if !ok {
+			continue
+		}
+		result, err := reader.ReadResource(ctx, uri)
+		if errors.Is(err, mcp.ErrResourceNotFound) {
+			continue
+		}
+		return result, err
+	}
+	return nil, fmt.Errorf("%w: %s", mcp.ErrResourceNotFound, uri)
+}
@@ -3,25 +3,32 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/websocket"
 	"github.com/johan-j/play-mcp/internal/plugins"
+	"github.com/johan-j/play-mcp/pkg/export"
 	"github.com/johan-j/play-mcp/pkg/mcp"
+	"github.com/johan-j/play-mcp/pkg/scheduler"
 	"github.com/sirupsen/logrus"
 )
 
 // MCPServer represents the main MCP server
 type MCPServer struct {
-	registry *plugins.Registry
-	logger   *logrus.Logger
-	upgrader websocket.Upgrader
-	clients  map[*websocket.Conn]*Client
-	mutex    sync.RWMutex
+	registry  *plugins.Registry
+	logger    *logrus.Logger
+	upgrader  websocket.Upgrader
+	clients   map[*websocket.Conn]*Client
+	mutex     sync.RWMutex
+	scheduler *scheduler.Scheduler
+	exports   *export.Store
 }
 
 // Client represents a connected MCP client
@@ -51,6 +58,16 @@ func NewMCPServer(registry *plugins.Registry, logger *logrus.Logger) *MCPServer
 	}
 }
 
+// SetScheduler exposes a scheduler's jobs on the /jobs routes
+func (s *MCPServer) SetScheduler(sched *scheduler.Scheduler) {
+	s.scheduler = sched
+}
+
+// SetExports serves a store's exported files at /exports/{id}
+func (s *MCPServer) SetExports(store *export.Store) {
+	s.exports = store
+}
+
 // Start starts the MCP server
 func (s *MCPServer) Start(config Config) error {
 	r := chi.NewRouter()
@@ -67,6 +84,10 @@ func (s *MCPServer) Start(config Config) error {
 	r.Get("/health", s.handleHealth)
 	r.Get("/tools", s.handleToolsList)
 	r.Get("/resources", s.handleResourcesList)
+	r.Get("/jobs", s.handleJobsList)
+	r.Get("/jobs/*", s.handleJobStatus)
+	r.Post("/jobs/*", s.handleJobRun)
+	r.Get("/exports/{id}", s.handleExportDownload)
 
 	address := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	s.logger.Infof("Starting MCP server on %s", address)
@@ -133,6 +154,8 @@ func (s *MCPServer) handleMessage(client *Client, message *mcp.Message) *mcp.Mes
 		return s.handleToolCall(client, message)
 	case "resources/list":
 		return s.handleResourcesList2(client, message)
+	case "resources/read":
+		return s.handleResourcesRead(client, message)
 	default:
 		return &mcp.Message{
 			JSONRPC: "2.0",
@@ -283,6 +306,57 @@ func (s *MCPServer) handleResourcesList2(client *Client, message *mcp.Message) *
 	}
 }
 
+// handleResourcesRead handles resources/read requests via WebSocket
+func (s *MCPServer) handleResourcesRead(client *Client, message *mcp.Message) *mcp.Message {
+	if !client.initialized {
+		return &mcp.Message{
+			JSONRPC: "2.0",
+			ID:      message.ID,
+			Error: &mcp.Error{
+				Code:    -32002,
+				Message: "Client not initialized",
+			},
+		}
+	}
+
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(message.Params, &params); err != nil || params.URI == "" {
+		return &mcp.Message{
+			JSONRPC: "2.0",
+			ID:      message.ID,
+			Error: &mcp.Error{
+				Code:    -32602,
+				Message: "Invalid resource read parameters",
+			},
+		}
+	}
+
+	result, err := s.registry.ReadResource(context.Background(), params.URI)
+	if err != nil {
+		return &mcp.Message{
+			JSONRPC: "2.0",
+			ID:      message.ID,
+			Error:   resourceReadError(err),
+		}
+	}
+
+	responseData, _ := json.Marshal(result)
+
+	return &mcp.Message{
+		JSONRPC: "2.0",
+		ID:      message.ID,
+		Result:  responseData,
+	}
+}
+
+// resourceReadError converts a failed resource read to an MCP error
+func resourceReadError(err error) *mcp.Error {
+	if errors.Is(err, mcp.ErrResourceNotFound) {
+		return &mcp.Error{Code: -32002, Message: "Resource not found", Data: err.Error()}
+	}
+	return &mcp.Error{Code: -32603, Message: "Resource read failed", Data: err.Error()}
+}
+
 // HTTP handlers for REST API access
 
 // handleHealth handles health check requests
@@ -313,6 +387,88 @@ func (s *MCPServer) handleResourcesList(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handleJobsList handles HTTP requests for the status of every scheduled job
+func (s *MCPServer) handleJobsList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	jobs := []scheduler.Status{}
+	if s.scheduler != nil {
+		jobs = s.scheduler.Statuses()
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs": jobs,
+	})
+}
+
+// handleJobStatus handles HTTP requests for one job's status at /jobs/{name}
+func (s *MCPServer) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.scheduler == nil {
+		s.writeJobError(w, scheduler.ErrUnknownJob)
+		return
+	}
+	status, err := s.scheduler.Status(chi.URLParam(r, "*"))
+	if err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleJobRun handles HTTP requests to start a job now at /jobs/{name}/run
+func (s *MCPServer) handleJobRun(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	name, ok := strings.CutSuffix(chi.URLParam(r, "*"), "/run")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "not found"})
+		return
+	}
+	if s.scheduler == nil {
+		s.writeJobError(w, scheduler.ErrUnknownJob)
+		return
+	}
+	if err := s.scheduler.Trigger(name); err != nil {
+		s.writeJobError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job":    name,
+		"status": "started",
+	})
+}
+
+// writeJobError writes a scheduler error with a matching status code
+func (s *MCPServer) writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, scheduler.ErrJobRunning):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
+
+// handleExportDownload serves an exported file until it expires
+func (s *MCPServer) handleExportDownload(w http.ResponseWriter, r *http.Request) {
+	var file *export.File
+	ok := false
+	if s.exports != nil {
+		file, ok = s.exports.Get(chi.URLParam(r, "id"))
+	}
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "export not found or expired"})
+		return
+	}
+	w.Header().Set("Content-Type", file.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.Write(file.Data)
+}
+
 // handleHTTPMCP handles HTTP MCP JSON-RPC requests
 func (s *MCPServer) handleHTTPMCP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -341,6 +497,8 @@ func (s *MCPServer) handleHTTPMCP(w http.ResponseWriter, r *http.Request) {
 		s.handleHTTPToolCall(w, request)
 	case "resources/list":
 		s.handleHTTPResourcesList(w, request)
+	case "resources/read":
+		s.handleHTTPResourcesRead(w, request)
 	default:
 		response := mcp.JSONRPCResponse{
 			JSONRPC: "2.0",
@@ -410,6 +568,36 @@ func (s *MCPServer) handleHTTPResourcesList(w http.ResponseWriter, request mcp.J
 	json.NewEncoder(w).Encode(response)
 }
 
+func (s *MCPServer) handleHTTPResourcesRead(w http.ResponseWriter, request mcp.JSONRPCRequest) {
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(request.Params, &params); err != nil || params.URI == "" {
+		response := mcp.JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &mcp.JSONRPCError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := mcp.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+	}
+	result, err := s.registry.ReadResource(context.Background(), params.URI)
+	if err != nil {
+		readErr := resourceReadError(err)
+		response.Error = &mcp.JSONRPCError{Code: readErr.Code, Message: readErr.Message, Data: readErr.Data}
+	} else {
+		response.Result = result
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
 func (s *MCPServer) handleHTTPToolCall(w http.ResponseWriter, request mcp.JSONRPCRequest) {
 	var params mcp.ToolCallParams
 	if err := json.Unmarshal(request.Params, &params); err != nil {
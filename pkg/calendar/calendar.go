@@ -0,0 +1,397 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Exchange time zones must resolve even without system tzdata
+)
+
+// Market states reported by Status
+const (
+	StatePre     = "pre"
+	StateRegular = "regular"
+	StatePost    = "post"
+	StateClosed  = "closed"
+)
+
+// Clock is a time of day in an exchange's local time zone
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+func (c Clock) on(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), c.Hour, c.Minute, 0, 0, date.Location())
+}
+
+// Calendar describes an exchange's trading sessions and holidays
+type Calendar struct {
+	Exchange   string
+	Name       string
+	Location   *time.Location
+	PreOpen    Clock
+	Open       Clock
+	Close      Clock
+	PostClose  Clock
+	EarlyClose Clock // Regular session close on early-close days
+	// holidays returns the full-day closures and early-close days for a year,
+	// keyed by YYYY-MM-DD. A nil func means the exchange only closes on weekends.
+	holidays func(year int) (map[string]string, map[string]string)
+	years    sync.Map // year -> [2]map[string]string
+}
+
+// Session represents the trading hours of one day
+type Session struct {
+	Date       string    `json:"date"`
+	PreOpen    time.Time `json:"preOpen"`
+	Open       time.Time `json:"open"`
+	Close      time.Time `json:"close"`
+	PostClose  time.Time `json:"postClose"`
+	EarlyClose bool      `json:"earlyClose"`
+	Note       string    `json:"note,omitempty"`
+}
+
+// Status represents the state of a market at a point in time
+type Status struct {
+	Exchange     string    `json:"exchange"`
+	TimeZone     string    `json:"timeZone"`
+	LocalTime    time.Time `json:"localTime"`
+	State        string    `json:"state"`
+	IsTradingDay bool      `json:"isTradingDay"`
+	Holiday      string    `json:"holiday,omitempty"`
+	EarlyClose   bool      `json:"earlyClose"`
+	Session      *Session  `json:"session,omitempty"`
+	LastClose    time.Time `json:"lastClose"`
+	NextOpen     time.Time `json:"nextOpen"`
+}
+
+var calendars = map[string]*Calendar{}
+
+func register(c *Calendar) *Calendar {
+	calendars[c.Exchange] = c
+	return c
+}
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("calendar: failed to load time zone %s: %v", name, err))
+	}
+	return loc
+}
+
+// closures returns the cached holiday and early-close tables for a year
+func (c *Calendar) closures(year int) (map[string]string, map[string]string) {
+	if c.holidays == nil {
+		return nil, nil
+	}
+	if cached, ok := c.years.Load(year); ok {
+		tables := cached.([2]map[string]string)
+		return tables[0], tables[1]
+	}
+	closed, early := c.holidays(year)
+	c.years.Store(year, [2]map[string]string{closed, early})
+	return closed, early
+}
+
+var newYork = mustLoad("America/New_York")
+
+// NYSE is the New York Stock Exchange calendar
+var NYSE = register(&Calendar{
+	Exchange:   "NYSE",
+	Name:       "New York Stock Exchange",
+	Location:   newYork,
+	PreOpen:    Clock{4, 0},
+	Open:       Clock{9, 30},
+	Close:      Clock{16, 0},
+	PostClose:  Clock{20, 0},
+	EarlyClose: Clock{13, 0},
+	holidays:   usEquityHolidays,
+})
+
+// NASDAQ shares the NYSE holiday schedule and session hours
+var NASDAQ = register(&Calendar{
+	Exchange:   "NASDAQ",
+	Name:       "Nasdaq Stock Market",
+	Location:   newYork,
+	PreOpen:    Clock{4, 0},
+	Open:       Clock{9, 30},
+	Close:      Clock{16, 0},
+	PostClose:  Clock{20, 0},
+	EarlyClose: Clock{13, 0},
+	holidays:   usEquityHolidays,
+})
+
+func init() {
+	// Non-US venues are modelled with their local regular hours only; their
+	// holiday schedules are not tracked, so only weekends are treated as closed.
+	for _, c := range []*Calendar{
+		{Exchange: "TSE", Name: "Tokyo Stock Exchange", Location: mustLoad("Asia/Tokyo"), Open: Clock{9, 0}, Close: Clock{15, 0}},
+		{Exchange: "LSE", Name: "London Stock Exchange", Location: mustLoad("Europe/London"), Open: Clock{8, 0}, Close: Clock{16, 30}},
+		{Exchange: "XETRA", Name: "Xetra", Location: mustLoad("Europe/Berlin"), Open: Clock{9, 0}, Close: Clock{17, 30}},
+		{Exchange: "TSX", Name: "Toronto Stock Exchange", Location: mustLoad("America/Toronto"), Open: Clock{9, 30}, Close: Clock{16, 0}},
+	} {
+		c.PreOpen, c.PostClose, c.EarlyClose = c.Open, c.Close, c.Close
+		register(c)
+	}
+}
+
+// ForExchange returns the calendar for an exchange code such as NYSE or NASDAQ
+func ForExchange(code string) (*Calendar, error) {
+	c, ok := calendars[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return nil, fmt.Errorf("unknown exchange: %s (supported: %s)", code, strings.Join(Exchanges(), ", "))
+	}
+	return c, nil
+}
+
+// Exchanges returns the supported exchange codes
+func Exchanges() []string {
+	codes := make([]string, 0, len(calendars))
+	for code := range calendars {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Holiday returns the holiday name if the exchange is closed all day on date
+func (c *Calendar) Holiday(date time.Time) (string, bool) {
+	date = date.In(c.Location)
+	closed, _ := c.closures(date.Year())
+	name, ok := closed[date.Format("2006-01-02")]
+	return name, ok
+}
+
+// IsEarlyClose reports whether the regular session ends early on date
+func (c *Calendar) IsEarlyClose(date time.Time) (string, bool) {
+	date = date.In(c.Location)
+	_, early := c.closures(date.Year())
+	name, ok := early[date.Format("2006-01-02")]
+	return name, ok
+}
+
+// IsTradingDay reports whether the exchange holds a regular session on date
+func (c *Calendar) IsTradingDay(date time.Time) bool {
+	date = date.In(c.Location)
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := c.Holiday(date)
+	return !holiday
+}
+
+// SessionOn returns the trading session for date, or nil if the market is closed
+func (c *Calendar) SessionOn(date time.Time) *Session {
+	date = date.In(c.Location)
+	if !c.IsTradingDay(date) {
+		return nil
+	}
+
+	session := &Session{
+		Date:      date.Format("2006-01-02"),
+		PreOpen:   c.PreOpen.on(date),
+		Open:      c.Open.on(date),
+		Close:     c.Close.on(date),
+		PostClose: c.PostClose.on(date),
+	}
+	if note, early := c.IsEarlyClose(date); early {
+		session.EarlyClose = true
+		session.Note = note
+		session.Close = c.EarlyClose.on(date)
+		// Extended hours end four hours after an early close, as with a normal close
+		session.PostClose = session.Close.Add(c.PostClose.on(date).Sub(c.Close.on(date)))
+	}
+	return session
+}
+
+// Status returns the market state at t
+func (c *Calendar) Status(t time.Time) Status {
+	local := t.In(c.Location)
+	status := Status{
+		Exchange:     c.Exchange,
+		TimeZone:     c.Location.String(),
+		LocalTime:    local,
+		State:        StateClosed,
+		IsTradingDay: c.IsTradingDay(local),
+		LastClose:    c.LastClose(t),
+		NextOpen:     c.NextOpen(t),
+	}
+	status.Holiday, _ = c.Holiday(local)
+
+	if session := c.SessionOn(local); session != nil {
+		status.Session = session
+		status.EarlyClose = session.EarlyClose
+		switch {
+		case local.Before(session.PreOpen):
+		case local.Before(session.Open):
+			status.State = StatePre
+		case local.Before(session.Close):
+			status.State = StateRegular
+		case local.Before(session.PostClose):
+			status.State = StatePost
+		}
+	}
+	return status
+}
+
+// LastClose returns the most recent regular-session close at or before t
+func (c *Calendar) LastClose(t time.Time) time.Time {
+	local := t.In(c.Location)
+	for day := local; ; day = day.AddDate(0, 0, -1) {
+		if session := c.SessionOn(day); session != nil && !session.Close.After(local) {
+			return session.Close
+		}
+	}
+}
+
+// NextOpen returns the next regular-session open strictly after t
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	local := t.In(c.Location)
+	for day := local; ; day = day.AddDate(0, 0, 1) {
+		if session := c.SessionOn(day); session != nil && session.Open.After(local) {
+			return session.Open
+		}
+	}
+}
+
+// TradingDays returns the dates with a regular session between start and end inclusive
+func (c *Calendar) TradingDays(start, end time.Time) []time.Time {
+	var days []time.Time
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, c.Location)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, c.Location)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if c.IsTradingDay(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// usEquityHolidays implements the NYSE holiday rules. Holidays falling on a
+// Saturday are observed the Friday before and on a Sunday the Monday after,
+// except New Year's Day, which is not moved back into the previous year.
+func usEquityHolidays(year int) (map[string]string, map[string]string) {
+	closed := map[string]string{}
+	early := map[string]string{}
+
+	add := func(date time.Time, name string) {
+		closed[date.Format("2006-01-02")] = name
+	}
+	observed := func(date time.Time) time.Time {
+		switch date.Weekday() {
+		case time.Saturday:
+			return date.AddDate(0, 0, -1)
+		case time.Sunday:
+			return date.AddDate(0, 0, 1)
+		}
+		return date
+	}
+	fixed := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	if newYear := fixed(time.January, 1); newYear.Weekday() != time.Saturday {
+		add(observed(newYear), "New Year's Day")
+	}
+	add(nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King Jr. Day")
+	add(nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday")
+	add(easterSunday(year).AddDate(0, 0, -2), "Good Friday")
+	add(lastWeekday(year, time.May, time.Monday), "Memorial Day")
+	if year >= 2022 {
+		add(observed(fixed(time.June, 19)), "Juneteenth National Independence Day")
+	}
+	add(observed(fixed(time.July, 4)), "Independence Day")
+	add(nthWeekday(year, time.September, time.Monday, 1), "Labor Day")
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving, "Thanksgiving Day")
+	add(observed(fixed(time.December, 25)), "Christmas Day")
+
+	// Early closes apply only when the day is otherwise a normal trading day
+	for date, name := range map[time.Time]string{
+		fixed(time.July, 3):           "Independence Day (observed early close)",
+		thanksgiving.AddDate(0, 0, 1): "Day after Thanksgiving",
+		fixed(time.December, 24):      "Christmas Eve",
+	} {
+		key := date.Format("2006-01-02")
+		if _, holiday := closed[key]; holiday || date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		early[key] = name
+	}
+
+	return closed, early
+}
+
+// nthWeekday returns the nth occurrence of weekday in the month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last occurrence of weekday in the month
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easterSunday computes Western Easter using the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// Closure represents a full-day holiday or an early close
+type Closure struct {
+	Date       string `json:"date"`
+	Name       string `json:"name"`
+	EarlyClose bool   `json:"earlyClose"`
+}
+
+// UpcomingClosures returns up to n holidays and early closes on or after t
+func (c *Calendar) UpcomingClosures(t time.Time, n int) []Closure {
+	closures := []Closure{}
+	if c.holidays == nil {
+		return closures
+	}
+
+	local := t.In(c.Location)
+	today := local.Format("2006-01-02")
+	for year := local.Year(); year <= local.Year()+1 && len(closures) < n; year++ {
+		closed, early := c.closures(year)
+
+		var yearClosures []Closure
+		for date, name := range closed {
+			yearClosures = append(yearClosures, Closure{Date: date, Name: name})
+		}
+		for date, name := range early {
+			yearClosures = append(yearClosures, Closure{Date: date, Name: name, EarlyClose: true})
+		}
+		sort.Slice(yearClosures, func(i, j int) bool { return yearClosures[i].Date < yearClosures[j].Date })
+
+		for _, closure := range yearClosures {
+			if closure.Date >= today && len(closures) < n {
+				closures = append(closures, closure)
+			}
+		}
+	}
+	return closures
+}
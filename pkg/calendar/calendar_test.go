@@ -0,0 +1,113 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUSEquityHolidays(t *testing.T) {
+	// Published NYSE holiday and early-close schedules
+	tests := []struct {
+		year   int
+		closed []string
+		early  []string
+	}{
+		{
+			year:   2024,
+			closed: []string{"2024-01-01", "2024-01-15", "2024-02-19", "2024-03-29", "2024-05-27", "2024-06-19", "2024-07-04", "2024-09-02", "2024-11-28", "2024-12-25"},
+			early:  []string{"2024-07-03", "2024-11-29", "2024-12-24"},
+		},
+		{
+			year:   2025,
+			closed: []string{"2025-01-01", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26", "2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25"},
+			early:  []string{"2025-07-03", "2025-11-28", "2025-12-24"},
+		},
+		{
+			// New Year's Day on a Saturday is not observed; Juneteenth and
+			// Christmas fall on weekends and move to the adjacent weekday
+			year:   2022,
+			closed: []string{"2022-01-17", "2022-02-21", "2022-04-15", "2022-05-30", "2022-06-20", "2022-07-04", "2022-09-05", "2022-11-24", "2022-12-26"},
+			early:  []string{"2022-11-25"},
+		},
+	}
+
+	for _, tt := range tests {
+		closed, early := usEquityHolidays(tt.year)
+		if len(closed) != len(tt.closed) {
+			t.Errorf("%d: got %d holidays, want %d: %v", tt.year, len(closed), len(tt.closed), closed)
+		}
+		for _, date := range tt.closed {
+			if _, ok := closed[date]; !ok {
+				t.Errorf("%d: expected holiday on %s", tt.year, date)
+			}
+		}
+		if len(early) != len(tt.early) {
+			t.Errorf("%d: got %d early closes, want %d: %v", tt.year, len(early), len(tt.early), early)
+		}
+		for _, date := range tt.early {
+			if _, ok := early[date]; !ok {
+				t.Errorf("%d: expected early close on %s", tt.year, date)
+			}
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	at := func(value string) time.Time {
+		ts, err := time.ParseInLocation("2006-01-02 15:04", value, NYSE.Location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		name      string
+		at        string
+		state     string
+		lastClose string
+		nextOpen  string
+	}{
+		{"pre-market", "2024-03-05 08:00", StatePre, "2024-03-04 16:00", "2024-03-05 09:30"},
+		{"regular session", "2024-03-05 10:00", StateRegular, "2024-03-04 16:00", "2024-03-06 09:30"},
+		{"after hours", "2024-03-05 17:30", StatePost, "2024-03-05 16:00", "2024-03-06 09:30"},
+		{"overnight", "2024-03-05 23:00", StateClosed, "2024-03-05 16:00", "2024-03-06 09:30"},
+		{"weekend", "2024-03-09 12:00", StateClosed, "2024-03-08 16:00", "2024-03-11 09:30"},
+		{"holiday", "2024-11-28 12:00", StateClosed, "2024-11-27 16:00", "2024-11-29 09:30"},
+		{"early close", "2024-11-29 14:00", StatePost, "2024-11-29 13:00", "2024-12-02 09:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := NYSE.Status(at(tt.at))
+			if status.State != tt.state {
+				t.Errorf("State = %s, want %s", status.State, tt.state)
+			}
+			if !status.LastClose.Equal(at(tt.lastClose)) {
+				t.Errorf("LastClose = %v, want %s", status.LastClose, tt.lastClose)
+			}
+			if !status.NextOpen.Equal(at(tt.nextOpen)) {
+				t.Errorf("NextOpen = %v, want %s", status.NextOpen, tt.nextOpen)
+			}
+		})
+	}
+}
+
+func TestTradingDays(t *testing.T) {
+	start := time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	var got []string
+	for _, day := range NASDAQ.TradingDays(start, end) {
+		got = append(got, day.Format("2006-01-02"))
+	}
+	want := []string{"2024-12-23", "2024-12-24", "2024-12-26", "2024-12-27", "2024-12-30", "2024-12-31", "2025-01-02", "2025-01-03"}
+	if len(got) != len(want) {
+		t.Fatalf("TradingDays = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("TradingDays[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Export formats
+const (
+	FormatCSV     = "csv"
+	FormatXLSX    = "xlsx"
+	FormatGeoJSON = "geojson"
+	FormatParquet = "parquet"
+)
+
+// Formats lists the export formats
+var Formats = []string{FormatCSV, FormatXLSX, FormatGeoJSON, FormatParquet}
+
+// mimeTypes maps formats to the media types files are served with
+var mimeTypes = map[string]string{
+	FormatCSV:     "text/csv",
+	FormatXLSX:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatGeoJSON: "application/geo+json",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// Columns GeoJSON features take their coordinates from
+const (
+	LatitudeColumn  = "latitude"
+	LongitudeColumn = "longitude"
+)
+
+// File is an exported file
+type File struct {
+	Name     string // File name with extension, e.g. "sold-honolulu-hi.csv"
+	MimeType string
+	Data     []byte
+	Rows     int
+	Columns  int
+}
+
+// Binary reports whether the file is binary rather than text
+func (f *File) Binary() bool {
+	return !strings.HasPrefix(f.MimeType, "text/") && f.MimeType != mimeTypes[FormatGeoJSON]
+}
+
+// Encode writes a table in a format, keeping only the named columns when any
+// are given. GeoJSON keeps only rows with coordinates, as point features.
+func Encode(t *Table, name, format string, columns []string) (*File, error) {
+	mimeType, ok := mimeTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q (available: %s)", format, strings.Join(Formats, ", "))
+	}
+
+	// GeoJSON reads coordinates before columns are selected, so they need
+	// not be exported as properties too
+	var points [][2]float64
+	if format == FormatGeoJSON {
+		var err error
+		if t, points, err = located(t); err != nil {
+			return nil, err
+		}
+	}
+	t, err := t.Select(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	switch format {
+	case FormatCSV:
+		data, err = encodeCSV(t)
+	case FormatXLSX:
+		data, err = encodeXLSX(t)
+	case FormatGeoJSON:
+		data, err = encodeGeoJSON(t, points)
+	case FormatParquet:
+		data, err = encodeParquet(t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", format, err)
+	}
+	return &File{
+		Name:     name + "." + format,
+		MimeType: mimeType,
+		Data:     data,
+		Rows:     len(t.Rows),
+		Columns:  len(t.Columns),
+	}, nil
+}
+
+func encodeCSV(t *Table) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(t.Columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, value := range row {
+			record[i] = text(value)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// located returns the rows of a table with coordinates and their [lon, lat]
+// positions
+func located(t *Table) (*Table, [][2]float64, error) {
+	lat, lon := t.Column(LatitudeColumn), t.Column(LongitudeColumn)
+	if lat < 0 || lon < 0 {
+		return nil, nil, fmt.Errorf("GeoJSON export needs %s and %s, which these results do not have", LatitudeColumn, LongitudeColumn)
+	}
+	kept := &Table{Columns: t.Columns}
+	var points [][2]float64
+	for _, row := range t.Rows {
+		y, okLat := row[lat].(float64)
+		x, okLon := row[lon].(float64)
+		if !okLat || !okLon || x == 0 && y == 0 {
+			continue
+		}
+		kept.Rows = append(kept.Rows, row)
+		points = append(points, [2]float64{x, y})
+	}
+	if len(points) == 0 {
+		return nil, nil, fmt.Errorf("none of the results have coordinates to export as GeoJSON")
+	}
+	return kept, points, nil
+}
+
+// encodeGeoJSON writes a FeatureCollection of points with the table's
+// columns as properties, in column order
+func encodeGeoJSON(t *Table, points [][2]float64) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"type":"FeatureCollection","features":[`)
+	for i, row := range t.Rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		position, _ := json.Marshal(points[i])
+		fmt.Fprintf(&buf, `{"type":"Feature","geometry":{"type":"Point","coordinates":%s},"properties":{`, position)
+		for j, column := range t.Columns {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(column)
+			value, err := json.Marshal(row[j])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteString("}}")
+	}
+	buf.WriteString("]}\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,339 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/xuri/excelize/v2"
+)
+
+type money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type listing struct {
+	Address   string   `json:"address"`
+	Price     money    `json:"price"`
+	Beds      int      `json:"beds"`
+	Pool      *bool    `json:"pool,omitempty"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Features  []string `json:"features"`
+}
+
+func testListings() []listing {
+	pool := true
+	return []listing{
+		{Address: "2819 Poelua St", Price: money{1500000, "USD"}, Beds: 3, Latitude: 21.3124, Longitude: -157.8087, Features: []string{"view", "lanai"}},
+		{Address: "1 Kahala Ave", Price: money{925000.5, "USD"}, Beds: 2, Pool: &pool},
+	}
+}
+
+func TestNewTable(t *testing.T) {
+	table, err := NewTable(testListings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"address", "price.amount", "price.currency", "beds", "latitude", "longitude", "features", "pool"}
+	if !reflect.DeepEqual(table.Columns, want) {
+		t.Fatalf("columns = %v", table.Columns)
+	}
+	if table.Rows[0][6] != `["view","lanai"]` || table.Rows[0][7] != nil || table.Rows[1][7] != true {
+		t.Errorf("rows = %v", table.Rows)
+	}
+
+	selected, err := table.Select([]string{"price", "address"})
+	if err != nil || !reflect.DeepEqual(selected.Columns, []string{"price.amount", "price.currency", "address"}) {
+		t.Errorf("selected = %v, %v", selected, err)
+	}
+	if _, err := table.Select([]string{"bedrooms"}); err == nil || !strings.Contains(err.Error(), "address, beds") {
+		t.Errorf("unknown column error = %v", err)
+	}
+}
+
+func TestEncodeCSVAndGeoJSON(t *testing.T) {
+	table, _ := NewTable(testListings())
+	file, err := Encode(table, "sold", FormatCSV, []string{"address", "price.amount"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(file.Data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"address", "price.amount"}, {"2819 Poelua St", "1500000"}, {"1 Kahala Ave", "925000.5"}}
+	if !reflect.DeepEqual(records, want) || file.Name != "sold.csv" || file.Binary() {
+		t.Errorf("csv = %v (%s)", records, file.Name)
+	}
+
+	// Only the listing with coordinates becomes a feature
+	file, err = Encode(table, "sold", FormatGeoJSON, []string{"address"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(file.Data, &collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 1 || collection.Features[0].Geometry.Coordinates[0] != -157.8087 || len(collection.Features[0].Properties) != 1 {
+		t.Errorf("geojson = %s", file.Data)
+	}
+
+	prices, _ := NewTable([]money{{1, "USD"}})
+	if _, err := Encode(prices, "prices", FormatGeoJSON, nil); err == nil {
+		t.Error("expected an error exporting GeoJSON without coordinates")
+	}
+	if _, err := Encode(prices, "prices", "pdf", nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestEncodeXLSX(t *testing.T) {
+	table, _ := NewTable(testListings())
+	file, err := Encode(table, "sold", FormatXLSX, []string{"address", "beds"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workbook, err := excelize.OpenReader(bytes.NewReader(file.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := workbook.GetRows(sheetName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"address", "beds"}, {"2819 Poelua St", "3"}, {"1 Kahala Ave", "2"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v", rows)
+	}
+	if cellType, _ := workbook.GetCellType(sheetName, "B2"); cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString {
+		t.Errorf("beds were written as text")
+	}
+}
+
+func TestEncodeParquet(t *testing.T) {
+	table, _ := NewTable(testListings())
+	file, err := Encode(table, "sold", FormatParquet, []string{"address", "price.amount", "beds", "pool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := file.Data
+	if !bytes.HasPrefix(data, parquetMagic) || !bytes.HasSuffix(data, parquetMagic) {
+		t.Fatal("missing PAR1 magic")
+	}
+	length := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &compactReader{data: data[len(data)-8-length : len(data)-8]}
+	meta := r.readStruct()
+
+	if meta[3] != int64(2) {
+		t.Errorf("num_rows = %v", meta[3])
+	}
+	var names []string
+	var types []int64
+	for _, element := range meta[2].([]interface{})[1:] {
+		names = append(names, element.(map[int16]interface{})[4].(string))
+		types = append(types, element.(map[int16]interface{})[1].(int64))
+	}
+	if !reflect.DeepEqual(names, []string{"address", "price.amount", "beds", "pool"}) ||
+		!reflect.DeepEqual(types, []int64{parquetByteArray, parquetDouble, parquetInt64, parquetBoolean}) {
+		t.Errorf("schema = %v %v", names, types)
+	}
+
+	// Read back the price column's page: definition levels, then doubles
+	chunks := meta[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	column := chunks[1].(map[int16]interface{})[3].(map[int16]interface{})
+	page := &compactReader{data: data[column[9].(int64):]}
+	header := page.readStruct()
+	body := page.data[page.pos : page.pos+int(header[3].(int64))]
+	levelsLength := int(binary.LittleEndian.Uint32(body))
+	values := body[4+levelsLength:]
+	got := []float64{
+		math.Float64frombits(binary.LittleEndian.Uint64(values)),
+		math.Float64frombits(binary.LittleEndian.Uint64(values[8:])),
+	}
+	if !reflect.DeepEqual(got, []float64{1500000, 925000.5}) {
+		t.Errorf("prices = %v", got)
+	}
+}
+
+func TestParquetReadsBack(t *testing.T) {
+	table, _ := NewTable(testListings())
+	file, err := Encode(table, "sold", FormatParquet, []string{"address", "price.amount", "beds", "pool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Open the file with an independent reader rather than our own decoder
+	f, err := parquet.OpenFile(bytes.NewReader(file.Data), int64(len(file.Data)))
+	if err != nil {
+		t.Fatalf("parquet-go cannot open the file: %v", err)
+	}
+	if f.NumRows() != 2 {
+		t.Errorf("rows = %d", f.NumRows())
+	}
+	var names []string
+	for _, path := range f.Schema().Columns() {
+		names = append(names, strings.Join(path, "."))
+	}
+	if !reflect.DeepEqual(names, []string{"address", "price.amount", "beds", "pool"}) {
+		t.Errorf("columns = %v", names)
+	}
+
+	reader := parquet.NewReader(bytes.NewReader(file.Data))
+	defer reader.Close()
+	rows := make([]parquet.Row, 2)
+	if n, err := reader.ReadRows(rows); n != 2 {
+		t.Fatalf("read %d rows: %v", n, err)
+	}
+	got := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		for _, v := range row {
+			var cell interface{}
+			switch {
+			case v.IsNull():
+			case v.Kind() == parquet.ByteArray:
+				cell = v.String()
+			case v.Kind() == parquet.Double:
+				cell = v.Double()
+			case v.Kind() == parquet.Int64:
+				cell = v.Int64()
+			case v.Kind() == parquet.Boolean:
+				cell = v.Boolean()
+			}
+			got[i] = append(got[i], cell)
+		}
+	}
+	want := [][]interface{}{
+		{"2819 Poelua St", 1500000.0, int64(3), nil},
+		{"1 Kahala Ave", 925000.5, int64(2), true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestRespond(t *testing.T) {
+	store := NewStore(time.Hour)
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	exporter := NewExporter(store, "http://localhost:8080/")
+
+	req, ok, err := ParseRequest(map[string]interface{}{"export": map[string]interface{}{"format": "xlsx", "delivery": "link"}})
+	if err != nil || !ok {
+		t.Fatalf("ParseRequest = %+v, %v, %v", req, ok, err)
+	}
+	response := exporter.Respond(testListings(), "sold", req)
+	var link Link
+	if response.IsError || json.Unmarshal([]byte(response.Content[1].Text), &link) != nil || !strings.HasPrefix(link.URL, "http://localhost:8080/exports/") {
+		t.Fatalf("link response = %+v", response)
+	}
+	if file, ok := store.Get(strings.TrimPrefix(link.URL, "http://localhost:8080/exports/")); !ok || file.Name != "sold.xlsx" {
+		t.Errorf("stored file = %+v, %v", file, ok)
+	}
+	now = now.Add(time.Hour)
+	if _, ok := store.Get(strings.TrimPrefix(link.URL, "http://localhost:8080/exports/")); ok {
+		t.Error("file still available after it expired")
+	}
+
+	// Binary files are embedded base64-encoded; a nil exporter can only embed
+	var embedding *Exporter
+	response = embedding.Respond(testListings(), "sold", Request{Format: FormatParquet, Delivery: DeliveryEmbedded})
+	resource := response.Content[1].Resource
+	if response.IsError || resource == nil || resource.URI != "export://sold.parquet" || resource.Text != "" {
+		t.Fatalf("embedded response = %+v", response)
+	}
+	if data, err := base64.StdEncoding.DecodeString(resource.Blob); err != nil || !bytes.HasPrefix(data, parquetMagic) {
+		t.Errorf("blob does not decode to a parquet file: %v", err)
+	}
+	if response := embedding.Respond(testListings(), "sold", req); !response.IsError {
+		t.Error("expected an error for a link without a store")
+	}
+
+	if _, _, err := ParseRequest(map[string]interface{}{"export": map[string]interface{}{"format": "csv", "delivery": "email"}}); err == nil {
+		t.Error("expected an error for an unknown delivery")
+	}
+	if _, ok, _ := ParseRequest(map[string]interface{}{}); ok {
+		t.Error("found an export in a call without one")
+	}
+}
+
+// compactReader decodes Thrift compact protocol structs into maps of field
+// ID to value, enough to check the Parquet footer
+type compactReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		b := r.data[r.pos]
+		r.pos++
+		if b == 0 {
+			return fields
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		fields[id] = r.readValue(b & 0x0F)
+	}
+}
+
+func (r *compactReader) readValue(kind byte) interface{} {
+	switch kind {
+	case 1, 2:
+		return kind == 1
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.data[r.pos]
+		r.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.readValue(header & 0x0F)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unsupported thrift type")
+}
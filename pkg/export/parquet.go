@@ -0,0 +1,295 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// This is a minimal Parquet writer: one row group, one uncompressed PLAIN
+// data page per column, and every column OPTIONAL so cells may be null.
+// Metadata is Thrift's compact protocol, per parquet.thrift.
+
+// Parquet physical types, encodings and schema enums
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionOptional = 1
+	convertedUTF8      = 0
+	pageData           = 0
+	codecUncompressed  = 0
+)
+
+// parquetMagic starts and ends a Parquet file
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn is a table column with the physical type its values share
+type parquetColumn struct {
+	name  string
+	kind  int32
+	index int
+}
+
+// parquetColumns picks each column's type: BOOLEAN or INT64 or DOUBLE when
+// every value is one, and UTF8 strings otherwise
+func parquetColumns(t *Table) []parquetColumn {
+	columns := make([]parquetColumn, len(t.Columns))
+	for i, name := range t.Columns {
+		bools, ints, floats, strs := 0, 0, 0, 0
+		for _, row := range t.Rows {
+			switch v := row[i].(type) {
+			case bool:
+				bools++
+			case float64:
+				if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+					ints++
+				} else {
+					floats++
+				}
+			case string:
+				strs++
+			}
+		}
+		kind := int32(parquetByteArray)
+		switch {
+		case strs > 0 || bools > 0 && ints+floats > 0:
+		case bools > 0:
+			kind = parquetBoolean
+		case floats > 0:
+			kind = parquetDouble
+		case ints > 0:
+			kind = parquetInt64
+		}
+		columns[i] = parquetColumn{name: name, kind: kind, index: i}
+	}
+	return columns
+}
+
+func encodeParquet(t *Table) ([]byte, error) {
+	columns := parquetColumns(t)
+
+	var buf bytes.Buffer
+	buf.Write(parquetMagic)
+	chunks := make([]thrift, len(columns))
+	var totalSize int64
+	for i, column := range columns {
+		offset := int64(buf.Len())
+		page := column.page(t.Rows)
+
+		var header thrift
+		header.i32(1, pageData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.begin(5) // DataPageHeader
+		header.i32(1, int32(len(t.Rows)))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.end()
+		header.stop()
+		buf.Write(header.buf)
+		buf.Write(page)
+		size := int64(len(header.buf) + len(page))
+		totalSize += size
+
+		var chunk thrift
+		chunk.i64(2, offset)
+		chunk.begin(3) // ColumnMetaData
+		chunk.i32(1, column.kind)
+		chunk.list(2, thriftI32, 2)
+		chunk.varint(zigzag(encodingPlain))
+		chunk.varint(zigzag(encodingRLE))
+		chunk.list(3, thriftBinary, 1)
+		chunk.bytes([]byte(column.name))
+		chunk.i32(4, codecUncompressed)
+		chunk.i64(5, int64(len(t.Rows)))
+		chunk.i64(6, size)
+		chunk.i64(7, size)
+		chunk.i64(9, offset)
+		chunk.end()
+		chunks[i] = chunk
+	}
+
+	var meta thrift
+	meta.i32(1, 1) // Version
+	meta.list(2, thriftStruct, len(columns)+1)
+	root := thrift{}
+	root.binary(4, "schema")
+	root.i32(5, int32(len(columns)))
+	root.stop()
+	meta.buf = append(meta.buf, root.buf...)
+	for _, column := range columns {
+		var element thrift
+		element.i32(1, column.kind)
+		element.i32(3, repetitionOptional)
+		element.binary(4, column.name)
+		if column.kind == parquetByteArray {
+			element.i32(6, convertedUTF8)
+		}
+		element.stop()
+		meta.buf = append(meta.buf, element.buf...)
+	}
+	meta.i64(3, int64(len(t.Rows)))
+	meta.list(4, thriftStruct, 1)
+	var group thrift
+	group.list(1, thriftStruct, len(chunks))
+	for _, chunk := range chunks {
+		chunk.stop()
+		group.buf = append(group.buf, chunk.buf...)
+	}
+	group.i64(2, totalSize)
+	group.i64(3, int64(len(t.Rows)))
+	group.stop()
+	meta.buf = append(meta.buf, group.buf...)
+	meta.binary(6, "play-mcp")
+	meta.stop()
+
+	buf.Write(meta.buf)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(meta.buf)))
+	buf.Write(parquetMagic)
+	return buf.Bytes(), nil
+}
+
+// page encodes a column's data page: the definition levels, 1 for a value
+// and 0 for null, then the values
+func (c parquetColumn) page(rows [][]interface{}) []byte {
+	var levels []byte
+	var values bytes.Buffer
+	var bits []bool
+	run, last := 0, -1
+	flush := func() {
+		if run > 0 {
+			levels = binary.AppendUvarint(levels, uint64(run)<<1) // RLE run header
+			levels = append(levels, byte(last))
+		}
+	}
+	for _, row := range rows {
+		value := row[c.index]
+		level := 0
+		if value != nil {
+			level = 1
+			switch c.kind {
+			case parquetBoolean:
+				bits = append(bits, value.(bool))
+			case parquetInt64:
+				binary.Write(&values, binary.LittleEndian, int64(value.(float64)))
+			case parquetDouble:
+				binary.Write(&values, binary.LittleEndian, value.(float64))
+			default:
+				s := text(value)
+				binary.Write(&values, binary.LittleEndian, uint32(len(s)))
+				values.WriteString(s)
+			}
+		}
+		if level != last {
+			flush()
+			run, last = 0, level
+		}
+		run++
+	}
+	flush()
+
+	// Booleans are bit-packed, least significant bit first
+	if c.kind == parquetBoolean {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, bit := range bits {
+			if bit {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		values.Write(packed)
+	}
+
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	page = append(page, levels...)
+	return append(page, values.Bytes()...)
+}
+
+// Thrift compact protocol field types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thrift writes a struct in Thrift's compact protocol
+type thrift struct {
+	buf    []byte
+	last   int16   // Previous field ID, which the next is written relative to
+	parent []int16 // Previous field IDs of enclosing structs
+}
+
+func (t *thrift) field(id int16, kind byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|kind)
+	} else {
+		t.buf = append(t.buf, kind)
+		t.varint(zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thrift) varint(v uint64) {
+	t.buf = binary.AppendUvarint(t.buf, v)
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thrift) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.bytes([]byte(s))
+}
+
+func (t *thrift) bytes(b []byte) {
+	t.varint(uint64(len(b)))
+	t.buf = append(t.buf, b...)
+}
+
+// list starts a list field of n elements, which the caller then writes
+func (t *thrift) list(id int16, kind byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|kind)
+	} else {
+		t.buf = append(t.buf, 0xF0|kind)
+		t.varint(uint64(n))
+	}
+}
+
+// begin starts a nested struct field; end finishes it
+func (t *thrift) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+func (t *thrift) end() {
+	t.stop()
+	t.last = t.parent[len(t.parent)-1]
+	t.parent = t.parent[:len(t.parent)-1]
+}
+
+// stop ends a struct
+func (t *thrift) stop() {
+	t.buf = append(t.buf, 0)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Store keeps exported files for download until they expire. It is safe
+// for concurrent use.
+type Store struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	files map[string]stored
+	now   func() time.Time
+}
+
+type stored struct {
+	file      *File
+	expiresAt time.Time
+}
+
+// NewStore creates a store whose files expire ttl after they are added
+func NewStore(ttl time.Duration) *Store {
+	return &Store{ttl: ttl, files: make(map[string]stored), now: time.Now}
+}
+
+// Put adds a file and returns its ID and when it expires
+func (s *Store) Put(file *File) (string, time.Time) {
+	var b [16]byte
+	rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	expiresAt := s.now().Add(s.ttl)
+	s.files[id] = stored{file: file, expiresAt: expiresAt}
+	return id, expiresAt
+}
+
+// Get returns an unexpired file by ID
+func (s *Store) Get(id string) (*File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	entry, ok := s.files[id]
+	return entry.file, ok
+}
+
+// sweep drops expired files
+func (s *Store) sweep() {
+	now := s.now()
+	for id, entry := range s.files {
+		if !now.Before(entry.expiresAt) {
+			delete(s.files, id)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package export writes tool results as CSV, XLSX, GeoJSON or Parquet files
+// and keeps them for download for a while
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table is tool results flattened to rows of named columns. Nested objects
+// become dotted columns such as "price.amount"; arrays are kept whole as
+// JSON text. Values are strings, float64s, bools or nil.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// NewTable flattens v, a slice of records or a single record, into a table
+// by its JSON encoding. Columns are ordered by first appearance.
+func NewTable(v interface{}) (*Table, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode results: %v", err)
+	}
+	var records []json.RawMessage
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("failed to read results: %v", err)
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		records = []json.RawMessage{trimmed}
+	default:
+		return nil, fmt.Errorf("results are not records")
+	}
+
+	t := &Table{}
+	index := make(map[string]int)
+	for i, record := range records {
+		values := make(map[int]interface{})
+		err := flatten(record, "", func(column string, value interface{}) {
+			at, ok := index[column]
+			if !ok {
+				at = len(t.Columns)
+				index[column] = at
+				t.Columns = append(t.Columns, column)
+			}
+			values[at] = value
+		})
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", i, err)
+		}
+		row := make([]interface{}, len(t.Columns))
+		for at, value := range values {
+			row[at] = value
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	// Rows read before a column first appeared are short
+	for i := range t.Rows {
+		for len(t.Rows[i]) < len(t.Columns) {
+			t.Rows[i] = append(t.Rows[i], nil)
+		}
+	}
+	return t, nil
+}
+
+// flatten calls add for each scalar or array in a JSON object, keeping the
+// object's key order
+func flatten(data json.RawMessage, prefix string, add func(column string, value interface{})) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("record is not an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		column, _ := tok.(string)
+		if prefix != "" {
+			column = prefix + "." + column
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		switch raw[0] {
+		case '{':
+			if err := flatten(raw, column, add); err != nil {
+				return err
+			}
+		case '[':
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return err
+			}
+			if compact.String() == "[]" {
+				add(column, nil)
+			} else {
+				add(column, compact.String())
+			}
+		default:
+			var value interface{}
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return err
+			}
+			add(column, value)
+		}
+	}
+	return nil
+}
+
+// Select keeps only the named columns, in the order given. A name also
+// selects the columns nested under it, so "price" selects "price.amount"
+// and "price.currency".
+func (t *Table) Select(names []string) (*Table, error) {
+	if len(names) == 0 {
+		return t, nil
+	}
+	var picked []int
+	seen := make(map[int]bool)
+	for _, name := range names {
+		found := false
+		for i, column := range t.Columns {
+			if column == name || strings.HasPrefix(column, name+".") {
+				found = true
+				if !seen[i] {
+					seen[i] = true
+					picked = append(picked, i)
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(t.topColumns(), ", "))
+		}
+	}
+
+	selected := &Table{}
+	for _, i := range picked {
+		selected.Columns = append(selected.Columns, t.Columns[i])
+	}
+	for _, row := range t.Rows {
+		values := make([]interface{}, len(picked))
+		for j, i := range picked {
+			values[j] = row[i]
+		}
+		selected.Rows = append(selected.Rows, values)
+	}
+	return selected, nil
+}
+
+// Column returns the index of a column, or -1
+func (t *Table) Column(name string) int {
+	for i, column := range t.Columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// topColumns returns the distinct top-level column names, sorted
+func (t *Table) topColumns() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, column := range t.Columns {
+		top, _, _ := strings.Cut(column, ".")
+		if !seen[top] {
+			seen[top] = true
+			names = append(names, top)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// text formats a cell for text formats such as CSV
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return formatNumber(v)
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatNumber writes numbers without an exponent
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
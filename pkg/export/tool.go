@@ -0,0 +1,165 @@
+package export
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/mcp"
+)
+
+// Delivery modes: the file inside the tool response, or a download link
+const (
+	DeliveryEmbedded = "embedded"
+	DeliveryLink     = "link"
+)
+
+// Request is the export a tool call asks for
+type Request struct {
+	Format   string   `json:"format"`
+	Columns  []string `json:"columns,omitempty"`
+	Delivery string   `json:"delivery,omitempty"`
+}
+
+// Link describes an exported file waiting to be downloaded
+type Link struct {
+	URL       string    `json:"url"`
+	Name      string    `json:"name"`
+	MimeType  string    `json:"mimeType"`
+	Rows      int       `json:"rows"`
+	Columns   int       `json:"columns"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Schema returns the input schema of the export argument of tools whose
+// results can be exported
+func Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Export the results as a file instead of returning them as JSON",
+		"properties": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        Formats,
+				"description": "File format; geojson needs results with latitude and longitude",
+			},
+			"columns": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Columns to include, in order (default all). Nested fields are dotted, e.g. price.amount; naming a parent such as price includes all of its fields.",
+			},
+			"delivery": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{DeliveryEmbedded, DeliveryLink},
+				"description": "embedded returns the file in the response (default); link returns a download URL that expires",
+			},
+		},
+		"required": []string{"format"},
+	}
+}
+
+// ParseRequest reads a tool call's export argument; ok is false when the
+// call asks for no export
+func ParseRequest(args map[string]interface{}) (req Request, ok bool, err error) {
+	raw, present := args["export"]
+	if !present || raw == nil {
+		return req, false, nil
+	}
+	object, isObject := raw.(map[string]interface{})
+	if !isObject {
+		return req, false, fmt.Errorf("export must be an object with a format")
+	}
+	req.Format, _ = object["format"].(string)
+	req.Format = strings.ToLower(req.Format)
+	if req.Format == "" {
+		return req, false, fmt.Errorf("export.format is required (available: %s)", strings.Join(Formats, ", "))
+	}
+	if columns, ok := object["columns"].([]interface{}); ok {
+		for _, column := range columns {
+			if name, ok := column.(string); ok && name != "" {
+				req.Columns = append(req.Columns, name)
+			}
+		}
+	}
+	req.Delivery, _ = object["delivery"].(string)
+	switch req.Delivery {
+	case "":
+		req.Delivery = DeliveryEmbedded
+	case DeliveryEmbedded, DeliveryLink:
+	default:
+		return req, false, fmt.Errorf("unknown export delivery %q (available: %s, %s)", req.Delivery, DeliveryEmbedded, DeliveryLink)
+	}
+	return req, true, nil
+}
+
+// Exporter answers tool calls that ask for an export. Without a store it
+// can only embed files.
+type Exporter struct {
+	store   *Store
+	baseURL string
+}
+
+// NewExporter creates an exporter whose download links are baseURL (e.g.
+// http://localhost:8080) followed by /exports/{id}
+func NewExporter(store *Store, baseURL string) *Exporter {
+	return &Exporter{store: store, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Respond exports results, a slice of records or one record, as a file named
+// name plus the format's extension, and returns it as a tool response. A
+// nil exporter embeds files.
+func (e *Exporter) Respond(results interface{}, name string, req Request) *mcp.ToolCallResponse {
+	file, err := e.export(results, name, req)
+	if err != nil {
+		return &mcp.ToolCallResponse{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: err.Error()}},
+		}
+	}
+	summary := fmt.Sprintf("Exported %d rows and %d columns as %s", file.Rows, file.Columns, file.Name)
+
+	if req.Delivery == DeliveryLink {
+		id, expiresAt := e.store.Put(file)
+		link := Link{
+			URL:       e.baseURL + "/exports/" + id,
+			Name:      file.Name,
+			MimeType:  file.MimeType,
+			Rows:      file.Rows,
+			Columns:   file.Columns,
+			ExpiresAt: expiresAt.UTC().Truncate(time.Second),
+		}
+		data, _ := json.MarshalIndent(link, "", "  ")
+		return &mcp.ToolCallResponse{
+			Content: []mcp.Content{
+				{Type: "text", Text: fmt.Sprintf("%s; download it from %s before %s:", summary, link.URL, link.ExpiresAt.Format(time.RFC3339))},
+				{Type: "text", Text: string(data)},
+			},
+		}
+	}
+
+	resource := &mcp.ResourceContents{URI: "export://" + file.Name, MimeType: file.MimeType}
+	if file.Binary() {
+		resource.Blob = base64.StdEncoding.EncodeToString(file.Data)
+	} else {
+		resource.Text = string(file.Data)
+	}
+	return &mcp.ToolCallResponse{
+		Content: []mcp.Content{
+			{Type: "text", Text: summary + ":"},
+			{Type: "resource", Resource: resource},
+		},
+	}
+}
+
+func (e *Exporter) export(results interface{}, name string, req Request) (*File, error) {
+	if req.Delivery == DeliveryLink && (e == nil || e.store == nil) {
+		return nil, fmt.Errorf("download links are not available on this server; use embedded delivery")
+	}
+	table, err := NewTable(results)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(table, name, req.Format, req.Columns)
+}
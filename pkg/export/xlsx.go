@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bytes"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetName is the name of the one worksheet of an XLSX export
+const sheetName = "Results"
+
+// encodeXLSX writes a workbook with the table on one sheet, its header row
+// frozen. Numbers and booleans are written as such so spreadsheets can
+// compute with them.
+func encodeXLSX(t *Table) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", sheetName); err != nil {
+		return nil, err
+	}
+
+	sw, err := f.NewStreamWriter(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, err
+	}
+	header := make([]interface{}, len(t.Columns))
+	for i, column := range t.Columns {
+		header[i] = column
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+	for i, row := range t.Rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return nil, err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return nil, err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,259 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Boundary kinds
+const (
+	KindNeighborhood   = "neighborhood"
+	KindSchoolDistrict = "school_district"
+)
+
+// defaultNameFields are the feature properties tried, in order, for a
+// boundary's name when an import does not say which to use
+var defaultNameFields = []string{"name", "NAME", "Name", "neighborhood", "NEIGHBORHOOD", "district", "DISTRICT", "NAMELSAD"}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Boundary is a named area such as a neighborhood or school district
+type Boundary struct {
+	Kind  string       `json:"kind"`
+	Name  string       `json:"name"`
+	Slug  string       `json:"slug"` // Used in listing site URLs, e.g. "manoa"
+	City  string       `json:"city,omitempty"`
+	State string       `json:"state,omitempty"`
+	Area  MultiPolygon `json:"-"`
+
+	bounds BBox
+}
+
+// BoundaryImport says where to read boundaries of one kind from and which
+// feature properties hold their names and slugs. City and state apply to
+// every boundary the file holds unless its features give their own.
+type BoundaryImport struct {
+	Path      string `json:"path"` // GeoJSON, or a Shapefile's .shp
+	Kind      string `json:"kind"` // neighborhood or school_district
+	City      string `json:"city,omitempty"`
+	State     string `json:"state,omitempty"`
+	NameField string `json:"nameField,omitempty"`
+	SlugField string `json:"slugField,omitempty"`
+}
+
+// BoundaryRegistry holds neighborhood and school district boundaries and
+// finds the ones containing a point. It is safe for concurrent use.
+type BoundaryRegistry struct {
+	mu         sync.RWMutex
+	boundaries []Boundary
+}
+
+// NewBoundaryRegistry creates an empty registry
+func NewBoundaryRegistry() *BoundaryRegistry {
+	return &BoundaryRegistry{}
+}
+
+// LoadBoundaryRegistry imports the boundary files listed in a JSON config of
+// the form {"imports": [...]}. Relative paths are read from the config's
+// directory.
+func LoadBoundaryRegistry(path string) (*BoundaryRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read boundary config: %v", err)
+	}
+	var config struct {
+		Imports []BoundaryImport `json:"imports"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid boundary config %s: %v", path, err)
+	}
+
+	r := NewBoundaryRegistry()
+	for _, imp := range config.Imports {
+		if !filepath.IsAbs(imp.Path) {
+			imp.Path = filepath.Join(filepath.Dir(path), imp.Path)
+		}
+		if _, err := r.Import(imp); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Import reads the boundaries in a GeoJSON file or Shapefile and returns how
+// many it added
+func (r *BoundaryRegistry) Import(imp BoundaryImport) (int, error) {
+	var features []Feature
+	var err error
+	if strings.EqualFold(filepath.Ext(imp.Path), ".shp") {
+		features, err = ReadShapefile(imp.Path)
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(imp.Path); err == nil {
+			features, err = ParseFeatures(data)
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to import boundaries from %s: %v", imp.Path, err)
+	}
+	n, err := r.AddFeatures(features, imp)
+	if err != nil {
+		return n, fmt.Errorf("failed to import boundaries from %s: %v", imp.Path, err)
+	}
+	return n, nil
+}
+
+// AddFeatures adds a boundary per feature, named by the import's name field
+func (r *BoundaryRegistry) AddFeatures(features []Feature, imp BoundaryImport) (int, error) {
+	nameFields := defaultNameFields
+	if imp.NameField != "" {
+		nameFields = []string{imp.NameField}
+	}
+	for i, feature := range features {
+		b := Boundary{
+			Kind:  imp.Kind,
+			Name:  propertyString(feature.Properties, nameFields...),
+			City:  imp.City,
+			State: imp.State,
+			Area:  feature.Geometry,
+		}
+		if imp.SlugField != "" {
+			b.Slug = propertyString(feature.Properties, imp.SlugField)
+		}
+		if city := propertyString(feature.Properties, "city", "CITY"); city != "" {
+			b.City = city
+		}
+		if state := propertyString(feature.Properties, "state", "STATE"); state != "" {
+			b.State = state
+		}
+		if b.Name == "" {
+			return i, fmt.Errorf("feature %d has no %s property", i, strings.Join(nameFields, " or "))
+		}
+		if err := r.Add(b); err != nil {
+			return i, err
+		}
+	}
+	return len(features), nil
+}
+
+// Add adds a boundary, slugging its name when it has no slug
+func (r *BoundaryRegistry) Add(b Boundary) error {
+	if b.Kind != KindNeighborhood && b.Kind != KindSchoolDistrict {
+		return fmt.Errorf("unknown boundary kind %q (available: %s, %s)", b.Kind, KindNeighborhood, KindSchoolDistrict)
+	}
+	if len(b.Area) == 0 {
+		return fmt.Errorf("boundary %s has no polygons", b.Name)
+	}
+	if b.Slug == "" {
+		b.Slug = Slug(b.Name)
+	}
+	b.bounds = b.Area.Bounds()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.boundaries = append(r.boundaries, b)
+	return nil
+}
+
+// Bounds returns the boundary's bounding box
+func (b Boundary) Bounds() BBox {
+	return b.bounds
+}
+
+// Len returns the number of boundaries
+func (r *BoundaryRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.boundaries)
+}
+
+// Locate returns the boundary of a kind containing p. Where boundaries
+// overlap, the one with the smallest bounding box wins, so a neighborhood
+// nested in a larger one is preferred.
+func (r *BoundaryRegistry) Locate(kind string, p Point) (Boundary, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found Boundary
+	best := -1.0
+	for _, b := range r.boundaries {
+		if b.Kind != kind || !b.bounds.Contains(p) || !b.Area.Contains(p) {
+			continue
+		}
+		if size := boxArea(b.bounds); best < 0 || size < best {
+			found, best = b, size
+		}
+	}
+	return found, best >= 0
+}
+
+// Find returns the boundary of a kind whose name or slug matches name, in
+// the city and state when given
+func (r *BoundaryRegistry) Find(kind, city, state, name string) (Boundary, bool) {
+	want := Slug(name)
+	for _, b := range r.List(kind, city, state) {
+		if b.Slug == want || Slug(b.Name) == want {
+			return b, true
+		}
+	}
+	return Boundary{}, false
+}
+
+// List returns the boundaries of a kind, or of every kind when kind is
+// empty, in the city and state when given, ordered by state, city and name.
+// Boundaries without a city or state match any.
+func (r *BoundaryRegistry) List(kind, city, state string) []Boundary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []Boundary
+	for _, b := range r.boundaries {
+		if kind != "" && b.Kind != kind ||
+			city != "" && b.City != "" && !strings.EqualFold(b.City, city) ||
+			state != "" && b.State != "" && !strings.EqualFold(b.State, state) {
+			continue
+		}
+		list = append(list, b)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].State != list[j].State {
+			return list[i].State < list[j].State
+		}
+		if list[i].City != list[j].City {
+			return list[i].City < list[j].City
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Slug lower-cases s and joins its words with dashes, e.g. "Manoa Valley"
+// becomes "manoa-valley"
+func Slug(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// propertyString returns the first of the named feature properties that is
+// set, as a string
+func propertyString(properties map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		switch v := properties[name].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%g", v)
+		}
+	}
+	return ""
+}
+
+func boxArea(b BBox) float64 {
+	return (b.Max.Lat - b.Min.Lat) * (b.Max.Lon - b.Min.Lon)
+}
@@ -0,0 +1,79 @@
+// Package geo provides coordinates, GeoJSON polygons, an in-process spatial
+// index and geocoding for properties
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// earthRadiusMiles is the mean radius of the Earth
+const earthRadiusMiles = 3958.8
+
+// Distance units accepted by ToMiles
+var milesPer = map[string]float64{
+	"mi": 1,
+	"km": 0.621371,
+	"m":  0.000621371,
+	"ft": 1.0 / 5280,
+}
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Valid reports whether p is a coordinate on Earth other than 0,0, which
+// listing sites use for a missing location
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180 && (p.Lat != 0 || p.Lon != 0)
+}
+
+// DistanceMiles returns the great-circle distance between two points
+func DistanceMiles(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLon := lat2-lat1, radians(b.Lon-a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ToMiles converts a distance in mi, km, m or ft to miles
+func ToMiles(value float64, unit string) (float64, error) {
+	if unit == "" {
+		unit = "mi"
+	}
+	factor, ok := milesPer[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown distance unit %q (available: mi, km, m, ft)", unit)
+	}
+	return value * factor, nil
+}
+
+// BBox is a latitude/longitude bounding box
+type BBox struct {
+	Min, Max Point
+}
+
+// Contains reports whether p is inside the box, edges included
+func (b BBox) Contains(p Point) bool {
+	return p.Lat >= b.Min.Lat && p.Lat <= b.Max.Lat && p.Lon >= b.Min.Lon && p.Lon <= b.Max.Lon
+}
+
+// around returns the box holding every point within miles of center
+func around(center Point, miles float64) BBox {
+	dLat := miles / earthRadiusMiles * 180 / math.Pi
+	dLon := 180.0
+	// Near the poles a radius spans every longitude
+	if cos := math.Cos(radians(center.Lat)); cos > 1e-6 {
+		dLon = math.Min(180, dLat/cos)
+	}
+	return BBox{
+		Min: Point{Lat: math.Max(-90, center.Lat-dLat), Lon: center.Lon - dLon},
+		Max: Point{Lat: math.Min(90, center.Lat+dLat), Lon: center.Lon + dLon},
+	}
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,243 @@
+package geo
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDistanceMiles(t *testing.T) {
+	// Honolulu Hale to the Kahala Hotel is about 6 miles
+	d := DistanceMiles(Point{21.3069, -157.8583}, Point{21.2713, -157.7738})
+	if math.Abs(d-5.9) > 0.2 {
+		t.Errorf("distance = %.2f mi", d)
+	}
+	if km, _ := ToMiles(1, "km"); math.Abs(km-0.621) > 0.001 {
+		t.Errorf("1 km = %v mi", km)
+	}
+	if _, err := ToMiles(1, "league"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
+
+func TestParseAreaWithHole(t *testing.T) {
+	area, err := ParseArea([]byte(`{"type": "FeatureCollection", "features": [
+	  {"type": "Feature", "properties": {"name": "square"},
+	   "geometry": {"type": "Polygon", "coordinates": [
+	     [[-158, 21], [-157, 21], [-157, 22], [-158, 22], [-158, 21]],
+	     [[-157.6, 21.4], [-157.4, 21.4], [-157.4, 21.6], [-157.6, 21.6], [-157.6, 21.4]]]}},
+	  {"type": "Feature", "properties": {}, "geometry": {"type": "Point", "coordinates": [0, 0]}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		p    Point
+		want bool
+	}{
+		{Point{21.2, -157.8}, true},
+		{Point{21.5, -157.5}, false}, // In the hole
+		{Point{20.9, -157.5}, false},
+		{Point{21.5, -158.1}, false},
+	}
+	for _, tt := range tests {
+		if got := area.Contains(tt.p); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if _, err := ParseArea([]byte(`{"type": "Point", "coordinates": [0, 0]}`)); err == nil {
+		t.Error("expected an error for GeoJSON without polygons")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	x := NewIndex()
+	x.Put("manoa", Point{21.3133, -157.8116})
+	x.Put("kaimuki", Point{21.2887, -157.7990})
+	x.Put("waikiki", Point{21.2810, -157.8230})
+	x.Put("hilo", Point{19.7071, -155.0816})
+	x.Put("nowhere", Point{})
+
+	hits := x.Within(Point{21.3100, -157.8100}, 2.5)
+	if len(hits) != 3 || hits[0].ID != "manoa" || hits[0].Miles > 0.3 {
+		t.Fatalf("hits = %+v", hits)
+	}
+
+	x.Put("manoa", Point{19.71, -155.08}) // Moved
+	if hits := x.Within(Point{21.3100, -157.8100}, 2.5); len(hits) != 2 {
+		t.Errorf("after move, hits = %+v", hits)
+	}
+	if x.Len() != 4 {
+		t.Errorf("len = %d, want 4", x.Len())
+	}
+
+	area := MultiPolygon{{Outer: []Point{{19, -156}, {20, -156}, {20, -155}, {19, -155}}}}
+	if hits := x.InArea(area); len(hits) != 2 || hits[0].ID != "hilo" || hits[1].ID != "manoa" {
+		t.Errorf("area hits = %+v", hits)
+	}
+}
+
+func TestLocalGeocoder(t *testing.T) {
+	g := NewLocalGeocoder(nil)
+	g.AddAddress(Query{Address: "2819 Poelua St", City: "Honolulu", State: "HI"}, Point{21.3120, -157.8090})
+	g.AddZipCode("96822", Point{21.3133, -157.8116})
+	g.AddCity("Honolulu", "HI", Point{21.3069, -157.8583})
+
+	tests := []struct {
+		query     Query
+		precision string
+	}{
+		{Query{Address: "2819  poelua st", City: "Honolulu", State: "HI"}, PrecisionAddress},
+		{Query{Address: "1 Other St", City: "Honolulu", State: "HI", ZipCode: "96822-1234"}, PrecisionZip},
+		{Query{Address: "1 Other St", City: "honolulu", State: "HI"}, PrecisionCity},
+	}
+	for _, tt := range tests {
+		result, err := g.Geocode(context.Background(), tt.query)
+		if err != nil || result.Precision != tt.precision {
+			t.Errorf("Geocode(%+v) = %+v, %v; want %s precision", tt.query, result, err, tt.precision)
+		}
+	}
+	if _, err := g.Geocode(context.Background(), Query{Address: "1 Main St", City: "Boise", State: "ID"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown address error = %v", err)
+	}
+}
+
+func TestBoundaryRegistry(t *testing.T) {
+	features, err := ParseFeatures([]byte(`{"type": "FeatureCollection", "features": [
+	  {"type": "Feature", "properties": {"name": "Manoa Valley", "slug": "manoa"},
+	   "geometry": {"type": "Polygon", "coordinates": [[[-157.83, 21.29], [-157.79, 21.29], [-157.79, 21.35], [-157.83, 21.35]]]}},
+	  {"type": "Feature", "properties": {"name": "Upper Manoa"},
+	   "geometry": {"type": "Polygon", "coordinates": [[[-157.82, 21.32], [-157.80, 21.32], [-157.80, 21.34], [-157.82, 21.34]]]}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewBoundaryRegistry()
+	if n, err := r.AddFeatures(features, BoundaryImport{Kind: KindNeighborhood, City: "Honolulu", State: "HI", SlugField: "slug"}); err != nil || n != 2 {
+		t.Fatalf("AddFeatures = %d, %v", n, err)
+	}
+	if _, err := r.AddFeatures(features, BoundaryImport{Kind: "ward"}); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+
+	// The nested neighborhood wins over the one around it
+	if b, ok := r.Locate(KindNeighborhood, Point{21.33, -157.81}); !ok || b.Name != "Upper Manoa" || b.Slug != "upper-manoa" {
+		t.Errorf("Locate nested = %+v, %v", b, ok)
+	}
+	if b, ok := r.Locate(KindNeighborhood, Point{21.30, -157.81}); !ok || b.Slug != "manoa" {
+		t.Errorf("Locate = %+v, %v", b, ok)
+	}
+	if _, ok := r.Locate(KindSchoolDistrict, Point{21.30, -157.81}); ok {
+		t.Error("found a school district in a registry without any")
+	}
+
+	for _, name := range []string{"manoa valley", "Manoa", "upper-manoa"} {
+		if _, ok := r.Find(KindNeighborhood, "honolulu", "HI", name); !ok {
+			t.Errorf("Find(%q) found nothing", name)
+		}
+	}
+	if _, ok := r.Find(KindNeighborhood, "Austin", "TX", "Manoa"); ok {
+		t.Error("found Manoa in Austin")
+	}
+}
+
+func TestReadShapefile(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "districts")
+	// A clockwise square with a counterclockwise hole, then a null shape
+	outer := []Point{{21, -158}, {22, -158}, {22, -157}, {21, -157}, {21, -158}}
+	hole := []Point{{21.4, -157.6}, {21.4, -157.4}, {21.6, -157.4}, {21.6, -157.6}, {21.4, -157.6}}
+	if err := os.WriteFile(base+".shp", testShp([][][]Point{{outer, hole}, nil}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".dbf", testDbf([][2]string{{"Honolulu", "1"}, {"Empty", "2"}}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	features, err := ReadShapefile(base + ".shp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 1 || features[0].Properties["NAME"] != "Honolulu" || features[0].Properties["ID"] != 1.0 {
+		t.Fatalf("features = %+v", features)
+	}
+	area := features[0].Geometry
+	if len(area) != 1 || len(area[0].Holes) != 1 || !area.Contains(Point{21.2, -157.8}) || area.Contains(Point{21.5, -157.5}) {
+		t.Errorf("area = %+v", area)
+	}
+
+	if err := os.WriteFile(base+".prj", []byte(`PROJCS["NAD83 / UTM zone 4N"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadShapefile(base + ".shp"); err == nil {
+		t.Error("expected an error for a projected shapefile")
+	}
+}
+
+// testShp encodes polygon records, each a list of rings, as a .shp file
+func testShp(records [][][]Point) []byte {
+	var body []byte
+	for i, rings := range records {
+		var content []byte
+		if rings == nil {
+			content = binary.LittleEndian.AppendUint32(nil, shapeNull)
+		} else {
+			content = binary.LittleEndian.AppendUint32(nil, shapePolygon)
+			content = append(content, make([]byte, 32)...) // Bounding box, unread
+			points := 0
+			for _, ring := range rings {
+				points += len(ring)
+			}
+			content = binary.LittleEndian.AppendUint32(content, uint32(len(rings)))
+			content = binary.LittleEndian.AppendUint32(content, uint32(points))
+			start := 0
+			for _, ring := range rings {
+				content = binary.LittleEndian.AppendUint32(content, uint32(start))
+				start += len(ring)
+			}
+			for _, ring := range rings {
+				for _, p := range ring {
+					content = binary.LittleEndian.AppendUint64(content, math.Float64bits(p.Lon))
+					content = binary.LittleEndian.AppendUint64(content, math.Float64bits(p.Lat))
+				}
+			}
+		}
+		body = binary.BigEndian.AppendUint32(body, uint32(i+1))
+		body = binary.BigEndian.AppendUint32(body, uint32(len(content)/2))
+		body = append(body, content...)
+	}
+	header := make([]byte, 100)
+	binary.BigEndian.PutUint32(header, 9994)
+	binary.BigEndian.PutUint32(header[24:], uint32((100+len(body))/2))
+	binary.LittleEndian.PutUint32(header[28:], 1000)
+	binary.LittleEndian.PutUint32(header[32:], shapePolygon)
+	return append(header, body...)
+}
+
+// testDbf encodes records of a NAME character field and an ID number field
+func testDbf(records [][2]string) []byte {
+	const nameLength, idLength = 20, 5
+	header := make([]byte, 32)
+	header[0] = 3
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(records)))
+	binary.LittleEndian.PutUint16(header[8:], 32+2*32+1)
+	binary.LittleEndian.PutUint16(header[10:], 1+nameLength+idLength)
+	for _, f := range []struct {
+		name   string
+		kind   byte
+		length int
+	}{{"NAME", 'C', nameLength}, {"ID", 'N', idLength}} {
+		descriptor := make([]byte, 32)
+		copy(descriptor, f.name)
+		descriptor[11], descriptor[16] = f.kind, byte(f.length)
+		header = append(header, descriptor...)
+	}
+	header = append(header, 0x0D)
+	for _, r := range records {
+		header = append(header, ' ')
+		header = append(header, fmt.Sprintf("%-*s%*s", nameLength, r[0], idLength, r[1])...)
+	}
+	return append(header, 0x1A)
+}
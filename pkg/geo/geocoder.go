@@ -0,0 +1,182 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by a Geocoder that cannot place an address
+var ErrNotFound = errors.New("location not found")
+
+// Geocoding precisions, most precise first
+const (
+	PrecisionAddress = "address"
+	PrecisionZip     = "zip"
+	PrecisionCity    = "city"
+)
+
+// Query is an address to geocode
+type Query struct {
+	Address string `json:"address"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	ZipCode string `json:"zipCode,omitempty"`
+}
+
+// Result is a geocoded location and how precisely it was placed
+type Result struct {
+	Point     Point  `json:"point"`
+	Precision string `json:"precision"` // address, zip or city
+}
+
+// Geocoder places addresses on the map
+type Geocoder interface {
+	Geocode(ctx context.Context, query Query) (Result, error)
+}
+
+// AddressKey identifies an address for a LocalGeocoder; callers pass one that
+// treats different spellings of an address alike
+type AddressKey func(address, city, state string) string
+
+// LocalGeocoder is an offline geocoder backed by tables of known addresses,
+// ZIP code centroids and city centroids, falling back from the most precise
+// match to the least. It is safe for concurrent use.
+type LocalGeocoder struct {
+	mu        sync.RWMutex
+	key       AddressKey
+	addresses map[string]Point
+	zipCodes  map[string]Point
+	cities    map[string]Point
+}
+
+// localGeocoderFile is the JSON layout LoadLocalGeocoder reads
+type localGeocoderFile struct {
+	Addresses []struct {
+		Query
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"addresses"`
+	ZipCodes map[string]Point `json:"zipCodes"`
+	Cities   []struct {
+		City  string  `json:"city"`
+		State string  `json:"state"`
+		Lat   float64 `json:"lat"`
+		Lon   float64 `json:"lon"`
+	} `json:"cities"`
+}
+
+// NewLocalGeocoder creates an empty local geocoder. A nil key matches
+// addresses case-insensitively with runs of spaces collapsed.
+func NewLocalGeocoder(key AddressKey) *LocalGeocoder {
+	if key == nil {
+		key = func(address, city, state string) string {
+			return strings.ToLower(strings.Join([]string{
+				strings.Join(strings.Fields(address), " "), strings.Join(strings.Fields(city), " "), strings.TrimSpace(state),
+			}, "|"))
+		}
+	}
+	return &LocalGeocoder{
+		key:       key,
+		addresses: make(map[string]Point),
+		zipCodes:  make(map[string]Point),
+		cities:    make(map[string]Point),
+	}
+}
+
+// LoadLocalGeocoder reads a local geocoder's tables from a JSON file of
+// addresses, ZIP code centroids and city centroids
+func LoadLocalGeocoder(path string, key AddressKey) (*LocalGeocoder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geocoder data: %v", err)
+	}
+	var file localGeocoderFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid geocoder data %s: %v", path, err)
+	}
+
+	g := NewLocalGeocoder(key)
+	for _, a := range file.Addresses {
+		if err := g.AddAddress(a.Query, Point{Lat: a.Lat, Lon: a.Lon}); err != nil {
+			return nil, err
+		}
+	}
+	for zip, p := range file.ZipCodes {
+		if err := g.AddZipCode(zip, p); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range file.Cities {
+		if err := g.AddCity(c.City, c.State, Point{Lat: c.Lat, Lon: c.Lon}); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// AddAddress records the location of a street address
+func (g *LocalGeocoder) AddAddress(query Query, p Point) error {
+	if !p.Valid() {
+		return fmt.Errorf("invalid location %v for %s", p, query.Address)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addresses[g.key(query.Address, query.City, query.State)] = p
+	return nil
+}
+
+// AddZipCode records the centroid of a ZIP code
+func (g *LocalGeocoder) AddZipCode(zip string, p Point) error {
+	if !p.Valid() {
+		return fmt.Errorf("invalid location %v for ZIP code %s", p, zip)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.zipCodes[strings.TrimSpace(zip)] = p
+	return nil
+}
+
+// AddCity records the centroid of a city
+func (g *LocalGeocoder) AddCity(city, state string, p Point) error {
+	if !p.Valid() {
+		return fmt.Errorf("invalid location %v for %s, %s", p, city, state)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cities[cityKey(city, state)] = p
+	return nil
+}
+
+// Geocode places an address at its recorded location, or else at the
+// centroid of its ZIP code or city
+func (g *LocalGeocoder) Geocode(ctx context.Context, query Query) (Result, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if query.Address != "" {
+		if p, ok := g.addresses[g.key(query.Address, query.City, query.State)]; ok {
+			return Result{Point: p, Precision: PrecisionAddress}, nil
+		}
+	}
+	// Five-digit ZIP+4 prefixes share the centroid
+	zip := strings.TrimSpace(query.ZipCode)
+	if len(zip) > 5 {
+		zip = zip[:5]
+	}
+	if p, ok := g.zipCodes[zip]; ok && zip != "" {
+		return Result{Point: p, Precision: PrecisionZip}, nil
+	}
+	if p, ok := g.cities[cityKey(query.City, query.State)]; ok {
+		return Result{Point: p, Precision: PrecisionCity}, nil
+	}
+	return Result{}, fmt.Errorf("%w: %s, %s, %s", ErrNotFound, query.Address, query.City, query.State)
+}
+
+func cityKey(city, state string) string {
+	return strings.ToLower(strings.Join(strings.Fields(city), " ") + "|" + strings.TrimSpace(state))
+}
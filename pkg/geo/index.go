@@ -0,0 +1,148 @@
+package geo
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// defaultCellDegrees is the index's grid cell size, about 0.7 miles of latitude
+const defaultCellDegrees = 0.01
+
+// Index is an in-process spatial index of points by ID, bucketed into a
+// latitude/longitude grid. It is safe for concurrent use.
+type Index struct {
+	mu     sync.RWMutex
+	cell   float64
+	cells  map[cellKey]map[string]Point
+	points map[string]Point
+}
+
+type cellKey struct {
+	lat, lon int
+}
+
+// Hit is an indexed point found by a query
+type Hit struct {
+	ID    string  `json:"id"`
+	Point Point   `json:"point"`
+	Miles float64 `json:"miles"` // Distance from a radius query's center
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		cell:   defaultCellDegrees,
+		cells:  make(map[cellKey]map[string]Point),
+		points: make(map[string]Point),
+	}
+}
+
+// Len returns the number of indexed points
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.points)
+}
+
+// Put adds or moves the point with an ID; invalid points remove it
+func (x *Index) Put(id string, p Point) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	if !p.Valid() {
+		return
+	}
+	key := x.key(p)
+	if x.cells[key] == nil {
+		x.cells[key] = make(map[string]Point)
+	}
+	x.cells[key][id] = p
+	x.points[id] = p
+}
+
+// Get returns the point indexed for an ID
+func (x *Index) Get(id string) (Point, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	p, ok := x.points[id]
+	return p, ok
+}
+
+// Remove drops the point with an ID
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *Index) remove(id string) {
+	p, ok := x.points[id]
+	if !ok {
+		return
+	}
+	key := x.key(p)
+	delete(x.cells[key], id)
+	if len(x.cells[key]) == 0 {
+		delete(x.cells, key)
+	}
+	delete(x.points, id)
+}
+
+// Within returns the points within miles of center, nearest first
+func (x *Index) Within(center Point, miles float64) []Hit {
+	var hits []Hit
+	x.scan(around(center, miles), func(id string, p Point) {
+		if d := DistanceMiles(center, p); d <= miles {
+			hits = append(hits, Hit{ID: id, Point: p, Miles: d})
+		}
+	})
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Miles != hits[j].Miles {
+			return hits[i].Miles < hits[j].Miles
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+// InArea returns the points inside an area, ordered by ID
+func (x *Index) InArea(area MultiPolygon) []Hit {
+	var hits []Hit
+	x.scan(area.Bounds(), func(id string, p Point) {
+		if area.Contains(p) {
+			hits = append(hits, Hit{ID: id, Point: p})
+		}
+	})
+	sort.Slice(hits, func(i, j int) bool { return hits[i].ID < hits[j].ID })
+	return hits
+}
+
+// scan visits the points in the grid cells covering a box. Boxes covering
+// more cells than there are points are answered by visiting every point.
+func (x *Index) scan(box BBox, visit func(id string, p Point)) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	min, max := x.key(box.Min), x.key(box.Max)
+	if cells := float64(max.lat-min.lat+1) * float64(max.lon-min.lon+1); cells > float64(len(x.cells)) {
+		for id, p := range x.points {
+			if box.Contains(p) {
+				visit(id, p)
+			}
+		}
+		return
+	}
+	for lat := min.lat; lat <= max.lat; lat++ {
+		for lon := min.lon; lon <= max.lon; lon++ {
+			for id, p := range x.cells[cellKey{lat, lon}] {
+				visit(id, p)
+			}
+		}
+	}
+}
+
+func (x *Index) key(p Point) cellKey {
+	return cellKey{int(math.Floor(p.Lat / x.cell)), int(math.Floor(p.Lon / x.cell))}
+}
@@ -0,0 +1,230 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Polygon is an area bounded by an outer ring, less any holes. Rings are
+// closed implicitly; a repeated first point is allowed.
+type Polygon struct {
+	Outer []Point
+	Holes [][]Point
+}
+
+// Bounds returns the polygon's bounding box
+func (p Polygon) Bounds() BBox {
+	box := BBox{Min: Point{Lat: 90, Lon: 180}, Max: Point{Lat: -90, Lon: -180}}
+	for _, pt := range p.Outer {
+		box.Min.Lat, box.Min.Lon = minFloat(box.Min.Lat, pt.Lat), minFloat(box.Min.Lon, pt.Lon)
+		box.Max.Lat, box.Max.Lon = maxFloat(box.Max.Lat, pt.Lat), maxFloat(box.Max.Lon, pt.Lon)
+	}
+	return box
+}
+
+// Contains reports whether pt is inside the polygon and outside its holes
+func (p Polygon) Contains(pt Point) bool {
+	if !inRing(p.Outer, pt) {
+		return false
+	}
+	for _, hole := range p.Holes {
+		if inRing(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRing tests a point against a ring by ray casting
+func inRing(ring []Point, pt Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lon < (b.Lon-a.Lon)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// MultiPolygon is an area made of one or more polygons
+type MultiPolygon []Polygon
+
+// Contains reports whether pt is inside any of the polygons
+func (m MultiPolygon) Contains(pt Point) bool {
+	for _, polygon := range m {
+		if polygon.Contains(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds returns the bounding box of all the polygons
+func (m MultiPolygon) Bounds() BBox {
+	box := BBox{Min: Point{Lat: 90, Lon: 180}, Max: Point{Lat: -90, Lon: -180}}
+	for _, polygon := range m {
+		b := polygon.Bounds()
+		box.Min.Lat, box.Min.Lon = minFloat(box.Min.Lat, b.Min.Lat), minFloat(box.Min.Lon, b.Min.Lon)
+		box.Max.Lat, box.Max.Lon = maxFloat(box.Max.Lat, b.Max.Lat), maxFloat(box.Max.Lon, b.Max.Lon)
+	}
+	return box
+}
+
+// Feature is a GeoJSON feature with a polygonal geometry
+type Feature struct {
+	Geometry   MultiPolygon
+	Properties map[string]interface{}
+}
+
+// geoJSON holds the members of any GeoJSON object this package reads
+type geoJSON struct {
+	Type        string                 `json:"type"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+	Geometry    *geoJSON               `json:"geometry"`
+	Geometries  []geoJSON              `json:"geometries"`
+	Features    []geoJSON              `json:"features"`
+	Properties  map[string]interface{} `json:"properties"`
+}
+
+// ParseFeatures reads the polygonal features of a GeoJSON FeatureCollection,
+// Feature or bare Polygon or MultiPolygon geometry. A bare geometry becomes
+// a single feature without properties.
+func ParseFeatures(data []byte) ([]Feature, error) {
+	var obj geoJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	return obj.features()
+}
+
+// ParseArea reads a GeoJSON object as one area: the union of its polygons
+func ParseArea(data []byte) (MultiPolygon, error) {
+	features, err := ParseFeatures(data)
+	if err != nil {
+		return nil, err
+	}
+	var area MultiPolygon
+	for _, feature := range features {
+		area = append(area, feature.Geometry...)
+	}
+	if len(area) == 0 {
+		return nil, fmt.Errorf("GeoJSON has no polygons")
+	}
+	return area, nil
+}
+
+func (g geoJSON) features() ([]Feature, error) {
+	switch g.Type {
+	case "FeatureCollection":
+		var features []Feature
+		for i, member := range g.Features {
+			f, err := member.features()
+			if err != nil {
+				return nil, fmt.Errorf("feature %d: %v", i, err)
+			}
+			features = append(features, f...)
+		}
+		return features, nil
+	case "Feature":
+		if g.Geometry == nil {
+			return nil, nil
+		}
+		shape, err := g.Geometry.geometry()
+		if err != nil || len(shape) == 0 {
+			return nil, err
+		}
+		return []Feature{{Geometry: shape, Properties: g.Properties}}, nil
+	default:
+		shape, err := g.geometry()
+		if err != nil {
+			return nil, err
+		}
+		return []Feature{{Geometry: shape}}, nil
+	}
+}
+
+// geometry reads a geometry's polygons; points and lines have none
+func (g geoJSON) geometry() (MultiPolygon, error) {
+	switch g.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %v", err)
+		}
+		polygon, err := newPolygon(rings)
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{polygon}, nil
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %v", err)
+		}
+		var shape MultiPolygon
+		for _, rings := range polygons {
+			polygon, err := newPolygon(rings)
+			if err != nil {
+				return nil, err
+			}
+			shape = append(shape, polygon)
+		}
+		return shape, nil
+	case "GeometryCollection":
+		var shape MultiPolygon
+		for _, member := range g.Geometries {
+			polygons, err := member.geometry()
+			if err != nil {
+				return nil, err
+			}
+			shape = append(shape, polygons...)
+		}
+		return shape, nil
+	case "Point", "MultiPoint", "LineString", "MultiLineString":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q", g.Type)
+	}
+}
+
+// newPolygon builds a polygon from GeoJSON rings of [lon, lat] positions
+func newPolygon(rings [][][]float64) (Polygon, error) {
+	if len(rings) == 0 {
+		return Polygon{}, fmt.Errorf("polygon has no rings")
+	}
+	var polygon Polygon
+	for i, ring := range rings {
+		points := make([]Point, 0, len(ring))
+		for _, position := range ring {
+			if len(position) < 2 {
+				return Polygon{}, fmt.Errorf("position %v needs a longitude and latitude", position)
+			}
+			points = append(points, Point{Lat: position[1], Lon: position[0]})
+		}
+		if len(points) < 3 {
+			return Polygon{}, fmt.Errorf("polygon ring has %d positions, need at least 3", len(points))
+		}
+		if i == 0 {
+			polygon.Outer = points
+		} else {
+			polygon.Holes = append(polygon.Holes, points)
+		}
+	}
+	return polygon, nil
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,221 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Shapefile shape types with polygon geometry; the Z and M variants carry
+// extra measures after the points, which are ignored
+const (
+	shapeNull     = 0
+	shapePolygon  = 5
+	shapePolygonZ = 15
+	shapePolygonM = 25
+)
+
+// ReadShapefile reads the polygons of a Shapefile as features, with the
+// attributes of the .dbf file beside it as their properties. Coordinates
+// must be longitude and latitude; a projected Shapefile, as its .prj file
+// says, is an error.
+func ReadShapefile(path string) ([]Feature, error) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	if prj, err := os.ReadFile(base + ".prj"); err == nil && bytes.HasPrefix(bytes.TrimSpace(prj), []byte("PROJCS")) {
+		return nil, fmt.Errorf("shapefile uses projected coordinates; reproject it to WGS84 longitude and latitude")
+	}
+
+	shp, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := parseShp(shp)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	if dbf, err := os.ReadFile(base + ".dbf"); err == nil {
+		if records, err = parseDbf(dbf); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var features []Feature
+	for i, shape := range shapes {
+		if len(shape) == 0 {
+			continue
+		}
+		feature := Feature{Geometry: shape}
+		if i < len(records) {
+			feature.Properties = records[i]
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+// parseShp reads the polygons of each record of a .shp file; null shapes
+// are kept, empty, so records line up with the .dbf
+func parseShp(data []byte) ([]MultiPolygon, error) {
+	if len(data) < 100 || binary.BigEndian.Uint32(data) != 9994 {
+		return nil, fmt.Errorf("not a shapefile")
+	}
+
+	var shapes []MultiPolygon
+	for offset := 100; offset+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset+4:])) * 2 // 16-bit words
+		content := offset + 8
+		if length < 4 || content+length > len(data) {
+			return nil, fmt.Errorf("shapefile record %d is truncated", len(shapes)+1)
+		}
+		shape, err := parseShpPolygon(data[content : content+length])
+		if err != nil {
+			return nil, fmt.Errorf("shapefile record %d: %v", len(shapes)+1, err)
+		}
+		shapes = append(shapes, shape)
+		offset = content + length
+	}
+	return shapes, nil
+}
+
+// parseShpPolygon reads a polygon record: a bounding box, part and point
+// counts, the index of each part's first point, then the points
+func parseShpPolygon(record []byte) (MultiPolygon, error) {
+	switch shapeType := binary.LittleEndian.Uint32(record); shapeType {
+	case shapeNull:
+		return nil, nil
+	case shapePolygon, shapePolygonZ, shapePolygonM:
+	default:
+		return nil, fmt.Errorf("unsupported shape type %d; only polygons are boundaries", shapeType)
+	}
+	if len(record) < 44 {
+		return nil, fmt.Errorf("polygon is truncated")
+	}
+	numParts := int(binary.LittleEndian.Uint32(record[36:]))
+	numPoints := int(binary.LittleEndian.Uint32(record[40:]))
+	pointsAt := 44 + 4*numParts
+	if numParts < 0 || numPoints < 0 || pointsAt+16*numPoints > len(record) {
+		return nil, fmt.Errorf("polygon is truncated")
+	}
+
+	points := make([]Point, numPoints)
+	for i := range points {
+		at := pointsAt + 16*i
+		points[i] = Point{
+			Lon: math.Float64frombits(binary.LittleEndian.Uint64(record[at:])),
+			Lat: math.Float64frombits(binary.LittleEndian.Uint64(record[at+8:])),
+		}
+		if points[i].Lat < -90 || points[i].Lat > 90 || points[i].Lon < -180 || points[i].Lon > 180 {
+			return nil, fmt.Errorf("point %v is not a longitude and latitude; reproject the shapefile to WGS84", points[i])
+		}
+	}
+
+	// Outer rings run clockwise and holes counterclockwise; each hole
+	// belongs to the outer ring around it
+	var shape MultiPolygon
+	var holes [][]Point
+	for part := 0; part < numParts; part++ {
+		start := int(binary.LittleEndian.Uint32(record[44+4*part:]))
+		end := numPoints
+		if part+1 < numParts {
+			end = int(binary.LittleEndian.Uint32(record[44+4*(part+1):]))
+		}
+		if start < 0 || start > end || end > numPoints {
+			return nil, fmt.Errorf("polygon part %d is out of range", part)
+		}
+		ring := points[start:end]
+		if len(ring) < 3 {
+			continue
+		}
+		if signedArea(ring) < 0 {
+			shape = append(shape, Polygon{Outer: ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+	for _, hole := range holes {
+		for i := range shape {
+			if inRing(shape[i].Outer, hole[0]) {
+				shape[i].Holes = append(shape[i].Holes, hole)
+				break
+			}
+		}
+	}
+	return shape, nil
+}
+
+// signedArea is positive for counterclockwise rings and negative for
+// clockwise ones, with longitude as x and latitude as y
+func signedArea(ring []Point) float64 {
+	area := 0.0
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		area += ring[j].Lon*ring[i].Lat - ring[i].Lon*ring[j].Lat
+	}
+	return area / 2
+}
+
+// parseDbf reads the records of a dBASE file as properties. Numeric fields
+// become numbers and the rest trimmed strings; deleted records are kept,
+// without properties, so records line up with the .shp.
+func parseDbf(data []byte) ([]map[string]interface{}, error) {
+	if len(data) < 32 {
+		return nil, fmt.Errorf("dbf file is truncated")
+	}
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	headerLength := int(binary.LittleEndian.Uint16(data[8:]))
+	recordLength := int(binary.LittleEndian.Uint16(data[10:]))
+
+	type field struct {
+		name    string
+		length  int
+		numeric bool
+	}
+	var fields []field
+	for at := 32; at+32 <= headerLength && at < len(data) && data[at] != 0x0D; at += 32 {
+		name := string(bytes.TrimRight(data[at:at+11], "\x00"))
+		kind := data[at+11]
+		fields = append(fields, field{name: name, length: int(data[at+16]), numeric: kind == 'N' || kind == 'F'})
+	}
+
+	records := make([]map[string]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		at := headerLength + i*recordLength
+		if at+recordLength > len(data) {
+			return nil, fmt.Errorf("dbf record %d is truncated", i+1)
+		}
+		if data[at] == '*' {
+			records = append(records, nil)
+			continue
+		}
+		record := make(map[string]interface{}, len(fields))
+		pos := at + 1 // Past the deletion flag
+		for _, f := range fields {
+			if pos+f.length > at+recordLength {
+				return nil, fmt.Errorf("dbf field %s overruns its record", f.name)
+			}
+			value := strings.TrimSpace(string(data[pos : pos+f.length]))
+			pos += f.length
+			if value == "" {
+				continue
+			}
+			if f.numeric {
+				if n, err := strconv.ParseFloat(value, 64); err == nil {
+					record[f.name] = n
+					continue
+				}
+			}
+			record[f.name] = value
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
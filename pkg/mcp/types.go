@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 )
 
 // Message represents a generic MCP message
@@ -50,9 +51,10 @@ type ToolCallResponse struct {
 
 // Content represents MCP content
 type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
-	Data string `json:"data,omitempty"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"` // Set for embedded resources, of type "resource"
 }
 
 // Resource represents an MCP resource
@@ -72,6 +74,23 @@ type Plugin interface {
 	GetResources() []Resource
 }
 
+// ResourceReader is implemented by plugins whose resources can be read
+type ResourceReader interface {
+	// ReadResource returns the contents of a resource URI, or ErrResourceNotFound
+	ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error)
+}
+
+// ErrResourceNotFound is returned for a resource URI no plugin serves
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ResourceContents is the contents of a read resource
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"` // Base64 binary contents
+}
+
 // ServerInfo represents server information for initialization
 type ServerInfo struct {
 	Name    string `json:"name"`
@@ -145,3 +164,11 @@ type ToolsListResult struct {
 type ResourcesListResult struct {
 	Resources []Resource `json:"resources"`
 }
+
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
@@ -0,0 +1,378 @@
+package records
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File formats
+const (
+	FormatCSV   = "csv"
+	FormatFixed = "fixed" // Fixed-width columns
+)
+
+// parcelFields are the fields a county's parcel file can map
+var parcelFields = []string{"apn", "address", "unit", "city", "zipCode", "neighborhood", "landUse", "yearBuilt", "squareFeet",
+	"bedrooms", "bathrooms", "lotSize", "landValue", "improvementValue", "assessedValue", "taxAmount", "taxYear", "latitude", "longitude"}
+
+// saleFields are the fields a county's sales file can map
+var saleFields = []string{"apn", "address", "unit", "city", "date", "price", "documentNumber", "deedType"}
+
+// defaultDateLayouts are tried in order for sale dates when a file does not
+// give its layout
+var defaultDateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "20060102", "2006/01/02"}
+
+// County maps one county's public-record files to parcels and sales
+type County struct {
+	Name  string `json:"name"` // e.g. "honolulu-hi"
+	State string `json:"state"`
+	City  string `json:"city,omitempty"` // For rows without a city column
+	// PropertyTypes maps the county's land use codes to property types such
+	// as house or condo
+	PropertyTypes map[string]string `json:"propertyTypes,omitempty"`
+	Parcels       FileMapping       `json:"parcels"`
+	Sales         *FileMapping      `json:"sales,omitempty"`
+}
+
+// FileMapping says how to read a CSV or fixed-width file. Columns maps field
+// names to a CSV header, or to a fixed-width file's 1-based, inclusive
+// character range such as "1-12".
+type FileMapping struct {
+	Path       string            `json:"path"`
+	Format     string            `json:"format,omitempty"`     // csv (default) or fixed
+	Delimiter  string            `json:"delimiter,omitempty"`  // CSV field separator, default ","
+	SkipLines  int               `json:"skipLines,omitempty"`  // Header lines of a fixed-width file
+	DateLayout string            `json:"dateLayout,omitempty"` // Go time layout of dates, e.g. 01/02/2006
+	Columns    map[string]string `json:"columns"`
+}
+
+// ImportStats counts what an import read
+type ImportStats struct {
+	County         string `json:"county"`
+	Parcels        int    `json:"parcels"`
+	Sales          int    `json:"sales"`
+	UnmatchedSales int    `json:"unmatchedSales"` // Sales of parcels missing from the roll
+	Skipped        int    `json:"skipped"`        // Rows with unreadable values
+}
+
+// LoadDataset imports the counties listed in a JSON config of the form
+// {"counties": [...]}. Relative paths are read from the config's directory.
+func LoadDataset(path string) (*Dataset, []ImportStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read public records config: %v", err)
+	}
+	var config struct {
+		Counties []County `json:"counties"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("invalid public records config %s: %v", path, err)
+	}
+
+	d := NewDataset()
+	var stats []ImportStats
+	for _, county := range config.Counties {
+		for _, m := range []*FileMapping{&county.Parcels, county.Sales} {
+			if m != nil && m.Path != "" && !filepath.IsAbs(m.Path) {
+				m.Path = filepath.Join(filepath.Dir(path), m.Path)
+			}
+		}
+		s, err := d.Import(county)
+		if err != nil {
+			return nil, nil, err
+		}
+		stats = append(stats, s)
+	}
+	return d, stats, nil
+}
+
+// Import reads a county's parcel file and then its sales file, joining
+// sales to parcels by parcel number or, for sales without one, address
+func (d *Dataset) Import(county County) (ImportStats, error) {
+	stats := ImportStats{County: county.Name}
+	if county.Name == "" || county.State == "" {
+		return stats, fmt.Errorf("county mapping needs a name and state")
+	}
+	if err := county.Parcels.check(parcelFields, "apn", "address"); err != nil {
+		return stats, fmt.Errorf("county %s parcels: %v", county.Name, err)
+	}
+	if county.Sales != nil {
+		if err := county.Sales.check(saleFields, "date", "price"); err != nil {
+			return stats, fmt.Errorf("county %s sales: %v", county.Name, err)
+		}
+		if county.Sales.Columns["apn"] == "" && county.Sales.Columns["address"] == "" {
+			return stats, fmt.Errorf("county %s sales: an apn or address column is required", county.Name)
+		}
+	}
+
+	err := county.Parcels.read(func(row map[string]string) {
+		parcel, err := county.parcel(row)
+		if err == nil {
+			err = d.Add(parcel)
+		}
+		if err != nil {
+			stats.Skipped++
+			return
+		}
+		stats.Parcels++
+	})
+	if err != nil {
+		return stats, fmt.Errorf("county %s parcels: %v", county.Name, err)
+	}
+	if county.Sales == nil {
+		return stats, nil
+	}
+
+	err = county.Sales.read(func(row map[string]string) {
+		sale, err := county.Sales.sale(row)
+		switch {
+		case err != nil:
+			stats.Skipped++
+		case d.addSale(county.State, row["apn"], address(row), county.city(row), sale):
+			stats.Sales++
+		default:
+			stats.UnmatchedSales++
+		}
+	})
+	if err != nil {
+		return stats, fmt.Errorf("county %s sales: %v", county.Name, err)
+	}
+	return stats, nil
+}
+
+// parcel reads a parcel from a row of the county's parcel file
+func (c County) parcel(row map[string]string) (Parcel, error) {
+	parcel := Parcel{
+		APN:          row["apn"],
+		County:       c.Name,
+		Address:      address(row),
+		City:         c.city(row),
+		State:        c.State,
+		ZipCode:      row["zipCode"],
+		Neighborhood: row["neighborhood"],
+		LandUse:      row["landUse"],
+	}
+	if parcel.LandUse != "" {
+		parcel.PropertyType = c.PropertyTypes[parcel.LandUse]
+	}
+	var err error
+	ints := map[string]*int{
+		"yearBuilt": &parcel.YearBuilt, "squareFeet": &parcel.SquareFeet, "bedrooms": &parcel.Bedrooms, "taxYear": &parcel.TaxYear,
+		"landValue": &parcel.LandValue, "improvementValue": &parcel.ImprovementValue, "assessedValue": &parcel.AssessedValue,
+	}
+	for field, dst := range ints {
+		if *dst, err = parseInt(row[field]); err != nil {
+			return parcel, fmt.Errorf("%s: %v", field, err)
+		}
+	}
+	floats := map[string]*float64{
+		"bathrooms": &parcel.Bathrooms, "lotSize": &parcel.LotSize, "taxAmount": &parcel.TaxAmount,
+		"latitude": &parcel.Latitude, "longitude": &parcel.Longitude,
+	}
+	for field, dst := range floats {
+		if *dst, err = parseNumber(row[field]); err != nil {
+			return parcel, fmt.Errorf("%s: %v", field, err)
+		}
+	}
+	return parcel, nil
+}
+
+// city returns a row's city, or the county's default
+func (c County) city(row map[string]string) string {
+	if row["city"] != "" {
+		return row["city"]
+	}
+	return c.City
+}
+
+// address returns a row's street address with its unit
+func address(row map[string]string) string {
+	if row["unit"] == "" {
+		return row["address"]
+	}
+	return row["address"] + " #" + strings.TrimPrefix(row["unit"], "#")
+}
+
+// sale reads a sale from a row of a sales file
+func (m FileMapping) sale(row map[string]string) (Sale, error) {
+	date, err := m.date(row["date"])
+	if err != nil {
+		return Sale{}, err
+	}
+	price, err := parseInt(row["price"])
+	if err != nil {
+		return Sale{}, fmt.Errorf("price: %v", err)
+	}
+	return Sale{Date: date, Price: price, DocumentNumber: row["documentNumber"], DeedType: row["deedType"]}, nil
+}
+
+// date reads a date in the file's layout as 2006-01-02
+func (m FileMapping) date(s string) (string, error) {
+	layouts := defaultDateLayouts
+	if m.DateLayout != "" {
+		layouts = []string{m.DateLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("unreadable date %q", s)
+}
+
+// parseNumber reads a number such as "1,234.50" or "$950,000"; empty is 0
+func parseNumber(s string) (float64, error) {
+	s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseInt reads a whole number, rounding any fraction
+func parseInt(s string) (int, error) {
+	v, err := parseNumber(s)
+	if v < 0 {
+		return int(v - 0.5), err
+	}
+	return int(v + 0.5), err
+}
+
+// check validates a mapping's format and columns against the fields a file
+// can map
+func (m FileMapping) check(fields []string, required ...string) error {
+	if m.Path == "" {
+		return fmt.Errorf("a path is required")
+	}
+	switch m.Format {
+	case "", FormatCSV, FormatFixed:
+	default:
+		return fmt.Errorf("unknown format %q (available: %s, %s)", m.Format, FormatCSV, FormatFixed)
+	}
+	if len([]rune(m.Delimiter)) > 1 {
+		return fmt.Errorf("delimiter must be one character")
+	}
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field] = true
+	}
+	for field, column := range m.Columns {
+		if !known[field] {
+			return fmt.Errorf("unknown field %q (available: %s)", field, strings.Join(fields, ", "))
+		}
+		if m.Format == FormatFixed {
+			if _, _, err := columnRange(column); err != nil {
+				return fmt.Errorf("%s: %v", field, err)
+			}
+		}
+	}
+	for _, field := range required {
+		if m.Columns[field] == "" {
+			return fmt.Errorf("a %s column is required", field)
+		}
+	}
+	return nil
+}
+
+// columnRange reads a fixed-width column such as "13-52" as 0-based byte
+// offsets [start, end)
+func columnRange(column string) (int, int, error) {
+	from, to, ok := strings.Cut(column, "-")
+	start, err1 := strconv.Atoi(strings.TrimSpace(from))
+	end, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil || start < 1 || end < start {
+		return 0, 0, fmt.Errorf("column %q is not a range such as 1-12", column)
+	}
+	return start - 1, end, nil
+}
+
+// read calls fn with each data row of the file, as trimmed values by field
+func (m FileMapping) read(fn func(row map[string]string)) error {
+	f, err := os.Open(m.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if m.Format == FormatFixed {
+		return m.readFixed(f, fn)
+	}
+	return m.readCSV(f, fn)
+}
+
+func (m FileMapping) readCSV(r io.Reader, fn func(row map[string]string)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if m.Delimiter != "" {
+		reader.Comma = []rune(m.Delimiter)[0]
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read the header of %s: %v", m.Path, err)
+	}
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	columns := make(map[string]int, len(m.Columns))
+	for field, name := range m.Columns {
+		i, ok := positions[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return fmt.Errorf("%s has no %q column for %s", m.Path, name, field)
+		}
+		columns[field] = i
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", m.Path, err)
+		}
+		row := make(map[string]string, len(columns))
+		for field, i := range columns {
+			if i < len(record) {
+				row[field] = strings.TrimSpace(record[i])
+			}
+		}
+		fn(row)
+	}
+}
+
+func (m FileMapping) readFixed(r io.Reader, fn func(row map[string]string)) error {
+	type span struct{ start, end int }
+	columns := make(map[string]span, len(m.Columns))
+	for field, column := range m.Columns {
+		start, end, _ := columnRange(column)
+		columns[field] = span{start, end}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 0; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line < m.SkipLines || strings.TrimSpace(text) == "" {
+			continue
+		}
+		row := make(map[string]string, len(columns))
+		for field, c := range columns {
+			if c.start < len(text) {
+				row[field] = strings.TrimSpace(text[c.start:min(c.end, len(text))])
+			}
+		}
+		fn(row)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", m.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Package records loads county assessor rolls and recorded sales into a
+// local dataset of parcels and joins them with scraped listings
+package records
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// Parcel is a county assessor's record of a property, with the sales
+// recorded against it
+type Parcel struct {
+	APN          string  `json:"apn"`
+	County       string  `json:"county"`
+	Address      string  `json:"address"`
+	City         string  `json:"city"`
+	State        string  `json:"state"`
+	ZipCode      string  `json:"zipCode,omitempty"`
+	Neighborhood string  `json:"neighborhood,omitempty"`
+	LandUse      string  `json:"landUse,omitempty"`      // The county's land use code
+	PropertyType string  `json:"propertyType,omitempty"` // The land use code's property type, when the county maps it
+	YearBuilt    int     `json:"yearBuilt,omitempty"`
+	SquareFeet   int     `json:"squareFeet,omitempty"`
+	Bedrooms     int     `json:"bedrooms,omitempty"`
+	Bathrooms    float64 `json:"bathrooms,omitempty"`
+	LotSize      float64 `json:"lotSize,omitempty"`
+	Latitude     float64 `json:"latitude,omitempty"`
+	Longitude    float64 `json:"longitude,omitempty"`
+	// Assessment; the assessed value is land plus improvements unless the
+	// roll gives it
+	LandValue        int     `json:"landValue,omitempty"`
+	ImprovementValue int     `json:"improvementValue,omitempty"`
+	AssessedValue    int     `json:"assessedValue,omitempty"`
+	TaxAmount        float64 `json:"taxAmount,omitempty"` // Annual
+	TaxYear          int     `json:"taxYear,omitempty"`
+	Sales            []Sale  `json:"sales,omitempty"` // Oldest first
+}
+
+// Sale is a recorded deed transfer
+type Sale struct {
+	Date           string `json:"date"` // 2006-01-02
+	Price          int    `json:"price"`
+	DocumentNumber string `json:"documentNumber,omitempty"`
+	DeedType       string `json:"deedType,omitempty"`
+}
+
+// LastSale returns the parcel's most recent sale with a price. Transfers
+// recorded for nothing, such as quitclaims, are skipped.
+func (p Parcel) LastSale() (Sale, bool) {
+	for i := len(p.Sales) - 1; i >= 0; i-- {
+		if p.Sales[i].Price > 0 {
+			return p.Sales[i], true
+		}
+	}
+	return Sale{}, false
+}
+
+// Property returns the parcel as a listing: sold at its last sale, with the
+// sales as its price history
+func (p Parcel) Property() scraper.Property {
+	property := p.facts()
+	property.ID = scraper.PropertyID(p.Address, p.City, p.State)
+	property.Address, property.City, property.State = p.Address, p.City, p.State
+	property.Neighborhood = p.Neighborhood
+	if sale, ok := p.LastSale(); ok {
+		property.Status, property.Price, property.SoldDate = "sold", sale.Price, sale.Date
+		if p.SquareFeet > 0 {
+			property.PricePerSqFt = sale.Price / p.SquareFeet
+		}
+	}
+	for i := len(p.Sales) - 1; i >= 0; i-- {
+		if sale := p.Sales[i]; sale.Price > 0 {
+			property.PriceHistory = append(property.PriceHistory, fmt.Sprintf("Sold %s on %s", dollars(sale.Price), sale.Date))
+		}
+	}
+	return property
+}
+
+// facts returns the parcel's physical and tax facts as a listing, without
+// its address or sales
+func (p Parcel) facts() scraper.Property {
+	property := scraper.Property{
+		APN:          p.APN,
+		ZipCode:      p.ZipCode,
+		PropertyType: p.PropertyType,
+		YearBuilt:    p.YearBuilt,
+		SquareFeet:   p.SquareFeet,
+		Bedrooms:     p.Bedrooms,
+		Bathrooms:    p.Bathrooms,
+		LotSize:      p.LotSize,
+		Latitude:     p.Latitude,
+		Longitude:    p.Longitude,
+	}
+	if p.TaxAmount > 0 {
+		property.PropertyTax = dollars(int(p.TaxAmount+0.5)) + "/yr"
+	}
+	return property
+}
+
+// dollars formats a whole dollar amount, e.g. "$1,200,000"
+func dollars(amount int) string {
+	digits := fmt.Sprint(amount)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return "$" + digits
+}
+
+var nonAPN = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NormalizeAPN returns an assessor's parcel number without its punctuation,
+// so "1-2-3-045-006-0000" and "123045006-0000" compare equal
+func NormalizeAPN(apn string) string {
+	return nonAPN.ReplaceAllString(strings.ToLower(apn), "")
+}
+
+// apnKey identifies a parcel number within a state, as counties in
+// different states can use the same numbers
+func apnKey(state, apn string) string {
+	return strings.ToLower(strings.TrimSpace(state)) + "|" + NormalizeAPN(apn)
+}
+
+// Dataset holds imported parcels, found by parcel number or address. It is
+// safe for concurrent use.
+type Dataset struct {
+	mu        sync.RWMutex
+	parcels   map[string]*Parcel // By apnKey
+	addresses map[string]string  // apnKeys by scraper.PropertyKey
+	counties  map[string]bool
+}
+
+// NewDataset creates an empty dataset
+func NewDataset() *Dataset {
+	return &Dataset{
+		parcels:   make(map[string]*Parcel),
+		addresses: make(map[string]string),
+		counties:  make(map[string]bool),
+	}
+}
+
+// Add adds a parcel, replacing any with the same parcel number in its state
+func (d *Dataset) Add(parcel Parcel) error {
+	if NormalizeAPN(parcel.APN) == "" {
+		return fmt.Errorf("parcel at %s has no APN", parcel.Address)
+	}
+	if parcel.Address == "" || parcel.State == "" {
+		return fmt.Errorf("parcel %s needs an address and state", parcel.APN)
+	}
+	if parcel.AssessedValue == 0 {
+		parcel.AssessedValue = parcel.LandValue + parcel.ImprovementValue
+	}
+	sortSales(parcel.Sales)
+
+	key := apnKey(parcel.State, parcel.APN)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.parcels[key]; ok {
+		delete(d.addresses, scraper.PropertyKey(old.Address, old.City, old.State))
+	}
+	d.parcels[key] = &parcel
+	d.addresses[scraper.PropertyKey(parcel.Address, parcel.City, parcel.State)] = key
+	if parcel.County != "" {
+		d.counties[parcel.County] = true
+	}
+	return nil
+}
+
+// addSale records a sale against a parcel found by parcel number or, when
+// the sale has none, address, and reports whether one was found
+func (d *Dataset) addSale(state, apn, address, city string, sale Sale) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := apnKey(state, apn)
+	if NormalizeAPN(apn) == "" {
+		key = d.addresses[scraper.PropertyKey(address, city, state)]
+	}
+	parcel, ok := d.parcels[key]
+	if !ok {
+		return false
+	}
+	for _, recorded := range parcel.Sales {
+		if recorded.Date == sale.Date && (recorded.DocumentNumber == sale.DocumentNumber || recorded.Price == sale.Price) {
+			return true
+		}
+	}
+	parcel.Sales = append(parcel.Sales, sale)
+	sortSales(parcel.Sales)
+	return true
+}
+
+// sortSales orders sales oldest first
+func sortSales(sales []Sale) {
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].Date < sales[j].Date })
+}
+
+// Parcel returns the parcel with a parcel number in a state
+func (d *Dataset) Parcel(state, apn string) (Parcel, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.get(apnKey(state, apn))
+}
+
+// Find returns the parcel at a street address, however it is spelled
+func (d *Dataset) Find(address, city, state string) (Parcel, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.get(d.addresses[scraper.PropertyKey(address, city, state)])
+}
+
+// get returns a copy of a parcel; callers hold d.mu
+func (d *Dataset) get(key string) (Parcel, bool) {
+	parcel, ok := d.parcels[key]
+	if !ok {
+		return Parcel{}, false
+	}
+	copied := *parcel
+	copied.Sales = append([]Sale(nil), parcel.Sales...)
+	return copied, true
+}
+
+// Match returns the parcel a listing describes, by its APN when it has one
+// and otherwise by its address
+func (d *Dataset) Match(property scraper.Property) (Parcel, bool) {
+	if property.APN != "" {
+		if parcel, ok := d.Parcel(property.State, property.APN); ok {
+			return parcel, true
+		}
+	}
+	return d.Find(property.Address, property.City, property.State)
+}
+
+// Enrich fills in the facts a listing lacks from its parcel, recording
+// them in its provenance as coming from SourceName, and reports whether a
+// parcel matched. Listing values are kept; the county's tax bill replaces a
+// listed tax.
+func (d *Dataset) Enrich(property *scraper.Property) (Parcel, bool) {
+	parcel, ok := d.Match(*property)
+	if !ok {
+		return parcel, false
+	}
+	if property.Provenance == nil {
+		property.Provenance = make(map[string]string)
+	}
+	facts := parcel.facts()
+	fill := func(field string, missing bool, set func()) {
+		if missing {
+			set()
+			property.Provenance[field] = SourceName
+		}
+	}
+	fill("apn", property.APN == "", func() { property.APN = facts.APN })
+	fill("zipCode", property.ZipCode == "" && facts.ZipCode != "", func() { property.ZipCode = facts.ZipCode })
+	fill("propertyType", (property.PropertyType == "" || property.PropertyType == "unknown") && facts.PropertyType != "", func() { property.PropertyType = facts.PropertyType })
+	fill("yearBuilt", property.YearBuilt == 0 && facts.YearBuilt > 0, func() { property.YearBuilt = facts.YearBuilt })
+	fill("squareFeet", property.SquareFeet == 0 && facts.SquareFeet > 0, func() { property.SquareFeet = facts.SquareFeet })
+	fill("bedrooms", property.Bedrooms == 0 && facts.Bedrooms > 0, func() { property.Bedrooms = facts.Bedrooms })
+	fill("bathrooms", property.Bathrooms == 0 && facts.Bathrooms > 0, func() { property.Bathrooms = facts.Bathrooms })
+	fill("lotSize", property.LotSize == 0 && facts.LotSize > 0, func() { property.LotSize = facts.LotSize })
+	fill("location", property.Latitude == 0 && property.Longitude == 0 && (facts.Latitude != 0 || facts.Longitude != 0), func() {
+		property.Latitude, property.Longitude = facts.Latitude, facts.Longitude
+	})
+	fill("propertyTax", facts.PropertyTax != "", func() { property.PropertyTax = facts.PropertyTax })
+	if property.Price > 0 && property.SquareFeet > 0 {
+		property.PricePerSqFt = property.Price / property.SquareFeet
+	}
+	return parcel, true
+}
+
+// Parcels returns a city's parcels, or a neighborhood's when neighborhood is
+// given, ordered by address
+func (d *Dataset) Parcels(city, state, neighborhood string) []Parcel {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var parcels []Parcel
+	for key, parcel := range d.parcels {
+		if !strings.EqualFold(parcel.City, city) || !strings.EqualFold(parcel.State, state) {
+			continue
+		}
+		if neighborhood != "" && !strings.EqualFold(parcel.Neighborhood, neighborhood) {
+			continue
+		}
+		copied, _ := d.get(key)
+		parcels = append(parcels, copied)
+	}
+	sort.Slice(parcels, func(i, j int) bool { return parcels[i].Address < parcels[j].Address })
+	return parcels
+}
+
+// Len returns the number of parcels
+func (d *Dataset) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.parcels)
+}
+
+// Counties returns the names of the imported counties
+func (d *Dataset) Counties() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	counties := make([]string, 0, len(d.counties))
+	for county := range d.counties {
+		counties = append(counties, county)
+	}
+	sort.Strings(counties)
+	return counties
+}
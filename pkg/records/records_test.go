@@ -0,0 +1,139 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+func loadSample(t *testing.T) *Dataset {
+	t.Helper()
+	d, stats, err := LoadDataset(filepath.Join("testdata", "sample-county", "records.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ImportStats{County: "sample-county", Parcels: 4, Sales: 5, UnmatchedSales: 1, Skipped: 1}
+	if len(stats) != 1 || stats[0] != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+	return d
+}
+
+func TestImport(t *testing.T) {
+	d := loadSample(t)
+	if d.Len() != 4 || len(d.Counties()) != 1 {
+		t.Fatalf("%d parcels in %v", d.Len(), d.Counties())
+	}
+
+	// Found by parcel number however it is punctuated
+	parcel, ok := d.Parcel("hi", "129036012-0000")
+	if !ok {
+		t.Fatal("parcel not found by APN")
+	}
+	if parcel.Address != "2819 POELUA STREET" || parcel.PropertyType != "house" || parcel.Bathrooms != 2 || parcel.LotSize != 7500 || parcel.Latitude != 21.3124 {
+		t.Errorf("parcel = %+v", parcel)
+	}
+	if parcel.AssessedValue != 1450000 || parcel.TaxAmount != 4350 || parcel.TaxYear != 2025 {
+		t.Errorf("assessment = %v, tax %v in %d", parcel.AssessedValue, parcel.TaxAmount, parcel.TaxYear)
+	}
+	if len(parcel.Sales) != 2 || parcel.Sales[0] != (Sale{Date: "2012-06-15", Price: 890000, DocumentNumber: "A-44512871", DeedType: "WARRANTY"}) || parcel.Sales[1].Date != "2021-03-10" {
+		t.Errorf("sales = %+v", parcel.Sales)
+	}
+
+	// Found by address however it is spelled, with the unit joined on
+	condo, ok := d.Find("2772 Kalawao Street Unit 29", "Honolulu", "HI")
+	if !ok || condo.PropertyType != "condo" || condo.Address != "2772 KALAWAO ST #29" || len(condo.Sales) != 1 {
+		t.Errorf("condo = %+v, %v", condo, ok)
+	}
+	if _, ok := d.Find("2772 Kalawao St", "Honolulu", "HI"); ok {
+		t.Error("found a unit by the building's address")
+	}
+
+	// The quitclaim has no price, so the last sale is the one before it
+	hipawai, _ := d.Find("2714 Hipawai Pl", "Honolulu", "HI")
+	if sale, ok := hipawai.LastSale(); !ok || sale.Date != "2016-11-02" || len(hipawai.Sales) != 2 {
+		t.Errorf("last sale = %+v of %+v", sale, hipawai.Sales)
+	}
+	if parcels := d.Parcels("Honolulu", "HI", "Manoa"); len(parcels) != 3 || parcels[0].Address != "2714 HIPAWAI PL" {
+		t.Errorf("Manoa parcels = %+v", parcels)
+	}
+}
+
+func TestImportMappingErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "parcels.csv")
+	if err := os.WriteFile(path, []byte("TMK,ADDRESS\n1-2-3,1 Main St\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, county := range []County{
+		{Name: "x", Parcels: FileMapping{Path: path, Columns: map[string]string{"apn": "TMK", "address": "ADDRESS"}}},
+		{Name: "x", State: "HI", Parcels: FileMapping{Path: path, Columns: map[string]string{"apn": "TMK"}}},
+		{Name: "x", State: "HI", Parcels: FileMapping{Path: path, Columns: map[string]string{"apn": "TMK", "address": "ADDRESS", "owner": "OWNER"}}},
+		{Name: "x", State: "HI", Parcels: FileMapping{Path: path, Columns: map[string]string{"apn": "TMK", "address": "SITUS"}}},
+		{Name: "x", State: "HI", Parcels: FileMapping{Path: path, Format: FormatFixed, Columns: map[string]string{"apn": "1-12", "address": "13"}}},
+		{Name: "x", State: "HI", Parcels: FileMapping{Path: path, Format: "xlsx", Columns: map[string]string{"apn": "TMK", "address": "ADDRESS"}}},
+		{Name: "x", State: "HI", Parcels: FileMapping{Path: path, Columns: map[string]string{"apn": "TMK", "address": "ADDRESS"}},
+			Sales: &FileMapping{Path: path, Columns: map[string]string{"date": "DATE", "price": "PRICE"}}},
+	} {
+		if _, err := NewDataset().Import(county); err == nil {
+			t.Errorf("expected an error for %+v", county)
+		}
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	d := loadSample(t)
+
+	// A scraped listing with a price and beds but no size, year or parcel
+	listing := scraper.Property{Address: "2819 Poelua St", City: "Honolulu", State: "HI", Price: 1500000, Bedrooms: 4,
+		PropertyTax: "$3,900/yr", Provenance: map[string]string{"price": "homes.com"}}
+	parcel, ok := d.Enrich(&listing)
+	if !ok || parcel.APN != "1-2-9-036-012-0000" {
+		t.Fatalf("matched %+v, %v", parcel, ok)
+	}
+	if listing.Bedrooms != 4 || listing.SquareFeet != 1850 || listing.YearBuilt != 1958 || listing.APN != parcel.APN || listing.PricePerSqFt != 810 {
+		t.Errorf("enriched = %+v", listing)
+	}
+	if listing.PropertyTax != "$4,350/yr" || listing.Provenance["squareFeet"] != SourceName || listing.Provenance["price"] != "homes.com" || listing.Provenance["bedrooms"] != "" {
+		t.Errorf("tax %s, provenance %v", listing.PropertyTax, listing.Provenance)
+	}
+
+	// The APN wins over an address the county spells differently
+	listing = scraper.Property{APN: "1-3-2-011-004-0000", Address: "1 Waialae Avenue Bldg A", City: "Honolulu", State: "HI"}
+	if parcel, ok := d.Enrich(&listing); !ok || parcel.Neighborhood != "KAIMUKI" || listing.SquareFeet != 1200 {
+		t.Errorf("matched by APN: %+v, %v", parcel, ok)
+	}
+	if _, ok := d.Enrich(&scraper.Property{Address: "5 Kapahulu Ave", City: "Honolulu", State: "HI"}); ok {
+		t.Error("matched a property missing from the roll")
+	}
+}
+
+func TestSource(t *testing.T) {
+	source := NewSource(loadSample(t))
+	ctx := context.Background()
+	sold, err := source.Search(ctx, scraper.SearchQuery{City: "Honolulu", State: "HI"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Waialae Ave has no recorded sale; the condo sold most recently
+	if len(sold) != 3 || sold[0].Address != "2772 KALAWAO ST #29" || sold[0].ID != scraper.PropertyID("2772 Kalawao St #29", "Honolulu", "HI") {
+		t.Fatalf("sold = %+v", sold)
+	}
+	poelua := sold[1]
+	if poelua.Status != "sold" || poelua.Price != 1250000 || poelua.SoldDate != "2021-03-10" || poelua.PricePerSqFt != 675 {
+		t.Errorf("Poelua = %+v", poelua)
+	}
+	if len(poelua.PriceHistory) != 2 || poelua.PriceHistory[0] != "Sold $1,250,000 on 2021-03-10" {
+		t.Errorf("price history = %v", poelua.PriceHistory)
+	}
+	if kaimuki, _ := source.Search(ctx, scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Kaimuki"}); len(kaimuki) != 0 {
+		t.Errorf("Kaimuki = %+v", kaimuki)
+	}
+	if _, err := source.Search(ctx, scraper.SearchQuery{City: "Honolulu", State: "HI", Status: "for_sale"}); !errors.Is(err, scraper.ErrNotSupported) {
+		t.Errorf("for sale search error = %v", err)
+	}
+}
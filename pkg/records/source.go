@@ -0,0 +1,60 @@
+package records
+
+import (
+	"context"
+	"sort"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// SourceName names public records as a listing source and in provenance
+const SourceName = "public-records"
+
+// Source serves a dataset's recorded sales as sold listings, for searches
+// that should not depend on scraping. Records have no pages, listings for
+// sale or market statistics.
+type Source struct {
+	dataset *Dataset
+}
+
+// NewSource creates a listing source backed by a dataset
+func NewSource(dataset *Dataset) *Source {
+	return &Source{dataset: dataset}
+}
+
+// Name returns the source name
+func (s *Source) Name() string {
+	return SourceName
+}
+
+// Matches reports false, as public records have no property pages
+func (s *Source) Matches(rawURL string) bool {
+	return false
+}
+
+// Search returns the parcels in a city or neighborhood that have a priced
+// sale, most recently sold first. Parcels are only found by neighborhood
+// when the county's roll names one.
+func (s *Source) Search(ctx context.Context, query scraper.SearchQuery) ([]scraper.Property, error) {
+	if query.Status != "" && query.Status != "sold" {
+		return nil, scraper.ErrNotSupported
+	}
+	properties := []scraper.Property{}
+	for _, parcel := range s.dataset.Parcels(query.City, query.State, query.Neighborhood) {
+		if _, ok := parcel.LastSale(); ok {
+			properties = append(properties, parcel.Property())
+		}
+	}
+	sort.SliceStable(properties, func(i, j int) bool { return properties[i].SoldDate > properties[j].SoldDate })
+	return properties, nil
+}
+
+// Detail is not supported
+func (s *Source) Detail(ctx context.Context, url string) (*scraper.Property, error) {
+	return nil, scraper.ErrNotSupported
+}
+
+// MarketStats is not supported
+func (s *Source) MarketStats(ctx context.Context, query scraper.SearchQuery) (*scraper.MarketStats, error) {
+	return nil, scraper.ErrNotSupported
+}
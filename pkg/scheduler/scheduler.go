@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrUnknownJob is returned for a job name that was never added
+var ErrUnknownJob = errors.New("unknown job")
+
+// ErrJobRunning is returned when a job is triggered while it is already running
+var ErrJobRunning = errors.New("job is already running")
+
+// JobFunc runs a job, returning a result to keep as the job's latest
+type JobFunc func(ctx context.Context) (interface{}, error)
+
+// Options configures a Scheduler
+type Options struct {
+	MaxConcurrent int           // Jobs allowed to run at once; 0 means 1
+	Jitter        time.Duration // Scheduled runs start after a random delay of up to Jitter
+	HistorySize   int           // Runs kept per job; 0 means 20
+}
+
+// Run records one run of a job
+type Run struct {
+	Trigger    string    `json:"trigger"` // schedule or manual
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Duration   string    `json:"duration"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Status describes a job and its recent runs
+type Status struct {
+	Name       string      `json:"name"`
+	Schedule   string      `json:"schedule"`
+	Running    bool        `json:"running"`
+	NextRun    *time.Time  `json:"nextRun,omitempty"`
+	LastRun    *Run        `json:"lastRun,omitempty"`
+	LastResult interface{} `json:"lastResult,omitempty"` // From the latest successful run
+	History    []Run       `json:"history"`              // Newest first
+}
+
+// job is a scheduled job and its state
+type job struct {
+	name     string
+	schedule string
+	run      JobFunc
+	entry    cron.EntryID
+
+	running    bool
+	history    []Run
+	lastResult interface{}
+}
+
+// Scheduler runs jobs on cron schedules, limiting how many run at once and
+// keeping each job's run history and latest result
+type Scheduler struct {
+	cron    *cron.Cron
+	options Options
+	slots   chan struct{}
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	now    func() time.Time
+	jitter func(max time.Duration) time.Duration
+}
+
+// New creates a scheduler; call Start to begin running scheduled jobs
+func New(options Options) *Scheduler {
+	if options.MaxConcurrent < 1 {
+		options.MaxConcurrent = 1
+	}
+	if options.HistorySize < 1 {
+		options.HistorySize = 20
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cron:    cron.New(),
+		options: options,
+		slots:   make(chan struct{}, options.MaxConcurrent),
+		jobs:    make(map[string]*job),
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+		jitter: func(max time.Duration) time.Duration {
+			if max <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(max)))
+		},
+	}
+}
+
+// Add registers a job under a standard five-field cron schedule such as
+// "0 6 * * *", or a descriptor such as "@hourly" or "@every 30m"
+func (s *Scheduler) Add(name, schedule string, run JobFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job %s already added", name)
+	}
+	j := &job{name: name, schedule: schedule, run: run}
+	entry, err := s.cron.AddFunc(schedule, func() { s.scheduled(j) })
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %v", schedule, name, err)
+	}
+	j.entry = entry
+	s.jobs[name] = j
+	return nil
+}
+
+// Start begins running jobs on their schedules
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop stops scheduling, cancels running jobs and waits for them to return.
+// Cancelling comes first, as cron waits for scheduled runs to return.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.cron.Stop().Done()
+	s.wg.Wait()
+}
+
+// scheduled runs a job at its scheduled time after a random delay, skipping
+// the run if the previous one has not finished
+func (s *Scheduler) scheduled(j *job) {
+	select {
+	case <-time.After(s.jitter(s.options.Jitter)):
+	case <-s.ctx.Done():
+		return
+	}
+	if err := s.claim(j); err != nil {
+		log.Printf("Skipping scheduled run of %s: previous run still in progress", j.name)
+		return
+	}
+	s.execute(j, "schedule")
+}
+
+// Trigger starts a job immediately in the background
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	if err := s.claim(j); err != nil {
+		return err
+	}
+	go s.execute(j, "manual")
+	return nil
+}
+
+// RunNow runs a job immediately and waits for it to finish
+func (s *Scheduler) RunNow(name string) (Run, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return Run{}, fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	if err := s.claim(j); err != nil {
+		return Run{}, err
+	}
+	return s.execute(j, "manual")
+}
+
+// claim marks a job as running, failing if it already is
+func (s *Scheduler) claim(j *job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.running {
+		return fmt.Errorf("%w: %s", ErrJobRunning, j.name)
+	}
+	j.running = true
+	s.wg.Add(1)
+	return nil
+}
+
+// execute runs a claimed job once a concurrency slot is free and records the run
+func (s *Scheduler) execute(j *job, trigger string) (Run, error) {
+	defer s.wg.Done()
+
+	run := Run{Trigger: trigger}
+	var result interface{}
+	var err error
+	select {
+	case s.slots <- struct{}{}:
+		run.StartedAt = s.now()
+		result, err = j.run(s.ctx)
+		<-s.slots
+	case <-s.ctx.Done():
+		run.StartedAt = s.now()
+		err = s.ctx.Err()
+	}
+	run.FinishedAt = s.now()
+	run.Duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond).String()
+	if err != nil {
+		run.Error = err.Error()
+		log.Printf("Job %s failed: %v", j.name, err)
+	}
+
+	s.mu.Lock()
+	j.running = false
+	j.history = append([]Run{run}, j.history...)
+	if len(j.history) > s.options.HistorySize {
+		j.history = j.history[:s.options.HistorySize]
+	}
+	if err == nil {
+		j.lastResult = result
+	}
+	s.mu.Unlock()
+
+	return run, err
+}
+
+// Status returns the status of a job
+func (s *Scheduler) Status(name string) (Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	return s.status(j), nil
+}
+
+// Statuses returns the status of every job, sorted by name
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, s.status(j))
+	}
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Name < statuses[b].Name })
+	return statuses
+}
+
+// status builds a job's status; s.mu must be held
+func (s *Scheduler) status(j *job) Status {
+	status := Status{
+		Name:       j.name,
+		Schedule:   j.schedule,
+		Running:    j.running,
+		LastResult: j.lastResult,
+		History:    append([]Run{}, j.history...),
+	}
+	if next := s.cron.Entry(j.entry).Next; !next.IsZero() {
+		status.NextRun = &next
+	}
+	if len(j.history) > 0 {
+		last := j.history[0]
+		status.LastRun = &last
+	}
+	return status
+}
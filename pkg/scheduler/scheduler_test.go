@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunNowRecordsHistory(t *testing.T) {
+	s := New(Options{HistorySize: 2})
+	defer s.Stop()
+
+	var calls int32
+	fail := false
+	if err := s.Add("honolulu-hi/manoa", "0 5 * * *", func(ctx context.Context) (interface{}, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if fail {
+			return nil, errors.New("homes.com returned 503")
+		}
+		return n, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.RunNow("honolulu-hi/manoa"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A failed run is recorded but keeps the previous result
+	fail = true
+	if run, err := s.RunNow("honolulu-hi/manoa"); err == nil || run.Error == "" {
+		t.Fatalf("failed run = %+v, %v", run, err)
+	}
+
+	status, err := s.Status("honolulu-hi/manoa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LastResult != int32(2) {
+		t.Errorf("last result = %v, want 2", status.LastResult)
+	}
+	if len(status.History) != 2 || status.LastRun == nil || status.LastRun.Error == "" || status.History[1].Error != "" {
+		t.Errorf("history = %+v", status.History)
+	}
+	if status.Schedule != "0 5 * * *" || status.Running {
+		t.Errorf("status = %+v", status)
+	}
+
+	if _, err := s.RunNow("austin-tx"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("err = %v, want ErrUnknownJob", err)
+	}
+}
+
+func TestAddRejectsInvalidSchedules(t *testing.T) {
+	s := New(Options{})
+	noop := func(ctx context.Context) (interface{}, error) { return nil, nil }
+
+	if err := s.Add("daily", "0 5 * *", noop); err == nil {
+		t.Error("expected an error for a four-field schedule")
+	}
+	if err := s.Add("hourly", "@every 30m", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("hourly", "@hourly", noop); err == nil {
+		t.Error("expected an error for a duplicate job")
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	s := New(Options{MaxConcurrent: 1})
+	defer s.Stop()
+
+	var running, maxRunning int32
+	release := make(chan struct{})
+	job := func(ctx context.Context) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		<-release
+		return nil, nil
+	}
+	s.Add("a", "@daily", job)
+	s.Add("b", "@daily", job)
+
+	if err := s.Trigger("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger("b"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return atomic.LoadInt32(&running) == 1 })
+
+	// A job waiting for a slot still counts as running
+	if err := s.Trigger("a"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("err = %v, want ErrJobRunning", err)
+	}
+	if err := s.Trigger("b"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("err = %v, want ErrJobRunning", err)
+	}
+
+	close(release)
+	waitFor(t, func() bool {
+		a, _ := s.Status("a")
+		b, _ := s.Status("b")
+		return len(a.History) == 1 && len(b.History) == 1
+	})
+	if maxRunning != 1 {
+		t.Errorf("%d jobs ran at once, want 1", maxRunning)
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStopCancelsScheduledRuns(t *testing.T) {
+	s := New(Options{})
+	started := make(chan struct{}, 1)
+	if err := s.Add("honolulu-hi", "@every 1s", func(ctx context.Context) (interface{}, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled run never started")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop blocked on a running job")
+	}
+}
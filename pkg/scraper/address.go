@@ -0,0 +1,164 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Address is a street address split into USPS Publication 28 components,
+// each in its standard upper-case abbreviated form
+type Address struct {
+	Number          string `json:"number,omitempty"`
+	PreDirectional  string `json:"preDirectional,omitempty"`
+	Street          string `json:"street"`
+	Suffix          string `json:"suffix,omitempty"`
+	PostDirectional string `json:"postDirectional,omitempty"`
+	UnitType        string `json:"unitType,omitempty"` // APT, STE, UNIT, or # when the designator is unknown
+	Unit            string `json:"unit,omitempty"`
+}
+
+// streetSuffixes maps street suffixes and their common variants to the USPS abbreviation
+var streetSuffixes = map[string]string{
+	"ALLEY": "ALY", "ALLY": "ALY", "ALY": "ALY",
+	"AVENUE": "AVE", "AVE": "AVE", "AV": "AVE", "AVEN": "AVE", "AVENU": "AVE", "AVN": "AVE", "AVNUE": "AVE",
+	"BEND": "BND", "BND": "BND",
+	"BOULEVARD": "BLVD", "BLVD": "BLVD", "BOUL": "BLVD", "BOULV": "BLVD", "BLV": "BLVD",
+	"CIRCLE": "CIR", "CIR": "CIR", "CIRC": "CIR", "CIRCL": "CIR", "CRCL": "CIR",
+	"COURT": "CT", "CT": "CT", "CRT": "CT",
+	"COVE": "CV", "CV": "CV",
+	"CROSSING": "XING", "XING": "XING", "CRSSNG": "XING",
+	"DRIVE": "DR", "DR": "DR", "DRIV": "DR", "DRV": "DR",
+	"EXPRESSWAY": "EXPY", "EXPY": "EXPY", "EXPW": "EXPY", "EXPR": "EXPY",
+	"FREEWAY": "FWY", "FWY": "FWY", "FRWY": "FWY",
+	"HEIGHTS": "HTS", "HTS": "HTS", "HT": "HTS",
+	"HIGHWAY": "HWY", "HWY": "HWY", "HIWAY": "HWY", "HIWY": "HWY", "HWAY": "HWY",
+	"HILL": "HL", "HL": "HL",
+	"LANE": "LN", "LN": "LN",
+	"LOOP": "LOOP", "LOOPS": "LOOP",
+	"MALL":    "MALL",
+	"PARKWAY": "PKWY", "PKWY": "PKWY", "PKY": "PKWY", "PARKWY": "PKWY",
+	"PATH": "PATH", "PATHS": "PATH",
+	"PIKE":  "PIKE",
+	"PLACE": "PL", "PL": "PL",
+	"PLAZA": "PLZ", "PLZ": "PLZ", "PLZA": "PLZ",
+	"POINT": "PT", "PT": "PT",
+	"RIDGE": "RDG", "RDG": "RDG",
+	"ROAD": "RD", "RD": "RD",
+	"ROW":    "ROW",
+	"RUN":    "RUN",
+	"SQUARE": "SQ", "SQ": "SQ", "SQR": "SQ",
+	"STREET": "ST", "ST": "ST", "STR": "ST", "STRT": "ST",
+	"TERRACE": "TER", "TER": "TER", "TERR": "TER",
+	"TRAIL": "TRL", "TRL": "TRL", "TRAILS": "TRL", "TRLS": "TRL",
+	"VIEW": "VW", "VW": "VW",
+	"WALK": "WALK",
+	"WAY":  "WAY", "WY": "WAY",
+}
+
+// directionals maps compass directions to the USPS abbreviation
+var directionals = map[string]string{
+	"NORTH": "N", "N": "N", "SOUTH": "S", "S": "S", "EAST": "E", "E": "E", "WEST": "W", "W": "W",
+	"NORTHEAST": "NE", "NE": "NE", "NORTHWEST": "NW", "NW": "NW",
+	"SOUTHEAST": "SE", "SE": "SE", "SOUTHWEST": "SW", "SW": "SW",
+}
+
+// unitDesignators maps secondary unit designators to the USPS abbreviation
+var unitDesignators = map[string]string{
+	"APARTMENT": "APT", "APT": "APT",
+	"BUILDING": "BLDG", "BLDG": "BLDG",
+	"FLOOR": "FL", "FL": "FL",
+	"LOT":       "LOT",
+	"PENTHOUSE": "PH", "PH": "PH",
+	"ROOM": "RM", "RM": "RM",
+	"SPACE": "SPC", "SPC": "SPC",
+	"SUITE": "STE", "STE": "STE",
+	"TRAILER": "TRLR", "TRLR": "TRLR",
+	"UNIT": "UNIT",
+}
+
+// addressPunctuation matches characters dropped before an address is split
+// into words; # and - are kept for unit numbers and hyphenated house numbers
+var addressPunctuation = regexp.MustCompile(`[^A-Z0-9#\-/ ]+`)
+
+// NormalizeAddress splits a street address such as "2772 Kalawao Street #29"
+// into its components, abbreviating suffixes, directionals and unit designators
+func NormalizeAddress(street string) Address {
+	cleaned := addressPunctuation.ReplaceAllString(strings.ToUpper(street), " ")
+	cleaned = strings.ReplaceAll(cleaned, "#", " # ")
+	words := strings.Fields(cleaned)
+
+	var address Address
+	for i, word := range words {
+		designator, isUnit := unitDesignators[word]
+		if word == "#" {
+			designator, isUnit = "#", true
+		}
+		// A designator needs a street before it and an identifier after it
+		if isUnit && i > 1 && i < len(words)-1 {
+			address.UnitType = designator
+			address.Unit = strings.Join(words[i+1:], "")
+			words = words[:i]
+			break
+		}
+	}
+
+	if len(words) > 1 && startsWithDigit(words[0]) {
+		address.Number, words = words[0], words[1:]
+	}
+	// "N Main St" has a directional, but in "North St" it is the street name
+	if len(words) > 2 || len(words) == 2 && streetSuffixes[words[1]] == "" {
+		if dir, ok := directionals[words[0]]; ok {
+			address.PreDirectional, words = dir, words[1:]
+		}
+	}
+	if len(words) > 1 {
+		if dir, ok := directionals[words[len(words)-1]]; ok {
+			address.PostDirectional, words = dir, words[:len(words)-1]
+		}
+	}
+	if len(words) > 1 {
+		if suffix, ok := streetSuffixes[words[len(words)-1]]; ok {
+			address.Suffix, words = suffix, words[:len(words)-1]
+		}
+	}
+	address.Street = strings.Join(words, " ")
+	return address
+}
+
+// String formats the address on one line, e.g. "2772 KALAWAO ST UNIT 29"
+func (a Address) String() string {
+	parts := []string{a.Number, a.PreDirectional, a.Street, a.Suffix, a.PostDirectional}
+	if a.Unit != "" {
+		parts = append(parts, a.UnitType, a.Unit)
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// PropertyKey returns the canonical key for a property, which is the same
+// for every spelling of its address: suffixes and directionals are
+// abbreviated and the unit designator is ignored, so "2772 Kalawao St Unit 29"
+// and "2772 Kalawao Street #29" in Honolulu, HI both give
+// "2772 kalawao st 29|honolulu|hi"
+func PropertyKey(street, city, state string) string {
+	address := NormalizeAddress(street)
+	line := strings.Join(strings.Fields(strings.Join([]string{
+		address.Number, address.PreDirectional, address.Street, address.Suffix, address.PostDirectional, address.Unit,
+	}, " ")), " ")
+	return strings.ToLower(line + "|" + strings.Join(strings.Fields(city), " ") + "|" + strings.TrimSpace(state))
+}
+
+// propertyIDCleaner matches the runs of characters replaced by dashes in property IDs
+var propertyIDCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// PropertyID returns the stable ID of the property at an address, derived
+// from its PropertyKey, e.g. "prop_2772-kalawao-st-29-honolulu-hi"
+func PropertyID(street, city, state string) string {
+	if strings.TrimSpace(street) == "" {
+		return "prop_unknown"
+	}
+	return "prop_" + strings.Trim(propertyIDCleaner.ReplaceAllString(PropertyKey(street, city, state), "-"), "-")
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
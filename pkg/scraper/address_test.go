@@ -0,0 +1,44 @@
+package scraper
+
+import "testing"
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"2772 Kalawao St Unit 29", "2772 KALAWAO ST UNIT 29"},
+		{"2772 Kalawao Street #29", "2772 KALAWAO ST # 29"},
+		{"1715 North 10th Street", "1715 N 10TH ST"},
+		{"4500 N. Lamar Blvd., Apt. 12", "4500 N LAMAR BLVD APT 12"},
+		{"88 Cedar Lane Suite 200", "88 CEDAR LN STE 200"},
+		{"500 Main Street Southwest", "500 MAIN ST SW"},
+		{"123 North St", "123 NORTH ST"},
+		{"94-1234 Waipahu Depot Road", "94-1234 WAIPAHU DEPOT RD"},
+		{"61 Eastern Promenade", "61 EASTERN PROMENADE"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAddress(tt.in).String(); got != tt.want {
+			t.Errorf("NormalizeAddress(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPropertyKey(t *testing.T) {
+	same := [][3]string{
+		{"2772 Kalawao St Unit 29", "Honolulu", "HI"},
+		{"2772 Kalawao Street #29", "honolulu", "hi"},
+		{"2772 KALAWAO ST APT 29", " Honolulu ", "HI"},
+	}
+	want := "2772 kalawao st 29|honolulu|hi"
+	for _, address := range same {
+		if got := PropertyKey(address[0], address[1], address[2]); got != want {
+			t.Errorf("PropertyKey(%q) = %q, want %q", address[0], got, want)
+		}
+	}
+	if id := PropertyID(same[0][0], same[0][1], same[0][2]); id != "prop_2772-kalawao-st-29-honolulu-hi" {
+		t.Errorf("PropertyID = %q", id)
+	}
+	if PropertyKey("2772 Kalawao St Unit 30", "Honolulu", "HI") == want {
+		t.Error("different units share a key")
+	}
+}
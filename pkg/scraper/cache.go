@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is a cached page along with the validators used to revalidate it
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	StatusCode   int       `json:"statusCode"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	StoredAt     time.Time `json:"storedAt"`
+	Body         []byte    `json:"body"`
+}
+
+// page returns the entry as a fetched page
+func (e *cacheEntry) page() *Page {
+	header := http.Header{}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("Last-Modified", e.LastModified)
+	}
+	return &Page{URL: e.URL, StatusCode: e.StatusCode, Header: header, Body: e.Body, FromCache: true}
+}
+
+// diskCache stores one JSON file per URL in a directory
+type diskCache struct {
+	dir string
+}
+
+// path returns the file a URL is cached in
+func (c *diskCache) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get returns the cached entry for a URL, or nil if there is none
+func (c *diskCache) get(rawURL string) *cacheEntry {
+	data, err := os.ReadFile(c.path(rawURL))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != rawURL {
+		return nil
+	}
+	return &entry
+}
+
+// put writes an entry, replacing the file atomically so readers never see a partial entry
+func (c *diskCache) put(entry *cacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(entry.URL))
+}
@@ -0,0 +1,447 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is returned when a site's robots.txt forbids fetching a page
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// StatusError is returned when a page cannot be fetched because the site
+// answered with an error status, after any retries
+type StatusError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration // Set when the site asked us to come back later
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("fetching %s: HTTP %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", e.RetryAfter)
+	}
+	return msg
+}
+
+// FetchPolicy controls how politely a listing source fetches pages
+type FetchPolicy struct {
+	UserAgent         string        // Identifies the crawler; also matched against robots.txt groups
+	RequestsPerSecond float64       // Sustained request rate per host; 0 disables rate limiting
+	Burst             int           // Requests allowed back to back before the rate applies
+	RespectRobots     bool          // Check robots.txt before fetching and honor its Crawl-delay
+	MaxRetries        int           // Retries after a 429, a 5xx or a network error
+	BaseBackoff       time.Duration // First retry delay, doubled on each attempt
+	MaxBackoff        time.Duration // Longest delay between retries, and the longest Retry-After waited out
+	CacheDir          string        // Directory of the on-disk HTTP cache; empty disables caching
+	CacheTTL          time.Duration // How long a cached page is served without revalidating
+}
+
+// DefaultFetchPolicy returns the policy sources use unless configured otherwise
+func DefaultFetchPolicy() FetchPolicy {
+	return FetchPolicy{
+		UserAgent:         "play-mcp/1.0 (+https://github.com/johan-j/play-mcp)",
+		RequestsPerSecond: 0.5,
+		Burst:             2,
+		RespectRobots:     true,
+		MaxRetries:        3,
+		BaseBackoff:       2 * time.Second,
+		MaxBackoff:        time.Minute,
+		CacheTTL:          6 * time.Hour,
+	}
+}
+
+// FetchPolicies holds a fetch policy per listing source name. The "default"
+// entry applies to sources without their own.
+type FetchPolicies map[string]FetchPolicy
+
+// For returns the policy for a source
+func (p FetchPolicies) For(source string) FetchPolicy {
+	if policy, ok := p[strings.ToLower(source)]; ok {
+		return policy
+	}
+	if policy, ok := p["default"]; ok {
+		return policy
+	}
+	return DefaultFetchPolicy()
+}
+
+// fetchPolicyConfig is a FetchPolicy as written in a config file, where
+// unset fields inherit and durations are strings such as "1m30s"
+type fetchPolicyConfig struct {
+	UserAgent         *string  `json:"userAgent"`
+	RequestsPerSecond *float64 `json:"requestsPerSecond"`
+	Burst             *int     `json:"burst"`
+	RespectRobots     *bool    `json:"respectRobots"`
+	MaxRetries        *int     `json:"maxRetries"`
+	BaseBackoff       string   `json:"baseBackoff"`
+	MaxBackoff        string   `json:"maxBackoff"`
+	CacheDir          *string  `json:"cacheDir"`
+	CacheTTL          string   `json:"cacheTTL"`
+}
+
+// LoadFetchPolicies reads per-source fetch policies from a JSON file such as
+//
+//	{"default": {"cacheDir": "/var/cache/play-mcp", "cacheTTL": "12h"},
+//	 "redfin.com": {"requestsPerSecond": 0.2, "maxRetries": 5}}
+//
+// Each source inherits unset fields from "default", which inherits from DefaultFetchPolicy.
+func LoadFetchPolicies(path string) (FetchPolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fetch policy file: %v", err)
+	}
+	var configs map[string]fetchPolicyConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse fetch policy file: %v", err)
+	}
+
+	base := DefaultFetchPolicy()
+	if config, ok := configs["default"]; ok {
+		if base, err = config.apply(base); err != nil {
+			return nil, fmt.Errorf("default: %v", err)
+		}
+	}
+	policies := FetchPolicies{"default": base}
+	for name, config := range configs {
+		if name == "default" {
+			continue
+		}
+		policy, err := config.apply(base)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		policies[strings.ToLower(name)] = policy
+	}
+	return policies, nil
+}
+
+// apply overrides the fields of policy that the config sets
+func (c fetchPolicyConfig) apply(policy FetchPolicy) (FetchPolicy, error) {
+	if c.UserAgent != nil {
+		policy.UserAgent = *c.UserAgent
+	}
+	if c.RequestsPerSecond != nil {
+		policy.RequestsPerSecond = *c.RequestsPerSecond
+	}
+	if c.Burst != nil {
+		policy.Burst = *c.Burst
+	}
+	if c.RespectRobots != nil {
+		policy.RespectRobots = *c.RespectRobots
+	}
+	if c.MaxRetries != nil {
+		policy.MaxRetries = *c.MaxRetries
+	}
+	if c.CacheDir != nil {
+		policy.CacheDir = *c.CacheDir
+	}
+	durations := []struct {
+		value  string
+		target *time.Duration
+		name   string
+	}{
+		{c.BaseBackoff, &policy.BaseBackoff, "baseBackoff"},
+		{c.MaxBackoff, &policy.MaxBackoff, "maxBackoff"},
+		{c.CacheTTL, &policy.CacheTTL, "cacheTTL"},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid %s: %v", d.name, err)
+		}
+		*d.target = parsed
+	}
+	if policy.RequestsPerSecond < 0 || policy.Burst < 0 || policy.MaxRetries < 0 {
+		return policy, fmt.Errorf("requestsPerSecond, burst and maxRetries must not be negative")
+	}
+	return policy, nil
+}
+
+// Page is a fetched page
+type Page struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	FromCache  bool
+}
+
+// Fetcher fetches pages for a listing source according to its FetchPolicy:
+// rate limited per host, checked against robots.txt, retried with backoff
+// and cached on disk with conditional revalidation
+type Fetcher struct {
+	client *http.Client
+	policy FetchPolicy
+	cache  *diskCache
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	robots  map[string]*robotsRules
+
+	// Replaceable in tests
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func() float64
+}
+
+// NewFetcher creates a fetcher that sends requests with client
+func NewFetcher(client *http.Client, policy FetchPolicy) *Fetcher {
+	f := &Fetcher{
+		client:  client,
+		policy:  policy,
+		buckets: make(map[string]*tokenBucket),
+		robots:  make(map[string]*robotsRules),
+		now:     time.Now,
+		sleep:   sleepContext,
+		jitter:  rand.Float64,
+	}
+	if policy.CacheDir != "" {
+		f.cache = &diskCache{dir: policy.CacheDir}
+	}
+	return f
+}
+
+// Policy returns the fetcher's policy
+func (f *Fetcher) Policy() FetchPolicy {
+	return f.policy
+}
+
+// Get fetches a page, returning a StatusError for non-2xx responses and
+// ErrDisallowed for pages robots.txt forbids
+func (f *Fetcher) Get(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.policy.RespectRobots {
+		rules, err := f.robotsFor(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if !rules.allowed(u.RequestURI()) {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowed, rawURL)
+		}
+	}
+
+	var cached *cacheEntry
+	if f.cache != nil {
+		cached = f.cache.get(rawURL)
+		if cached != nil && f.now().Sub(cached.StoredAt) < f.policy.CacheTTL {
+			return cached.page(), nil
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := f.do(ctx, u, cached)
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			delay = f.backoff(attempt)
+		case resp.StatusCode == http.StatusNotModified && cached != nil:
+			cached.StoredAt = f.now()
+			f.store(cached)
+			return cached.page(), nil
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			if resp.StatusCode == http.StatusOK && f.cache != nil {
+				f.store(&cacheEntry{
+					URL:          rawURL,
+					StatusCode:   resp.StatusCode,
+					ETag:         resp.Header.Get("ETag"),
+					LastModified: resp.Header.Get("Last-Modified"),
+					StoredAt:     f.now(),
+					Body:         resp.Body,
+				})
+			}
+			return resp, nil
+		case retryableStatus(resp.StatusCode):
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), f.now())
+			err = &StatusError{URL: rawURL, StatusCode: resp.StatusCode, RetryAfter: retryAfter}
+			if retryAfter > f.policy.MaxBackoff {
+				return nil, err
+			}
+			delay = f.backoff(attempt)
+			if retryAfter > delay {
+				delay = retryAfter
+			}
+		default:
+			return nil, &StatusError{URL: rawURL, StatusCode: resp.StatusCode}
+		}
+
+		if attempt >= f.policy.MaxRetries {
+			return nil, err
+		}
+		log.Printf("Retrying %s in %s: %v", rawURL, delay.Round(time.Millisecond), err)
+		if err := f.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// do sends a single rate-limited request, revalidating cached when set
+func (f *Fetcher) do(ctx context.Context, u *url.URL, cached *cacheEntry) (*Page, error) {
+	if err := f.wait(ctx, u.Host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.policy.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{URL: u.String(), StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// store writes a cache entry, logging rather than failing the fetch on error
+func (f *Fetcher) store(entry *cacheEntry) {
+	if err := f.cache.put(entry); err != nil {
+		log.Printf("Failed to cache %s: %v", entry.URL, err)
+	}
+}
+
+// wait blocks until the host's rate limit allows another request
+func (f *Fetcher) wait(ctx context.Context, host string) error {
+	f.mu.Lock()
+	bucket, ok := f.buckets[host]
+	if !ok {
+		bucket = newTokenBucket(f.policy.RequestsPerSecond, f.policy.Burst)
+		f.buckets[host] = bucket
+	}
+	delay := bucket.reserve(f.now())
+	f.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	return f.sleep(ctx, delay)
+}
+
+// backoff returns the delay before retry attempt+1: exponential, capped at
+// MaxBackoff, with the upper half jittered so retries from many clients spread out
+func (f *Fetcher) backoff(attempt int) time.Duration {
+	d := float64(f.policy.BaseBackoff) * math.Pow(2, float64(attempt))
+	if limit := float64(f.policy.MaxBackoff); d > limit {
+		d = limit
+	}
+	return time.Duration(d/2 + d/2*f.jitter())
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext sleeps for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tokenBucket limits the request rate to a host. A request reserves a token
+// up front, so concurrent callers queue behind each other.
+type tokenBucket struct {
+	rate   float64 // Tokens added per second; 0 means unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// limit lowers the bucket's rate to at most one request per interval
+func (b *tokenBucket) limit(interval time.Duration) {
+	rate := 1 / interval.Seconds()
+	if b.rate <= 0 || rate < b.rate {
+		b.rate = rate
+		b.burst = 1
+		b.tokens = math.Min(b.tokens, 1)
+	}
+}
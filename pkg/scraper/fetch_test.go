@@ -0,0 +1,267 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestFetcher returns a fetcher on a fake clock that records its sleeps
+// instead of waiting
+func newTestFetcher(policy FetchPolicy) (*Fetcher, *time.Time, *[]time.Duration) {
+	now := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	f := NewFetcher(http.DefaultClient, policy)
+	f.now = func() time.Time { return now }
+	f.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+		return nil
+	}
+	f.jitter = func() float64 { return 0 }
+	return f, &now, &sleeps
+}
+
+func TestFetcherRetriesWithBackoff(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&hits, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, "<html>ok</html>")
+		}
+	}))
+	defer server.Close()
+
+	policy := DefaultFetchPolicy()
+	policy.RequestsPerSecond = 0
+	policy.RespectRobots = false
+	f, _, sleeps := newTestFetcher(policy)
+
+	page, err := f.Get(context.Background(), server.URL+"/listing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(page.Body) != "<html>ok</html>" {
+		t.Errorf("body = %q", page.Body)
+	}
+	// Retry-After outweighs the first 1s backoff; the second backoff doubles to 2s
+	if want := []time.Duration{3 * time.Second, 2 * time.Second}; !reflect.DeepEqual(*sleeps, want) {
+		t.Errorf("sleeps = %v, want %v", *sleeps, want)
+	}
+}
+
+func TestFetcherGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/later":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	policy := DefaultFetchPolicy()
+	policy.RequestsPerSecond = 0
+	policy.RespectRobots = false
+	policy.MaxRetries = 2
+
+	tests := []struct {
+		path       string
+		status     int
+		retryAfter time.Duration
+		sleeps     int
+	}{
+		{"/missing", http.StatusNotFound, 0, 0},
+		{"/later", http.StatusServiceUnavailable, time.Hour, 0}, // Longer than MaxBackoff, so not waited out
+		{"/down", http.StatusBadGateway, 0, 2},
+	}
+	for _, tt := range tests {
+		f, _, sleeps := newTestFetcher(policy)
+		_, err := f.Get(context.Background(), server.URL+tt.path)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("%s: err = %v, want a StatusError", tt.path, err)
+		}
+		if statusErr.StatusCode != tt.status || statusErr.RetryAfter != tt.retryAfter || len(*sleeps) != tt.sleeps {
+			t.Errorf("%s: got %+v after %d retries", tt.path, statusErr, len(*sleeps))
+		}
+	}
+}
+
+func TestFetcherRobots(t *testing.T) {
+	var pageHits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /\n\nUser-agent: play-mcp\nDisallow: /private/\nCrawl-delay: 5\n")
+			return
+		}
+		atomic.AddInt32(&pageHits, 1)
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	f, _, sleeps := newTestFetcher(DefaultFetchPolicy())
+	ctx := context.Background()
+
+	if _, err := f.Get(ctx, server.URL+"/private/listing"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("err = %v, want ErrDisallowed", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := f.Get(ctx, server.URL+"/austin-tx/sold/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pageHits != 2 {
+		t.Errorf("page hits = %d, want 2", pageHits)
+	}
+	// Crawl-delay spaces the two page requests 5s apart
+	if want := []time.Duration{5 * time.Second}; !reflect.DeepEqual(*sleeps, want) {
+		t.Errorf("sleeps = %v, want %v", *sleeps, want)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := []byte(`# comment
+User-agent: Googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /search
+Allow: /search/sold
+Disallow: /*.pdf$
+Disallow: /api/*/private
+`)
+	rules := parseRobots(robots, "play-mcp/1.0 (+https://github.com/johan-j/play-mcp)")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/austin-tx/sold/", true},
+		{"/search?q=austin", false},
+		{"/search/sold/austin", true},
+		{"/brochure.pdf", false},
+		{"/brochure.pdf?v=2", true},
+		{"/api/v1/private/data", false},
+		{"/api/v1/public", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("allowed(%s) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestFetcherCacheRevalidates(t *testing.T) {
+	var hits, revalidations int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidations, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "<html>listing</html>")
+	}))
+	defer server.Close()
+
+	policy := DefaultFetchPolicy()
+	policy.RequestsPerSecond = 0
+	policy.CacheDir = t.TempDir()
+	policy.CacheTTL = time.Hour
+	f, now, _ := newTestFetcher(policy)
+	ctx := context.Background()
+	url := server.URL + "/listing"
+
+	first, err := f.Get(ctx, url)
+	if err != nil || first.FromCache {
+		t.Fatalf("first fetch: %+v, %v", first, err)
+	}
+
+	// A fresh entry is served without a request, even by a new fetcher on the same directory
+	g, _, _ := newTestFetcher(policy)
+	g.now = f.now
+	second, err := g.Get(ctx, url)
+	if err != nil || !second.FromCache || hits != 1 {
+		t.Fatalf("second fetch: %+v, %v (hits %d)", second, err, hits)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	third, err := f.Get(ctx, url)
+	if err != nil || !third.FromCache || string(third.Body) != "<html>listing</html>" {
+		t.Fatalf("third fetch: %+v, %v", third, err)
+	}
+	if hits != 2 || revalidations != 1 {
+		t.Errorf("hits = %d, revalidations = %d, want 2 and 1", hits, revalidations)
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(policy.CacheDir, "*.json"))
+	if len(entries) != 1 {
+		t.Errorf("cache holds %d entries, want 1", len(entries))
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(1, 2)
+
+	var waits []time.Duration
+	for i := 0; i < 4; i++ {
+		waits = append(waits, bucket.reserve(start))
+	}
+	if want := []time.Duration{0, 0, time.Second, 2 * time.Second}; !reflect.DeepEqual(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+	// The queued reservations are paid back before new tokens accumulate
+	if wait := bucket.reserve(start.Add(5 * time.Second)); wait != 0 {
+		t.Errorf("wait after 5s = %v, want 0", wait)
+	}
+}
+
+func TestLoadFetchPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fetch.json")
+	config := `{"default": {"cacheDir": "/tmp/play-mcp", "cacheTTL": "12h"},
+		"Redfin.com": {"requestsPerSecond": 0.2, "maxBackoff": "2m"}}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	policies, err := LoadFetchPolicies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redfin, homes := policies.For("redfin.com"), policies.For("homes.com")
+	if redfin.RequestsPerSecond != 0.2 || redfin.MaxBackoff != 2*time.Minute || redfin.CacheTTL != 12*time.Hour || redfin.CacheDir != "/tmp/play-mcp" {
+		t.Errorf("redfin.com policy = %+v", redfin)
+	}
+	if homes.RequestsPerSecond != DefaultFetchPolicy().RequestsPerSecond || homes.CacheDir != "/tmp/play-mcp" {
+		t.Errorf("homes.com policy = %+v", homes)
+	}
+
+	registry := NewDefaultSourceRegistry()
+	if err := registry.SetFetchPolicies(FetchPolicies{"zillow.com": DefaultFetchPolicy()}); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
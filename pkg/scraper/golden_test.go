@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+)
+
+// TestGoldenParsers replays recorded pages through each parser and compares
+// the result with testdata/golden. Run with -update after an intended parser
+// change, or -record to refresh the fixtures from the live sites.
+func TestGoldenParsers(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(h *HomesScraper, r *RedfinScraper) (interface{}, error)
+	}{
+		{"list_manoa_jsonld", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.ScrapeManoa("sold")
+		}},
+		{"list_austin_dom", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.Search(context.Background(), SearchQuery{City: "Austin", State: "TX", Status: "sold"})
+		}},
+		{"list_portland_app_state", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.ScrapeNeighborhood("Portland", "ME", "East End", "sold")
+		}},
+		{"list_boise_text", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.ScrapeNeighborhood("Boise", "ID", "", "sold")
+		}},
+		{"stats_manoa", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.ScrapeNeighborhoodStats("Honolulu", "HI", "Manoa")
+		}},
+		{"stats_boise_text", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.ScrapeMarketStats("Boise", "ID")
+		}},
+		{"detail_homes", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return h.Detail(context.Background(), "https://www.homes.com/property/2819-poelua-st-honolulu-hi/n207sqkl8vl1p/")
+		}},
+		{"detail_homes_active", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			// An active listing whose nearby sold homes must not mark it sold
+			return h.Detail(context.Background(), "https://www.homes.com/property/3125-kahawai-st-honolulu-hi/r5m2x8kq1c4tz/")
+		}},
+		{"detail_redfin", func(h *HomesScraper, r *RedfinScraper) (interface{}, error) {
+			return r.Detail(context.Background(), "https://www.redfin.com/HI/Honolulu/2819-Poelua-St-96822/home/88513618")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Replayed pages need no throttling; robots.txt has no fixture and so allows everything
+			policy := DefaultFetchPolicy()
+			policy.RequestsPerSecond = 0
+			client := newReplayClient(t)
+			homes, redfin := NewHomesScraperWithClient(client), NewRedfinScraperWithClient(client)
+			homes.SetFetchPolicy(policy)
+			redfin.SetFetchPolicy(policy)
+
+			got, err := tt.run(homes, redfin)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if stats, ok := got.(*MarketStats); ok {
+				stats.Timestamp = "" // Stamped with the scrape time
+			}
+			checkGolden(t, tt.name, got)
+		})
+	}
+}
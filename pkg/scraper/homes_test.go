@@ -1,40 +1,212 @@
 package scraper
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
-func TestHomesScraper(t *testing.T) {
-	scraper := NewHomesScraper()
+func TestParseListingPageAnyLocation(t *testing.T) {
+	html := `<html><body><ul>
+<li class="placard-container"><div class="placard">
+  <p class="price">$1,150,000</p><span>3 Beds</span><span>2 Baths</span><span>1,480 Sq Ft</span>
+  <p>Single Family Home</p><p>SOLD MAR 4, 2025</p>
+  <p class="address">1423 Alameda Ave, San Jose, CA 95126</p>
+</div></li>
+<li class="placard-container"><div class="placard">
+  <p class="price">$415,500</p><span>2 Beds</span><span>2.5 Baths</span><span>1,120 Sq Ft</span>
+  <p>Townhouse</p><p>Built 1998</p>
+  <p class="address">88 Cedar Ln #12, Austin, TX 78704</p>
+</div></li>
+</ul></body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	// Test market stats scraping
-	t.Run("ScrapeMarketStats", func(t *testing.T) {
-		stats, err := scraper.ScrapeMarketStats("Honolulu", "HI")
-		if err != nil {
-			t.Fatalf("Failed to scrape market stats: %v", err)
+	properties := parseListingPage(doc, "sold")
+	if len(properties) != 2 {
+		t.Fatalf("got %d properties, want 2: %+v", len(properties), properties)
+	}
+
+	first, second := properties[0], properties[1]
+	if first.Address != "1423 Alameda Ave" || first.City != "San Jose" || first.State != "CA" || first.ZipCode != "95126" {
+		t.Errorf("unexpected location: %+v", first)
+	}
+	if first.Price != 1150000 || first.Bedrooms != 3 || first.SquareFeet != 1480 || first.PropertyType != "house" || first.SoldDate != "MAR 4, 2025" {
+		t.Errorf("unexpected details: %+v", first)
+	}
+	if second.Address != "88 Cedar Ln #12" || second.City != "Austin" || second.ZipCode != "78704" {
+		t.Errorf("unexpected location: %+v", second)
+	}
+	if second.Price != 415500 || second.Bathrooms != 2.5 || second.PropertyType != "townhouse" || second.YearBuilt != 1998 {
+		t.Errorf("unexpected details: %+v", second)
+	}
+}
+
+func TestParseAddressSlug(t *testing.T) {
+	tests := []struct {
+		slug                 string
+		address, city, state string
+	}{
+		{"2819-poelua-st-honolulu-hi", "2819 Poelua St", "Honolulu", "HI"},
+		{"123-main-st-apt-4-san-jose-ca", "123 Main St Apt 4", "San Jose", "CA"},
+		{"4500-n-lamar-blvd-austin-tx", "4500 N Lamar Blvd", "Austin", "TX"},
+	}
+	for _, tt := range tests {
+		address, city, state := parseAddressSlug(tt.slug)
+		if address != tt.address || city != tt.city || state != tt.state {
+			t.Errorf("parseAddressSlug(%s) = %q, %q, %q", tt.slug, address, city, state)
 		}
+	}
+}
 
-		if stats.Area == "" {
-			t.Error("Area should not be empty")
+func TestLocationPath(t *testing.T) {
+	if got := locationPath("San Francisco", "CA", "Noe Valley"); got != "san-francisco-ca/noe-valley-neighborhood" {
+		t.Errorf("locationPath = %s", got)
+	}
+	if got := locationPath("Austin", "TX", ""); got != "austin-tx" {
+		t.Errorf("locationPath = %s", got)
+	}
+	// A registry slug is used as is rather than derived from the name
+	query := SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa", NeighborhoodSlug: "manoa-valley"}
+	if got := locationPath(query.City, query.State, query.neighborhoodSlug()); got != "honolulu-hi/manoa-valley-neighborhood" {
+		t.Errorf("locationPath = %s", got)
+	}
+}
+
+func TestListingStatus(t *testing.T) {
+	doc := parseHTML(t, `<html><body>
+<div class="placard"><p class="price">$780,000</p><span>Pending</span><p class="address">9 Elm Ct, Boise, ID 83702</p></div>
+<div class="placard"><p class="price">$455,000</p><span>New listing</span><p class="address">13 Elm Ct, Boise, ID 83702</p></div>
+</body></html>`)
+	properties := parseListingPage(doc, "for_sale")
+	if len(properties) != 2 || properties[0].Status != "pending" || properties[1].Status != "for_sale" {
+		t.Errorf("statuses = %+v", properties)
+	}
+
+	// Detail pages only give a status they state
+	for _, tt := range []struct {
+		text, soldDate, want string
+	}{
+		{"3 Beds 2 Baths $1,150,000", "", ""},
+		{"Status: Active 3 Beds", "", "for_sale"},
+		{"Listing status: Under Contract", "", "under_contract"},
+		{"SOLD JAN 17, 2025", "JAN 17, 2025", "sold"},
+	} {
+		if got := detailStatus(tt.text, tt.soldDate); got != tt.want {
+			t.Errorf("detailStatus(%q) = %q, want %q", tt.text, got, tt.want)
 		}
+	}
+}
+
+// lookupFunc adapts a function to PropertyLookup
+type lookupFunc func(address, city, state string) (*Property, bool)
+
+func (f lookupFunc) LookupProperty(ctx context.Context, address, city, state string) (*Property, bool) {
+	return f(address, city, state)
+}
+
+// requestLog records the URLs an HTTP client requests
+type requestLog struct {
+	next http.RoundTripper
+	urls []string
+}
 
-		t.Logf("Market Stats: %+v", stats)
-	})
+func (l *requestLog) RoundTrip(req *http.Request) (*http.Response, error) {
+	l.urls = append(l.urls, req.URL.String())
+	return l.next.RoundTrip(req)
+}
 
-	// Test property scraping
-	t.Run("ScrapeManoa", func(t *testing.T) {
-		properties, err := scraper.ScrapeManoa("sold")
+func TestHomesDetailLookup(t *testing.T) {
+	const url = "https://www.homes.com/property/2819-poelua-st-honolulu-hi/n207sqkl8vl1p/"
+	detail := func(lookup PropertyLookup) (*Property, []string) {
+		t.Helper()
+		policy := DefaultFetchPolicy()
+		policy.RequestsPerSecond = 0
+		requests := &requestLog{next: newReplayClient(t).Transport}
+		h := NewHomesScraperWithClient(&http.Client{Transport: requests})
+		h.SetFetchPolicy(policy)
+		if lookup != nil {
+			h.SetPropertyLookup(lookup)
+		}
+		property, err := h.Detail(context.Background(), url)
 		if err != nil {
-			t.Fatalf("Failed to scrape properties: %v", err)
+			t.Fatal(err)
 		}
+		return property, requests.urls
+	}
 
-		if len(properties) == 0 {
-			t.Error("Should have found some properties")
+	// A stored record of the same address is used without fetching anything
+	stored := &Property{Address: "2819 Poelua Street", City: "Honolulu", State: "HI", Price: 1}
+	if property, urls := detail(lookupFunc(func(address, city, state string) (*Property, bool) { return stored, true })); property != stored || len(urls) != 0 {
+		t.Errorf("exact lookup = %+v, fetched %v", property, urls)
+	}
+	// A record of another house on the street is not, and no sold list is scraped to find one
+	other := &Property{Address: "12819 Poelua St", City: "Honolulu", State: "HI", Price: 1}
+	for _, lookup := range []PropertyLookup{nil, lookupFunc(func(address, city, state string) (*Property, bool) { return other, true })} {
+		property, urls := detail(lookup)
+		if property == other || property.Price == 1 {
+			t.Errorf("used a stored record of another address: %+v", property)
+		}
+		for _, u := range urls {
+			if strings.Contains(u, "/sold") {
+				t.Errorf("scraped a sold list: %v", urls)
+			}
 		}
+	}
+}
+
+// listingPages serves search result pages, giving for each path the street
+// number of its first listing card and how many cards it holds
+type listingPages map[string][2]int
+
+func (pages listingPages) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body strings.Builder
+	body.WriteString("<html><body>")
+	first, count := pages[req.URL.Path][0], pages[req.URL.Path][1]
+	for i := first; i < first+count; i++ {
+		fmt.Fprintf(&body, `<div class="placard"><p class="price">$%d</p><p class="address">%d Elm Ct, Boise, ID 83702</p></div>`, 400000+i*1000, i)
+	}
+	body.WriteString("</body></html>")
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"text/html"}},
+		Body: io.NopCloser(strings.NewReader(body.String())), Request: req}, nil
+}
 
-		t.Logf("Found %d properties", len(properties))
-		if len(properties) > 0 {
-			t.Logf("First property: %+v", properties[0])
+func TestHomesSearchComplete(t *testing.T) {
+	search := func(pages listingPages, known map[string]bool) (int, bool) {
+		t.Helper()
+		policy := DefaultFetchPolicy()
+		policy.RequestsPerSecond = 0
+		h := NewHomesScraperWithClient(&http.Client{Transport: pages})
+		h.SetFetchPolicy(policy)
+		properties, complete, err := h.SearchComplete(context.Background(), SearchQuery{City: "Boise", State: "ID", Status: "for_sale", KnownIDs: known})
+		if err != nil {
+			t.Fatal(err)
 		}
-	})
+		return len(properties), complete
+	}
+
+	// A short page is the last one
+	if n, complete := search(listingPages{"/boise-id/for_sale/": {100, 20}, "/boise-id/for_sale/p2/": {200, 5}}, nil); n != 25 || !complete {
+		t.Errorf("short last page: %d listings, complete %v", n, complete)
+	}
+	// Full pages up to the page limit may leave more unread
+	full := listingPages{"/boise-id/for_sale/": {100, 20}, "/boise-id/for_sale/p2/": {200, 20}, "/boise-id/for_sale/p3/": {300, 20}, "/boise-id/for_sale/p4/": {400, 5}}
+	if n, complete := search(full, nil); n != 60 || complete {
+		t.Errorf("page limit: %d listings, complete %v", n, complete)
+	}
+	// So may stopping at a page of known listings
+	known := make(map[string]bool)
+	for i := 100; i < 120; i++ {
+		known[PropertyID(fmt.Sprintf("%d Elm Ct", i), "Boise", "ID")] = true
+	}
+	if n, complete := search(full, known); n != 20 || complete {
+		t.Errorf("known page: %d listings, complete %v", n, complete)
+	}
 }
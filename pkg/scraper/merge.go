@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"reflect"
+	"strings"
+)
+
+// MergeRules ranks listing sources per field, most trusted first, for
+// MergeSource. Fields without a rule use the "default" ranking; sources
+// missing from a ranking come after those in it.
+type MergeRules map[string][]string
+
+// DefaultMergeRules trusts Redfin's MLS-fed sale facts and homes.com's
+// listing copy; every other field goes to whichever source extracted it with
+// more confidence
+var DefaultMergeRules = MergeRules{
+	"price":        {"redfin.com", "homes.com"},
+	"soldDate":     {"redfin.com", "homes.com"},
+	"daysOnMarket": {"redfin.com", "homes.com"},
+	"description":  {"homes.com", "redfin.com"},
+	"agent":        {"homes.com", "redfin.com"},
+	"brokerage":    {"homes.com", "redfin.com"},
+}
+
+// rank returns a source's position in a field's ranking, or the length of the
+// ranking when the source is not in it
+func (r MergeRules) rank(field, source string) int {
+	ranking, ok := r[field]
+	if !ok {
+		ranking = r["default"]
+	}
+	for i, s := range ranking {
+		if s == source {
+			return i
+		}
+	}
+	return len(ranking)
+}
+
+// untrackedField is a Property field MergeSource fills from any source with a
+// value, by index and JSON name
+type untrackedField struct {
+	index int
+	name  string
+}
+
+// untrackedFields are the Property fields that MergeSource fills from any
+// source with a value: those not in listingFields, less the ones it derives
+// itself
+var untrackedFields = func() []untrackedField {
+	tracked := map[string]bool{"ID": true, "PricePerSqFt": true, "Status": true, "Confidence": true, "Provenance": true,
+		"Latitude": true, "Longitude": true} // Tracked together as location
+	for _, field := range listingFields {
+		tracked[field.name] = true
+	}
+	var fields []untrackedField
+	t := reflect.TypeOf(Property{})
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		jsonName := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if !tracked[name] && !tracked[jsonName] {
+			fields = append(fields, untrackedField{index: i, name: jsonName})
+		}
+	}
+	return fields
+}()
+
+// MergeSource reconciles a record of the same property from source into dst,
+// field by field. A field is taken from src when dst lacks it, when dst's
+// value came from the same source (so it is a newer copy), when the rules rank
+// src's source higher, or, between equally ranked sources, when src extracted
+// it with more confidence. Fields without confidence scores, such as school
+// details, are taken from src whenever it has them. dst.Provenance records
+// where each value came from; a dst without provenance is treated as having
+// come from source.
+func MergeSource(dst *Property, src Property, source string, rules MergeRules) {
+	if dst.Confidence == nil {
+		dst.Confidence = make(map[string]float64)
+	}
+	if dst.Provenance == nil {
+		dst.Provenance = make(map[string]string)
+	}
+	for _, field := range listingFields {
+		if !field.isSet(&src) {
+			continue
+		}
+		if field.isSet(dst) {
+			current, ok := dst.Provenance[field.name]
+			if !ok {
+				current = source
+			}
+			if current != source {
+				srcRank, dstRank := rules.rank(field.name, source), rules.rank(field.name, current)
+				if srcRank > dstRank || srcRank == dstRank && src.Confidence[field.name] <= dst.Confidence[field.name] {
+					continue
+				}
+			}
+		}
+		field.copy(dst, &src)
+		if confidence, ok := src.Confidence[field.name]; ok {
+			dst.Confidence[field.name] = confidence
+		} else {
+			delete(dst.Confidence, field.name)
+		}
+		dst.Provenance[field.name] = source
+	}
+	dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	for _, field := range untrackedFields {
+		if !sv.Field(field.index).IsZero() {
+			dv.Field(field.index).Set(sv.Field(field.index))
+			dst.Provenance[field.name] = source
+		}
+	}
+	// The listing status goes with the price it describes
+	if src.Status != "" && dst.Provenance["price"] == source {
+		dst.Status = src.Status
+	}
+	if dst.Status == "" {
+		dst.Status = src.Status
+	}
+	if dst.Address != "" {
+		dst.ID = PropertyID(dst.Address, dst.City, dst.State)
+	}
+	if dst.Price > 0 && dst.SquareFeet > 0 {
+		dst.PricePerSqFt = dst.Price / dst.SquareFeet
+	}
+}
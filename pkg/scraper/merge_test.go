@@ -0,0 +1,52 @@
+package scraper
+
+import "testing"
+
+func TestMergeSource(t *testing.T) {
+	homes := Property{
+		Address: "2772 Kalawao St Unit 29", City: "Honolulu", State: "HI", Status: "sold",
+		Price: 1940000, SquareFeet: 1200, Description: "Corner unit with valley views", ElementarySchool: "Noelani Elementary School",
+		Confidence: map[string]float64{"address": confidenceJSONLD, "price": confidenceJSONLD, "squareFeet": confidenceCardText, "description": confidenceDOM},
+	}
+	redfin := Property{
+		Address: "2772 Kalawao Street #29", City: "Honolulu", State: "HI", Status: "sold",
+		Price: 1942000, SquareFeet: 1215, Bedrooms: 3, Description: "Updated 3 bedroom",
+		Confidence: map[string]float64{"address": confidenceDOM, "price": confidenceDOM, "squareFeet": confidenceDOM, "bedrooms": confidenceDOM, "description": confidenceDOM},
+	}
+
+	var merged Property
+	MergeSource(&merged, homes, "homes.com", DefaultMergeRules)
+	MergeSource(&merged, redfin, "redfin.com", DefaultMergeRules)
+
+	checks := []struct {
+		field  string
+		got    interface{}
+		want   interface{}
+		source string
+	}{
+		{"price", merged.Price, 1942000, "redfin.com"},                                          // Ranked above homes.com
+		{"description", merged.Description, "Corner unit with valley views", "homes.com"},       // Ranked above redfin.com
+		{"squareFeet", merged.SquareFeet, 1215, "redfin.com"},                                   // Higher confidence
+		{"address", merged.Address, "2772 Kalawao St Unit 29", "homes.com"},                     // Higher confidence
+		{"bedrooms", merged.Bedrooms, 3, "redfin.com"},                                          // Only redfin.com has it
+		{"elementarySchool", merged.ElementarySchool, "Noelani Elementary School", "homes.com"}, // Untracked, only homes.com has it
+	}
+	for _, c := range checks {
+		if c.got != c.want || merged.Provenance[c.field] != c.source {
+			t.Errorf("%s = %v from %s, want %v from %s", c.field, c.got, merged.Provenance[c.field], c.want, c.source)
+		}
+	}
+	if merged.PricePerSqFt != 1942000/1215 {
+		t.Errorf("merged = %+v", merged)
+	}
+	if merged.ID != "prop_2772-kalawao-st-29-honolulu-hi" {
+		t.Errorf("ID = %s", merged.ID)
+	}
+
+	// A newer record from the same source replaces its own values
+	homes.Description = "Price improved"
+	MergeSource(&merged, homes, "homes.com", DefaultMergeRules)
+	if merged.Description != "Price improved" || merged.Price != 1942000 {
+		t.Errorf("after homes.com refresh: description %q, price %d", merged.Description, merged.Price)
+	}
+}
@@ -0,0 +1,298 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RedfinScraper handles scraping property detail pages from redfin.com
+type RedfinScraper struct {
+	client  *http.Client
+	fetcher *Fetcher
+}
+
+// NewRedfinScraper creates a new redfin.com scraper
+func NewRedfinScraper() *RedfinScraper {
+	return NewRedfinScraperWithClient(newHTTPClient())
+}
+
+// NewRedfinScraperWithClient creates a redfin.com scraper that fetches pages with client
+func NewRedfinScraperWithClient(client *http.Client) *RedfinScraper {
+	return &RedfinScraper{client: client, fetcher: NewFetcher(client, DefaultFetchPolicy())}
+}
+
+// SetFetchPolicy changes how the scraper rate limits, retries and caches requests
+func (r *RedfinScraper) SetFetchPolicy(policy FetchPolicy) {
+	r.fetcher = NewFetcher(r.client, policy)
+}
+
+// Name returns the source name
+func (r *RedfinScraper) Name() string {
+	return "redfin.com"
+}
+
+// Matches reports whether the URL is a redfin.com page
+func (r *RedfinScraper) Matches(rawURL string) bool {
+	return hostMatches(rawURL, "redfin.com")
+}
+
+// Search is not supported; Redfin search results are rendered client-side
+func (r *RedfinScraper) Search(ctx context.Context, query SearchQuery) ([]Property, error) {
+	return nil, ErrNotSupported
+}
+
+// MarketStats is not supported for redfin.com
+func (r *RedfinScraper) MarketStats(ctx context.Context, query SearchQuery) (*MarketStats, error) {
+	return nil, ErrNotSupported
+}
+
+// Detail scrapes property details from a Redfin URL
+func (r *RedfinScraper) Detail(ctx context.Context, url string) (*Property, error) {
+	log.Printf("Fetching property details from Redfin URL: %s", url)
+
+	property := &Property{}
+
+	// Parse address from Redfin URL format: /HI/Honolulu/2819-Poelua-St-96822/home/88513618
+	urlParts := strings.Split(url, "/")
+	for i, part := range urlParts {
+		// Look for state (2 letters)
+		if len(part) == 2 && strings.ToUpper(part) == part {
+			property.State = part
+			// Next part should be city
+			if i+1 < len(urlParts) {
+				property.City = urlParts[i+1]
+			}
+			// Next part should be address-zipcode
+			if i+2 < len(urlParts) {
+				addressPart := urlParts[i+2]
+				// Split on last dash to separate address from zipcode
+				lastDashIndex := strings.LastIndex(addressPart, "-")
+				if lastDashIndex > 0 {
+					address := addressPart[:lastDashIndex]
+					zipCode := addressPart[lastDashIndex+1:]
+
+					// Convert dashes to spaces and capitalize
+					addressComponents := strings.Split(address, "-")
+					for j, comp := range addressComponents {
+						addressComponents[j] = strings.Title(comp)
+					}
+					property.Address = strings.Join(addressComponents, " ")
+					property.ZipCode = zipCode
+				}
+			}
+			break
+		}
+	}
+
+	page, err := r.fetcher.Get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Redfin page: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redfin page: %v", err)
+	}
+
+	// Extract data from Redfin page structure
+	pageText := doc.Text()
+
+	log.Printf("Extracting comprehensive property details from Redfin...")
+
+	// Enhanced regex patterns for better extraction
+	priceRegex := regexp.MustCompile(`\$([0-9,]+(?:\.[0-9]{2})?)`)
+	sqftRegex := regexp.MustCompile(`([0-9,]+)\s*(?:sq\.?\s*ft\.?|square\s+feet)`)
+	bedroomRegex := regexp.MustCompile(`(\d+)\s*(?:bed|bedroom)`)
+	bathroomRegex := regexp.MustCompile(`([0-9.]+)\s*(?:bath|bathroom)`)
+	yearRegex := regexp.MustCompile(`(?i)built\s*:?\s*(?:in\s+)?(\d{4})`)
+	lotSizeRegex := regexp.MustCompile(`([0-9,.]+)\s*(?:acres?|ac\b)`)
+	daysOnMarketRegex := regexp.MustCompile(`(\d+)\s*days?\s*on\s*market`)
+
+	// School-related patterns
+	schoolDistrictRegex := regexp.MustCompile(`(?:district|District):\s*([^,\n]+)`)
+	elementaryRegex := regexp.MustCompile(`Elementary:\s*([^,\n]+)`)
+	middleRegex := regexp.MustCompile(`Middle:\s*([^,\n]+)`)
+	highRegex := regexp.MustCompile(`High:\s*([^,\n]+)`)
+
+	// Property condition and features
+	conditionRegex := regexp.MustCompile(`Condition:\s*([^,\n]+)`)
+	propertyTaxRegex := regexp.MustCompile(`Property\s+Tax:\s*\$([0-9,]+)`)
+	hoaRegex := regexp.MustCompile(`HOA:\s*\$([0-9,]+)`)
+	parkingRegex := regexp.MustCompile(`(\d+)\s*car\s*garage|(\d+)\s*parking\s*space`)
+
+	// Extract basic property details
+	if priceMatch := priceRegex.FindStringSubmatch(pageText); len(priceMatch) > 1 {
+		property.Price = parsePrice(priceMatch[1])
+	}
+
+	if sqftMatch := sqftRegex.FindStringSubmatch(pageText); len(sqftMatch) > 1 {
+		property.SquareFeet = parsePrice(sqftMatch[1])
+	}
+
+	if bedMatch := bedroomRegex.FindStringSubmatch(pageText); len(bedMatch) > 1 {
+		property.Bedrooms = parseInt(bedMatch[1])
+	}
+
+	if bathMatch := bathroomRegex.FindStringSubmatch(pageText); len(bathMatch) > 1 {
+		property.Bathrooms = parseFloat(bathMatch[1])
+	}
+
+	if yearMatch := yearRegex.FindStringSubmatch(pageText); len(yearMatch) > 1 {
+		property.YearBuilt = parseInt(yearMatch[1])
+	}
+
+	// Only a status the listing states; a detail fetch must not relist or sell a property
+	property.Status, property.SoldDate = detailListing(doc, redfinDetailHeader)
+
+	if lotMatch := lotSizeRegex.FindStringSubmatch(pageText); len(lotMatch) > 1 {
+		property.LotSize = parseFloat(lotMatch[1])
+	}
+
+	if daysMatch := daysOnMarketRegex.FindStringSubmatch(pageText); len(daysMatch) > 1 {
+		property.DaysOnMarket = parseInt(daysMatch[1])
+	}
+
+	// Extract school information
+	if districtMatch := schoolDistrictRegex.FindStringSubmatch(pageText); len(districtMatch) > 1 {
+		property.SchoolDistrict = strings.TrimSpace(districtMatch[1])
+	}
+
+	if elemMatch := elementaryRegex.FindStringSubmatch(pageText); len(elemMatch) > 1 {
+		property.ElementarySchool = strings.TrimSpace(elemMatch[1])
+	}
+
+	if middleMatch := middleRegex.FindStringSubmatch(pageText); len(middleMatch) > 1 {
+		property.MiddleSchool = strings.TrimSpace(middleMatch[1])
+	}
+
+	if highMatch := highRegex.FindStringSubmatch(pageText); len(highMatch) > 1 {
+		property.HighSchool = strings.TrimSpace(highMatch[1])
+	}
+
+	// Extract property condition and financial details
+	if condMatch := conditionRegex.FindStringSubmatch(pageText); len(condMatch) > 1 {
+		property.PropertyCondition = strings.TrimSpace(condMatch[1])
+	}
+
+	if taxMatch := propertyTaxRegex.FindStringSubmatch(pageText); len(taxMatch) > 1 {
+		property.PropertyTax = "$" + taxMatch[1]
+	}
+
+	if hoaMatch := hoaRegex.FindStringSubmatch(pageText); len(hoaMatch) > 1 {
+		property.HOAFees = "$" + hoaMatch[1]
+	}
+
+	// Extract parking information
+	if parkingMatch := parkingRegex.FindStringSubmatch(pageText); len(parkingMatch) > 1 {
+		if parkingMatch[1] != "" {
+			property.ParkingSpaces = parseInt(parkingMatch[1])
+			property.Garage = parkingMatch[1] + " car garage"
+		} else if parkingMatch[2] != "" {
+			property.ParkingSpaces = parseInt(parkingMatch[2])
+		}
+	}
+
+	// Extract property description from specific Redfin elements
+	doc.Find(".remarks, .property-description, .listing-description, .public-remarks").Each(func(i int, s *goquery.Selection) {
+		desc := strings.TrimSpace(s.Text())
+		if desc != "" && len(desc) > len(property.Description) {
+			property.Description = desc
+		}
+	})
+
+	// If no structured description found, look for description patterns in text
+	if property.Description == "" {
+		descRegex := regexp.MustCompile(`(?i)description[:\s]*([^.]{50,500}\.?)`)
+		if descMatch := descRegex.FindStringSubmatch(pageText); len(descMatch) > 1 {
+			property.Description = strings.TrimSpace(descMatch[1])
+		}
+	}
+
+	// Extract comprehensive features list
+	var features []string
+
+	// Look for structured feature lists
+	doc.Find(".amenity-group li, .feature-list li, .amenities li, .features li").Each(func(i int, s *goquery.Selection) {
+		feature := strings.TrimSpace(s.Text())
+		if feature != "" && len(feature) < 100 {
+			features = append(features, feature)
+		}
+	})
+
+	// Extract features from common property details sections
+	featurePatterns := []string{
+		`Heating:\s*([^,\n]+)`,
+		`Cooling:\s*([^,\n]+)`,
+		`Flooring:\s*([^,\n]+)`,
+		`Appliances:\s*([^,\n]+)`,
+		`Roof:\s*([^,\n]+)`,
+		`Foundation:\s*([^,\n]+)`,
+		`View:\s*([^,\n]+)`,
+		`Fireplace:\s*([^,\n]+)`,
+	}
+
+	for _, pattern := range featurePatterns {
+		regex := regexp.MustCompile(pattern)
+		if match := regex.FindStringSubmatch(pageText); len(match) > 1 {
+			features = append(features, strings.TrimSpace(match[1]))
+		}
+	}
+
+	// Add specific fields based on extracted features
+	for _, feature := range features {
+		lower := strings.ToLower(feature)
+		if strings.Contains(lower, "heating") {
+			property.Heating = feature
+		}
+		if strings.Contains(lower, "cooling") || strings.Contains(lower, "air") {
+			property.Cooling = feature
+		}
+		if strings.Contains(lower, "floor") {
+			property.Flooring = append(property.Flooring, feature)
+		}
+		if strings.Contains(lower, "appliance") {
+			property.Appliances = append(property.Appliances, feature)
+		}
+	}
+
+	property.Features = features
+
+	// Extract agent and brokerage information
+	doc.Find(".agent-name, .listing-agent, .brokerage-name").Each(func(i int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if strings.Contains(strings.ToLower(text), "agent") && property.Agent == "" {
+			property.Agent = text
+		}
+		if strings.Contains(strings.ToLower(text), "broker") && property.Brokerage == "" {
+			property.Brokerage = text
+		}
+	})
+
+	// Set property type with more specific detection
+	property.PropertyType = "house"
+	lowerText := strings.ToLower(pageText)
+	if strings.Contains(lowerText, "condominium") || strings.Contains(lowerText, "condo") {
+		property.PropertyType = "condo"
+	} else if strings.Contains(lowerText, "townhouse") || strings.Contains(lowerText, "townhome") {
+		property.PropertyType = "townhouse"
+	} else if strings.Contains(lowerText, "apartment") {
+		property.PropertyType = "apartment"
+	}
+
+	// Generate ID
+	property.ID = PropertyID(property.Address, property.City, property.State)
+
+	// Calculate price per sqft
+	if property.Price > 0 && property.SquareFeet > 0 {
+		property.PricePerSqFt = property.Price / property.SquareFeet
+	}
+
+	return property, nil
+}
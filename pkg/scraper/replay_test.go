@@ -0,0 +1,157 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	update = flag.Bool("update", false, "rewrite golden files in testdata/golden with the current parser output")
+	record = flag.Bool("record", false, "fetch pages from the live sites and save sanitized fixtures to testdata/fixtures")
+)
+
+// replayTransport serves recorded pages from testdata/fixtures. In -record
+// mode it fetches each page live first and stores a sanitized copy. Pages
+// without a fixture get a 404, as a blocked or missing page would.
+type replayTransport struct {
+	t    *testing.T
+	live http.RoundTripper
+}
+
+// newReplayClient returns an HTTP client backed by recorded fixtures
+func newReplayClient(t *testing.T) *http.Client {
+	return &http.Client{Transport: &replayTransport{t: t, live: newHTTPClient().Transport}}
+}
+
+// fixturePath maps a URL to its fixture file, e.g.
+// https://www.homes.com/austin-tx/sold/ -> testdata/fixtures/www.homes.com_austin-tx_sold.html
+func fixturePath(u *url.URL) string {
+	name := regexp.MustCompile(`[^A-Za-z0-9.-]+`).ReplaceAllString(u.Host+u.Path, "_")
+	return filepath.Join("testdata", "fixtures", strings.Trim(name, "_")+".html")
+}
+
+func (r *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := fixturePath(req.URL)
+
+	if *record {
+		resp, err := r.live.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			if err := os.WriteFile(path, sanitizeHTML(body), 0o644); err != nil {
+				return nil, err
+			}
+			r.t.Logf("recorded %s -> %s", req.URL, path)
+		} else {
+			r.t.Logf("not recording %s: HTTP %d", req.URL, resp.StatusCode)
+		}
+	}
+
+	status, body := http.StatusOK, []byte{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		body = data
+	case os.IsNotExist(err):
+		status = http.StatusNotFound
+	default:
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+var (
+	scriptRegex   = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script>`)
+	commentRegex  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	emailRegex    = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phoneRegex    = regexp.MustCompile(`\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`)
+	trackingRegex = regexp.MustCompile(`([?&])(utm_[a-z]+|gclid|fbclid|sessionid|sid)=[^&"'\s]*`)
+)
+
+// sanitizeHTML strips what a fixture does not need and should not keep:
+// third-party and analytics scripts, comments, contact details and
+// tracking parameters. Structured data scripts the parsers read are kept.
+func sanitizeHTML(body []byte) []byte {
+	body = scriptRegex.ReplaceAllFunc(body, func(script []byte) []byte {
+		match := scriptRegex.FindSubmatch(script)
+		attrs, content := string(match[1]), string(match[2])
+		if strings.Contains(attrs, "application/ld+json") || strings.Contains(attrs, "application/json") ||
+			strings.Contains(attrs, "__NEXT_DATA__") || appStateRegex.MatchString(strings.TrimSpace(content)) {
+			return script
+		}
+		return nil
+	})
+	body = commentRegex.ReplaceAll(body, nil)
+	body = emailRegex.ReplaceAll(body, []byte("agent@example.com"))
+	body = phoneRegex.ReplaceAll(body, []byte("(555) 555-0100"))
+	body = trackingRegex.ReplaceAll(body, []byte("$1"))
+	return body
+}
+
+// checkGolden compares value, as indented JSON, with testdata/golden/name.json,
+// rewriting the file instead when -update is set
+func checkGolden(t *testing.T, name string, value interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test ./pkg/scraper -update): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match the parser output; review the diff and run go test ./pkg/scraper -update if the change is intended\n got: %s", path, got)
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	in := `<html><head><!-- build 42 --><script src="https://cdn.example.com/analytics.js"></script>
+<script>gtag('config', 'G-123');</script>
+<script type="application/ld+json">{"@type": "Residence"}</script></head>
+<body><a href="/listing?id=7&utm_source=mail">Call Jane at (808) 555-1234 or jane.doe@realty.com</a></body></html>`
+
+	out := string(sanitizeHTML([]byte(in)))
+	for _, gone := range []string{"analytics.js", "gtag", "build 42", "808", "jane.doe", "utm_source"} {
+		if strings.Contains(out, gone) {
+			t.Errorf("sanitized HTML still contains %q:\n%s", gone, out)
+		}
+	}
+	for _, kept := range []string{`{"@type": "Residence"}`, "agent@example.com", "(555) 555-0100", "/listing?id=7"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("sanitized HTML lost %q:\n%s", kept, out)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsTTL is how long robots.txt rules are reused before fetching them again
+const robotsTTL = 24 * time.Hour
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules are the robots.txt rules that apply to our user agent on one host
+type robotsRules struct {
+	rules       []robotsRule
+	crawlDelay  time.Duration
+	disallowAll bool // robots.txt could not be read because of a server error
+	fetchedAt   time.Time
+}
+
+// allowed reports whether a path (with query) may be fetched. The longest
+// matching pattern wins and Allow wins a tie, as in RFC 9309.
+func (r *robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !robotsPatternMatches(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsFor returns the robots.txt rules for a URL's host, fetching them on
+// first use. A missing robots.txt (4xx) allows everything; a server error
+// disallows everything until the rules are fetched again.
+func (f *Fetcher) robotsFor(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	key := u.Scheme + "://" + u.Host
+	f.mu.Lock()
+	rules, ok := f.robots[key]
+	f.mu.Unlock()
+	if ok && f.now().Sub(rules.fetchedAt) < robotsTTL {
+		return rules, nil
+	}
+
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	page, err := f.do(ctx, robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", robotsURL, err)
+	}
+
+	switch {
+	case page.StatusCode >= 200 && page.StatusCode < 300:
+		rules = parseRobots(page.Body, f.policy.UserAgent)
+	case page.StatusCode >= 500:
+		log.Printf("%s returned HTTP %d, treating the site as disallowed", robotsURL, page.StatusCode)
+		rules = &robotsRules{disallowAll: true}
+	default:
+		rules = &robotsRules{}
+	}
+	rules.fetchedAt = f.now()
+
+	f.mu.Lock()
+	f.robots[key] = rules
+	if rules.crawlDelay > 0 {
+		bucket, ok := f.buckets[u.Host]
+		if !ok {
+			bucket = newTokenBucket(f.policy.RequestsPerSecond, f.policy.Burst)
+			f.buckets[u.Host] = bucket
+		}
+		bucket.limit(rules.crawlDelay)
+	}
+	f.mu.Unlock()
+	return rules, nil
+}
+
+// parseRobots extracts the rules for userAgent from a robots.txt file. The
+// groups naming the agent's product token are used, or the "*" groups when
+// none do.
+func parseRobots(data []byte, userAgent string) *robotsRules {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var (
+		specific, wildcard robotsRules
+		matchedAgent       bool
+		groupAgents        []string
+		inRules            bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		if field == "user-agent" {
+			// A user-agent line after rules starts a new group
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+			continue
+		}
+
+		inRules = true
+		var targets []*robotsRules
+		for _, agent := range groupAgents {
+			switch {
+			case agent == "*":
+				targets = append(targets, &wildcard)
+			case token != "" && agent == token:
+				targets = append(targets, &specific)
+				matchedAgent = true
+			}
+		}
+
+		for _, target := range targets {
+			switch field {
+			case "allow", "disallow":
+				if value != "" {
+					target.rules = append(target.rules, robotsRule{pattern: value, allow: field == "allow"})
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					target.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if matchedAgent {
+		return &specific
+	}
+	return &wildcard
+}
+
+// robotsPatternMatches reports whether a robots.txt path pattern matches a
+// path. "*" matches any sequence and a trailing "$" anchors the end.
+func robotsPatternMatches(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for _, part := range parts[1:] {
+		i := strings.Index(path[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+	if !anchored {
+		return true
+	}
+	// The last part must be able to end exactly at the end of the path
+	last := parts[len(parts)-1]
+	return pos == len(path) || (len(parts) > 1 && strings.HasSuffix(path, last))
+}
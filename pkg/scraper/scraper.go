@@ -0,0 +1,152 @@
+package scraper
+
+import (
+	"crypto/tls"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property represents a property from homes.com or redfin.com
+type Property struct {
+	ID           string   `json:"id"`
+	Address      string   `json:"address"`
+	City         string   `json:"city"`
+	State        string   `json:"state"`
+	ZipCode      string   `json:"zipCode"`
+	APN          string   `json:"apn,omitempty"` // Assessor's parcel number
+	Price        int      `json:"price"`
+	Bedrooms     int      `json:"bedrooms"`
+	Bathrooms    float64  `json:"bathrooms"`
+	SquareFeet   int      `json:"squareFeet"`
+	LotSize      float64  `json:"lotSize"`
+	PricePerSqFt int      `json:"pricePerSqFt"`
+	YearBuilt    int      `json:"yearBuilt"`
+	Latitude     float64  `json:"latitude,omitempty"`
+	Longitude    float64  `json:"longitude,omitempty"`
+	PropertyType string   `json:"propertyType"`
+	Status       string   `json:"status"`
+	SoldDate     string   `json:"soldDate"`
+	DaysOnMarket int      `json:"daysOnMarket"`
+	Description  string   `json:"description"`
+	Features     []string `json:"features"`
+	Agent        string   `json:"agent"`
+	Brokerage    string   `json:"brokerage"`
+	// Enhanced fields for pricing analysis
+	PropertyCondition  string   `json:"propertyCondition"`
+	SchoolDistrict     string   `json:"schoolDistrict"`
+	ElementarySchool   string   `json:"elementarySchool"`
+	MiddleSchool       string   `json:"middleSchool"`
+	HighSchool         string   `json:"highSchool"`
+	SchoolRatings      []string `json:"schoolRatings"`
+	Neighborhood       string   `json:"neighborhood"`
+	PropertyTax        string   `json:"propertyTax"`
+	HOAFees            string   `json:"hoaFees"`
+	ParkingSpaces      int      `json:"parkingSpaces"`
+	Garage             string   `json:"garage"`
+	Heating            string   `json:"heating"`
+	Cooling            string   `json:"cooling"`
+	Flooring           []string `json:"flooring"`
+	Appliances         []string `json:"appliances"`
+	LastRenovated      string   `json:"lastRenovated"`
+	PriceHistory       []string `json:"priceHistory"`
+	NearbyComparables  []string `json:"nearbyComparables"`
+	WalkScore          string   `json:"walkScore"`
+	TransitScore       string   `json:"transitScore"`
+	DistanceToBeach    string   `json:"distanceToBeach"`
+	DistanceToDowntown string   `json:"distanceToDowntown"`
+	FloodZone          string   `json:"floodZone"`
+	HomeInsurance      string   `json:"homeInsurance"`
+	// Confidence maps field names to how reliably they were extracted, from 0 to 1
+	Confidence map[string]float64 `json:"confidence,omitempty"`
+	// Provenance maps field names to the listing source a merged value came from
+	Provenance map[string]string `json:"provenance,omitempty"`
+}
+
+// MarketStats represents market statistics for an area
+type MarketStats struct {
+	Area                    string  `json:"area"`
+	MedianSalePrice         int     `json:"medianSalePrice"`
+	MedianSingleFamilyPrice int     `json:"medianSingleFamilyPrice"`
+	MedianTownhousePrice    int     `json:"medianTownhousePrice"`
+	AveragePricePerSqFt     int     `json:"averagePricePerSqFt"`
+	HomesForSale            int     `json:"homesForSale"`
+	SalesLast12Months       int     `json:"salesLast12Months"`
+	AverageDaysOnMarket     int     `json:"averageDaysOnMarket"`
+	MonthsOfSupply          float64 `json:"monthsOfSupply"`
+	YearOverYearChange      float64 `json:"yearOverYearChange"`
+	Timestamp               string  `json:"timestamp"`
+}
+
+// newHTTPClient creates an HTTP client tuned for fetching listing pages
+func newHTTPClient() *http.Client {
+	// Create a more robust HTTP transport that mimics browser behavior
+	transport := &http.Transport{
+		ForceAttemptHTTP2:     false, // Force HTTP/1.1 to avoid HTTP/2 stream errors
+		DisableKeepAlives:     false,
+		DisableCompression:    false,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: false,
+			MinVersion:         tls.VersionTLS12,
+		},
+	}
+
+	return &http.Client{
+		Timeout:   45 * time.Second, // Increased timeout
+		Transport: transport,
+	}
+}
+
+// Helper functions for parsing
+func parsePrice(s string) int {
+	// Remove $ and commas, extract numbers
+	re := regexp.MustCompile(`[0-9,]+`)
+	match := re.FindString(s)
+	if match == "" {
+		return 0
+	}
+
+	cleaned := strings.ReplaceAll(match, ",", "")
+	price, _ := strconv.Atoi(cleaned)
+	return price
+}
+
+func parseInt(s string) int {
+	re := regexp.MustCompile(`[0-9]+`)
+	match := re.FindString(s)
+	if match == "" {
+		return 0
+	}
+
+	val, _ := strconv.Atoi(match)
+	return val
+}
+
+func parseFloat(s string) float64 {
+	re := regexp.MustCompile(`[0-9]+\.?[0-9]*`)
+	match := re.FindString(s)
+	if match == "" {
+		return 0
+	}
+
+	val, _ := strconv.ParseFloat(match, 64)
+	return val
+}
+
+func parsePercent(s string) float64 {
+	re := regexp.MustCompile(`-?[0-9]+\.?[0-9]*`)
+	match := re.FindString(s)
+	if match == "" {
+		return 0
+	}
+
+	val, _ := strconv.ParseFloat(match, 64)
+	return val
+}
@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrNotSupported is returned by a ListingSource for operations its site does not offer
+var ErrNotSupported = errors.New("operation not supported by this listing source")
+
+// SearchQuery describes a location to search for listings
+type SearchQuery struct {
+	City         string `json:"city,omitempty"`
+	State        string `json:"state,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	// NeighborhoodSlug is the neighborhood's URL slug from a boundary
+	// registry; sources slugify Neighborhood when it is empty
+	NeighborhoodSlug string `json:"neighborhoodSlug,omitempty"`
+	Status           string `json:"status,omitempty"` // sold or for_sale
+	// KnownIDs holds the IDs of listings already stored for this search.
+	// Results are newest first, so a source may stop paging at a page of known listings.
+	KnownIDs map[string]bool `json:"-"`
+}
+
+// neighborhoodSlug returns the query's neighborhood as a URL slug: the
+// registry's slug when there is one, or else the name slugified
+func (q SearchQuery) neighborhoodSlug() string {
+	if q.NeighborhoodSlug != "" {
+		return q.NeighborhoodSlug
+	}
+	return slugify(q.Neighborhood)
+}
+
+// PropertyLookup finds a previously scraped property by street address
+type PropertyLookup interface {
+	LookupProperty(ctx context.Context, address, city, state string) (*Property, bool)
+}
+
+// lookupConfigurable is implemented by sources that can resolve properties from stored data
+type lookupConfigurable interface {
+	SetPropertyLookup(lookup PropertyLookup)
+}
+
+// ListingSource is an adapter for a single listing site
+type ListingSource interface {
+	// Name returns a short identifier such as "homes.com"
+	Name() string
+	// Matches reports whether the source can handle a property page URL
+	Matches(rawURL string) bool
+	// Search returns listings for a location
+	Search(ctx context.Context, query SearchQuery) ([]Property, error)
+	// Detail returns the details of a single property page
+	Detail(ctx context.Context, url string) (*Property, error)
+	// MarketStats returns market statistics for a location
+	MarketStats(ctx context.Context, query SearchQuery) (*MarketStats, error)
+}
+
+// CompleteSearcher is implemented by sources that can tell whether a search
+// returned every listing for its location, rather than stopping at a page
+// limit or at a page of known listings
+type CompleteSearcher interface {
+	SearchComplete(ctx context.Context, query SearchQuery) (properties []Property, complete bool, err error)
+}
+
+// fetchConfigurable is implemented by sources whose fetch policy can be changed
+type fetchConfigurable interface {
+	SetFetchPolicy(policy FetchPolicy)
+}
+
+// SourceRegistry holds the available listing sources
+type SourceRegistry struct {
+	sources map[string]ListingSource
+	order   []string
+}
+
+// NewSourceRegistry creates an empty source registry
+func NewSourceRegistry() *SourceRegistry {
+	return &SourceRegistry{
+		sources: make(map[string]ListingSource),
+	}
+}
+
+// NewDefaultSourceRegistry creates a registry with every built-in source
+func NewDefaultSourceRegistry() *SourceRegistry {
+	registry := NewSourceRegistry()
+	registry.Register(NewHomesScraper())
+	registry.Register(NewRedfinScraper())
+	return registry
+}
+
+// Register adds a source to the registry
+func (r *SourceRegistry) Register(source ListingSource) error {
+	// Keyed in lower case, as Get looks sources up
+	name := strings.ToLower(source.Name())
+	if _, exists := r.sources[name]; exists {
+		return fmt.Errorf("listing source %s already registered", name)
+	}
+	r.sources[name] = source
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get retrieves a source by name
+func (r *SourceRegistry) Get(name string) (ListingSource, bool) {
+	source, exists := r.sources[strings.ToLower(name)]
+	return source, exists
+}
+
+// Names returns the names of all registered sources
+func (r *SourceRegistry) Names() []string {
+	names := append([]string(nil), r.order...)
+	sort.Strings(names)
+	return names
+}
+
+// SetFetchPolicies applies per-source fetch policies to the registered sources
+func (r *SourceRegistry) SetFetchPolicies(policies FetchPolicies) error {
+	for name := range policies {
+		if _, ok := r.sources[strings.ToLower(name)]; !ok && name != "default" {
+			return fmt.Errorf("fetch policy for unknown listing source %s (available: %s)", name, strings.Join(r.Names(), ", "))
+		}
+	}
+	for _, name := range r.order {
+		if source, ok := r.sources[name].(fetchConfigurable); ok {
+			source.SetFetchPolicy(policies.For(name))
+		}
+	}
+	return nil
+}
+
+// SetPropertyLookup lets sources resolve properties from stored data instead of re-scraping
+func (r *SourceRegistry) SetPropertyLookup(lookup PropertyLookup) {
+	for _, name := range r.order {
+		if source, ok := r.sources[name].(lookupConfigurable); ok {
+			source.SetPropertyLookup(lookup)
+		}
+	}
+}
+
+// ForURL returns the first registered source that handles the URL
+func (r *SourceRegistry) ForURL(rawURL string) (ListingSource, error) {
+	for _, name := range r.order {
+		if source := r.sources[name]; source.Matches(rawURL) {
+			return source, nil
+		}
+	}
+	return nil, fmt.Errorf("no listing source handles %s (supported sites: %s)", rawURL, strings.Join(r.Names(), ", "))
+}
+
+// hostMatches reports whether the URL's host is domain or one of its subdomains
+func hostMatches(rawURL, domain string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
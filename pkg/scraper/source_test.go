@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSourceRegistryForURL(t *testing.T) {
+	registry := NewDefaultSourceRegistry()
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.homes.com/property/2819-poelua-st-honolulu-hi/n207sqkl8vl1p/", "homes.com"},
+		{"https://www.redfin.com/HI/Honolulu/2819-Poelua-St-96822/home/88513618", "redfin.com"},
+		{"https://redfin.com/HI/Honolulu/2819-Poelua-St-96822/home/88513618", "redfin.com"},
+		{"https://example.com/redfin.com/home/1", ""},
+		{"https://notredfin.com/HI/Honolulu/home/1", ""},
+	}
+
+	for _, tt := range tests {
+		source, err := registry.ForURL(tt.url)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ForURL(%s) = %s, want error", tt.url, source.Name())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ForURL(%s): %v", tt.url, err)
+			continue
+		}
+		if source.Name() != tt.want {
+			t.Errorf("ForURL(%s) = %s, want %s", tt.url, source.Name(), tt.want)
+		}
+	}
+}
+
+func TestSourceRegistryRejectsDuplicates(t *testing.T) {
+	registry := NewSourceRegistry()
+	if err := registry.Register(NewHomesScraper()); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(NewHomesScraper()); err == nil {
+		t.Error("expected error registering a source twice")
+	}
+	if _, ok := registry.Get("homes.com"); !ok {
+		t.Error("homes.com not found by name")
+	}
+}
+
+// namedSource is a source with a given name and no listings
+type namedSource struct{ name string }
+
+func (s namedSource) Name() string            { return s.name }
+func (s namedSource) Matches(url string) bool { return false }
+func (s namedSource) Search(ctx context.Context, query SearchQuery) ([]Property, error) {
+	return nil, ErrNotSupported
+}
+func (s namedSource) Detail(ctx context.Context, url string) (*Property, error) {
+	return nil, ErrNotSupported
+}
+func (s namedSource) MarketStats(ctx context.Context, query SearchQuery) (*MarketStats, error) {
+	return nil, ErrNotSupported
+}
+
+func TestSourceRegistryIgnoresNameCase(t *testing.T) {
+	registry := NewSourceRegistry()
+	if err := registry.Register(namedSource{"Zillow.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := registry.Get("Zillow.com"); !ok {
+		t.Error("mixed-case source not found by its name")
+	}
+	if err := registry.Register(namedSource{"zillow.com"}); err == nil {
+		t.Error("expected error registering a source twice in another case")
+	}
+}
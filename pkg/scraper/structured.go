@@ -0,0 +1,358 @@
+package scraper
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Confidence levels for each way a listing field can be extracted, from
+// publisher-declared structured data down to pattern matches over free text
+const (
+	confidenceJSONLD   = 0.95
+	confidenceAppState = 0.9
+	confidenceDOM      = 0.75
+	confidenceCardText = 0.6
+	confidencePageText = 0.35
+	// Fields guessed from keywords rather than read from a labelled value
+	confidenceInferred = 0.5
+)
+
+// listingFields are the Property fields tracked in Property.Confidence
+var listingFields = []struct {
+	name  string
+	isSet func(p *Property) bool
+	copy  func(dst, src *Property)
+}{
+	{"address", func(p *Property) bool { return p.Address != "" }, func(d, s *Property) { d.Address = s.Address }},
+	{"city", func(p *Property) bool { return p.City != "" }, func(d, s *Property) { d.City = s.City }},
+	{"state", func(p *Property) bool { return p.State != "" }, func(d, s *Property) { d.State = s.State }},
+	{"zipCode", func(p *Property) bool { return p.ZipCode != "" }, func(d, s *Property) { d.ZipCode = s.ZipCode }},
+	{"price", func(p *Property) bool { return p.Price > 0 }, func(d, s *Property) { d.Price = s.Price }},
+	{"bedrooms", func(p *Property) bool { return p.Bedrooms > 0 }, func(d, s *Property) { d.Bedrooms = s.Bedrooms }},
+	{"bathrooms", func(p *Property) bool { return p.Bathrooms > 0 }, func(d, s *Property) { d.Bathrooms = s.Bathrooms }},
+	{"squareFeet", func(p *Property) bool { return p.SquareFeet > 0 }, func(d, s *Property) { d.SquareFeet = s.SquareFeet }},
+	{"lotSize", func(p *Property) bool { return p.LotSize > 0 }, func(d, s *Property) { d.LotSize = s.LotSize }},
+	{"yearBuilt", func(p *Property) bool { return p.YearBuilt > 0 }, func(d, s *Property) { d.YearBuilt = s.YearBuilt }},
+	{"propertyType", func(p *Property) bool { return p.PropertyType != "" && p.PropertyType != "unknown" }, func(d, s *Property) { d.PropertyType = s.PropertyType }},
+	{"soldDate", func(p *Property) bool { return p.SoldDate != "" }, func(d, s *Property) { d.SoldDate = s.SoldDate }},
+	{"daysOnMarket", func(p *Property) bool { return p.DaysOnMarket > 0 }, func(d, s *Property) { d.DaysOnMarket = s.DaysOnMarket }},
+	{"description", func(p *Property) bool { return p.Description != "" }, func(d, s *Property) { d.Description = s.Description }},
+	{"agent", func(p *Property) bool { return p.Agent != "" }, func(d, s *Property) { d.Agent = s.Agent }},
+	{"brokerage", func(p *Property) bool { return p.Brokerage != "" }, func(d, s *Property) { d.Brokerage = s.Brokerage }},
+	{"location", func(p *Property) bool { return p.Latitude != 0 || p.Longitude != 0 }, func(d, s *Property) { d.Latitude, d.Longitude = s.Latitude, s.Longitude }},
+}
+
+// scoreFields records confidence for every populated field of a property
+func scoreFields(p *Property, confidence float64) {
+	if p.Confidence == nil {
+		p.Confidence = make(map[string]float64)
+	}
+	for _, field := range listingFields {
+		if field.isSet(p) {
+			if _, scored := p.Confidence[field.name]; !scored {
+				p.Confidence[field.name] = confidence
+			}
+		}
+	}
+}
+
+// mergeProperty fills dst with the fields src extracted with higher confidence
+func mergeProperty(dst *Property, src Property) {
+	if dst.Confidence == nil {
+		dst.Confidence = make(map[string]float64)
+	}
+	for _, field := range listingFields {
+		if !field.isSet(&src) {
+			continue
+		}
+		if !field.isSet(dst) || src.Confidence[field.name] > dst.Confidence[field.name] {
+			field.copy(dst, &src)
+			dst.Confidence[field.name] = src.Confidence[field.name]
+		}
+	}
+	finishProperty(dst)
+}
+
+// finishProperty derives the ID and price per square foot from extracted fields
+func finishProperty(p *Property) {
+	if p.Address != "" {
+		p.ID = PropertyID(p.Address, p.City, p.State)
+	}
+	if p.Price > 0 && p.SquareFeet > 0 {
+		p.PricePerSqFt = p.Price / p.SquareFeet
+		p.Confidence["pricePerSqFt"] = minFloat(p.Confidence["price"], p.Confidence["squareFeet"])
+	}
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// extractJSONLD returns listings described by schema.org JSON-LD blocks
+func extractJSONLD(doc *goquery.Document, status string) []Property {
+	var properties []Property
+	doc.Find(`script[type="application/ld+json"]`).Each(func(i int, s *goquery.Selection) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return
+		}
+		walkListings(data, nil, func(obj map[string]interface{}) {
+			if property, ok := listingFromJSON(obj, status, confidenceJSONLD); ok {
+				properties = append(properties, property)
+			}
+		})
+	})
+	return properties
+}
+
+// appStateRegex matches state objects assigned in inline scripts, e.g.
+// window.__INITIAL_STATE__ = {...};
+var appStateRegex = regexp.MustCompile(`(?s)window\.__[A-Za-z_]+__\s*=\s*(\{.*\})\s*;?\s*$`)
+
+// extractAppState returns listings found in JSON state embedded for client-side rendering
+func extractAppState(doc *goquery.Document, status string) []Property {
+	var blobs []string
+	doc.Find(`script#__NEXT_DATA__, script[type="application/json"]`).Each(func(i int, s *goquery.Selection) {
+		blobs = append(blobs, s.Text())
+	})
+	doc.Find("script:not([type]), script[type='text/javascript']").Each(func(i int, s *goquery.Selection) {
+		if match := appStateRegex.FindStringSubmatch(strings.TrimSpace(s.Text())); len(match) > 1 {
+			blobs = append(blobs, match[1])
+		}
+	})
+
+	var properties []Property
+	for _, blob := range blobs {
+		var data interface{}
+		if err := json.Unmarshal([]byte(blob), &data); err != nil {
+			continue
+		}
+		walkListings(data, nil, func(obj map[string]interface{}) {
+			if property, ok := listingFromJSON(obj, status, confidenceAppState); ok {
+				properties = append(properties, property)
+			}
+		})
+	}
+	return properties
+}
+
+// walkListings visits every JSON object that carries a street address. Fields
+// of a schema.org wrapper such as RealEstateListing (its offers, for example)
+// are passed down to the residence it describes.
+func walkListings(value interface{}, inherited map[string]interface{}, visit func(map[string]interface{})) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			walkListings(item, inherited, visit)
+		}
+	case map[string]interface{}:
+		merged := v
+		if len(inherited) > 0 {
+			merged = make(map[string]interface{}, len(v)+len(inherited))
+			for key, val := range inherited {
+				merged[key] = val
+			}
+			for key, val := range v {
+				merged[key] = val
+			}
+		}
+		if streetAddress(merged) != "" {
+			visit(merged)
+		}
+
+		var pass map[string]interface{}
+		if _, typed := v["@type"]; typed {
+			pass = make(map[string]interface{})
+			for _, key := range []string{"offers", "price"} {
+				if val, ok := v[key]; ok {
+					pass[key] = val
+				}
+			}
+		}
+		// Visit keys in order so listings come out in a stable order
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if key != "address" && key != "offers" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkListings(v[key], pass, visit)
+		}
+	}
+}
+
+// jsonAliases lists the keys used for each field by schema.org and common listing site state
+var jsonAliases = map[string][]string{
+	"street":       {"streetAddress", "addressLine1", "street", "line"},
+	"city":         {"addressLocality", "city"},
+	"state":        {"addressRegion", "stateCode", "state"},
+	"zip":          {"postalCode", "zipCode", "zip", "postal_code"},
+	"price":        {"price", "listPrice", "soldPrice", "lastSoldPrice", "salePrice"},
+	"beds":         {"numberOfBedrooms", "numberOfRooms", "bedrooms", "beds"},
+	"baths":        {"numberOfBathroomsTotal", "bathrooms", "baths", "bathroomsTotal"},
+	"sqft":         {"floorSize", "livingArea", "squareFeet", "sqft", "sqFt"},
+	"lot":          {"lotSize", "lotSizeAcres"},
+	"yearBuilt":    {"yearBuilt"},
+	"type":         {"propertyType", "homeType", "@type"},
+	"soldDate":     {"soldDate", "dateSold", "lastSoldDate"},
+	"daysOnMarket": {"daysOnMarket", "dom"},
+	"description":  {"description", "remarks"},
+	"latitude":     {"latitude", "lat"},
+	"longitude":    {"longitude", "lng", "lon", "long"},
+}
+
+// lookup returns the first value found under the field's aliases, checking
+// nested address and schema.org GeoCoordinates objects for location fields
+func lookup(obj map[string]interface{}, field string) interface{} {
+	for _, key := range jsonAliases[field] {
+		if val, ok := obj[key]; ok && val != nil {
+			return val
+		}
+	}
+	for _, nested := range []string{"address", "geo"} {
+		if inner, ok := obj[nested].(map[string]interface{}); ok {
+			for _, key := range jsonAliases[field] {
+				if val, ok := inner[key]; ok && val != nil {
+					return val
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// streetAddress returns the street line of a listing object, which some
+// sites store directly as a string under "address"
+func streetAddress(obj map[string]interface{}) string {
+	if street := jsonString(lookup(obj, "street")); street != "" {
+		return street
+	}
+	if address, ok := obj["address"].(string); ok {
+		return strings.TrimSpace(address)
+	}
+	return ""
+}
+
+// listingFromJSON builds a property from a JSON object describing one listing
+func listingFromJSON(obj map[string]interface{}, status string, confidence float64) (Property, bool) {
+	property := Property{Status: status}
+	property.Address = streetAddress(obj)
+	property.City = jsonString(lookup(obj, "city"))
+	property.State = strings.ToUpper(jsonString(lookup(obj, "state")))
+	property.ZipCode = jsonString(lookup(obj, "zip"))
+
+	price := lookup(obj, "price")
+	if offers := obj["offers"]; price == nil && offers != nil {
+		if list, ok := offers.([]interface{}); ok && len(list) > 0 {
+			offers = list[0]
+		}
+		if offer, ok := offers.(map[string]interface{}); ok {
+			price = offer["price"]
+		}
+	}
+	property.Price = int(jsonNumber(price))
+	property.Bedrooms = int(jsonNumber(lookup(obj, "beds")))
+	property.Bathrooms = jsonNumber(lookup(obj, "baths"))
+	property.SquareFeet = int(jsonNumber(lookup(obj, "sqft")))
+	property.LotSize = jsonNumber(lookup(obj, "lot"))
+	property.YearBuilt = int(jsonNumber(lookup(obj, "yearBuilt")))
+	property.SoldDate = jsonString(lookup(obj, "soldDate"))
+	property.DaysOnMarket = int(jsonNumber(lookup(obj, "daysOnMarket")))
+	property.Description = jsonString(lookup(obj, "description"))
+	lat, lon := jsonCoordinate(lookup(obj, "latitude")), jsonCoordinate(lookup(obj, "longitude"))
+	if lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 && (lat != 0 || lon != 0) {
+		property.Latitude, property.Longitude = lat, lon
+	}
+
+	if property.Address == "" {
+		return property, false
+	}
+
+	scoreFields(&property, confidence)
+	property.PropertyType = propertyTypeFromText(jsonString(lookup(obj, "type")))
+	if property.PropertyType != "unknown" {
+		property.Confidence["propertyType"] = confidence
+	}
+	finishProperty(&property)
+	return property, true
+}
+
+// jsonString returns a JSON scalar as a trimmed string
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonString(v[0])
+		}
+	}
+	return ""
+}
+
+// jsonNumber returns a JSON number, numeric string or schema.org
+// QuantitativeValue as a float
+func jsonNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		return parseFloat(strings.ReplaceAll(v, ",", ""))
+	case map[string]interface{}:
+		return jsonNumber(v["value"])
+	}
+	return 0
+}
+
+// jsonCoordinate returns a JSON number or numeric string as a signed
+// coordinate; jsonNumber would drop the sign of a string
+func jsonCoordinate(value interface{}) float64 {
+	if s, ok := value.(string); ok {
+		v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return v
+	}
+	return jsonNumber(value)
+}
+
+// parseListingCard extracts a property from a result card using the class
+// names listing sites use for each field, then fills any gaps from the
+// card's own text
+func parseListingCard(s *goquery.Selection, status string) Property {
+	property := Property{Status: listingStatus(cardText(s), status)}
+	text := func(selector string) string {
+		return strings.TrimSpace(s.Find(selector).First().Text())
+	}
+
+	if match := listingAddressRegex.FindStringSubmatch(text(".property-address, .listing-address, [class*='address']")); len(match) > 4 {
+		property.Address = strings.TrimSpace(match[1])
+		property.City = strings.TrimSpace(match[2])
+		property.State = match[3]
+		property.ZipCode = match[4]
+	}
+	property.Price = parsePrice(text(".price, .listing-price, [class*='price']"))
+	property.Bedrooms = parseInt(text(".beds, .bedrooms, [class*='bed']"))
+	property.Bathrooms = parseFloat(text(".baths, .bathrooms, [class*='bath']"))
+	property.SquareFeet = parsePrice(text(".sqft, .square-feet, [class*='sqft']"))
+	property.YearBuilt = parseInt(text(".year-built, .built"))
+	property.DaysOnMarket = parseInt(text(".days-on-market, .dom"))
+	property.Description = text(".description, .listing-description")
+	property.Agent = text(".agent-name, .listing-agent")
+	property.Brokerage = text(".brokerage-name, .listing-brokerage")
+	scoreFields(&property, confidenceDOM)
+
+	// Labels such as "3 Beds" are often unmarked spans, so read the card text too
+	mergeProperty(&property, parseListingText(cardText(s), status, confidenceCardText))
+	return property
+}
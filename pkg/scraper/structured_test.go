@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func parseHTML(t *testing.T, html string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParseListingPageJSONLD(t *testing.T) {
+	doc := parseHTML(t, `<html><head>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "ItemList", "description": "Homes sold in Ballard",
+ "itemListElement": [
+  {"@type": "RealEstateListing", "offers": {"@type": "Offer", "price": "925000"},
+   "mainEntity": {"@type": "SingleFamilyResidence", "numberOfBedrooms": 3, "numberOfBathroomsTotal": 2,
+     "floorSize": {"@type": "QuantitativeValue", "value": 1650, "unitCode": "FTK"}, "yearBuilt": 1926,
+     "address": {"@type": "PostalAddress", "streetAddress": "2210 NW 60th St", "addressLocality": "Seattle", "addressRegion": "WA", "postalCode": "98107"}}},
+  {"@type": "RealEstateListing", "offers": {"@type": "Offer", "price": 610000},
+   "mainEntity": {"@type": "Apartment", "numberOfBedrooms": 2,
+     "address": {"@type": "PostalAddress", "streetAddress": "5450 Leary Ave NW #404", "addressLocality": "Seattle", "addressRegion": "WA", "postalCode": "98107"}}}
+ ]}
+</script></head><body>
+<div class="placard"><span class="price">$999,999</span><p class="address">2210 NW 60th St, Seattle, WA 98107</p><span>3 Beds</span></div>
+</body></html>`)
+
+	properties := parseListingPage(doc, "sold")
+	if len(properties) != 2 {
+		t.Fatalf("got %d properties, want 2: %+v", len(properties), properties)
+	}
+
+	house := properties[0]
+	if house.Address != "2210 NW 60th St" || house.City != "Seattle" || house.ZipCode != "98107" {
+		t.Errorf("unexpected location: %+v", house)
+	}
+	// The card's price is lower confidence than the structured offer
+	if house.Price != 925000 || house.Confidence["price"] != confidenceJSONLD {
+		t.Errorf("price = %d (confidence %v), want 925000 from JSON-LD", house.Price, house.Confidence["price"])
+	}
+	if house.SquareFeet != 1650 || house.Bathrooms != 2 || house.YearBuilt != 1926 || house.PropertyType != "house" {
+		t.Errorf("unexpected details: %+v", house)
+	}
+	if house.Description != "" {
+		t.Errorf("list description leaked into listing: %q", house.Description)
+	}
+
+	condo := properties[1]
+	if condo.Price != 610000 || condo.PropertyType != "apartment" || condo.Bedrooms != 2 {
+		t.Errorf("unexpected listing: %+v", condo)
+	}
+}
+
+func TestParseListingPageAppState(t *testing.T) {
+	doc := parseHTML(t, `<html><body><script>
+window.__INITIAL_STATE__ = {"search": {"results": [
+  {"address": {"line": "17 Orchard St", "city": "Portland", "stateCode": "me", "postalCode": "04101"},
+   "listPrice": 535000, "beds": 3, "baths": 1.5, "sqft": "1,410", "homeType": "TOWNHOUSE", "daysOnMarket": 12}
+]}};
+</script></body></html>`)
+
+	properties := parseListingPage(doc, "sold")
+	if len(properties) != 1 {
+		t.Fatalf("got %d properties, want 1", len(properties))
+	}
+	p := properties[0]
+	if p.Address != "17 Orchard St" || p.City != "Portland" || p.State != "ME" || p.ZipCode != "04101" {
+		t.Errorf("unexpected location: %+v", p)
+	}
+	if p.Price != 535000 || p.SquareFeet != 1410 || p.Bathrooms != 1.5 || p.PropertyType != "townhouse" || p.DaysOnMarket != 12 {
+		t.Errorf("unexpected details: %+v", p)
+	}
+	if p.Confidence["price"] != confidenceAppState || p.Confidence["pricePerSqFt"] != confidenceAppState {
+		t.Errorf("unexpected confidence: %v", p.Confidence)
+	}
+}
+
+func TestParseListingPageNoCrossCardContamination(t *testing.T) {
+	// The second card has no price or beds; the text-window parser used to
+	// borrow them from the first card.
+	doc := parseHTML(t, `<html><body>
+<div class="placard"><p class="price">$780,000</p><span>4 Beds</span><p class="address">9 Elm Ct, Boise, ID 83702</p></div>
+<div class="placard"><p class="address">11 Elm Ct, Boise, ID 83702</p><p>Contact agent</p></div>
+<div class="placard"><p class="price">$455,000</p><p class="address">13 Elm Ct, Boise, ID 83702</p><span>2 Beds</span></div>
+</body></html>`)
+
+	properties := parseListingPage(doc, "for_sale")
+	if len(properties) != 2 {
+		t.Fatalf("got %d properties, want 2 (the unpriced card is dropped): %+v", len(properties), properties)
+	}
+	if properties[0].Bedrooms != 4 || properties[1].Price != 455000 || properties[1].Bedrooms != 2 {
+		t.Errorf("fields attached to the wrong card: %+v", properties)
+	}
+	if properties[0].Confidence["price"] != confidenceDOM || properties[0].Confidence["bedrooms"] != confidenceCardText {
+		t.Errorf("unexpected confidence: %v", properties[0].Confidence)
+	}
+}
+
+func TestParseListingPageCoordinates(t *testing.T) {
+	doc := parseHTML(t, `<html><head>
+<script type="application/ld+json">
+[{"@type": "SingleFamilyResidence", "price": 1500000, "geo": {"@type": "GeoCoordinates", "latitude": 21.3124, "longitude": -157.8087},
+  "address": {"streetAddress": "2819 Poelua St", "addressLocality": "Honolulu", "addressRegion": "HI"}},
+ {"@type": "SingleFamilyResidence", "price": 1650000, "geo": {"latitude": 0, "longitude": 0},
+  "address": {"streetAddress": "2750 Hipawai Pl", "addressLocality": "Honolulu", "addressRegion": "HI"}}]
+</script>
+<script id="__NEXT_DATA__" type="application/json">
+{"props": {"homes": [{"streetAddress": "1 Kahala Ave", "city": "Honolulu", "state": "HI", "price": 900000, "lat": "21.2713", "lng": "-157.7738"}]}}
+</script></head></html>`)
+
+	properties := parseListingPage(doc, "sold")
+	if len(properties) != 3 {
+		t.Fatalf("got %d properties, want 3", len(properties))
+	}
+	if p := properties[0]; p.Latitude != 21.3124 || p.Longitude != -157.8087 || p.Confidence["location"] != confidenceJSONLD {
+		t.Errorf("JSON-LD location = %v, %v", p.Latitude, p.Longitude)
+	}
+	if p := properties[1]; p.Latitude != 0 || p.Longitude != 0 {
+		t.Errorf("0,0 should be treated as missing, got %v, %v", p.Latitude, p.Longitude)
+	}
+	if p := properties[2]; p.Latitude != 21.2713 || p.Longitude != -157.7738 {
+		t.Errorf("app state location = %v, %v", p.Latitude, p.Longitude)
+	}
+}
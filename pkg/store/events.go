@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// Listing events, found by comparing a property's listing with the one saved
+// before it
+const (
+	EventListed        = "listed"
+	EventPriceCut      = "price_cut"
+	EventPriceIncrease = "price_increase"
+	EventPending       = "pending"
+	EventSold          = "sold"
+	EventRelisted      = "relisted"
+	EventWithdrawn     = "withdrawn"
+)
+
+// ListingEvents lists the listing events
+var ListingEvents = []string{EventListed, EventPriceCut, EventPriceIncrease, EventPending, EventSold, EventRelisted, EventWithdrawn}
+
+// Listing stages that statuses are grouped into
+const (
+	stageActive    = "active"
+	stagePending   = "pending"
+	stageSold      = "sold"
+	stageOffMarket = "off_market"
+)
+
+// stages maps the statuses listing sites report to listing stages
+var stages = map[string]string{
+	"for_sale": stageActive, "active": stageActive, "new": stageActive, "coming_soon": stageActive,
+	"price_reduced": stageActive, "back_on_market": stageActive,
+	"pending": stagePending, "contingent": stagePending, "under_contract": stagePending, "active_under_contract": stagePending,
+	"sold": stageSold, "closed": stageSold, "recently_sold": stageSold,
+	"off_market": stageOffMarket, "withdrawn": stageOffMarket, "expired": stageOffMarket,
+	"cancelled": stageOffMarket, "canceled": stageOffMarket, "delisted": stageOffMarket,
+}
+
+// stage returns the listing stage of a status, or "" when it is unknown
+func stage(status string) string {
+	key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(status)))
+	return stages[key]
+}
+
+// ListingEvent is a change in a property's listing seen between two saves
+type ListingEvent struct {
+	ID             int64     `json:"id"`
+	PropertyID     string    `json:"propertyId"`
+	Event          string    `json:"event"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previousStatus,omitempty"`
+	Price          int       `json:"price,omitempty"`
+	PreviousPrice  int       `json:"previousPrice,omitempty"`
+	Source         string    `json:"source"`
+	ObservedAt     time.Time `json:"observedAt"`
+	// Initial marks the first sighting of a property, dated by when its
+	// listing says it was listed or sold rather than when it was seen
+	Initial bool `json:"initial,omitempty"`
+	// The property's current address and listing, in change queries
+	Address string `json:"address,omitempty"`
+	City    string `json:"city,omitempty"`
+	State   string `json:"state,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// saleDateLayouts are the sale date formats listing sites and public records use
+var saleDateLayouts = []string{"2006-01-02", "Jan 2, 2006", "January 2, 2006", "1/2/2006"}
+
+// saleDate parses a sale date such as "JAN 17, 2025" or "2025-01-17"
+func saleDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range saleDateLayouts {
+		// time.Parse matches month names in any case
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// detectEvents compares a property's listing with the previous one saved,
+// nil for a property seen for the first time. Only statuses whose stage is
+// known produce events.
+//
+// A first sighting says nothing about when the property was listed or sold,
+// so it is dated by the listing's days on market or sale date as of now and
+// marked initial, and records nothing when the listing gives no date.
+func detectEvents(previous *scraper.Property, current scraper.Property, now time.Time) []ListingEvent {
+	to := stage(current.Status)
+	if to == "" {
+		return nil
+	}
+	event := ListingEvent{Status: current.Status, Price: current.Price}
+	if previous == nil {
+		switch to {
+		case stageActive:
+			if current.DaysOnMarket <= 0 {
+				return nil
+			}
+			event.Event, event.ObservedAt = EventListed, now.AddDate(0, 0, -current.DaysOnMarket)
+		case stageSold:
+			sold, ok := saleDate(current.SoldDate)
+			if !ok {
+				return nil
+			}
+			event.Event, event.ObservedAt = EventSold, sold
+		default:
+			return nil
+		}
+		event.Initial = true
+		return []ListingEvent{event}
+	}
+
+	from := stage(previous.Status)
+	event.PreviousStatus, event.PreviousPrice = previous.Status, previous.Price
+	switch {
+	case from == to && (to == stageActive || to == stagePending):
+		// Still on the market: only a price change is news
+		switch {
+		case previous.Price == 0 || current.Price == 0 || current.Price == previous.Price:
+			return nil
+		case current.Price < previous.Price:
+			event.Event = EventPriceCut
+		default:
+			event.Event = EventPriceIncrease
+		}
+	case from == to:
+		return nil
+	case to == stageActive && from == stageSold:
+		event.Event = EventListed // A new listing after a sale
+	case to == stageActive && from != "":
+		event.Event = EventRelisted // Back on the market after coming off it
+	case to == stageActive:
+		event.Event = EventListed
+	case to == stagePending:
+		event.Event = EventPending
+	case to == stageSold:
+		event.Event = EventSold
+	case to == stageOffMarket && (from == stageActive || from == stagePending):
+		event.Event = EventWithdrawn
+	default:
+		return nil
+	}
+	return []ListingEvent{event}
+}
+
+// ChangeQuery selects listing events in an area
+type ChangeQuery struct {
+	City         string
+	State        string
+	Neighborhood string // Properties scraped for the neighborhood or listed in it
+	Since        time.Time
+	Events       []string // Every event when empty
+	Limit        int      // Every match when zero
+}
+
+// listingEventColumns are the columns scanListingEvent reads
+const listingEventColumns = `listing_events.id, listing_events.property_id, listing_events.event, listing_events.status,
+	listing_events.previous_status, listing_events.price, listing_events.previous_price, listing_events.source,
+	listing_events.observed_at, listing_events.initial, properties.address, properties.city, properties.state, properties.url`
+
+// scanListingEvent reads an event selected with listingEventColumns
+func scanListingEvent(scan func(dest ...interface{}) error) (ListingEvent, error) {
+	var event ListingEvent
+	var observed string
+	err := scan(&event.ID, &event.PropertyID, &event.Event, &event.Status, &event.PreviousStatus, &event.Price,
+		&event.PreviousPrice, &event.Source, &observed, &event.Initial, &event.Address, &event.City, &event.State, &event.URL)
+	event.ObservedAt, _ = time.Parse(timeFormat, observed)
+	return event, err
+}
+
+// PropertyListingEvents returns the listing events recorded for a property,
+// oldest first
+func (s *Store) PropertyListingEvents(ctx context.Context, propertyID string) ([]ListingEvent, error) {
+	return s.listingEvents(ctx, `WHERE listing_events.property_id = ? ORDER BY listing_events.observed_at, listing_events.id`, propertyID)
+}
+
+// ListingChanges returns the listing events in an area since a time, newest
+// first
+func (s *Store) ListingChanges(ctx context.Context, q ChangeQuery) ([]ListingEvent, error) {
+	where := []string{"listing_events.observed_at >= ?"}
+	args := []interface{}{q.Since.UTC().Format(timeFormat)}
+	if q.City != "" {
+		where = append(where, "lower(properties.city) = lower(?)")
+		args = append(args, q.City)
+	}
+	if q.State != "" {
+		where = append(where, "lower(properties.state) = lower(?)")
+		args = append(args, q.State)
+	}
+	if q.Neighborhood != "" {
+		// Area keys are source|state|city|neighborhood|status
+		where = append(where, `(lower(json_extract(properties.data, '$.neighborhood')) = lower(?)
+			OR properties.id IN (SELECT property_id FROM area_properties WHERE area LIKE ?))`)
+		pattern := []string{"%", "%", "%", q.Neighborhood, "%"}
+		if q.State != "" {
+			pattern[1] = q.State
+		}
+		if q.City != "" {
+			pattern[2] = q.City
+		}
+		for i, part := range pattern {
+			pattern[i] = strings.ToLower(strings.TrimSpace(part))
+		}
+		args = append(args, q.Neighborhood, strings.Join(pattern, "|"))
+	}
+	if len(q.Events) > 0 {
+		where = append(where, "listing_events.event IN (?"+strings.Repeat(", ?", len(q.Events)-1)+")")
+		for _, event := range q.Events {
+			args = append(args, event)
+		}
+	}
+	query := "WHERE " + strings.Join(where, " AND ") + " ORDER BY listing_events.observed_at DESC, listing_events.id DESC"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+	return s.listingEvents(ctx, query, args...)
+}
+
+func (s *Store) listingEvents(ctx context.Context, where string, args ...interface{}) ([]ListingEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+listingEventColumns+`
+		FROM listing_events JOIN properties ON properties.id = listing_events.property_id `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listing events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []ListingEvent{}
+	for rows.Next() {
+		event, err := scanListingEvent(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
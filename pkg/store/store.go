@@ -0,0 +1,558 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver
+)
+
+// schema creates the store's tables. Properties keep the full scraped record
+// as JSON next to the columns used for lookups.
+const schema = `
+CREATE TABLE IF NOT EXISTS properties (
+	id            TEXT PRIMARY KEY,
+	source        TEXT NOT NULL,
+	url           TEXT NOT NULL DEFAULT '',
+	address       TEXT NOT NULL,
+	city          TEXT NOT NULL,
+	state         TEXT NOT NULL,
+	zip_code      TEXT NOT NULL DEFAULT '',
+	status        TEXT NOT NULL DEFAULT '',
+	price         INTEGER NOT NULL DEFAULT 0,
+	sold_date     TEXT NOT NULL DEFAULT '',
+	data          TEXT NOT NULL,
+	first_seen_at TEXT NOT NULL,
+	fetched_at    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS properties_location ON properties (state, city);
+CREATE INDEX IF NOT EXISTS properties_url ON properties (url);
+
+CREATE TABLE IF NOT EXISTS price_events (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	property_id TEXT NOT NULL REFERENCES properties (id),
+	event       TEXT NOT NULL,
+	price       INTEGER NOT NULL,
+	date        TEXT NOT NULL DEFAULT '',
+	source      TEXT NOT NULL,
+	observed_at TEXT NOT NULL,
+	UNIQUE (property_id, event, price, date)
+);
+
+CREATE TABLE IF NOT EXISTS listing_events (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	property_id     TEXT NOT NULL REFERENCES properties (id),
+	event           TEXT NOT NULL,
+	status          TEXT NOT NULL DEFAULT '',
+	previous_status TEXT NOT NULL DEFAULT '',
+	price           INTEGER NOT NULL DEFAULT 0,
+	previous_price  INTEGER NOT NULL DEFAULT 0,
+	source          TEXT NOT NULL,
+	observed_at     TEXT NOT NULL,
+	initial         INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS listing_events_property ON listing_events (property_id, observed_at);
+CREATE INDEX IF NOT EXISTS listing_events_observed ON listing_events (observed_at);
+
+CREATE TABLE IF NOT EXISTS scrape_runs (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	source         TEXT NOT NULL,
+	area           TEXT NOT NULL,
+	started_at     TEXT NOT NULL,
+	finished_at    TEXT,
+	found          INTEGER NOT NULL DEFAULT 0,
+	added          INTEGER NOT NULL DEFAULT 0,
+	error          TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS scrape_runs_area ON scrape_runs (area, finished_at);
+
+CREATE TABLE IF NOT EXISTS area_properties (
+	area        TEXT NOT NULL,
+	property_id TEXT NOT NULL REFERENCES properties (id),
+	PRIMARY KEY (area, property_id)
+);
+`
+
+// timeFormat is how timestamps are stored: fixed width in UTC, so they sort as text
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Store persists scraped properties, their price and listing events and
+// scrape runs in SQLite
+type Store struct {
+	db    *sql.DB
+	rules scraper.MergeRules
+	now   func() time.Time
+}
+
+// StoredProperty is a property with where and when it was scraped
+type StoredProperty struct {
+	scraper.Property
+	Source    string    `json:"source"`
+	URL       string    `json:"url,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// PriceEvent is a price observed for a property, such as its sale
+type PriceEvent struct {
+	PropertyID string    `json:"propertyId"`
+	Event      string    `json:"event"` // The listing status the price was seen with, e.g. sold or for_sale
+	Price      int       `json:"price"`
+	Date       string    `json:"date,omitempty"`
+	Source     string    `json:"source"`
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// ScrapeRun records one scrape of an area
+type ScrapeRun struct {
+	ID         int64     `json:"id"`
+	Source     string    `json:"source"`
+	Area       string    `json:"area"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	Found      int       `json:"found"`
+	Added      int       `json:"added"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Open opens or creates a store at path
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %v", err)
+	}
+	// SQLite allows one writer; a single connection avoids "database is locked"
+	db.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{"PRAGMA journal_mode = WAL", "PRAGMA foreign_keys = ON", "PRAGMA busy_timeout = 5000"} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to configure store: %v", err)
+		}
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create store schema: %v", err)
+	}
+	return &Store{db: db, rules: scraper.DefaultMergeRules, now: time.Now}, nil
+}
+
+// SetMergeRules sets which source's values win when sources disagree about a property
+func (s *Store) SetMergeRules(rules scraper.MergeRules) {
+	s.rules = rules
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AreaKey identifies a searched area for a source, e.g. "homes.com|hi|honolulu|manoa|sold"
+func AreaKey(source string, query scraper.SearchQuery) string {
+	status := query.Status
+	if status == "" {
+		status = "sold"
+	}
+	parts := []string{source, query.State, query.City, query.Neighborhood, status}
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, "|")
+}
+
+// StartRun records the start of a scrape of an area
+func (s *Store) StartRun(ctx context.Context, source, area string) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `INSERT INTO scrape_runs (source, area, started_at) VALUES (?, ?, ?)`,
+		source, area, s.now().UTC().Format(timeFormat))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record scrape run: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// FinishRun records the outcome of a scrape run
+func (s *Store) FinishRun(ctx context.Context, id int64, found, added int, runErr error) error {
+	message := ""
+	if runErr != nil {
+		message = runErr.Error()
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE scrape_runs SET finished_at = ?, found = ?, added = ?, error = ? WHERE id = ?`,
+		s.now().UTC().Format(timeFormat), found, added, message, id)
+	if err != nil {
+		return fmt.Errorf("failed to record scrape run: %v", err)
+	}
+	return nil
+}
+
+// LastRun returns the most recent successful scrape of an area
+func (s *Store) LastRun(ctx context.Context, area string) (*ScrapeRun, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, source, area, started_at, finished_at, found, added, error
+		FROM scrape_runs WHERE area = ? AND finished_at IS NOT NULL AND error = ''
+		ORDER BY finished_at DESC LIMIT 1`, area)
+
+	var run ScrapeRun
+	var started, finished string
+	err := row.Scan(&run.ID, &run.Source, &run.Area, &started, &finished, &run.Found, &run.Added, &run.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scrape runs: %v", err)
+	}
+	run.StartedAt, _ = time.Parse(timeFormat, started)
+	run.FinishedAt, _ = time.Parse(timeFormat, finished)
+	return &run, nil
+}
+
+// KnownIDs returns the IDs of the properties already stored for an area
+func (s *Store) KnownIDs(ctx context.Context, area string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT property_id FROM area_properties WHERE area = ?`, area)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read area properties: %v", err)
+	}
+	defer rows.Close()
+
+	known := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		known[id] = true
+	}
+	return known, rows.Err()
+}
+
+// SaveArea upserts the properties found by a scrape of an area and links
+// them to it, returning how many were new to the area
+func (s *Store) SaveArea(ctx context.Context, source, area string, properties []scraper.Property) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	added, now := 0, s.now()
+	for _, property := range properties {
+		if err := s.upsert(ctx, tx, source, "", property, now); err != nil {
+			return 0, err
+		}
+		result, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO area_properties (area, property_id) VALUES (?, ?)`, area, property.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to link property %s: %v", property.ID, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+// WithdrawMissing marks the listings of an area that a complete scrape of its
+// listings for sale no longer found, and that were last seen for sale, as
+// taken off the market, recording a withdrawn event for each. Pending
+// listings are left for a sold scrape to close. It returns how many were
+// withdrawn.
+func (s *Store) WithdrawMissing(ctx context.Context, source, area string, listed []scraper.Property) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	present := make(map[string]bool, len(listed))
+	for _, property := range listed {
+		present[property.ID] = true
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT properties.data
+		FROM area_properties JOIN properties ON properties.id = area_properties.property_id
+		WHERE area_properties.area = ?`, area)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read area properties: %v", err)
+	}
+	var missing []scraper.Property
+	for rows.Next() {
+		var data string
+		var property scraper.Property
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal([]byte(data), &property); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("corrupt stored property: %v", err)
+		}
+		if !present[property.ID] && stage(property.Status) == stageActive {
+			missing = append(missing, property)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := s.now().UTC().Format(timeFormat)
+	for _, previous := range missing {
+		property := previous
+		property.Status = stageOffMarket
+		data, err := json.Marshal(property)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE properties SET status = ?, data = ? WHERE id = ?`, property.Status, string(data), property.ID); err != nil {
+			return 0, fmt.Errorf("failed to save property %s: %v", property.ID, err)
+		}
+		if err := saveListingEvents(ctx, tx, property.ID, source, now, detectEvents(&previous, property, s.now())); err != nil {
+			return 0, err
+		}
+	}
+	return len(missing), tx.Commit()
+}
+
+// SaveProperty upserts a single property, such as one fetched from its detail page at url
+func (s *Store) SaveProperty(ctx context.Context, source, url string, property scraper.Property) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.upsert(ctx, tx, source, url, property, s.now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upsert inserts or updates a property keyed on its ID, merging it with what
+// other sources reported for the same property and keeping when it was first
+// seen. It records its price as a price event, and how its listing changed
+// since it was last saved as listing events.
+func (s *Store) upsert(ctx context.Context, tx *sql.Tx, source, url string, observed scraper.Property, fetchedAt time.Time) error {
+	if observed.ID == "" {
+		return fmt.Errorf("property %q has no ID", observed.Address)
+	}
+	previous, property, err := s.merged(ctx, tx, source, observed)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(property)
+	if err != nil {
+		return err
+	}
+	now := fetchedAt.UTC().Format(timeFormat)
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO properties
+		(id, source, url, address, city, state, zip_code, status, price, sold_date, data, first_seen_at, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			source = excluded.source,
+			url = CASE WHEN excluded.url != '' THEN excluded.url ELSE properties.url END,
+			address = excluded.address, city = excluded.city, state = excluded.state,
+			zip_code = excluded.zip_code, status = excluded.status, price = excluded.price,
+			sold_date = excluded.sold_date, data = excluded.data, fetched_at = excluded.fetched_at`,
+		property.ID, source, url, property.Address, property.City, property.State, property.ZipCode,
+		property.Status, property.Price, property.SoldDate, string(data), now, now)
+	if err != nil {
+		return fmt.Errorf("failed to save property %s: %v", property.ID, err)
+	}
+
+	if observed.Price > 0 {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO price_events (property_id, event, price, date, source, observed_at)
+			VALUES (?, ?, ?, ?, ?, ?)`, property.ID, observed.Status, observed.Price, observed.SoldDate, source, now)
+		if err != nil {
+			return fmt.Errorf("failed to save price event for %s: %v", property.ID, err)
+		}
+	}
+	return saveListingEvents(ctx, tx, property.ID, source, now, detectEvents(previous, property, fetchedAt))
+}
+
+// saveListingEvents records a property's listing events as observed now,
+// unless an event carries the date its listing gave
+func saveListingEvents(ctx context.Context, tx *sql.Tx, propertyID, source, now string, events []ListingEvent) error {
+	for _, event := range events {
+		observed := now
+		if !event.ObservedAt.IsZero() {
+			observed = event.ObservedAt.UTC().Format(timeFormat)
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO listing_events
+			(property_id, event, status, previous_status, price, previous_price, source, observed_at, initial)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, propertyID, event.Event, event.Status, event.PreviousStatus,
+			event.Price, event.PreviousPrice, source, observed, event.Initial)
+		if err != nil {
+			return fmt.Errorf("failed to save listing event for %s: %v", propertyID, err)
+		}
+	}
+	return nil
+}
+
+// merged returns the stored record of a property, nil when there is none,
+// and that record with a source's newly observed record merged into it
+func (s *Store) merged(ctx context.Context, tx *sql.Tx, source string, observed scraper.Property) (*scraper.Property, scraper.Property, error) {
+	var previous *scraper.Property
+	var property scraper.Property
+	var data string
+	err := tx.QueryRowContext(ctx, `SELECT data FROM properties WHERE id = ?`, observed.ID).Scan(&data)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, property, fmt.Errorf("failed to read property %s: %v", observed.ID, err)
+	default:
+		if err := json.Unmarshal([]byte(data), &property); err != nil {
+			return nil, property, fmt.Errorf("corrupt stored property %s: %v", observed.ID, err)
+		}
+		stored := property
+		previous = &stored
+	}
+	scraper.MergeSource(&property, observed, source, s.rules)
+	property.ID = observed.ID
+	return previous, property, nil
+}
+
+// propertyColumns are the columns scanProperty reads
+const propertyColumns = `properties.data, properties.source, properties.url, properties.first_seen_at, properties.fetched_at`
+
+// scanProperty reads a property selected with propertyColumns
+func scanProperty(scan func(dest ...interface{}) error) (StoredProperty, error) {
+	var stored StoredProperty
+	var data, firstSeen, fetched string
+	if err := scan(&data, &stored.Source, &stored.URL, &firstSeen, &fetched); err != nil {
+		return stored, err
+	}
+	if err := json.Unmarshal([]byte(data), &stored.Property); err != nil {
+		return stored, fmt.Errorf("corrupt stored property: %v", err)
+	}
+	stored.FirstSeen, _ = time.Parse(timeFormat, firstSeen)
+	stored.FetchedAt, _ = time.Parse(timeFormat, fetched)
+	return stored, nil
+}
+
+// AreaProperties returns the properties stored for an area, newest finds
+// first and in page order within a scrape
+func (s *Store) AreaProperties(ctx context.Context, area string) ([]StoredProperty, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+propertyColumns+`
+		FROM area_properties JOIN properties ON properties.id = area_properties.property_id
+		WHERE area_properties.area = ?
+		ORDER BY properties.first_seen_at DESC, area_properties.rowid ASC`, area)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read area properties: %v", err)
+	}
+	defer rows.Close()
+
+	properties := []StoredProperty{}
+	for rows.Next() {
+		stored, err := scanProperty(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, stored)
+	}
+	return properties, rows.Err()
+}
+
+// Properties returns every stored property
+func (s *Store) Properties(ctx context.Context) ([]StoredProperty, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+propertyColumns+` FROM properties ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read properties: %v", err)
+	}
+	defer rows.Close()
+
+	properties := []StoredProperty{}
+	for rows.Next() {
+		stored, err := scanProperty(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, stored)
+	}
+	return properties, rows.Err()
+}
+
+// Property returns a stored property by ID, or nil when there is none
+func (s *Store) Property(ctx context.Context, id string) (*StoredProperty, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+propertyColumns+` FROM properties WHERE id = ?`, id)
+	stored, err := scanProperty(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// PropertyByURL returns the property last fetched from a detail page URL
+func (s *Store) PropertyByURL(ctx context.Context, url string) (*StoredProperty, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+propertyColumns+` FROM properties WHERE url = ? LIMIT 1`, url)
+	stored, err := scanProperty(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// LookupProperty finds a stored property by street address within a city,
+// letting scrapers skip re-scraping listings to resolve a detail page
+func (s *Store) LookupProperty(ctx context.Context, address, city, state string) (*scraper.Property, bool) {
+	// Any spelling of the full address maps to the same ID
+	id := scraper.PropertyID(address, city, state)
+	stored, err := scanProperty(s.db.QueryRowContext(ctx, `SELECT `+propertyColumns+` FROM properties WHERE id = ?`, id).Scan)
+	if err == nil {
+		return &stored.Property, true
+	}
+
+	// An address without a unit matches a unit at that street address
+	if scraper.NormalizeAddress(address).Unit != "" {
+		return nil, false
+	}
+	key := scraper.PropertyKey(address, city, state)
+	rows, err := s.db.QueryContext(ctx, `SELECT `+propertyColumns+` FROM properties
+		WHERE lower(city) = lower(?) AND lower(state) = lower(?) ORDER BY fetched_at DESC`, city, state)
+	if err != nil {
+		return nil, false
+	}
+	defer rows.Close()
+	for rows.Next() {
+		stored, err := scanProperty(rows.Scan)
+		if err != nil {
+			return nil, false
+		}
+		street := scraper.NormalizeAddress(stored.Address)
+		street.UnitType, street.Unit = "", ""
+		if scraper.PropertyKey(street.String(), stored.City, stored.State) == key {
+			return &stored.Property, true
+		}
+	}
+	return nil, false
+}
+
+// PriceEvents returns the price events recorded for a property, oldest first
+func (s *Store) PriceEvents(ctx context.Context, propertyID string) ([]PriceEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT property_id, event, price, date, source, observed_at
+		FROM price_events WHERE property_id = ? ORDER BY observed_at, id`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []PriceEvent{}
+	for rows.Next() {
+		var event PriceEvent
+		var observed string
+		if err := rows.Scan(&event.PropertyID, &event.Event, &event.Price, &event.Date, &event.Source, &observed); err != nil {
+			return nil, err
+		}
+		event.ObservedAt, _ = time.Parse(timeFormat, observed)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,351 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johan-j/play-mcp/pkg/scraper"
+)
+
+// openTestStore opens a store in a temporary directory on a controllable clock
+func openTestStore(t *testing.T) (*Store, *time.Time) {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "properties.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func testProperty(id, address string, price int) scraper.Property {
+	return scraper.Property{ID: id, Address: address, City: "Honolulu", State: "HI", ZipCode: "96822", Price: price, Status: "sold", SoldDate: "MAR 14, 2025"}
+}
+
+func TestSaveAreaUpserts(t *testing.T) {
+	s, now := openTestStore(t)
+	ctx := context.Background()
+	area := AreaKey("homes.com", scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa"})
+	if area != "homes.com|hi|honolulu|manoa|sold" {
+		t.Errorf("AreaKey = %s", area)
+	}
+
+	added, err := s.SaveArea(ctx, "homes.com", area, []scraper.Property{
+		testProperty("prop_a", "2714 Hipawai Pl", 1600000),
+		testProperty("prop_b", "3122 Kaloaluiki St", 1850000),
+	})
+	if err != nil || added != 2 {
+		t.Fatalf("first save: added %d, %v", added, err)
+	}
+	firstSeen := *now
+
+	// A day later a new sale appears and prop_a's price is corrected
+	*now = now.Add(24 * time.Hour)
+	added, err = s.SaveArea(ctx, "homes.com", area, []scraper.Property{
+		testProperty("prop_c", "2772 Kalawao St", 1942000),
+		testProperty("prop_a", "2714 Hipawai Pl", 1610000),
+	})
+	if err != nil || added != 1 {
+		t.Fatalf("second save: added %d, %v", added, err)
+	}
+
+	properties, err := s.AreaProperties(ctx, area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range properties {
+		ids = append(ids, p.ID)
+	}
+	// Newest finds first, then page order
+	if len(ids) != 3 || ids[0] != "prop_c" || ids[1] != "prop_a" || ids[2] != "prop_b" {
+		t.Fatalf("area properties = %v", ids)
+	}
+	a := properties[1]
+	if a.Price != 1610000 || a.Source != "homes.com" || !a.FirstSeen.Equal(firstSeen) || !a.FetchedAt.Equal(*now) {
+		t.Errorf("upserted property = %+v", a)
+	}
+
+	events, err := s.PriceEvents(ctx, "prop_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Price != 1600000 || events[1].Price != 1610000 {
+		t.Errorf("price events = %+v", events)
+	}
+
+	known, err := s.KnownIDs(ctx, area)
+	if err != nil || len(known) != 3 || !known["prop_b"] {
+		t.Errorf("known IDs = %v, %v", known, err)
+	}
+}
+
+func TestScrapeRuns(t *testing.T) {
+	s, now := openTestStore(t)
+	ctx := context.Background()
+	area := "homes.com|tx|austin||sold"
+
+	if run, err := s.LastRun(ctx, area); err != nil || run != nil {
+		t.Fatalf("LastRun before any runs = %+v, %v", run, err)
+	}
+
+	id, err := s.StartRun(ctx, "homes.com", area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(time.Minute)
+	if err := s.FinishRun(ctx, id, 12, 12, nil); err != nil {
+		t.Fatal(err)
+	}
+	finished := *now
+
+	// A failed run does not count as a refresh
+	*now = now.Add(time.Hour)
+	id, _ = s.StartRun(ctx, "homes.com", area)
+	s.FinishRun(ctx, id, 0, 0, context.DeadlineExceeded)
+
+	run, err := s.LastRun(ctx, area)
+	if err != nil || run == nil {
+		t.Fatalf("LastRun = %+v, %v", run, err)
+	}
+	if !run.FinishedAt.Equal(finished) || run.Found != 12 || run.Added != 12 {
+		t.Errorf("LastRun = %+v", run)
+	}
+}
+
+func TestPropertyLookups(t *testing.T) {
+	s, _ := openTestStore(t)
+	ctx := context.Background()
+
+	s.SaveArea(ctx, "homes.com", "homes.com|hi|honolulu||sold", []scraper.Property{testProperty("prop_a", "2714 Hipawai Pl Unit 3", 1600000)})
+	url := "https://www.redfin.com/HI/Honolulu/2819-Poelua-St-96822/home/88513618"
+	if err := s.SaveProperty(ctx, "redfin.com", url, testProperty("prop_b", "2819 Poelua St", 1325000)); err != nil {
+		t.Fatal(err)
+	}
+
+	if p, ok := s.LookupProperty(ctx, "2714 hipawai pl", "HONOLULU", "hi"); !ok || p.ID != "prop_a" {
+		t.Errorf("LookupProperty = %+v, %v", p, ok)
+	}
+	if _, ok := s.LookupProperty(ctx, "2714 Hipawai Pl", "Austin", "TX"); ok {
+		t.Error("LookupProperty matched another city")
+	}
+	if _, ok := s.LookupProperty(ctx, "714 Hipawai Pl", "Honolulu", "HI"); ok {
+		t.Error("LookupProperty matched a longer house number")
+	}
+	if _, ok := s.LookupProperty(ctx, "2714 Hipawai Pl Unit 4", "Honolulu", "HI"); ok {
+		t.Error("LookupProperty matched another unit")
+	}
+
+	stored, err := s.PropertyByURL(ctx, url)
+	if err != nil || stored == nil || stored.ID != "prop_b" || stored.Source != "redfin.com" || stored.URL != url {
+		t.Errorf("PropertyByURL = %+v, %v", stored, err)
+	}
+	if stored, err := s.PropertyByURL(ctx, "https://www.homes.com/property/unknown/"); err != nil || stored != nil {
+		t.Errorf("PropertyByURL(unknown) = %+v, %v", stored, err)
+	}
+}
+
+func TestSaveMergesSources(t *testing.T) {
+	s, _ := openTestStore(t)
+	ctx := context.Background()
+
+	homes := scraper.Property{ID: scraper.PropertyID("2772 Kalawao St Unit 29", "Honolulu", "HI"), Address: "2772 Kalawao St Unit 29",
+		City: "Honolulu", State: "HI", Price: 1940000, Status: "sold", Description: "Corner unit with valley views"}
+	redfin := scraper.Property{ID: scraper.PropertyID("2772 Kalawao Street #29", "Honolulu", "HI"), Address: "2772 Kalawao Street #29",
+		City: "Honolulu", State: "HI", Price: 1942000, Status: "sold", Bedrooms: 3}
+	if homes.ID != redfin.ID {
+		t.Fatalf("IDs differ: %s, %s", homes.ID, redfin.ID)
+	}
+
+	s.SaveArea(ctx, "homes.com", "homes.com|hi|honolulu|manoa|sold", []scraper.Property{homes})
+	url := "https://www.redfin.com/HI/Honolulu/2772-Kalawao-St-96822/unit-29/home/1"
+	if err := s.SaveProperty(ctx, "redfin.com", url, redfin); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := s.PropertyByURL(ctx, url)
+	if err != nil || stored == nil {
+		t.Fatalf("PropertyByURL = %+v, %v", stored, err)
+	}
+	if stored.Price != 1942000 || stored.Description != "Corner unit with valley views" || stored.Bedrooms != 3 {
+		t.Errorf("merged property = %+v", stored.Property)
+	}
+	if stored.Provenance["price"] != "redfin.com" || stored.Provenance["description"] != "homes.com" {
+		t.Errorf("provenance = %v", stored.Provenance)
+	}
+
+	// Both sources' prices are kept as events
+	events, _ := s.PriceEvents(ctx, homes.ID)
+	if len(events) != 2 || events[0].Source != "homes.com" || events[1].Source != "redfin.com" {
+		t.Errorf("price events = %+v", events)
+	}
+	if p, ok := s.LookupProperty(ctx, "2772 Kalawao Street Apt 29", "Honolulu", "HI"); !ok || p.ID != homes.ID {
+		t.Errorf("LookupProperty = %+v, %v", p, ok)
+	}
+}
+
+func TestListingEvents(t *testing.T) {
+	s, now := openTestStore(t)
+	ctx := context.Background()
+	area := AreaKey("homes.com", scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa", Status: "for_sale"})
+	listing := func(status string, price int) scraper.Property {
+		p := testProperty("prop_a", "2714 Hipawai Pl", price)
+		p.Status, p.SoldDate, p.DaysOnMarket = status, "", 3
+		return p
+	}
+
+	// Each scrape is a day apart; rescraping an unchanged listing records nothing.
+	// It was first seen three days after it was listed.
+	start := *now
+	for _, scrape := range []scraper.Property{
+		listing("for_sale", 1650000),
+		listing("for_sale", 1650000),
+		listing("for_sale", 1599000),
+		listing("off_market", 1599000),
+		listing("for_sale", 1575000),
+		listing("pending", 1575000),
+		listing("sold", 1560000),
+	} {
+		if _, err := s.SaveArea(ctx, "homes.com", area, []scraper.Property{scrape}); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(24 * time.Hour)
+	}
+
+	events, err := s.PropertyListingEvents(ctx, "prop_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Event)
+	}
+	want := []string{EventListed, EventPriceCut, EventWithdrawn, EventRelisted, EventPending, EventSold}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if listed := events[0]; !listed.Initial || !listed.ObservedAt.Equal(start.AddDate(0, 0, -3)) {
+		t.Errorf("first sighting = %+v", listed)
+	}
+	if cut := events[1]; cut.Initial || cut.Price != 1599000 || cut.PreviousPrice != 1650000 || !cut.ObservedAt.Equal(start.Add(48*time.Hour)) || cut.Address != "2714 Hipawai Pl" {
+		t.Errorf("price cut = %+v", cut)
+	}
+
+	// Another neighborhood's listing is left out of Manoa's changes
+	other := testProperty("prop_b", "1 Kahala Ave", 900000)
+	other.Status, other.DaysOnMarket = "for_sale", 1
+	s.SaveArea(ctx, "homes.com", "homes.com|hi|honolulu|kahala|for_sale", []scraper.Property{other})
+	// A sale first seen long after it closed is no recent change
+	old := testProperty("prop_c", "2790 Hipawai Pl", 1100000)
+	old.Status, old.SoldDate = "sold", "2024-06-03"
+	s.SaveArea(ctx, "homes.com", "homes.com|hi|honolulu|manoa|sold", []scraper.Property{old})
+
+	changes, err := s.ListingChanges(ctx, ChangeQuery{City: "honolulu", State: "HI", Neighborhood: "Manoa", Since: start.Add(96 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes[0].Event != EventSold || changes[2].Event != EventRelisted {
+		t.Errorf("changes since day 5 = %+v", changes)
+	}
+	changes, _ = s.ListingChanges(ctx, ChangeQuery{City: "Honolulu", State: "HI", Since: start, Events: []string{EventListed, EventPriceCut}, Limit: 2})
+	if len(changes) != 2 || changes[0].PropertyID != "prop_b" || changes[1].Event != EventPriceCut {
+		t.Errorf("listings and price cuts = %+v", changes)
+	}
+}
+
+func TestWithdrawMissing(t *testing.T) {
+	s, now := openTestStore(t)
+	ctx := context.Background()
+	area := AreaKey("homes.com", scraper.SearchQuery{City: "Honolulu", State: "HI", Neighborhood: "Manoa", Status: "for_sale"})
+	listing := func(id, address, status string) scraper.Property {
+		p := testProperty(id, address, 1500000)
+		p.Status, p.SoldDate, p.DaysOnMarket = status, "", 5
+		return p
+	}
+	s.SaveArea(ctx, "homes.com", area, []scraper.Property{
+		listing("prop_a", "2714 Hipawai Pl", "for_sale"), listing("prop_b", "2750 Hipawai Pl", "pending"), listing("prop_c", "2760 Hipawai Pl", "for_sale"),
+	})
+	*now = now.Add(24 * time.Hour)
+
+	// prop_a is gone from the listings for sale; the pending prop_b is left to close
+	listed := []scraper.Property{listing("prop_c", "2760 Hipawai Pl", "for_sale")}
+	s.SaveArea(ctx, "homes.com", area, listed)
+	if n, err := s.WithdrawMissing(ctx, "homes.com", area, listed); err != nil || n != 1 {
+		t.Fatalf("WithdrawMissing = %d, %v", n, err)
+	}
+	events, _ := s.PropertyListingEvents(ctx, "prop_a")
+	if len(events) != 2 || events[1].Event != EventWithdrawn || events[1].PreviousStatus != "for_sale" || events[1].Status != "off_market" {
+		t.Errorf("prop_a events = %+v", events)
+	}
+	if p, ok := s.LookupProperty(ctx, "2714 Hipawai Pl", "Honolulu", "HI"); !ok || p.Status != "off_market" {
+		t.Errorf("withdrawn property = %+v", p)
+	}
+	// First seen pending, which gives no date, and never withdrawn
+	if events, _ := s.PropertyListingEvents(ctx, "prop_b"); len(events) != 0 {
+		t.Errorf("prop_b events = %+v", events)
+	}
+
+	// Already off the market, so a second pass records nothing
+	if n, err := s.WithdrawMissing(ctx, "homes.com", area, listed); err != nil || n != 0 {
+		t.Errorf("second WithdrawMissing = %d, %v", n, err)
+	}
+}
+
+func TestDetectEvents(t *testing.T) {
+	for _, test := range []struct {
+		from, to  string
+		fromPrice int
+		toPrice   int
+		want      string
+	}{
+		{"sold", "for_sale", 900000, 950000, EventListed},
+		{"for_sale", "Under Contract", 950000, 950000, EventPending},
+		{"pending", "for_sale", 950000, 950000, EventRelisted},
+		{"for_sale", "for_sale", 950000, 975000, EventPriceIncrease},
+		{"sold", "sold", 900000, 905000, ""},
+		{"for_sale", "unknown", 950000, 950000, ""},
+		{"sold", "withdrawn", 900000, 900000, ""},
+	} {
+		var previous *scraper.Property
+		if test.from != "" {
+			previous = &scraper.Property{Status: test.from, Price: test.fromPrice}
+		}
+		events := detectEvents(previous, scraper.Property{Status: test.to, Price: test.toPrice}, time.Now())
+		got := ""
+		if len(events) > 0 {
+			got = events[0].Event
+		}
+		if got != test.want {
+			t.Errorf("%s $%d -> %s $%d: %q, want %q", test.from, test.fromPrice, test.to, test.toPrice, got, test.want)
+		}
+	}
+
+	// First sightings are dated by the listing, and skipped without a date
+	now := time.Date(2025, 4, 15, 18, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		property scraper.Property
+		want     string
+		at       time.Time
+	}{
+		{scraper.Property{Status: "Sold", Price: 900000, SoldDate: "JAN 17, 2025"}, EventSold, time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{scraper.Property{Status: "sold", Price: 900000, SoldDate: "2024-06-03"}, EventSold, time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{scraper.Property{Status: "for_sale", Price: 950000, DaysOnMarket: 14}, EventListed, now.AddDate(0, 0, -14)},
+		{scraper.Property{Status: "sold", Price: 900000}, "", time.Time{}},
+		{scraper.Property{Status: "for_sale", Price: 950000}, "", time.Time{}},
+		{scraper.Property{Status: "pending", Price: 950000, DaysOnMarket: 30}, "", time.Time{}},
+	} {
+		events := detectEvents(nil, test.property, now)
+		switch {
+		case test.want == "" && len(events) > 0:
+			t.Errorf("first sighting of %+v: %+v, want none", test.property, events)
+		case test.want != "" && (len(events) != 1 || events[0].Event != test.want || !events[0].ObservedAt.Equal(test.at) || !events[0].Initial):
+			t.Errorf("first sighting of %+v: %+v, want initial %s at %v", test.property, events, test.want, test.at)
+		}
+	}
+}